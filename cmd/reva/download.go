@@ -19,15 +19,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/cheggaaa/pb"
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/internal/grpc/services/storageprovider"
 	"github.com/cs3org/reva/internal/http/services/datagateway"
 
 	"github.com/cs3org/reva/pkg/appctx"
@@ -41,6 +44,12 @@ func downloadCommand() *command {
 	cmd := newCommand("download")
 	cmd.Description = func() string { return "download a remote file to the local filesystem" }
 	cmd.Usage = func() string { return "Usage: download [-flags] <remote_file> <local_file>" }
+	verifyFlag := cmd.Bool("verify", false, "verifies the checksum of the downloaded file when the server provides one")
+
+	cmd.ResetFlags = func() {
+		*verifyFlag = false
+	}
+
 	cmd.Action = func(w ...io.Writer) error {
 		if cmd.NArg() < 2 {
 			return errors.New("Invalid arguments: " + cmd.Usage())
@@ -54,86 +63,136 @@ func downloadCommand() *command {
 			return err
 		}
 
-		ref := &provider.Reference{Path: remote}
-		req1 := &provider.StatRequest{Ref: ref}
-		ctx := getAuthContext()
-		res1, err := gatewayClient.Stat(ctx, req1)
+		absPath, err := utils.ResolvePath(local)
 		if err != nil {
 			return err
 		}
-		if res1.Status.Code != rpc.Code_CODE_OK {
-			return formatError(res1.Status)
-		}
 
-		info := res1.Info
+		return downloadFile(getAuthContext(), gatewayClient, remote, absPath, *verifyFlag)
+	}
+	return cmd
+}
 
-		req2 := &provider.InitiateFileDownloadRequest{
-			Ref: &provider.Reference{Path: remote},
-		}
-		res, err := gatewayClient.InitiateFileDownload(ctx, req2)
-		if err != nil {
-			return err
-		}
+// downloadFile downloads the remote file to the local absPath, verifying
+// its checksum when verify is set and the server provides one.
+func downloadFile(ctx context.Context, gatewayClient gateway.GatewayAPIClient, remote, absPath string, verify bool) error {
+	ref := &provider.Reference{Path: remote}
+	req1 := &provider.StatRequest{Ref: ref}
+	res1, err := gatewayClient.Stat(ctx, req1)
+	if err != nil {
+		return err
+	}
+	if res1.Status.Code != rpc.Code_CODE_OK {
+		return formatError(res1.Status)
+	}
 
-		if res.Status.Code != rpc.Code_CODE_OK {
-			return formatError(res.Status)
-		}
+	info := res1.Info
 
-		p, err := getDownloadProtocolInfo(res.Protocols, "simple")
-		if err != nil {
-			return err
-		}
+	req2 := &provider.InitiateFileDownloadRequest{
+		Ref: &provider.Reference{Path: remote},
+	}
+	res, err := gatewayClient.InitiateFileDownload(ctx, req2)
+	if err != nil {
+		return err
+	}
 
-		// TODO(labkode): upload to data server
-		fmt.Printf("Downloading from: %s\n", p.DownloadEndpoint)
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return formatError(res.Status)
+	}
 
-		content, err := checkDownloadWebdavRef(res.Protocols)
-		if err != nil {
-			if _, ok := err.(errtypes.IsNotSupported); !ok {
-				return err
-			}
-
-			dataServerURL := p.DownloadEndpoint
-			// TODO(labkode): do a protocol switch
-			httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, dataServerURL, nil)
-			if err != nil {
-				return err
-			}
-
-			httpReq.Header.Set(datagateway.TokenTransportHeader, p.Token)
-
-			httpRes, err := client.Do(httpReq)
-			if err != nil {
-				return err
-			}
-			defer httpRes.Body.Close()
-
-			if httpRes.StatusCode != http.StatusOK {
-				return err
-			}
-			content = httpRes.Body
+	p, err := getDownloadProtocolInfo(res.Protocols, "simple")
+	if err != nil {
+		return err
+	}
+
+	// TODO(labkode): upload to data server
+	fmt.Printf("Downloading from: %s\n", p.DownloadEndpoint)
+
+	var header http.Header
+	content, err := checkDownloadWebdavRef(res.Protocols)
+	if err != nil {
+		if _, ok := err.(errtypes.IsNotSupported); !ok {
+			return err
 		}
 
-		absPath, err := utils.ResolvePath(local)
+		dataServerURL := p.DownloadEndpoint
+		// TODO(labkode): do a protocol switch
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, dataServerURL, nil)
 		if err != nil {
 			return err
 		}
 
-		bar := pb.New(int(info.Size)).SetUnits(pb.U_BYTES)
-		bar.Start()
-		reader := bar.NewProxyReader(content)
+		httpReq.Header.Set(datagateway.TokenTransportHeader, p.Token)
 
-		fd, err := os.OpenFile(absPath, os.O_CREATE|os.O_WRONLY, 0644)
+		httpRes, err := client.Do(httpReq)
 		if err != nil {
 			return err
 		}
-		if _, err := io.Copy(fd, reader); err != nil {
-			return err
+		defer httpRes.Body.Close()
+
+		if httpRes.StatusCode != http.StatusOK {
+			return errors.New("download: GET request returned " + httpRes.Status)
 		}
-		bar.Finish()
+		content = httpRes.Body
+		header = httpRes.Header
+	}
+
+	bar := pb.New(int(info.Size)).SetUnits(pb.U_BYTES)
+	bar.Start()
+	reader := bar.NewProxyReader(content)
+
+	fd, err := os.OpenFile(absPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	if _, err := io.Copy(fd, reader); err != nil {
+		return err
+	}
+	bar.Finish()
+
+	if !verify {
 		return nil
 	}
-	return cmd
+	xsType, sum := downloadChecksum(info, header)
+	if sum == "" {
+		fmt.Println("No checksum provided by the server, skipping the verification")
+		return nil
+	}
+	return verifyChecksum(absPath, xsType, sum)
+}
+
+// downloadChecksum returns the checksum of a downloaded file, as found in
+// its metadata or else in the Digest or OC-Checksum response headers.
+func downloadChecksum(info *provider.ResourceInfo, header http.Header) (provider.ResourceChecksumType, string) {
+	if xs := info.GetChecksum(); xs.GetSum() != "" {
+		return xs.Type, xs.Sum
+	}
+	for _, h := range []struct{ name, sep string }{{"Digest", "="}, {"OC-Checksum", ":"}} {
+		if t, sum, ok := strings.Cut(header.Get(h.name), h.sep); ok && sum != "" {
+			return storageprovider.PKG2GRPCXS(strings.ToLower(t)), sum
+		}
+	}
+	return provider.ResourceChecksumType_RESOURCE_CHECKSUM_TYPE_UNSET, ""
+}
+
+// verifyChecksum compares the checksum of the local file with the expected one.
+func verifyChecksum(absPath string, xsType provider.ResourceChecksumType, expected string) error {
+	fd, err := os.Open(absPath)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	sum, err := computeXS(xsType, fd)
+	if err != nil {
+		return errors.Wrap(err, "download: cannot verify the checksum")
+	}
+	if !strings.EqualFold(sum, expected) {
+		return fmt.Errorf("download: %s checksum mismatch for %s: expected %s, got %s", storageprovider.GRPC2PKGXS(xsType), absPath, expected, sum)
+	}
+	fmt.Printf("Checksum verified: %s:%s\n", storageprovider.GRPC2PKGXS(xsType), sum)
+	return nil
 }
 
 func getDownloadProtocolInfo(
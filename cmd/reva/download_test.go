@@ -0,0 +1,119 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/crypto"
+)
+
+// downloadGateway serves a file whose metadata and Digest header may
+// advertise a checksum, from a data server that may tamper with it.
+type downloadGateway struct {
+	gateway.UnimplementedGatewayAPIServer
+	dataServer string
+	checksum   *provider.ResourceChecksum
+	digest     string
+	content    string
+}
+
+func (g *downloadGateway) Stat(_ context.Context, req *provider.StatRequest) (*provider.StatResponse, error) {
+	return &provider.StatResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		Info:   &provider.ResourceInfo{Path: req.Ref.Path, Size: uint64(len(g.content)), Checksum: g.checksum},
+	}, nil
+}
+
+func (g *downloadGateway) InitiateFileDownload(_ context.Context, req *provider.InitiateFileDownloadRequest) (*gateway.InitiateFileDownloadResponse, error) {
+	return &gateway.InitiateFileDownloadResponse{
+		Status:    &rpc.Status{Code: rpc.Code_CODE_OK},
+		Protocols: []*gateway.FileDownloadProtocol{{Protocol: "simple", DownloadEndpoint: g.dataServer + req.Ref.Path}},
+	}, nil
+}
+
+func (g *downloadGateway) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	if g.digest != "" {
+		w.Header().Set("Digest", g.digest)
+	}
+	_, _ = w.Write([]byte(g.content))
+}
+
+func TestDownloadVerifyChecksum(t *testing.T) {
+	g := &downloadGateway{}
+	data := httptest.NewServer(g)
+	t.Cleanup(data.Close)
+	g.dataServer = data.URL
+	serveGateway(t, g)
+
+	gwc, err := getClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, err := crypto.ComputeAdler32XS(strings.NewReader("original"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	adler32 := &provider.ResourceChecksum{Type: provider.ResourceChecksumType_RESOURCE_CHECKSUM_TYPE_ADLER32, Sum: sum}
+
+	tests := []struct {
+		description string
+		checksum    *provider.ResourceChecksum
+		digest      string
+		content     string
+		verify      bool
+		fails       bool
+	}{
+		{description: "intact content", checksum: adler32, content: "original", verify: true},
+		{description: "tampered content", checksum: adler32, content: "tampered", verify: true, fails: true},
+		{description: "tampered content with digest", digest: "ADLER32=" + sum, content: "tampered", verify: true, fails: true},
+		{description: "intact content with digest", digest: "ADLER32=" + sum, content: "original", verify: true},
+		{description: "no checksum", content: "tampered", verify: true},
+		{description: "no verification", checksum: adler32, content: "tampered"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g.checksum, g.digest, g.content = tt.checksum, tt.digest, tt.content
+			local := filepath.Join(t.TempDir(), "file.txt")
+
+			err := downloadFile(context.Background(), gwc, "/home/file.txt", local, tt.verify)
+			if tt.fails {
+				if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+					t.Fatalf("expected a checksum mismatch, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if b, _ := os.ReadFile(local); string(b) != tt.content {
+				t.Errorf("expected the downloaded content %q, got %q", tt.content, b)
+			}
+		})
+	}
+}
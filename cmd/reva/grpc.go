@@ -20,13 +20,17 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"strings"
+	"sync"
 
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/rgrpc"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -56,15 +60,67 @@ func getClient() (gateway.GatewayAPIClient, error) {
 }
 
 func getConn() (*grpc.ClientConn, error) {
+	versionCheck := grpc.WithUnaryInterceptor(versionCheckInterceptor)
 	if insecure {
-		return grpc.NewClient(conf.Host, grpc.WithTransportCredentials(ins.NewCredentials()))
+		return grpc.NewClient(conf.Host, grpc.WithTransportCredentials(ins.NewCredentials()), versionCheck)
 	}
 
 	// TODO(labkode): if in the future we want client-side certificate validation,
 	// we need to load the client cert here
-	tlsconf := &tls.Config{InsecureSkipVerify: skipverify}
+	tlsconf := grpcTLSConfig(skipverify, skipverifyhosts)
 	creds := credentials.NewTLS(tlsconf)
-	return grpc.NewClient(conf.Host, grpc.WithTransportCredentials(creds))
+	return grpc.NewClient(conf.Host, grpc.WithTransportCredentials(creds), versionCheck)
+}
+
+var (
+	// versionChecked makes sure the server version is compared
+	// only on the first request of the session.
+	versionChecked sync.Once
+
+	// warningOutput is where the version mismatch warning is printed.
+	warningOutput io.Writer = os.Stderr
+)
+
+// versionCheckInterceptor warns when the server handling the first
+// request runs a version incompatible with this tool.
+func versionCheckInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if noversioncheck {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	var header metadata.MD
+	err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header))...)
+	if v := header.Get(rgrpc.VersionHeader); len(v) > 0 {
+		versionChecked.Do(func() {
+			if !compatibleVersions(version, v[0]) {
+				fmt.Fprintf(warningOutput, "warning: reva-cli version %s may be incompatible with the server version %s, use -no-version-check to suppress this warning\n", version, v[0])
+			}
+		})
+	}
+	return err
+}
+
+// compatibleVersions reports whether the two versions share the same
+// major and minor version. Versions that cannot be parsed, like the
+// ones of development builds, are considered compatible.
+func compatibleVersions(a, b string) bool {
+	ma, ok := majorMinor(a)
+	if !ok {
+		return true
+	}
+	mb, ok := majorMinor(b)
+	if !ok {
+		return true
+	}
+	return ma == mb
+}
+
+func majorMinor(v string) (string, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(v, "v"), ".", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[0] + "." + parts[1], true
 }
 
 func formatError(status *rpc.Status) error {
@@ -0,0 +1,100 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	registry "github.com/cs3org/go-cs3apis/cs3/auth/registry/v1beta1"
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/pkg/rgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type versionedGateway struct {
+	gateway.UnimplementedGatewayAPIServer
+}
+
+func (versionedGateway) ListAuthProviders(context.Context, *registry.ListAuthProvidersRequest) (*gateway.ListAuthProvidersResponse, error) {
+	return &gateway.ListAuthProvidersResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}}, nil
+}
+
+// startVersionedGateway starts a gateway advertising the given server version.
+func startVersionedGateway(t *testing.T, serverVersion string) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		_ = grpc.SetHeader(ctx, metadata.Pairs(rgrpc.VersionHeader, serverVersion))
+		return handler(ctx, req)
+	}))
+	gateway.RegisterGatewayAPIServer(srv, versionedGateway{})
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func listAuthProviders(t *testing.T, cliVersion, serverVersion string, disableCheck bool) string {
+	var out bytes.Buffer
+	conf = &config{Host: startVersionedGateway(t, serverVersion)}
+	insecure, version, noversioncheck = true, cliVersion, disableCheck
+	versionChecked, warningOutput = sync.Once{}, &out
+
+	client, err := getClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ListAuthProviders(context.Background(), &registry.ListAuthProvidersRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestVersionCheck(t *testing.T) {
+	tests := []struct {
+		description   string
+		cliVersion    string
+		serverVersion string
+		disableCheck  bool
+		warned        bool
+	}{
+		{description: "same major and minor", cliVersion: "v3.1.0", serverVersion: "v3.1.4", warned: false},
+		{description: "different minor", cliVersion: "v3.1.0", serverVersion: "v3.0.2", warned: true},
+		{description: "different major", cliVersion: "v2.1.0", serverVersion: "v3.1.0", warned: true},
+		{description: "development build", cliVersion: "", serverVersion: "v3.1.0", warned: false},
+		{description: "check disabled", cliVersion: "v3.1.0", serverVersion: "v3.0.2", disableCheck: true, warned: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			out := listAuthProviders(t, tt.cliVersion, tt.serverVersion, tt.disableCheck)
+			if warned := strings.Contains(out, "warning"); warned != tt.warned {
+				t.Fatalf("expected warning=%v, got output %q", tt.warned, out)
+			}
+		})
+	}
+}
@@ -20,24 +20,144 @@ package main
 
 import (
 	"encoding/gob"
+	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"path"
+	"slices"
+	"strings"
+	"time"
 
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/utils/resourceid"
 	"github.com/pkg/errors"
 )
 
+// lsColumns are the columns that can be selected with the -columns flag,
+// in the order used for the json output when no column is selected.
+var lsColumns = []string{"name", "type", "size", "mtime", "etag", "id", "mimetype", "permissions"}
+
+// lsLongColumns are the columns of the long listing when no column is selected.
+var lsLongColumns = []string{"type", "mtime", "size", "id", "name"}
+
+// lsColumn returns the value of the given column for a listed resource.
+func lsColumn(info *provider.ResourceInfo, column, name string) interface{} {
+	switch column {
+	case "name":
+		return name
+	case "type":
+		return info.Type.String()
+	case "size":
+		return info.Size
+	case "mtime":
+		if info.Mtime == nil {
+			return ""
+		}
+		return time.Unix(int64(info.Mtime.Seconds), int64(info.Mtime.Nanos)).UTC().Format(time.RFC3339)
+	case "etag":
+		return info.Etag
+	case "id":
+		if info.Id == nil {
+			return ""
+		}
+		return resourceid.OwnCloudResourceIDWrap(info.Id)
+	case "mimetype":
+		return info.MimeType
+	case "permissions":
+		return formatPermissions(info.PermissionSet, info.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER)
+	}
+	return nil
+}
+
+// formatPermissions formats the permissions of a resource with the letters of the
+// WebDAV permissions: R to share, D to delete, NV to move, W to write a file and
+// CK to create in a folder.
+func formatPermissions(p *provider.ResourcePermissions, isDir bool) string {
+	if p == nil {
+		return ""
+	}
+	var b strings.Builder
+	if p.AddGrant {
+		b.WriteString("R")
+	}
+	if p.Delete {
+		b.WriteString("D")
+	}
+	if p.Move {
+		b.WriteString("NV")
+	}
+	if !isDir && p.InitiateFileUpload {
+		b.WriteString("W")
+	}
+	if isDir && p.CreateContainer {
+		b.WriteString("CK")
+	}
+	return b.String()
+}
+
+// parseColumns validates a comma separated list of columns.
+func parseColumns(list string) ([]string, error) {
+	if list == "" {
+		return nil, nil
+	}
+	var columns []string
+	for _, c := range strings.Split(list, ",") {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if !slices.Contains(lsColumns, c) {
+			return nil, fmt.Errorf("unknown column %q, the valid columns are: %s", c, strings.Join(lsColumns, ","))
+		}
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+// printColumns prints the given columns of the listed resources, one resource
+// per line or as a json array of objects.
+func printColumns(out io.Writer, infos []*provider.ResourceInfo, columns []string, fullPath, jsonOutput bool) error {
+	rows := make([]map[string]interface{}, 0, len(infos))
+	for _, info := range infos {
+		name := info.Path
+		if !fullPath {
+			name = path.Base(info.Path)
+		}
+		if jsonOutput {
+			row := make(map[string]interface{}, len(columns))
+			for _, c := range columns {
+				row[c] = lsColumn(info, c, name)
+			}
+			rows = append(rows, row)
+			continue
+		}
+		values := make([]string, 0, len(columns))
+		for _, c := range columns {
+			values = append(values, fmt.Sprint(lsColumn(info, c, name)))
+		}
+		if _, err := fmt.Fprintln(out, strings.Join(values, " ")); err != nil {
+			return err
+		}
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	}
+	return nil
+}
+
 func lsCommand() *command {
 	cmd := newCommand("ls")
 	cmd.Description = func() string { return "list a container contents" }
 	cmd.Usage = func() string { return "Usage: ls [-flags] <container_name>" }
-	longFlag := cmd.Bool("l", false, "long listing")
+	longFlag := cmd.Bool("l", false, "long listing, with the columns "+strings.Join(lsLongColumns, ","))
 	fullFlag := cmd.Bool("f", false, "shows full path")
+	columnsFlag := cmd.String("columns", "", "comma separated columns to print, among "+strings.Join(lsColumns, ","))
+	jsonFlag := cmd.Bool("json", false, "prints the listing as json")
 
 	cmd.ResetFlags = func() {
-		*longFlag, *fullFlag = false, false
+		*longFlag, *fullFlag, *columnsFlag, *jsonFlag = false, false, "", false
 	}
 
 	cmd.Action = func(w ...io.Writer) error {
@@ -45,6 +165,11 @@ func lsCommand() *command {
 			return errors.New("Invalid arguments: " + cmd.Usage())
 		}
 
+		columns, err := parseColumns(*columnsFlag)
+		if err != nil {
+			return err
+		}
+
 		fn := cmd.Args()[0]
 		client, err := getClient()
 		if err != nil {
@@ -65,28 +190,22 @@ func lsCommand() *command {
 		}
 
 		infos := res.Infos
-		for _, info := range infos {
-			p := info.Path
-			if !*fullFlag {
-				p = path.Base(info.Path)
-			}
-			if len(w) == 0 {
-				if *longFlag {
-					fmt.Printf("%s %s %d %v %s\n", info.Type, info.Mtime, info.Size, info.Id, p)
-				} else {
-					fmt.Println(p)
+		if len(w) == 0 {
+			if columns == nil {
+				switch {
+				case *longFlag:
+					columns = lsLongColumns
+				case *jsonFlag:
+					columns = lsColumns
+				default:
+					columns = []string{"name"}
 				}
 			}
+			return printColumns(os.Stdout, infos, columns, *fullFlag, *jsonFlag)
 		}
 
-		if len(w) != 0 {
-			enc := gob.NewEncoder(w[0])
-			if err := enc.Encode(infos); err != nil {
-				return err
-			}
-		}
-
-		return nil
+		enc := gob.NewEncoder(w[0])
+		return enc.Encode(infos)
 	}
 	return cmd
 }
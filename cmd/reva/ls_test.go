@@ -0,0 +1,130 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+)
+
+func listing() []*provider.ResourceInfo {
+	return []*provider.ResourceInfo{
+		{
+			Type:     provider.ResourceType_RESOURCE_TYPE_FILE,
+			Id:       &provider.ResourceId{StorageId: "storage", OpaqueId: "file"},
+			Path:     "/home/file.txt",
+			Etag:     "etag-file",
+			MimeType: "text/plain",
+			Size:     42,
+			Mtime:    &types.Timestamp{Seconds: 1700000000},
+			PermissionSet: &provider.ResourcePermissions{
+				Delete:             true,
+				InitiateFileUpload: true,
+				Move:               true,
+			},
+		},
+		{
+			Type:     provider.ResourceType_RESOURCE_TYPE_CONTAINER,
+			Id:       &provider.ResourceId{StorageId: "storage", OpaqueId: "dir"},
+			Path:     "/home/dir",
+			Etag:     "etag-dir",
+			MimeType: "httpd/unix-directory",
+			PermissionSet: &provider.ResourcePermissions{
+				AddGrant:        true,
+				CreateContainer: true,
+			},
+		},
+	}
+}
+
+func TestLsColumns(t *testing.T) {
+	columns, err := parseColumns("etag, NAME,size")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := printColumns(&out, listing(), columns, false, false); err != nil {
+		t.Fatal(err)
+	}
+	expected := "etag-file file.txt 42\netag-dir dir 0\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+
+	out.Reset()
+	columns, _ = parseColumns("mtime,name")
+	if err := printColumns(&out, listing()[:1], columns, true, false); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "2023-11-14T22:13:20Z /home/file.txt\n"; out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+
+	if _, err := parseColumns("name,owner"); err == nil {
+		t.Error("expected an error for an unknown column")
+	}
+}
+
+func TestLsColumnsJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := printColumns(&out, listing(), []string{"name", "size"}, false, true); err != nil {
+		t.Fatal(err)
+	}
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	for _, row := range rows {
+		if len(row) != 2 || row["name"] == nil || row["size"] == nil {
+			t.Errorf("expected only the name and size columns, got %v", row)
+		}
+	}
+	if rows[0]["name"] != "file.txt" || rows[0]["size"] != float64(42) {
+		t.Errorf("unexpected row %v", rows[0])
+	}
+}
+
+func TestLsLongColumns(t *testing.T) {
+	var out bytes.Buffer
+	if err := printColumns(&out, listing(), lsLongColumns, false, false); err != nil {
+		t.Fatal(err)
+	}
+	expected := "RESOURCE_TYPE_FILE 2023-11-14T22:13:20Z 42 storage!file file.txt\n" +
+		"RESOURCE_TYPE_CONTAINER  0 storage!dir dir\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestLsPermissions(t *testing.T) {
+	var out bytes.Buffer
+	if err := printColumns(&out, listing(), []string{"name", "permissions"}, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "file.txt DNVW\ndir RCK\n"; out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
@@ -19,10 +19,8 @@
 package main
 
 import (
-	"crypto/tls"
 	"flag"
 	"fmt"
-	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -35,6 +33,8 @@ var (
 	conf                                                        *config
 	host                                                        string
 	insecure, skipverify, disableargprompt, insecuredatagateway bool
+	noversioncheck                                              bool
+	skipverifyhosts                                             string
 	timeout                                                     int64
 
 	helpCommandOutput string
@@ -113,12 +113,20 @@ func init() {
 		false,
 		"whether to skip verifying the server's certificate chain and host name",
 	)
+	flag.StringVar(
+		&skipverifyhosts,
+		"skip-verify-host",
+		"",
+		"comma separated list of hosts whose certificate chain and host name are not verified",
+	)
 	flag.BoolVar(&disableargprompt, "disable-arg-prompt", false, "whether to disable prompts for command arguments")
 	flag.Int64Var(&timeout, "timeout", -1, "the timeout in seconds for executing the commands, -1 means no timeout")
-	flag.Parse()
+	flag.BoolVar(&noversioncheck, "no-version-check", false, "disables the warning when the server version differs from the one of this tool")
 }
 
 func main() {
+	flag.Parse()
+
 	if host != "" {
 		conf = &config{host}
 		if err := writeConfig(conf); err != nil {
@@ -127,7 +135,7 @@ func main() {
 		}
 	}
 
-	tr := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: insecuredatagateway}}
+	tr := httpTransport(insecuredatagateway, skipverifyhosts)
 
 	client = httpclient.New(
 		httpclient.RoundTripper(tr),
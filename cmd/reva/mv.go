@@ -19,8 +19,11 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"io"
 
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/pkg/errors"
@@ -30,10 +33,22 @@ func moveCommand() *command {
 	cmd := newCommand("mv")
 	cmd.Description = func() string { return "moves/rename a file/folder" }
 	cmd.Usage = func() string { return "Usage: mv [-flags] <source> <destination>" }
+	var overwrite bool
+	cmd.BoolVar(&overwrite, "f", false, "overwrites an existing destination")
+	cmd.BoolVar(&overwrite, "overwrite", false, "overwrites an existing destination")
+	noClobberFlag := cmd.Bool("n", false, "does not overwrite an existing destination")
+
+	cmd.ResetFlags = func() {
+		overwrite, *noClobberFlag = false, false
+	}
+
 	cmd.Action = func(w ...io.Writer) error {
 		if cmd.NArg() < 2 {
 			return errors.New("Invalid arguments: " + cmd.Usage())
 		}
+		if overwrite && *noClobberFlag {
+			return errors.New("Invalid arguments: -f and -n are mutually exclusive")
+		}
 
 		src := cmd.Args()[0]
 		dst := cmd.Args()[1]
@@ -44,19 +59,51 @@ func moveCommand() *command {
 			return err
 		}
 
-		sourceRef := &provider.Reference{Path: src}
-		targetRef := &provider.Reference{Path: dst}
-		req := &provider.MoveRequest{Source: sourceRef, Destination: targetRef}
-		res, err := client.Move(ctx, req)
+		return move(ctx, client, src, dst, overwrite, *noClobberFlag)
+	}
+	return cmd
+}
+
+// move moves src to dst. An existing dst is deleted first when overwrite
+// is set, skipped when noClobber is set and refused otherwise.
+func move(ctx context.Context, client gateway.GatewayAPIClient, src, dst string, overwrite, noClobber bool) error {
+	targetRef := &provider.Reference{Path: dst}
+	statRes, err := client.Stat(ctx, &provider.StatRequest{Ref: targetRef})
+	if err != nil {
+		return err
+	}
+
+	switch statRes.Status.Code {
+	case rpc.Code_CODE_NOT_FOUND:
+	case rpc.Code_CODE_OK:
+		switch {
+		case noClobber:
+			fmt.Printf("Not overwriting the existing destination %s\n", dst)
+			return nil
+		case !overwrite:
+			return fmt.Errorf("mv: destination %s already exists, use -f to overwrite it", dst)
+		}
+		delRes, err := client.Delete(ctx, &provider.DeleteRequest{Ref: targetRef})
 		if err != nil {
 			return err
 		}
-
-		if res.Status.Code != rpc.Code_CODE_OK {
-			return formatError(res.Status)
+		if delRes.Status.Code != rpc.Code_CODE_OK {
+			return formatError(delRes.Status)
 		}
+	default:
+		return formatError(statRes.Status)
+	}
 
-		return nil
+	sourceRef := &provider.Reference{Path: src}
+	req := &provider.MoveRequest{Source: sourceRef, Destination: targetRef}
+	res, err := client.Move(ctx, req)
+	if err != nil {
+		return err
 	}
-	return cmd
+
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return formatError(res.Status)
+	}
+
+	return nil
 }
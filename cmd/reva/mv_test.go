@@ -0,0 +1,116 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+)
+
+// moveGateway moves the entries of a flat set of paths.
+type moveGateway struct {
+	gateway.UnimplementedGatewayAPIServer
+
+	mu      sync.Mutex
+	paths   map[string]bool
+	deleted []string
+}
+
+func (g *moveGateway) Stat(_ context.Context, req *provider.StatRequest) (*provider.StatResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.paths[req.Ref.Path] {
+		return &provider.StatResponse{Status: &rpc.Status{Code: rpc.Code_CODE_NOT_FOUND}}, nil
+	}
+	return &provider.StatResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, Info: &provider.ResourceInfo{Path: req.Ref.Path}}, nil
+}
+
+func (g *moveGateway) Delete(_ context.Context, req *provider.DeleteRequest) (*provider.DeleteResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.paths, req.Ref.Path)
+	g.deleted = append(g.deleted, req.Ref.Path)
+	return &provider.DeleteResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}}, nil
+}
+
+func (g *moveGateway) Move(_ context.Context, req *provider.MoveRequest) (*provider.MoveResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paths[req.Destination.Path] {
+		return &provider.MoveResponse{Status: &rpc.Status{Code: rpc.Code_CODE_ALREADY_EXISTS}}, nil
+	}
+	delete(g.paths, req.Source.Path)
+	g.paths[req.Destination.Path] = true
+	return &provider.MoveResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}}, nil
+}
+
+func TestMoveOverwrite(t *testing.T) {
+	g := &moveGateway{}
+	serveGateway(t, g)
+	gwc, err := getClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		description string
+		overwrite   bool
+		noClobber   bool
+		fails       bool
+		moved       bool
+	}{
+		{description: "refused without overwrite", fails: true},
+		{description: "skipped with no clobber", noClobber: true},
+		{description: "overwritten", overwrite: true, moved: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g.paths, g.deleted = map[string]bool{"/home/src": true, "/home/dst": true}, nil
+
+			err := move(ctx, gwc, "/home/src", "/home/dst", tt.overwrite, tt.noClobber)
+			if tt.fails != (err != nil) {
+				t.Fatalf("expected failure %t, got %v", tt.fails, err)
+			}
+			if moved := !g.paths["/home/src"]; moved != tt.moved {
+				t.Errorf("expected moved %t, got %t", tt.moved, moved)
+			}
+			if !g.paths["/home/dst"] {
+				t.Error("expected the destination to exist")
+			}
+			if deleted := slices.Equal(g.deleted, []string{"/home/dst"}); deleted != tt.overwrite {
+				t.Errorf("expected the destination deleted %t, got %v", tt.overwrite, g.deleted)
+			}
+		})
+	}
+
+	g.paths = map[string]bool{"/home/src": true}
+	if err := move(ctx, gwc, "/home/src", "/home/new", false, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !g.paths["/home/new"] || g.paths["/home/src"] {
+		t.Errorf("expected the source to be moved to a new destination, got %v", g.paths)
+	}
+}
@@ -224,10 +224,10 @@ func getOCMSharePerm(p string) (*provider.ResourcePermissions, error) {
 
 func getOCMViewMode(p string) (appprovider.ViewMode, error) {
 	switch p {
-	case viewerPermission:
+	case viewerPermission, "read", "view":
 		return appprovider.ViewMode_VIEW_MODE_READ_ONLY, nil
-	case editorPermission:
+	case editorPermission, "write":
 		return appprovider.ViewMode_VIEW_MODE_READ_WRITE, nil
 	}
-	return 0, errors.New("invalid rol: " + p)
+	return 0, errors.New("invalid view mode: " + p)
 }
@@ -32,11 +32,11 @@ func ocmShareUpdateCommand() *command {
 	cmd.Description = func() string { return "update an OCM share" }
 	cmd.Usage = func() string { return "Usage: ocm-share-update [-flags] <share_id>" }
 
-	webdavRol := cmd.String("webdav-rol", "viewer", "the permission for the WebDAV access method (viewer or editor)")
-	webappViewMode := cmd.String("webapp-mode", "view", "the view mode for the Webapp access method (read or write)")
+	webdavRol := cmd.String("webdav-rol", "", "the permission for the WebDAV access method (viewer or editor)")
+	webappViewMode := cmd.String("webapp-mode", "", "the view mode for the Webapp access method (read or write)")
 
 	cmd.ResetFlags = func() {
-		*webdavRol, *webappViewMode = "viewer", "read"
+		*webdavRol, *webappViewMode = "", ""
 	}
 	cmd.Action = func(w ...io.Writer) error {
 		if cmd.NArg() < 1 {
@@ -49,56 +49,15 @@ func ocmShareUpdateCommand() *command {
 			return errors.New("use at least one of -webdav-rol or -webapp-mode flag")
 		}
 
-		ctx := getAuthContext()
-		shareClient, err := getClient()
+		shareRequest, err := newOCMShareUpdateRequest(id, *webdavRol, *webappViewMode)
 		if err != nil {
 			return err
 		}
 
-		shareRequest := &ocm.UpdateOCMShareRequest{
-			Ref: &ocm.ShareReference{
-				Spec: &ocm.ShareReference_Id{
-					Id: &ocm.ShareId{
-						OpaqueId: id,
-					},
-				},
-			},
-		}
-
-		if *webdavRol != "" {
-			perm, err := getOCMSharePerm(*webdavRol)
-			if err != nil {
-				return err
-			}
-			shareRequest.Field = append(shareRequest.Field, &ocm.UpdateOCMShareRequest_UpdateField{
-				Field: &ocm.UpdateOCMShareRequest_UpdateField_AccessMethods{
-					AccessMethods: &ocm.AccessMethod{
-						Term: &ocm.AccessMethod_WebdavOptions{
-							WebdavOptions: &ocm.WebDAVAccessMethod{
-								Permissions: perm,
-							},
-						},
-					},
-				},
-			})
-		}
-
-		if *webappViewMode != "" {
-			mode, err := getOCMViewMode(*webappViewMode)
-			if err != nil {
-				return err
-			}
-			shareRequest.Field = append(shareRequest.Field, &ocm.UpdateOCMShareRequest_UpdateField{
-				Field: &ocm.UpdateOCMShareRequest_UpdateField_AccessMethods{
-					AccessMethods: &ocm.AccessMethod{
-						Term: &ocm.AccessMethod_WebappOptions{
-							WebappOptions: &ocm.WebappAccessMethod{
-								ViewMode: mode,
-							},
-						},
-					},
-				},
-			})
+		ctx := getAuthContext()
+		shareClient, err := getClient()
+		if err != nil {
+			return err
 		}
 
 		shareRes, err := shareClient.UpdateOCMShare(ctx, shareRequest)
@@ -115,3 +74,55 @@ func ocmShareUpdateCommand() *command {
 	}
 	return cmd
 }
+
+// newOCMShareUpdateRequest builds the request updating the access methods
+// of an OCM share, leaving out the ones with an empty role or view mode.
+func newOCMShareUpdateRequest(id, webdavRol, webappViewMode string) (*ocm.UpdateOCMShareRequest, error) {
+	shareRequest := &ocm.UpdateOCMShareRequest{
+		Ref: &ocm.ShareReference{
+			Spec: &ocm.ShareReference_Id{
+				Id: &ocm.ShareId{
+					OpaqueId: id,
+				},
+			},
+		},
+	}
+
+	if webdavRol != "" {
+		perm, err := getOCMSharePerm(webdavRol)
+		if err != nil {
+			return nil, err
+		}
+		shareRequest.Field = append(shareRequest.Field, &ocm.UpdateOCMShareRequest_UpdateField{
+			Field: &ocm.UpdateOCMShareRequest_UpdateField_AccessMethods{
+				AccessMethods: &ocm.AccessMethod{
+					Term: &ocm.AccessMethod_WebdavOptions{
+						WebdavOptions: &ocm.WebDAVAccessMethod{
+							Permissions: perm,
+						},
+					},
+				},
+			},
+		})
+	}
+
+	if webappViewMode != "" {
+		mode, err := getOCMViewMode(webappViewMode)
+		if err != nil {
+			return nil, err
+		}
+		shareRequest.Field = append(shareRequest.Field, &ocm.UpdateOCMShareRequest_UpdateField{
+			Field: &ocm.UpdateOCMShareRequest_UpdateField_AccessMethods{
+				AccessMethods: &ocm.AccessMethod{
+					Term: &ocm.AccessMethod_WebappOptions{
+						WebappOptions: &ocm.WebappAccessMethod{
+							ViewMode: mode,
+						},
+					},
+				},
+			},
+		})
+	}
+
+	return shareRequest, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"testing"
+
+	appprovider "github.com/cs3org/go-cs3apis/cs3/app/provider/v1beta1"
+	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestOCMShareUpdateRequest(t *testing.T) {
+	req, err := newOCMShareUpdateRequest("share-id", "editor", "read")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Ref.GetId().GetOpaqueId() != "share-id" {
+		t.Errorf("expected the share id, got %v", req.Ref)
+	}
+	if len(req.Field) != 2 {
+		t.Fatalf("expected two access methods, got %d", len(req.Field))
+	}
+	webdav := req.Field[0].GetAccessMethods().GetWebdavOptions()
+	if !proto.Equal(webdav.GetPermissions(), conversions.NewEditorRole().CS3ResourcePermissions()) {
+		t.Errorf("expected the editor permissions, got %v", webdav.GetPermissions())
+	}
+	if mode := req.Field[1].GetAccessMethods().GetWebappOptions().GetViewMode(); mode != appprovider.ViewMode_VIEW_MODE_READ_ONLY {
+		t.Errorf("expected the read only view mode, got %s", mode)
+	}
+
+	req, err = newOCMShareUpdateRequest("share-id", "", "write")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(req.Field) != 1 {
+		t.Fatalf("expected only the webapp access method, got %d fields", len(req.Field))
+	}
+	if _, ok := req.Field[0].Field.(*ocm.UpdateOCMShareRequest_UpdateField_AccessMethods); !ok {
+		t.Fatalf("expected an access methods update, got %T", req.Field[0].Field)
+	}
+	if mode := req.Field[0].GetAccessMethods().GetWebappOptions().GetViewMode(); mode != appprovider.ViewMode_VIEW_MODE_READ_WRITE {
+		t.Errorf("expected the read write view mode, got %s", mode)
+	}
+
+	for _, tt := range [][2]string{{"owner", ""}, {"", "admin"}} {
+		if _, err := newOCMShareUpdateRequest("share-id", tt[0], tt[1]); err == nil {
+			t.Errorf("expected an error for %v", tt)
+		}
+	}
+}
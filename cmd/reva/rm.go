@@ -19,17 +19,31 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"fmt"
 	"io"
+	"os"
+	"strings"
 
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	storageproviderv1beta1pb "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/pkg/errors"
+	"golang.org/x/term"
 )
 
 func rmCommand() *command {
 	cmd := newCommand("rm")
 	cmd.Description = func() string { return "removes a file or folder" }
 	cmd.Usage = func() string { return "Usage: rm [-flags] <file_name>" }
+	forceFlag := cmd.Bool("f", false, "deletes folders without asking for confirmation")
+	recursiveFlag := cmd.Bool("r", false, "deletes non-empty folders")
+
+	cmd.ResetFlags = func() {
+		*forceFlag, *recursiveFlag = false, false
+	}
+
 	cmd.Action = func(w ...io.Writer) error {
 		if cmd.NArg() < 1 {
 			return errors.New("Invalid arguments: " + cmd.Usage())
@@ -42,18 +56,78 @@ func rmCommand() *command {
 			return err
 		}
 
-		ref := &storageproviderv1beta1pb.Reference{Path: fn}
-		req := &storageproviderv1beta1pb.DeleteRequest{Ref: ref}
-		res, err := client.Delete(ctx, req)
-		if err != nil {
-			return err
+		var confirm func(string) (bool, error)
+		if term.IsTerminal(int(os.Stdin.Fd())) {
+			confirm = promptConfirmation
+		}
+		return remove(ctx, client, fn, *recursiveFlag, *forceFlag, confirm)
+	}
+	return cmd
+}
+
+// promptConfirmation asks the user to confirm the question on the standard input.
+func promptConfirmation(question string) (bool, error) {
+	fmt.Printf("%s [y/N]: ", question)
+	answer, err := read(bufio.NewReader(os.Stdin))
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
+
+// remove deletes fn. Folders must be empty unless recursive is set, and
+// their deletion is confirmed unless force is set. A nil confirm means
+// that no confirmation can be asked, so that force is required.
+func remove(ctx context.Context, client gateway.GatewayAPIClient, fn string, recursive, force bool, confirm func(string) (bool, error)) error {
+	ref := &storageproviderv1beta1pb.Reference{Path: fn}
+
+	statRes, err := client.Stat(ctx, &storageproviderv1beta1pb.StatRequest{Ref: ref})
+	if err != nil {
+		return err
+	}
+	if statRes.Status.Code != rpc.Code_CODE_OK {
+		return formatError(statRes.Status)
+	}
+
+	if statRes.Info.Type == storageproviderv1beta1pb.ResourceType_RESOURCE_TYPE_CONTAINER {
+		if !recursive {
+			listRes, err := client.ListContainer(ctx, &storageproviderv1beta1pb.ListContainerRequest{Ref: ref})
+			if err != nil {
+				return err
+			}
+			if listRes.Status.Code != rpc.Code_CODE_OK {
+				return formatError(listRes.Status)
+			}
+			if len(listRes.Infos) > 0 {
+				return fmt.Errorf("rm: %s is not empty, use -r to delete it", fn)
+			}
 		}
 
-		if res.Status.Code != rpc.Code_CODE_OK {
-			return formatError(res.Status)
+		if !force {
+			if confirm == nil {
+				return fmt.Errorf("rm: use -f to delete the folder %s in non-interactive mode", fn)
+			}
+			ok, err := confirm(fmt.Sprintf("Delete the folder %s and all its contents?", fn))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Println("Aborted")
+				return nil
+			}
 		}
+	}
 
-		return nil
+	req := &storageproviderv1beta1pb.DeleteRequest{Ref: ref}
+	res, err := client.Delete(ctx, req)
+	if err != nil {
+		return err
 	}
-	return cmd
+
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return formatError(res.Status)
+	}
+
+	return nil
 }
@@ -0,0 +1,104 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+)
+
+// rmGateway serves a non-empty folder, an empty folder and a file.
+type rmGateway struct {
+	gateway.UnimplementedGatewayAPIServer
+
+	mu      sync.Mutex
+	deleted []string
+}
+
+func (g *rmGateway) Stat(_ context.Context, req *provider.StatRequest) (*provider.StatResponse, error) {
+	t := provider.ResourceType_RESOURCE_TYPE_CONTAINER
+	if req.Ref.Path == "/home/file" {
+		t = provider.ResourceType_RESOURCE_TYPE_FILE
+	}
+	return &provider.StatResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, Info: &provider.ResourceInfo{Type: t, Path: req.Ref.Path}}, nil
+}
+
+func (g *rmGateway) ListContainer(_ context.Context, req *provider.ListContainerRequest) (*provider.ListContainerResponse, error) {
+	res := &provider.ListContainerResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}}
+	if req.Ref.Path == "/home/dir" {
+		res.Infos = []*provider.ResourceInfo{{Path: "/home/dir/file"}}
+	}
+	return res, nil
+}
+
+func (g *rmGateway) Delete(_ context.Context, req *provider.DeleteRequest) (*provider.DeleteResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.deleted = append(g.deleted, req.Ref.Path)
+	return &provider.DeleteResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}}, nil
+}
+
+func TestRemove(t *testing.T) {
+	g := &rmGateway{}
+	serveGateway(t, g)
+	gwc, err := getClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	answer := func(ok bool) func(string) (bool, error) {
+		return func(string) (bool, error) { return ok, nil }
+	}
+
+	tests := []struct {
+		description string
+		path        string
+		recursive   bool
+		force       bool
+		confirm     func(string) (bool, error)
+		fails       bool
+		deleted     bool
+	}{
+		{description: "non-empty folder", path: "/home/dir", confirm: answer(true), fails: true},
+		{description: "non-empty folder forced", path: "/home/dir", force: true, fails: true},
+		{description: "non-empty folder in batch mode", path: "/home/dir", recursive: true, fails: true},
+		{description: "non-empty folder confirmed", path: "/home/dir", recursive: true, confirm: answer(true), deleted: true},
+		{description: "non-empty folder not confirmed", path: "/home/dir", recursive: true, confirm: answer(false)},
+		{description: "non-empty folder recursive and forced", path: "/home/dir", recursive: true, force: true, deleted: true},
+		{description: "empty folder forced", path: "/home/empty", force: true, deleted: true},
+		{description: "file in batch mode", path: "/home/file", deleted: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			g.deleted = nil
+			err := remove(context.Background(), gwc, tt.path, tt.recursive, tt.force, tt.confirm)
+			if tt.fails != (err != nil) {
+				t.Fatalf("expected failure %t, got %v", tt.fails, err)
+			}
+			if deleted := len(g.deleted) == 1 && g.deleted[0] == tt.path; deleted != tt.deleted {
+				t.Errorf("expected deleted %t, got %v", tt.deleted, g.deleted)
+			}
+		})
+	}
+}
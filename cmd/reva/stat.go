@@ -19,11 +19,19 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"os"
+	"strings"
+	"time"
 
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/internal/grpc/services/storageprovider"
+	"github.com/cs3org/reva/pkg/utils"
 	"github.com/pkg/errors"
 )
 
@@ -31,10 +39,20 @@ func statCommand() *command {
 	cmd := newCommand("stat")
 	cmd.Description = func() string { return "get the metadata for a file or folder" }
 	cmd.Usage = func() string { return "Usage: stat [-flags] <file_name>" }
+	checksumFlag := cmd.Bool("checksum", false, "shows the checksum of the file")
+	outputFlag := cmd.String("o", "text", "output format: text, json")
+
+	cmd.ResetFlags = func() {
+		*checksumFlag, *outputFlag = false, "text"
+	}
+
 	cmd.Action = func(w ...io.Writer) error {
 		if cmd.NArg() < 1 {
 			return errors.New("Invalid arguments: " + cmd.Usage())
 		}
+		if *outputFlag != "text" && *outputFlag != "json" {
+			return errors.New("Invalid output format: " + *outputFlag)
+		}
 
 		fn := cmd.Args()[0]
 
@@ -44,19 +62,74 @@ func statCommand() *command {
 			return err
 		}
 
-		ref := &provider.Reference{Path: fn}
-		req := &provider.StatRequest{Ref: ref}
-		res, err := client.Stat(ctx, req)
+		return statFile(ctx, client, fn, os.Stdout, *checksumFlag, *outputFlag == "json")
+	}
+	return cmd
+}
+
+// statFile prints the metadata of fn or, with checksum, its checksum.
+func statFile(ctx context.Context, client gateway.GatewayAPIClient, fn string, out io.Writer, checksum, jsonOutput bool) error {
+	ref := &provider.Reference{Path: fn}
+	req := &provider.StatRequest{Ref: ref}
+	res, err := client.Stat(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return formatError(res.Status)
+	}
+
+	return printStat(out, res.Info, checksum, jsonOutput)
+}
+
+// formatChecksum returns the checksum of a resource as type:sum, or none.
+func formatChecksum(info *provider.ResourceInfo) string {
+	xs := info.GetChecksum()
+	if xs.GetSum() == "" {
+		return "none"
+	}
+	return fmt.Sprintf("%s:%s", storageprovider.GRPC2PKGXS(xs.Type), xs.Sum)
+}
+
+func printStat(out io.Writer, info *provider.ResourceInfo, checksum, jsonOutput bool) error {
+	switch {
+	case checksum && jsonOutput:
+		return json.NewEncoder(out).Encode(map[string]string{"path": info.Path, "checksum": formatChecksum(info)})
+	case checksum:
+		_, err := fmt.Fprintln(out, formatChecksum(info))
+		return err
+	case jsonOutput:
+		b, err := utils.MarshalProtoV1ToJSON(info)
 		if err != nil {
 			return err
 		}
+		_, err = fmt.Fprintln(out, string(b))
+		return err
+	}
+	if _, err := fmt.Fprintln(out, info); err != nil {
+		return err
+	}
+	if info.Lock != nil {
+		_, err := fmt.Fprintln(out, formatLock(info.Lock))
+		return err
+	}
+	return nil
+}
 
-		if res.Status.Code != rpc.Code_CODE_OK {
-			return formatError(res.Status)
+// formatLock describes the type, holder and expiration of a lock.
+func formatLock(l *provider.Lock) string {
+	holder := l.AppName
+	if u := l.GetUser(); u != nil {
+		holder = u.OpaqueId
+		if u.Idp != "" {
+			holder += "@" + u.Idp
 		}
-
-		fmt.Println(res.Info)
-		return nil
 	}
-	return cmd
+	expiration := "never"
+	if l.Expiration != nil {
+		expiration = time.Unix(int64(l.Expiration.Seconds), 0).UTC().Format(time.RFC3339)
+	}
+	lockType := strings.ToLower(strings.TrimPrefix(l.Type.String(), "LOCK_TYPE_"))
+	return fmt.Sprintf("lock: type=%s holder=%s expires=%s", lockType, holder, expiration)
 }
@@ -0,0 +1,154 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+)
+
+// statGateway serves files with the given checksums and locks.
+type statGateway struct {
+	gateway.UnimplementedGatewayAPIServer
+	checksums map[string]*provider.ResourceChecksum
+	locks     map[string]*provider.Lock
+}
+
+func (g *statGateway) Stat(_ context.Context, req *provider.StatRequest) (*provider.StatResponse, error) {
+	return &provider.StatResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		Info: &provider.ResourceInfo{
+			Type:     provider.ResourceType_RESOURCE_TYPE_FILE,
+			Path:     req.Ref.Path,
+			Checksum: g.checksums[req.Ref.Path],
+			Lock:     g.locks[req.Ref.Path],
+		},
+	}, nil
+}
+
+func TestStatChecksum(t *testing.T) {
+	serveGateway(t, &statGateway{checksums: map[string]*provider.ResourceChecksum{
+		"/home/file.txt": {Type: provider.ResourceChecksumType_RESOURCE_CHECKSUM_TYPE_SHA1, Sum: "a9993e36"},
+	}})
+	gwc, err := getClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	if err := statFile(ctx, gwc, "/home/file.txt", &out, true, false); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "sha1:a9993e36\n" {
+		t.Errorf("expected the sha1 checksum, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := statFile(ctx, gwc, "/home/other.txt", &out, true, false); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "none\n" {
+		t.Errorf("expected no checksum, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := statFile(ctx, gwc, "/home/file.txt", &out, true, true); err != nil {
+		t.Fatal(err)
+	}
+	var res map[string]string
+	if err := json.Unmarshal(out.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res["path"] != "/home/file.txt" || res["checksum"] != "sha1:a9993e36" {
+		t.Errorf("unexpected json output %v", res)
+	}
+
+	out.Reset()
+	if err := statFile(ctx, gwc, "/home/file.txt", &out, false, true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"sum":"a9993e36"`) {
+		t.Errorf("expected the checksum in the json metadata, got %s", out.String())
+	}
+}
+
+func TestStatLock(t *testing.T) {
+	serveGateway(t, &statGateway{locks: map[string]*provider.Lock{
+		"/home/locked.txt": {
+			LockId:     "lock-id",
+			Type:       provider.LockType_LOCK_TYPE_WRITE,
+			User:       &userpb.UserId{Idp: "cernbox.cern.ch", OpaqueId: "einstein"},
+			Expiration: &types.Timestamp{Seconds: 1700000000},
+		},
+		"/home/app.txt": {LockId: "app-lock", Type: provider.LockType_LOCK_TYPE_SHARED, AppName: "collabora"},
+	}})
+	gwc, err := getClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for fn, expected := range map[string]string{
+		"/home/locked.txt": "lock: type=write holder=einstein@cernbox.cern.ch expires=2023-11-14T22:13:20Z\n",
+		"/home/app.txt":    "lock: type=shared holder=collabora expires=never\n",
+	} {
+		var out bytes.Buffer
+		if err := statFile(ctx, gwc, fn, &out, false, false); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(out.String(), expected) {
+			t.Errorf("%s: expected the lock line %q, got %q", fn, expected, out.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := statFile(ctx, gwc, "/home/unlocked.txt", &out, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "lock:") {
+		t.Errorf("expected no lock line, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := statFile(ctx, gwc, "/home/locked.txt", &out, false, true); err != nil {
+		t.Fatal(err)
+	}
+	var res struct {
+		Lock struct {
+			LockID string `json:"lockId"`
+			Type   string `json:"type"`
+		} `json:"lock"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Lock.LockID != "lock-id" || res.Lock.Type != "LOCK_TYPE_WRITE" {
+		t.Errorf("expected the lock in the json output, got %s", out.String())
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// parseHosts splits a comma separated list of hosts, dropping the ports.
+func parseHosts(list string) map[string]bool {
+	hosts := map[string]bool{}
+	for _, h := range strings.Split(list, ",") {
+		h = strings.TrimSpace(h)
+		if host, _, err := net.SplitHostPort(h); err == nil {
+			h = host
+		}
+		if h != "" {
+			hosts[strings.ToLower(h)] = true
+		}
+	}
+	return hosts
+}
+
+// tlsConfig returns the client TLS configuration for the given server.
+// The server certificate is verified unless skipVerify is set or the
+// server is one of the skipHosts.
+func tlsConfig(skipVerify bool, skipHosts map[string]bool, serverName string) *tls.Config {
+	if skipVerify || len(skipHosts) == 0 {
+		return &tls.Config{InsecureSkipVerify: skipVerify}
+	}
+
+	// the default verification is replaced by the one below, which is
+	// bound to serverName as the connection state lacks it for IP addresses
+	c := &tls.Config{ServerName: serverName, InsecureSkipVerify: true}
+	c.VerifyConnection = func(cs tls.ConnectionState) error {
+		if skipHosts[strings.ToLower(serverName)] {
+			return nil
+		}
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("tls: server did not provide a certificate")
+		}
+		opts := x509.VerifyOptions{
+			Roots:         c.RootCAs,
+			DNSName:       serverName,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range cs.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err := cs.PeerCertificates[0].Verify(opts)
+		return err
+	}
+	return c
+}
+
+// grpcTLSConfig returns the TLS configuration used to connect to the gateway.
+func grpcTLSConfig(skipVerify bool, skipHosts string) *tls.Config {
+	host, _, err := net.SplitHostPort(conf.Host)
+	if err != nil {
+		host = conf.Host
+	}
+	return tlsConfig(skipVerify, parseHosts(skipHosts), host)
+}
+
+// httpTransport returns the transport used to connect to the data gateways.
+func httpTransport(skipVerify bool, skipHosts string) *http.Transport {
+	hosts := parseHosts(skipHosts)
+	if skipVerify || len(hosts) == 0 {
+		return &http.Transport{TLSClientConfig: tlsConfig(skipVerify, nil, "")}
+	}
+	return &http.Transport{
+		DialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			d := &tls.Dialer{Config: tlsConfig(false, hosts, host)}
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestSkipVerifyHosts(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	t.Cleanup(srv.Close)
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(tr *http.Transport) error {
+		res, err := (&http.Client{Transport: tr}).Get(srv.URL)
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+
+	tests := []struct {
+		description string
+		skipVerify  bool
+		hosts       string
+		fails       bool
+	}{
+		{"no hosts", false, "", true},
+		{"other hosts", false, "dev.example.org, example.com:443", true},
+		{"listed host", false, "dev.example.org," + u.Hostname(), false},
+		{"listed host with port", false, u.Host, false},
+		{"skip verify", true, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			err := get(httpTransport(tt.skipVerify, tt.hosts))
+			if tt.fails && err == nil {
+				t.Error("expected the certificate verification to fail")
+			}
+			if !tt.fails && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	// the hosts that are not listed are still verified against the roots
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	for serverName, fails := range map[string]bool{u.Hostname(): false, "dev.example.org": true} {
+		c := tlsConfig(false, parseHosts("other.example.org"), serverName)
+		c.RootCAs = roots
+		err := get(&http.Transport{TLSClientConfig: c})
+		if fails && err == nil {
+			t.Errorf("%s: expected the host name verification to fail", serverName)
+		}
+		if !fails && err != nil {
+			t.Errorf("%s: unexpected error: %v", serverName, err)
+		}
+	}
+}
@@ -19,10 +19,12 @@
 package main
 
 import (
+	"context"
 	"encoding/gob"
 	"io"
 	"os"
 
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
 	datatx "github.com/cs3org/go-cs3apis/cs3/tx/v1beta1"
@@ -42,37 +44,17 @@ func transferListCommand() *command {
 			return err
 		}
 
-		// validate flags
-		var filters []*datatx.ListTransfersRequest_Filter
-		if *filterShareID != "" {
-			filters = append(filters, &datatx.ListTransfersRequest_Filter{
-				Type: datatx.ListTransfersRequest_Filter_TYPE_SHARE_ID,
-				Term: &datatx.ListTransfersRequest_Filter_ShareId{
-					ShareId: &ocm.ShareId{
-						OpaqueId: *filterShareID,
-					},
-				},
-			})
-		}
-
-		transferslistRequest := &datatx.ListTransfersRequest{
-			Filters: filters,
-		}
-
-		listTransfersResponse, err := client.ListTransfers(ctx, transferslistRequest)
+		transfers, err := listTransfers(ctx, client, *filterShareID)
 		if err != nil {
 			return err
 		}
-		if listTransfersResponse.Status.Code != rpc.Code_CODE_OK {
-			return formatError(listTransfersResponse.Status)
-		}
 
 		if len(w) == 0 {
 			t := table.NewWriter()
 			t.SetOutputMirror(os.Stdout)
 			t.AppendHeader(table.Row{"ShareId.OpaqueId", "Id.OpaqueId"})
 
-			for _, s := range listTransfersResponse.Transfers {
+			for _, s := range transfers {
 				t.AppendRows([]table.Row{
 					{s.ShareId.OpaqueId, s.Id.OpaqueId},
 				})
@@ -80,7 +62,7 @@ func transferListCommand() *command {
 			t.Render()
 		} else {
 			enc := gob.NewEncoder(w[0])
-			if err := enc.Encode(listTransfersResponse.Transfers); err != nil {
+			if err := enc.Encode(transfers); err != nil {
 				return err
 			}
 		}
@@ -89,3 +71,31 @@ func transferListCommand() *command {
 	}
 	return cmd
 }
+
+// listTransfers lists the transfers, of the given share if shareID is set.
+func listTransfers(ctx context.Context, client gateway.GatewayAPIClient, shareID string) ([]*datatx.TxInfo, error) {
+	var filters []*datatx.ListTransfersRequest_Filter
+	if shareID != "" {
+		filters = append(filters, &datatx.ListTransfersRequest_Filter{
+			Type: datatx.ListTransfersRequest_Filter_TYPE_SHARE_ID,
+			Term: &datatx.ListTransfersRequest_Filter_ShareId{
+				ShareId: &ocm.ShareId{
+					OpaqueId: shareID,
+				},
+			},
+		})
+	}
+
+	transferslistRequest := &datatx.ListTransfersRequest{
+		Filters: filters,
+	}
+
+	listTransfersResponse, err := client.ListTransfers(ctx, transferslistRequest)
+	if err != nil {
+		return nil, err
+	}
+	if listTransfersResponse.Status.Code != rpc.Code_CODE_OK {
+		return nil, formatError(listTransfersResponse.Status)
+	}
+	return listTransfersResponse.Transfers, nil
+}
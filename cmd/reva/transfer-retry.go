@@ -19,12 +19,16 @@
 package main
 
 import (
+	"context"
 	"encoding/gob"
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"strings"
 	"time"
 
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	datatx "github.com/cs3org/go-cs3apis/cs3/tx/v1beta1"
 	"github.com/jedib0t/go-pretty/table"
@@ -35,9 +39,29 @@ func transferRetryCommand() *command {
 	cmd.Description = func() string { return "retry a transfer" }
 	cmd.Usage = func() string { return "Usage: transfer-retry [-flags]" }
 	txID := cmd.String("txId", "", "the transfer identifier")
+	allFlag := cmd.Bool("all", false, "retries all the transfers with the given status")
+	statusFlag := cmd.String("status", "failed", "the status of the transfers to retry with -all")
+
+	cmd.ResetFlags = func() {
+		*txID, *allFlag, *statusFlag = "", false, "failed"
+	}
 
 	cmd.Action = func(w ...io.Writer) error {
 		// validate flags
+		if *allFlag {
+			if *txID != "" {
+				return errors.New("txId and all are mutually exclusive\n" + cmd.Usage())
+			}
+			status, err := parseTransferStatus(*statusFlag)
+			if err != nil {
+				return err
+			}
+			client, err := getClient()
+			if err != nil {
+				return err
+			}
+			return retryTransfers(getAuthContext(), client, status, os.Stdout)
+		}
 		if *txID == "" {
 			return errors.New("txId must be specified: use -txId flag\n" + cmd.Usage())
 		}
@@ -82,3 +106,48 @@ func transferRetryCommand() *command {
 	}
 	return cmd
 }
+
+// parseTransferStatus parses a transfer status, given with or without
+// its STATUS_TRANSFER_ prefix, e.g. failed.
+func parseTransferStatus(s string) (datatx.Status, error) {
+	name := strings.ToUpper(s)
+	for _, prefix := range []string{"", "STATUS_", "STATUS_TRANSFER_"} {
+		if v, ok := datatx.Status_value[prefix+name]; ok && v != int32(datatx.Status_STATUS_INVALID) {
+			return datatx.Status(v), nil
+		}
+	}
+	return datatx.Status_STATUS_INVALID, fmt.Errorf("invalid transfer status: %s", s)
+}
+
+// retryTransfers retries all the transfers with the given status,
+// reporting the outcome of each retry.
+func retryTransfers(ctx context.Context, client gateway.GatewayAPIClient, status datatx.Status, out io.Writer) error {
+	transfers, err := listTransfers(ctx, client, "")
+	if err != nil {
+		return err
+	}
+
+	var retried, failed int
+	for _, tx := range transfers {
+		if tx.Status != status {
+			continue
+		}
+		res, err := client.RetryTransfer(ctx, &datatx.RetryTransferRequest{TxId: tx.Id})
+		if err == nil && res.Status.Code != rpc.Code_CODE_OK {
+			err = formatError(res.Status)
+		}
+		if err != nil {
+			fmt.Fprintf(out, "Transfer %s: retry failed: %v\n", tx.Id.GetOpaqueId(), err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "Transfer %s: retried, status %s\n", tx.Id.GetOpaqueId(), res.TxInfo.GetStatus())
+		retried++
+	}
+
+	fmt.Fprintf(out, "Retried %d transfers, %d failed\n", retried, failed)
+	if failed > 0 {
+		return fmt.Errorf("transfer-retry: %d of %d retries failed", failed, retried+failed)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	datatx "github.com/cs3org/go-cs3apis/cs3/tx/v1beta1"
+)
+
+// transferGateway lists the given transfers and records the retries.
+type transferGateway struct {
+	gateway.UnimplementedGatewayAPIServer
+	transfers []*datatx.TxInfo
+	broken    string
+
+	mu      sync.Mutex
+	retried []string
+}
+
+func (g *transferGateway) ListTransfers(context.Context, *datatx.ListTransfersRequest) (*datatx.ListTransfersResponse, error) {
+	return &datatx.ListTransfersResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, Transfers: g.transfers}, nil
+}
+
+func (g *transferGateway) RetryTransfer(_ context.Context, req *datatx.RetryTransferRequest) (*datatx.RetryTransferResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.retried = append(g.retried, req.TxId.OpaqueId)
+	if req.TxId.OpaqueId == g.broken {
+		return &datatx.RetryTransferResponse{Status: &rpc.Status{Code: rpc.Code_CODE_INTERNAL}}, nil
+	}
+	return &datatx.RetryTransferResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		TxInfo: &datatx.TxInfo{Id: req.TxId, Status: datatx.Status_STATUS_TRANSFER_NEW},
+	}, nil
+}
+
+func TestRetryAllTransfers(t *testing.T) {
+	tx := func(id string, status datatx.Status) *datatx.TxInfo {
+		return &datatx.TxInfo{Id: &datatx.TxId{OpaqueId: id}, Status: status}
+	}
+	g := &transferGateway{transfers: []*datatx.TxInfo{
+		tx("tx-1", datatx.Status_STATUS_TRANSFER_FAILED),
+		tx("tx-2", datatx.Status_STATUS_TRANSFER_COMPLETE),
+		tx("tx-3", datatx.Status_STATUS_TRANSFER_FAILED),
+		tx("tx-4", datatx.Status_STATUS_TRANSFER_EXPIRED),
+		tx("tx-5", datatx.Status_STATUS_TRANSFER_FAILED),
+	}}
+	serveGateway(t, g)
+	gwc, err := getClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := parseTransferStatus("failed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := retryTransfers(context.Background(), gwc, status, &out); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"tx-1", "tx-3", "tx-5"}; !slices.Equal(g.retried, expected) {
+		t.Errorf("expected the retries of %v, got %v", expected, g.retried)
+	}
+	if !strings.Contains(out.String(), "Retried 3 transfers, 0 failed") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+
+	g.retried, g.broken = nil, "tx-3"
+	out.Reset()
+	if err := retryTransfers(context.Background(), gwc, status, &out); err == nil {
+		t.Error("expected the failed retry to be reported")
+	}
+	if expected := []string{"tx-1", "tx-3", "tx-5"}; !slices.Equal(g.retried, expected) {
+		t.Errorf("expected the retries of %v, got %v", expected, g.retried)
+	}
+	if !strings.Contains(out.String(), "Transfer tx-3: retry failed") || !strings.Contains(out.String(), "Retried 2 transfers, 1 failed") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
+
+func TestParseTransferStatus(t *testing.T) {
+	for s, expected := range map[string]datatx.Status{
+		"failed":                 datatx.Status_STATUS_TRANSFER_FAILED,
+		"EXPIRED":                datatx.Status_STATUS_TRANSFER_EXPIRED,
+		"destination_not_found":  datatx.Status_STATUS_DESTINATION_NOT_FOUND,
+		"STATUS_TRANSFER_FAILED": datatx.Status_STATUS_TRANSFER_FAILED,
+	} {
+		if status, err := parseTransferStatus(s); err != nil || status != expected {
+			t.Errorf("%s: expected %s, got %s (%v)", s, expected, status, err)
+		}
+	}
+	for _, s := range []string{"", "invalid", "broken"} {
+		if _, err := parseTransferStatus(s); err == nil {
+			t.Errorf("expected an error for %q", s)
+		}
+	}
+}
@@ -19,11 +19,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"math"
 	"net/http"
 	"os"
+	"path"
+	"path/filepath"
 	"strconv"
 
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
@@ -46,12 +50,15 @@ import (
 func uploadCommand() *command {
 	cmd := newCommand("upload")
 	cmd.Description = func() string { return "upload a local file to the remote server" }
-	cmd.Usage = func() string { return "Usage: upload [-flags] <file_name> <remote_target>" }
+	cmd.Usage = func() string { return "Usage: upload [-flags] <file_name|dir_name> <remote_target>" }
 	xsFlag := cmd.String("xs", "negotiate", "compute checksum")
 	protocolFlag := cmd.String("protocol", "simple", "protocol for file uploads: simple, negotiate")
+	recursiveFlag := cmd.Bool("r", false, "uploads a local directory recursively")
+	continueFlag := cmd.Bool("continue", false, "skips the files failing to upload in a recursive upload")
 
 	cmd.ResetFlags = func() {
 		*protocolFlag, *xsFlag = "simple", "negotiate"
+		*recursiveFlag, *continueFlag = false, false
 	}
 
 	cmd.Action = func(w ...io.Writer) error {
@@ -69,123 +76,192 @@ func uploadCommand() *command {
 			return err
 		}
 
-		fd, err := os.Open(absPath)
+		gwc, err := getClient()
 		if err != nil {
 			return err
 		}
-		defer fd.Close()
 
-		md, err := fd.Stat()
-		if err != nil {
-			return err
+		if *recursiveFlag {
+			return uploadTree(ctx, gwc, absPath, target, *protocolFlag, *xsFlag, *continueFlag)
 		}
+		return uploadFile(ctx, gwc, absPath, target, *protocolFlag, *xsFlag)
+	}
+	return cmd
+}
 
-		fmt.Printf("Local file size: %d bytes\n", md.Size())
+// uploadFile uploads the local file at absPath to the remote target.
+func uploadFile(ctx context.Context, gwc gateway.GatewayAPIClient, absPath, target, protocol, xsFlag string) error {
+	fd, err := os.Open(absPath)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
 
-		gwc, err := getClient()
-		if err != nil {
-			return err
-		}
+	md, err := fd.Stat()
+	if err != nil {
+		return err
+	}
 
-		req := &provider.InitiateFileUploadRequest{
-			Ref: &provider.Reference{Path: target},
-			Opaque: &typespb.Opaque{
-				Map: map[string]*typespb.OpaqueEntry{
-					"Upload-Length": {
-						Decoder: "plain",
-						Value:   []byte(strconv.FormatInt(md.Size(), 10)),
-					},
+	fmt.Printf("Local file size: %d bytes\n", md.Size())
+
+	req := &provider.InitiateFileUploadRequest{
+		Ref: &provider.Reference{Path: target},
+		Opaque: &typespb.Opaque{
+			Map: map[string]*typespb.OpaqueEntry{
+				"Upload-Length": {
+					Decoder: "plain",
+					Value:   []byte(strconv.FormatInt(md.Size(), 10)),
 				},
 			},
-		}
+		},
+	}
 
-		res, err := gwc.InitiateFileUpload(ctx, req)
-		if err != nil {
+	res, err := gwc.InitiateFileUpload(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return formatError(res.Status)
+	}
+
+	if err = checkUploadWebdavRef(res.Protocols, md, fd); err != nil {
+		if _, ok := err.(errtypes.IsNotSupported); !ok {
 			return err
 		}
+	} else {
+		return nil
+	}
 
-		if res.Status.Code != rpc.Code_CODE_OK {
-			return formatError(res.Status)
-		}
+	p, err := getUploadProtocolInfo(res.Protocols, protocol)
+	if err != nil {
+		return err
+	}
 
-		if err = checkUploadWebdavRef(res.Protocols, md, fd); err != nil {
-			if _, ok := err.(errtypes.IsNotSupported); !ok {
-				return err
-			}
-		} else {
-			return nil
-		}
+	fmt.Printf("Data server: %s\n", p.UploadEndpoint)
+	fmt.Printf("Allowed checksums: %+v\n", p.AvailableChecksums)
 
-		p, err := getUploadProtocolInfo(res.Protocols, *protocolFlag)
-		if err != nil {
-			return err
-		}
+	xsType, err := guessXS(xsFlag, p.AvailableChecksums)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Checksum selected: %s\n", xsType)
+
+	xs, err := computeXS(xsType, fd)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Local XS: %s:%s\n", xsType, xs)
+	// seek back reader to 0
+	if _, err := fd.Seek(0, 0); err != nil {
+		return err
+	}
 
-		fmt.Printf("Data server: %s\n", p.UploadEndpoint)
-		fmt.Printf("Allowed checksums: %+v\n", p.AvailableChecksums)
+	dataServerURL := p.UploadEndpoint
 
-		xsType, err := guessXS(*xsFlag, p.AvailableChecksums)
+	if protocol == "simple" {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPut, dataServerURL, fd)
 		if err != nil {
 			return err
 		}
-		fmt.Printf("Checksum selected: %s\n", xsType)
 
-		xs, err := computeXS(xsType, fd)
+		httpReq.Header.Set(datagateway.TokenTransportHeader, p.Token)
+		q := httpReq.URL.Query()
+		q.Add("xs", xs)
+		q.Add("xs_type", storageprovider.GRPC2PKGXS(xsType).String())
+		httpReq.URL.RawQuery = q.Encode()
+
+		httpRes, err := client.Do(httpReq)
 		if err != nil {
 			return err
 		}
-
-		fmt.Printf("Local XS: %s:%s\n", xsType, xs)
-		// seek back reader to 0
-		if _, err := fd.Seek(0, 0); err != nil {
-			return err
+		defer httpRes.Body.Close()
+		if httpRes.StatusCode != http.StatusOK {
+			return errors.New("upload: PUT request returned " + httpRes.Status)
 		}
+	} else {
+		return errors.New("upload: protocol not supported: " + protocol)
+	}
 
-		dataServerURL := p.UploadEndpoint
+	req2 := &provider.StatRequest{
+		Ref: &provider.Reference{Path: target},
+	}
+	res2, err := gwc.Stat(ctx, req2)
+	if err != nil {
+		return err
+	}
 
-		if *protocolFlag == "simple" {
-			httpReq, err := http.NewRequestWithContext(ctx, http.MethodPut, dataServerURL, fd)
-			if err != nil {
-				return err
-			}
+	if res2.Status.Code != rpc.Code_CODE_OK {
+		return formatError(res2.Status)
+	}
 
-			httpReq.Header.Set(datagateway.TokenTransportHeader, p.Token)
-			q := httpReq.URL.Query()
-			q.Add("xs", xs)
-			q.Add("xs_type", storageprovider.GRPC2PKGXS(xsType).String())
-			httpReq.URL.RawQuery = q.Encode()
+	info := res2.Info
 
-			httpRes, err := client.Do(httpReq)
-			if err != nil {
-				return err
-			}
-			defer httpRes.Body.Close()
-			if httpRes.StatusCode != http.StatusOK {
-				return errors.New("upload: PUT request returned " + httpRes.Status)
-			}
-		} else {
-			return errors.New("upload: protocol not supported: " + *protocolFlag)
-		}
+	fmt.Printf("File uploaded: %s:%s %d %s\n", info.Id.StorageId, info.Id.OpaqueId, info.Size, info.Path)
+
+	return nil
+}
 
-		req2 := &provider.StatRequest{
-			Ref: &provider.Reference{Path: target},
+// uploadTree uploads the local directory at root to the remote target,
+// creating the containers and preserving the relative paths of the files.
+// When keepGoing is set the failed files are reported and skipped.
+func uploadTree(ctx context.Context, gwc gateway.GatewayAPIClient, root, target, protocol, xsFlag string, keepGoing bool) error {
+	var dirs, files []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
 		}
-		res2, err := gwc.Stat(ctx, req2)
+		rel, err := filepath.Rel(root, p)
 		if err != nil {
 			return err
 		}
-
-		if res2.Status.Code != rpc.Code_CODE_OK {
-			return formatError(res2.Status)
+		switch {
+		case d.IsDir():
+			dirs = append(dirs, filepath.ToSlash(rel))
+		case d.Type().IsRegular():
+			files = append(files, filepath.ToSlash(rel))
+		default:
+			fmt.Printf("Skipping %s: not a regular file\n", rel)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(dirs) == 0 {
+		return errors.New("upload: not a directory: " + root)
+	}
 
-		info := res2.Info
+	for _, dir := range dirs {
+		res, err := gwc.CreateContainer(ctx, &provider.CreateContainerRequest{
+			Ref: &provider.Reference{Path: path.Join(target, dir)},
+		})
+		if err != nil {
+			return err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK && res.Status.Code != rpc.Code_CODE_ALREADY_EXISTS {
+			return formatError(res.Status)
+		}
+	}
 
-		fmt.Printf("File uploaded: %s:%s %d %s\n", info.Id.StorageId, info.Id.OpaqueId, info.Size, info.Path)
+	var failed int
+	for i, f := range files {
+		fmt.Printf("[%d/%d] Uploading %s\n", i+1, len(files), f)
+		if err := uploadFile(ctx, gwc, filepath.Join(root, filepath.FromSlash(f)), path.Join(target, f), protocol, xsFlag); err != nil {
+			if !keepGoing {
+				return errors.Wrapf(err, "upload: error uploading %s", f)
+			}
+			fmt.Printf("Error uploading %s: %v\n", f, err)
+			failed++
+		}
+	}
 
-		return nil
+	fmt.Printf("Uploaded %d files, %d failed\n", len(files)-failed, failed)
+	if failed > 0 {
+		return fmt.Errorf("upload: %d of %d files failed", failed, len(files))
 	}
-	return cmd
+	return nil
 }
 
 func getUploadProtocolInfo(protocolInfos []*gateway.FileUploadProtocol, protocol string) (*gateway.FileUploadProtocol, error) {
@@ -0,0 +1,172 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/httpclient"
+	"google.golang.org/grpc"
+)
+
+// uploadGateway records the containers created and serves the uploads
+// from a data server recording the uploaded files.
+type uploadGateway struct {
+	gateway.UnimplementedGatewayAPIServer
+	dataServer string
+
+	mu         sync.Mutex
+	containers []string
+	uploads    []string
+}
+
+func (g *uploadGateway) CreateContainer(_ context.Context, req *provider.CreateContainerRequest) (*provider.CreateContainerResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.containers = append(g.containers, req.Ref.Path)
+	return &provider.CreateContainerResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}}, nil
+}
+
+func (g *uploadGateway) InitiateFileUpload(_ context.Context, req *provider.InitiateFileUploadRequest) (*gateway.InitiateFileUploadResponse, error) {
+	return &gateway.InitiateFileUploadResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		Protocols: []*gateway.FileUploadProtocol{{
+			Protocol:           "simple",
+			UploadEndpoint:     g.dataServer + req.Ref.Path,
+			AvailableChecksums: []*provider.ResourceChecksumPriority{{Type: provider.ResourceChecksumType_RESOURCE_CHECKSUM_TYPE_ADLER32, Priority: 1}},
+		}},
+	}, nil
+}
+
+func (g *uploadGateway) Stat(_ context.Context, req *provider.StatRequest) (*provider.StatResponse, error) {
+	return &provider.StatResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		Info:   &provider.ResourceInfo{Id: &provider.ResourceId{StorageId: "storage", OpaqueId: "id"}, Path: req.Ref.Path},
+	}, nil
+}
+
+func (g *uploadGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "bad.txt") {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.uploads = append(g.uploads, r.URL.Path)
+}
+
+func startUploadGateway(t *testing.T) *uploadGateway {
+	g := &uploadGateway{}
+	data := httptest.NewServer(g)
+	t.Cleanup(data.Close)
+	g.dataServer = data.URL
+
+	serveGateway(t, g)
+	return g
+}
+
+// serveGateway serves the gateway and points the cli at it.
+func serveGateway(t *testing.T, g gateway.GatewayAPIServer) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	gateway.RegisterGatewayAPIServer(srv, g)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	t.Setenv("HOME", t.TempDir())
+	conf = &config{Host: lis.Addr().String()}
+	insecure, noversioncheck = true, true
+	client = httpclient.New()
+}
+
+// localTree creates the given files, with their parent directories, under a temporary directory.
+func localTree(t *testing.T, files ...string) string {
+	root := t.TempDir()
+	for _, f := range files {
+		p := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(f), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestRecursiveUpload(t *testing.T) {
+	g := startUploadGateway(t)
+	root := localTree(t, "a.txt", "docs/b.txt", "docs/nested/c.txt", "empty/.keep")
+	gwc, err := getClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := uploadTree(context.Background(), gwc, root, "/home/tree", "simple", "negotiate", false); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedContainers := []string{"/home/tree", "/home/tree/docs", "/home/tree/docs/nested", "/home/tree/empty"}
+	if !slices.Equal(g.containers, expectedContainers) {
+		t.Errorf("expected the containers %v, got %v", expectedContainers, g.containers)
+	}
+	expectedUploads := []string{"/home/tree/a.txt", "/home/tree/docs/b.txt", "/home/tree/docs/nested/c.txt", "/home/tree/empty/.keep"}
+	if !slices.Equal(g.uploads, expectedUploads) {
+		t.Errorf("expected the uploads %v, got %v", expectedUploads, g.uploads)
+	}
+}
+
+func TestRecursiveUploadFailures(t *testing.T) {
+	g := startUploadGateway(t)
+	root := localTree(t, "a.txt", "bad.txt", "c.txt")
+	gwc, err := getClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := uploadTree(context.Background(), gwc, root, "/home/tree", "simple", "negotiate", false); err == nil {
+		t.Error("expected the upload to stop at the failed file")
+	}
+	if expected := []string{"/home/tree/a.txt"}; !slices.Equal(g.uploads, expected) {
+		t.Errorf("expected the uploads %v, got %v", expected, g.uploads)
+	}
+
+	g.uploads = nil
+	if err := uploadTree(context.Background(), gwc, root, "/home/tree", "simple", "negotiate", true); err == nil {
+		t.Error("expected the failed file to be reported")
+	}
+	if expected := []string{"/home/tree/a.txt", "/home/tree/c.txt"}; !slices.Equal(g.uploads, expected) {
+		t.Errorf("expected the uploads %v, got %v", expected, g.uploads)
+	}
+}
@@ -49,13 +49,14 @@ import (
 )
 
 var (
-	versionFlag = flag.Bool("version", false, "show version and exit")
-	testFlag    = flag.Bool("t", false, "test configuration and exit")
-	signalFlag  = flag.String("s", "", "send signal to a master process: stop, quit, reload")
-	configFlag  = flag.String("c", "/etc/revad/revad.toml", "set configuration file")
-	pidFlag     = flag.String("p", "", "pid file. If empty defaults to a random file in the OS temporary directory")
-	dirFlag     = flag.String("dev-dir", "", "runs any toml file in the specified directory. Intended for development use only")
-	pluginsFlag = flag.Bool("plugins", false, "list all the plugins and exit")
+	versionFlag  = flag.Bool("version", false, "show version and exit")
+	testFlag     = flag.Bool("t", false, "test configuration and exit")
+	signalFlag   = flag.String("s", "", "send signal to a master process: stop, quit, reload")
+	configFlag   = flag.String("c", "/etc/revad/revad.toml", "set configuration file, - to read it from stdin")
+	pidFlag      = flag.String("p", "", "pid file. If empty defaults to a random file in the OS temporary directory")
+	dirFlag      = flag.String("dev-dir", "", "runs any toml file in the specified directory. Intended for development use only")
+	pluginsFlag  = flag.Bool("plugins", false, "list all the plugins and exit")
+	validateFlag = flag.Bool("validate", false, "validate the configuration, list the configured services and exit")
 
 	// Compile time variables initialized with gcc flags.
 	gitCommit, buildDate, version, goVersion string
@@ -73,6 +74,7 @@ func Main() {
 	handleVersionFlag()
 	handleSignalFlag()
 	handlePluginsFlag()
+	handleValidateFlag()
 
 	confs, err := getConfigs()
 	if err != nil {
@@ -145,6 +147,64 @@ func handlePluginsFlag() {
 	os.Exit(0)
 }
 
+func handleValidateFlag() {
+	if !*validateFlag {
+		return
+	}
+
+	files := getConfigFiles()
+	valid := true
+	for _, file := range files {
+		fmt.Printf("[%s]\n", file)
+		if !validateConfig(file) {
+			valid = false
+		}
+		fmt.Println()
+	}
+	if !valid {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// validateConfig loads the configuration file without constructing
+// any service, printing the configured services and the unrecognized keys.
+func validateConfig(file string) bool {
+	fd, err := openConfig(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error opening %s: %s\n", file, err)
+		return false
+	}
+	defer fd.Close()
+
+	c, warnings, err := config.Validate(fd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error validating %s: %s\n", file, err)
+		return false
+	}
+
+	var services []string
+	list := func(protocol string) config.ServiceFunc {
+		return func(s *config.Service) {
+			port := "-"
+			if p, err := s.Address.Lookup("port"); err == nil {
+				port = fmt.Sprint(p)
+			}
+			services = append(services, fmt.Sprintf("%s %s address=%s port=%s", protocol, s.Name, s.Address, port))
+		}
+	}
+	c.GRPC.ForEachService(list("grpc"))
+	c.HTTP.ForEachService(list("http"))
+	slices.Sort(services)
+	for _, s := range services {
+		fmt.Println(s)
+	}
+	for _, w := range warnings {
+		fmt.Printf("warning: %s\n", w)
+	}
+	return true
+}
+
 func nameOfFunction(f any) string {
 	return gorun.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
 }
@@ -220,12 +280,29 @@ func handleSignalFlag() {
 }
 
 func getConfigs() ([]*config.Config, error) {
+	configs, err := readConfigs(getConfigFiles())
+	if err != nil {
+		return nil, err
+	}
+
+	return configs, nil
+}
+
+// getConfigFiles returns the configuration files to be loaded,
+// exiting if none is found.
+func getConfigFiles() []string {
 	var confs []string
+	if *dirFlag != "" && *configFlag == stdinConfig {
+		fmt.Fprintf(os.Stderr, "cannot read the configuration from stdin together with the -dev-dir flag\n")
+		os.Exit(1)
+	}
+
 	// give priority to read from dev-dir
 	if *dirFlag != "" {
 		cfgs, err := getConfigsFromDir(*dirFlag)
 		if err != nil {
-			return nil, err
+			fmt.Fprintf(os.Stderr, "error reading the configuration directory: %s\n", err.Error())
+			os.Exit(1)
 		}
 		confs = append(confs, cfgs...)
 	} else {
@@ -237,13 +314,7 @@ func getConfigs() ([]*config.Config, error) {
 		fmt.Fprintf(os.Stderr, "no configuration found\n")
 		os.Exit(1)
 	}
-
-	configs, err := readConfigs(confs)
-	if err != nil {
-		return nil, err
-	}
-
-	return configs, nil
+	return confs
 }
 
 func getConfigsFromDir(dir string) (confs []string, err error) {
@@ -271,10 +342,23 @@ func getConfigsFromDir(dir string) (confs []string, err error) {
 	return
 }
 
+// stdinConfig is the configuration file name telling to read
+// the configuration from the standard input.
+const stdinConfig = "-"
+
+// openConfig opens the configuration file, or the standard input
+// if the file is stdinConfig.
+func openConfig(file string) (io.ReadCloser, error) {
+	if file == stdinConfig {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(file)
+}
+
 func readConfigs(files []string) ([]*config.Config, error) {
 	confs := make([]*config.Config, 0, len(files))
 	for _, conf := range files {
-		fd, err := os.Open(conf)
+		fd, err := openConfig(conf)
 		if err != nil {
 			return nil, err
 		}
@@ -308,6 +392,7 @@ func runSingle(conf *config.Config, pidfile string) {
 	reva, err := runtime.New(conf,
 		runtime.WithPidFile(pidfile),
 		runtime.WithLogger(log),
+		runtime.WithVersion(version),
 	)
 	if err != nil {
 		abort(log, "error creating reva runtime: %v", err)
@@ -0,0 +1,62 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package revadcmd
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadConfigsFromStdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		r.Close()
+	})
+
+	if _, err := w.WriteString(`
+[log]
+level = "debug"
+
+[grpc.services.gateway]
+address = "localhost:19000"
+`); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	confs, err := readConfigs([]string{stdinConfig})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(confs) != 1 {
+		t.Fatalf("expected one configuration, got %d", len(confs))
+	}
+	if confs[0].Log.Level != "debug" {
+		t.Errorf("expected log level debug, got %q", confs[0].Log.Level)
+	}
+	if _, ok := confs[0].GRPC.Services["gateway"]; !ok {
+		t.Errorf("expected the gateway service to be configured")
+	}
+}
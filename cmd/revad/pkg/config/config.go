@@ -56,6 +56,7 @@ type Shared struct {
 	DataGateway           string   `default:"http://0.0.0.0:19001/datagateway" key:"datagateway"                        mapstructure:"datagateway"`
 	SkipUserGroupsInToken bool     `key:"skip_user_groups_in_token"            mapstructure:"skip_user_groups_in_token"`
 	BlockedUsers          []string `default:"[]"                               key:"blocked_users"                      mapstructure:"blocked_users"`
+	TrustedProxies        []string `default:"[]"                               key:"trusted_proxies"                    mapstructure:"trusted_proxies"`
 }
 
 // Core holds the core configuration.
@@ -67,6 +68,9 @@ type Core struct {
 	TracingCollector   string `key:"tracing_collector"    mapstructure:"tracing_collector"`
 	TracingServiceName string `key:"tracing_service_name" mapstructure:"tracing_service_name"`
 	TracingService     string `key:"tracing_service"      mapstructure:"tracing_service"`
+	DebugAddress       string `key:"debug_address"        mapstructure:"debug_address"`
+	EnablePprof        bool   `key:"enable_pprof"         mapstructure:"enable_pprof"`
+	GracePeriod        int    `key:"grace_period"         mapstructure:"grace_period"`
 }
 
 // Vars holds the a set of configuration paramenters that
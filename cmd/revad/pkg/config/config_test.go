@@ -234,10 +234,11 @@ nats_token = "secret-token-example"`
 	assert.ErrorIs(t, err, nil)
 
 	assert.Equal(t, &Shared{
-		GatewaySVC:   "localhost:9142",
-		JWTSecret:    "secret",
-		DataGateway:  "http://0.0.0.0:19001/datagateway",
-		BlockedUsers: []string{},
+		GatewaySVC:     "localhost:9142",
+		JWTSecret:      "secret",
+		DataGateway:    "http://0.0.0.0:19001/datagateway",
+		BlockedUsers:   []string{},
+		TrustedProxies: []string{},
 	}, c2.Shared)
 
 	assert.Equal(t, &Log{
@@ -490,6 +491,7 @@ func TestDump(t *testing.T) {
 			"datagateway":               "",
 			"skip_user_groups_in_token": false,
 			"blocked_users":             []any{},
+			"trusted_proxies":           []any{},
 		},
 		"log": map[string]any{
 			"output": "/var/log/revad/revad-gateway.log",
@@ -504,6 +506,9 @@ func TestDump(t *testing.T) {
 			"tracing_service_name": "",
 			"tracing_service":      "",
 			"config_dump_file":     "",
+			"debug_address":        "",
+			"enable_pprof":         false,
+			"grace_period":         0,
 		},
 		"vars": map[string]any{
 			"db_username": "root",
@@ -0,0 +1,103 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+)
+
+// Validate loads the configuration from the reader, without constructing
+// any service, and returns a warning for each key that is not recognized.
+// Only the keys of the sections known to reva are checked, the configuration
+// of the single services is left to the services themselves.
+func Validate(r io.Reader) (*Config, []string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "config: error reading config")
+	}
+
+	c, err := Load(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var raw map[string]any
+	if _, err := toml.NewDecoder(bytes.NewReader(data)).Decode(&raw); err != nil {
+		return nil, nil, errors.Wrap(err, "config: error decoding toml data")
+	}
+
+	var warnings []string
+	unknownKeys(raw, reflect.TypeOf(c), "", &warnings)
+	sort.Strings(warnings)
+	return c, warnings, nil
+}
+
+// unknownKeys appends a warning for each key in raw that does not
+// match the key tag of a field of the struct t, recursing in the
+// fields that are structs themselves.
+func unknownKeys(raw map[string]any, t reflect.Type, prefix string, warnings *[]string) {
+	fields := keyFields(t)
+	for k, v := range raw {
+		name := k
+		if prefix != "" {
+			name = prefix + "." + k
+		}
+		ft, ok := fields[k]
+		if !ok {
+			*warnings = append(*warnings, fmt.Sprintf("unknown key %q", name))
+			continue
+		}
+		if m, ok := v.(map[string]any); ok && isStruct(ft) {
+			unknownKeys(m, ft, name, warnings)
+		}
+	}
+}
+
+// keyFields returns the types of the fields of the struct t
+// indexed by their key tag.
+func keyFields(t reflect.Type) map[string]reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key, _, _ := strings.Cut(f.Tag.Get("key"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		fields[key] = f.Type
+	}
+	return fields
+}
+
+func isStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
@@ -0,0 +1,76 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateUnknownKeys(t *testing.T) {
+	config := `
+[shared]
+jwt_secret = "secret"
+gatewaysvc_typo = "localhost:19000"
+
+[grpc]
+address = "localhost:19000"
+unknown = true
+
+[grpc.services.gateway]
+something = "test"
+
+[vars]
+anything = "value"
+
+[unknown_section]
+key = "value"`
+
+	c, warnings, err := Validate(strings.NewReader(config))
+	if err != nil {
+		t.Fatalf("not expected error: %v", err)
+	}
+
+	assert.Equal(t, "secret", c.Shared.JWTSecret)
+	assert.Equal(t, []string{
+		`unknown key "grpc.unknown"`,
+		`unknown key "shared.gatewaysvc_typo"`,
+		`unknown key "unknown_section"`,
+	}, warnings)
+}
+
+func TestValidateNoWarnings(t *testing.T) {
+	config := `
+[log]
+level = "info"
+
+[http]
+address = "localhost:19001"
+
+[http.services.dataprovider]
+driver = "localhome"`
+
+	_, warnings, err := Validate(strings.NewReader(config))
+	if err != nil {
+		t.Fatalf("not expected error: %v", err)
+	}
+	assert.Empty(t, warnings)
+}
@@ -45,10 +45,16 @@ type Watcher struct {
 	SL        Serverless
 	pidFile   string
 	childPIDs []int
+
+	gracePeriod time.Duration
 }
 
 const revaEnvPrefix = "REVA_FD_"
 
+// defaultGracePeriod is the time given to the servers to drain their
+// connections on a graceful shutdown.
+const defaultGracePeriod = 10 * time.Second
+
 // Option represent an option.
 type Option func(w *Watcher)
 
@@ -66,13 +72,25 @@ func WithPIDFile(fn string) Option {
 	}
 }
 
+// WithGracePeriod specifies how long the servers are given to drain their
+// connections on a graceful shutdown before being forcibly stopped.
+// A non positive duration keeps the default.
+func WithGracePeriod(d time.Duration) Option {
+	return func(w *Watcher) {
+		if d > 0 {
+			w.gracePeriod = d
+		}
+	}
+}
+
 // NewWatcher creates a Watcher.
 func NewWatcher(opts ...Option) *Watcher {
 	w := &Watcher{
-		log:      zerolog.Nop(),
-		graceful: os.Getenv("GRACEFUL") == "true",
-		ppid:     os.Getppid(),
-		ss:       make([]Server, 0),
+		log:         zerolog.Nop(),
+		graceful:    os.Getenv("GRACEFUL") == "true",
+		ppid:        os.Getppid(),
+		ss:          make([]Server, 0),
+		gracePeriod: defaultGracePeriod,
 	}
 
 	for _, opt := range opts {
@@ -91,6 +109,9 @@ func (w *Watcher) Exit(errc int) {
 
 // Clean cleans up existing pid files.
 func (w *Watcher) Clean() {
+	if w.pidFile == "" {
+		return
+	}
 	err := w.clean()
 	if err != nil {
 		w.log.Warn().Err(err).Msg("error removing pid file")
@@ -128,8 +149,12 @@ func (w *Watcher) readPID() (int, error) {
 	return pid, nil
 }
 
+// ErrStalePIDFile is returned when the pidfile points to a process that is
+// not running, or that is not revad.
+var ErrStalePIDFile = errors.New("stale pidfile")
+
 // GetProcessFromFile reads the pidfile and returns the running process or error if the process or file
-// are not available.
+// are not available, or if the pid does not belong to a running revad.
 func GetProcessFromFile(pfile string) (*os.Process, error) {
 	data, err := os.ReadFile(pfile)
 	if err != nil {
@@ -146,9 +171,34 @@ func GetProcessFromFile(pfile string) (*os.Process, error) {
 		return nil, err
 	}
 
+	// on unix systems FindProcess always succeeds, the signal zero
+	// tells whether the process actually exists
+	if err := process.Signal(syscall.Signal(0)); err != nil {
+		return nil, errors.Wrapf(ErrStalePIDFile, "pid %d in %s is not running", pid, pfile)
+	}
+
+	if !isSameExecutable(pid) {
+		return nil, errors.Wrapf(ErrStalePIDFile, "pid %d in %s does not belong to revad", pid, pfile)
+	}
+
 	return process, nil
 }
 
+// isSameExecutable reports whether the process with the given pid runs the
+// same executable as the current process. When this cannot be determined,
+// e.g. on systems without procfs, the process is assumed to match.
+func isSameExecutable(pid int) bool {
+	self, err := os.Executable()
+	if err != nil {
+		return true
+	}
+	exe, err := os.Readlink(filepath.Join("/proc", strconv.Itoa(pid), "exe"))
+	if err != nil {
+		return true
+	}
+	return filepath.Base(strings.TrimSuffix(exe, " (deleted)")) == filepath.Base(self)
+}
+
 // WritePID writes the pid to the configured pid file.
 func (w *Watcher) WritePID() error {
 	// Read in the pid file as a slice of bytes.
@@ -397,10 +447,62 @@ func (w *Watcher) SetServers(s []Server) { w.ss = s }
 // SetServerless sets the serverless that has to be watched.
 func (w *Watcher) SetServerless(s Serverless) { w.SL = s }
 
+// Shutdown gracefully stops the watched servers, letting the in-flight
+// requests complete. The servers still draining after the grace period
+// are forcibly stopped and an error is returned.
+func (w *Watcher) Shutdown() error {
+	done := make(chan error, 1)
+	go func() {
+		done <- w.gracefulStop()
+	}()
+
+	timer := time.NewTimer(w.gracePeriod)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		w.log.Info().Msg("deadline reached before draining active conns, hard stopping ...")
+		w.stop()
+		return fmt.Errorf("servers not drained within the grace period of %s", w.gracePeriod)
+	}
+}
+
+func (w *Watcher) gracefulStop() error {
+	for _, s := range w.ss {
+		w.log.Info().Msgf("fd to %s:%s gracefully closed ", s.Network(), s.Address())
+		if err := s.GracefulStop(); err != nil {
+			return errors.Wrap(err, "error stopping server")
+		}
+	}
+	if w.SL != nil {
+		if err := w.SL.GracefulStop(); err != nil {
+			return errors.Wrap(err, "error stopping serverless server")
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) stop() {
+	for _, s := range w.ss {
+		w.log.Info().Msgf("fd to %s:%s abruptly closed", s.Network(), s.Address())
+		if err := s.Stop(); err != nil {
+			w.log.Error().Err(err).Msg("error stopping server")
+		}
+	}
+	if w.SL != nil {
+		if err := w.SL.Stop(); err != nil {
+			w.log.Error().Err(err).Msg("error stopping serverless server")
+		}
+		w.log.Info().Msg("serverless services abruptly closed")
+	}
+}
+
 // TrapSignals captures the OS signal.
 func (w *Watcher) TrapSignals() {
 	signalCh := make(chan os.Signal, 1024)
-	signal.Notify(signalCh, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT)
+	signal.Notify(signalCh, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
 	for {
 		s := <-signalCh
 		w.log.Info().Msgf("%v signal received", s)
@@ -419,66 +521,18 @@ func (w *Watcher) TrapSignals() {
 				w.childPIDs = append(w.childPIDs, p.Pid)
 			}
 
-		case syscall.SIGQUIT:
-			w.log.Info().Msg("preparing for a graceful shutdown with deadline of 10 seconds")
-			go func() {
-				count := 10
-				ticker := time.NewTicker(time.Second)
-				for ; true; <-ticker.C {
-					w.log.Info().Msgf("shutting down in %d seconds", count-1)
-					count--
-					if count <= 0 {
-						w.log.Info().Msg("deadline reached before draining active conns, hard stopping ...")
-						for _, s := range w.ss {
-							err := s.Stop()
-							if err != nil {
-								w.log.Error().Err(err).Msg("error stopping server")
-							}
-							w.log.Info().Msgf("fd to %s:%s abruptly closed", s.Network(), s.Address())
-						}
-						err := w.SL.Stop()
-						if err != nil {
-							w.log.Error().Err(err).Msg("error stopping serverless server")
-						}
-						w.log.Info().Msg("serverless services abruptly closed")
-						w.Exit(1)
-					}
-				}
-			}()
-			for _, s := range w.ss {
-				w.log.Info().Msgf("fd to %s:%s gracefully closed ", s.Network(), s.Address())
-				err := s.GracefulStop()
-				if err != nil {
-					w.log.Error().Err(err).Msg("error stopping server")
-					w.log.Info().Msg("exit with error code 1")
-					w.Exit(1)
-				}
-			}
-			if w.SL != nil {
-				err := w.SL.GracefulStop()
-				if err != nil {
-					w.log.Error().Err(err).Msg("error stopping server")
-					w.log.Info().Msg("exit with error code 1")
-					w.Exit(1)
-				}
+		case syscall.SIGQUIT, syscall.SIGTERM:
+			w.log.Info().Msgf("preparing for a graceful shutdown with deadline of %s", w.gracePeriod)
+			if err := w.Shutdown(); err != nil {
+				w.log.Error().Err(err).Msg("error shutting down gracefully")
+				w.log.Info().Msg("exit with error code 1")
+				w.Exit(1)
 			}
 			w.log.Info().Msg("exit with error code 0")
 			w.Exit(0)
-		case syscall.SIGINT, syscall.SIGTERM:
+		case syscall.SIGINT:
 			w.log.Info().Msg("preparing for hard shutdown, aborting all conns")
-			for _, s := range w.ss {
-				w.log.Info().Msgf("fd to %s:%s abruptly closed", s.Network(), s.Address())
-				err := s.Stop()
-				if err != nil {
-					w.log.Error().Err(err).Msg("error stopping server")
-				}
-			}
-			err := w.SL.Stop()
-			if err != nil {
-				w.log.Error().Err(err).Msg("error stopping serverless server")
-			}
-			w.log.Info().Msg("serverless services abruptly closed")
-
+			w.stop()
 			w.Exit(0)
 		}
 	}
@@ -0,0 +1,150 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package grace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// httpServer is a Server wrapping a plain http server.
+type httpServer struct {
+	srv *http.Server
+	ln  net.Listener
+}
+
+func (s *httpServer) Start(ln net.Listener) error {
+	s.ln = ln
+	if err := s.srv.Serve(ln); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (s *httpServer) Stop() error         { return s.srv.Close() }
+func (s *httpServer) GracefulStop() error { return s.srv.Shutdown(context.Background()) }
+func (s *httpServer) Network() string     { return "tcp" }
+func (s *httpServer) Address() string     { return s.ln.Addr().String() }
+
+// startInFlight starts a server whose handler takes the given time to
+// complete, and issues a request to it. It returns once the request is
+// being handled, together with the channel receiving the request outcome.
+func startInFlight(t *testing.T, w *Watcher, handling time.Duration) <-chan error {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	s := &httpServer{
+		srv: &http.Server{Handler: http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(handling)
+			rw.WriteHeader(http.StatusOK)
+		})},
+	}
+	go func() { _ = s.Start(ln) }()
+	w.SetServers([]Server{s})
+
+	res := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err == nil {
+			resp.Body.Close()
+		}
+		res <- err
+	}()
+	<-started
+	return res
+}
+
+func TestShutdownWithinGracePeriod(t *testing.T) {
+	w := NewWatcher(WithGracePeriod(2 * time.Second))
+	res := startInFlight(t, w, 200*time.Millisecond)
+
+	if err := w.Shutdown(); err != nil {
+		t.Fatalf("unexpected error shutting down: %v", err)
+	}
+	if err := <-res; err != nil {
+		t.Fatalf("expected the in-flight request to complete, got %v", err)
+	}
+}
+
+func TestShutdownPastGracePeriod(t *testing.T) {
+	w := NewWatcher(WithGracePeriod(100 * time.Millisecond))
+	res := startInFlight(t, w, 5*time.Second)
+
+	start := time.Now()
+	if err := w.Shutdown(); err == nil {
+		t.Fatal("expected an error when the grace period is exceeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected the shutdown to be forced after the grace period, took %s", elapsed)
+	}
+	if err := <-res; err == nil {
+		t.Fatal("expected the in-flight request to be aborted")
+	}
+}
+
+func TestCleanRemovesPIDFile(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "revad.pid")
+	w := NewWatcher(WithPIDFile(pidFile))
+	if err := w.WritePID(); err != nil {
+		t.Fatal(err)
+	}
+
+	w.Clean()
+
+	if _, err := os.Stat(pidFile); !os.IsNotExist(err) {
+		t.Fatalf("expected the pid file to be removed, got %v", err)
+	}
+}
+
+func TestGetProcessFromFileStale(t *testing.T) {
+	// pids are bounded by pid_max, which is at most 2^22 on linux
+	pidFile := filepath.Join(t.TempDir(), "revad.pid")
+	if err := os.WriteFile(pidFile, []byte("99999999"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := GetProcessFromFile(pidFile); !errors.Is(err, ErrStalePIDFile) {
+		t.Fatalf("expected a stale pidfile error, got %v", err)
+	}
+}
+
+func TestGetProcessFromFileRunning(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "revad.pid")
+	if err := os.WriteFile(pidFile, []byte(fmt.Sprintf("%d", os.Getpid())), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := GetProcessFromFile(pidFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Pid != os.Getpid() {
+		t.Fatalf("expected pid %d, got %d", os.Getpid(), p.Pid)
+	}
+}
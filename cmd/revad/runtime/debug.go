@@ -0,0 +1,90 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package runtime
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// debugLabel is the label of the listener of the debug server.
+const debugLabel = "debug"
+
+// debugServer serves the runtime endpoints, like the health of
+// the services and optionally the pprof profiles, on an address
+// separated from the services one.
+type debugServer struct {
+	httpServer *http.Server
+	listener   net.Listener
+	log        *zerolog.Logger
+}
+
+func newDebugServer(servers []*Server, enablePprof bool, log *zerolog.Logger) *debugServer {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/health", healthHandler(servers))
+	if enablePprof {
+		// example: /debug/pprof/profile
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+	return &debugServer{
+		httpServer: &http.Server{Handler: mux},
+		log:        log,
+	}
+}
+
+// Start starts the debug server.
+func (s *debugServer) Start(ln net.Listener) error {
+	s.listener = ln
+	s.log.Info().Msgf("debug server listening at http://%s", ln.Addr())
+	if err := s.httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Stop stops the debug server.
+func (s *debugServer) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return s.httpServer.Shutdown(ctx)
+}
+
+// GracefulStop gracefully stops the debug server.
+func (s *debugServer) GracefulStop() error {
+	return s.httpServer.Shutdown(context.Background())
+}
+
+// Network returns the network type.
+func (s *debugServer) Network() string {
+	return s.listener.Addr().Network()
+}
+
+// Address returns the network address.
+func (s *debugServer) Address() string {
+	return s.listener.Addr().String()
+}
@@ -0,0 +1,77 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package runtime
+
+import (
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func startDebugServer(t *testing.T, enablePprof bool) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error creating listener: %v", err)
+	}
+	log := zerolog.Nop()
+	s := newDebugServer(nil, enablePprof, &log)
+	go func() { _ = s.Start(ln) }()
+	t.Cleanup(func() { _ = s.Stop() })
+	return "http://" + ln.Addr().String()
+}
+
+func statusCode(t *testing.T, url string) int {
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("error getting %s: %v", url, err)
+	}
+	defer res.Body.Close()
+	return res.StatusCode
+}
+
+func TestDebugServerPprof(t *testing.T) {
+	tests := map[string]struct {
+		enablePprof bool
+		expected    int
+	}{
+		"enabled": {
+			enablePprof: true,
+			expected:    http.StatusOK,
+		},
+		"disabled": {
+			enablePprof: false,
+			expected:    http.StatusNotFound,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			addr := startDebugServer(t, tt.enablePprof)
+			if code := statusCode(t, addr+"/debug/pprof/"); code != tt.expected {
+				t.Fatalf("unexpected status code for the pprof index: got %d, expected %d", code, tt.expected)
+			}
+			// the health endpoint is always served
+			if code := statusCode(t, addr+"/debug/health"); code != http.StatusOK {
+				t.Fatalf("unexpected status code for the health endpoint: got %d", code)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/cs3org/reva/pkg/appctx"
+)
+
+// HealthChecker is the interface that the grpc and http services
+// can implement to report their health in the debug health endpoint.
+type HealthChecker interface {
+	// Healthy returns an error if the service is not healthy.
+	Healthy(ctx context.Context) error
+}
+
+const (
+	healthStatusHealthy   = "healthy"
+	healthStatusUnhealthy = "unhealthy"
+	// healthStatusUnknown is reported for the services not implementing
+	// the HealthChecker interface, that are considered healthy.
+	healthStatusUnknown = "unknown"
+)
+
+type serviceHealth struct {
+	Service string `json:"service"`
+	Address string `json:"address"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+type healthReport struct {
+	Status   string          `json:"status"`
+	Services []serviceHealth `json:"services"`
+}
+
+// checkHealth returns the health of all the services run by the servers.
+func checkHealth(ctx context.Context, servers []*Server) healthReport {
+	report := healthReport{Status: healthStatusHealthy, Services: []serviceHealth{}}
+	for _, s := range servers {
+		for name, svc := range s.services {
+			h := serviceHealth{
+				Service: name,
+				Address: s.listener.Addr().String(),
+				Status:  healthStatusUnknown,
+			}
+			if c, ok := svc.(HealthChecker); ok {
+				h.Status = healthStatusHealthy
+				if err := c.Healthy(ctx); err != nil {
+					h.Status = healthStatusUnhealthy
+					h.Error = err.Error()
+					report.Status = healthStatusUnhealthy
+				}
+			}
+			report.Services = append(report.Services, h)
+		}
+	}
+	slices.SortFunc(report.Services, func(a, b serviceHealth) int {
+		if c := strings.Compare(a.Service, b.Service); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Address, b.Address)
+	})
+	return report
+}
+
+// healthHandler reports the aggregated health of the services,
+// responding with 200 only if all of them are healthy.
+func healthHandler(servers []*Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log := appctx.GetLogger(r.Context())
+		report := checkHealth(r.Context(), servers)
+
+		w.Header().Set("Content-Type", "application/json")
+		if report.Status != healthStatusHealthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			log.Error().Err(err).Msg("error encoding health report")
+		}
+	})
+}
@@ -0,0 +1,103 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type healthyService struct{}
+
+func (healthyService) Healthy(context.Context) error { return nil }
+
+type unhealthyService struct{}
+
+func (unhealthyService) Healthy(context.Context) error { return errors.New("database unreachable") }
+
+type plainService struct{}
+
+func newTestServer(t *testing.T, services map[string]any) *Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error creating listener: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	return &Server{listener: ln, services: services}
+}
+
+func getHealth(t *testing.T, servers []*Server) (int, healthReport) {
+	w := httptest.NewRecorder()
+	healthHandler(servers).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/health", nil))
+
+	var report healthReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("error decoding health report: %v", err)
+	}
+	return w.Code, report
+}
+
+func TestHealthHandler(t *testing.T) {
+	gs := newTestServer(t, map[string]any{
+		"gateway":      healthyService{},
+		"authregistry": plainService{},
+	})
+	hs := newTestServer(t, map[string]any{
+		"ocdav": healthyService{},
+	})
+
+	code, report := getHealth(t, []*Server{gs, hs})
+	assert.Equal(t, 200, code)
+	assert.Equal(t, healthReport{
+		Status: "healthy",
+		Services: []serviceHealth{
+			{Service: "authregistry", Address: gs.listener.Addr().String(), Status: "unknown"},
+			{Service: "gateway", Address: gs.listener.Addr().String(), Status: "healthy"},
+			{Service: "ocdav", Address: hs.listener.Addr().String(), Status: "healthy"},
+		},
+	}, report)
+}
+
+func TestHealthHandlerUnhealthy(t *testing.T) {
+	gs := newTestServer(t, map[string]any{
+		"gateway":         healthyService{},
+		"storageprovider": unhealthyService{},
+	})
+	hs := newTestServer(t, map[string]any{
+		"ocdav": plainService{},
+	})
+
+	code, report := getHealth(t, []*Server{gs, hs})
+	assert.Equal(t, 503, code)
+	assert.Equal(t, healthReport{
+		Status: "unhealthy",
+		Services: []serviceHealth{
+			{Service: "gateway", Address: gs.listener.Addr().String(), Status: "healthy"},
+			{Service: "ocdav", Address: hs.listener.Addr().String(), Status: "unknown"},
+			{Service: "storageprovider", Address: gs.listener.Addr().String(), Status: "unhealthy", Error: "database unreachable"},
+		},
+	}, report)
+}
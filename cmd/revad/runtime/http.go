@@ -22,6 +22,7 @@ import (
 	"path"
 	"sort"
 
+	"github.com/cs3org/reva/internal/http/interceptors/accesslog"
 	"github.com/cs3org/reva/internal/http/interceptors/appctx"
 	"github.com/cs3org/reva/internal/http/interceptors/auth"
 	"github.com/cs3org/reva/internal/http/interceptors/log"
@@ -67,12 +68,23 @@ func initHTTPMiddlewares(conf map[string]map[string]any, unprotected []string, l
 		return nil, errors.Wrap(err, "rhttp: error creating auth middleware")
 	}
 
-	middlewares := []global.Middleware{
-		authMiddle,
+	middlewares := []global.Middleware{authMiddle}
+	// the access log runs before the auth middleware so that the
+	// requests rejected by the authentication are logged too
+	if c, ok := conf["accesslog"]; ok {
+		accessLog, err := accesslog.New(c)
+		if err != nil {
+			return nil, errors.Wrap(err, "rhttp: error creating access log middleware")
+		}
+		middlewares = append(middlewares, accessLog)
+		logger.Info().Msg("http middleware enabled: accesslog")
+	}
+
+	middlewares = append(middlewares,
 		log.New(),
 		appctx.New(*logger),
 		trace.New(),
-	}
+	)
 
 	for _, triple := range triples {
 		middlewares = append(middlewares, triple.Middleware)
@@ -34,6 +34,7 @@ type Options struct {
 	Registry registry.Registry
 	PidFile  string
 	Ctx      context.Context
+	Version  string
 }
 
 // newOptions initializes the available default options.
@@ -78,3 +79,10 @@ func WithContext(ctx context.Context) Option {
 		o.Ctx = ctx
 	}
 }
+
+// WithVersion sets the version of revad advertised to the clients.
+func WithVersion(version string) Option {
+	return func(o *Options) {
+		o.Version = version
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package runtime
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cs3org/reva/cmd/revad/pkg/config"
+	"github.com/cs3org/reva/pkg/utils/maps"
+)
+
+// dependsOnKey is the key in the configuration of a service
+// listing the services that must be started before it.
+const dependsOnKey = "depends_on"
+
+// serverGroup is the configuration of a server, either grpc or http,
+// holding the services listening on the same address.
+type serverGroup struct {
+	grpc *config.GRPC
+	http *config.HTTP
+	// level is the startup level of the server: a server is started
+	// once the servers of all the lower levels accept connections.
+	level int
+}
+
+func (g serverGroup) name() string {
+	if g.grpc != nil {
+		return "grpc server at " + g.grpc.Address.String()
+	}
+	return "http server at " + g.http.Address.String()
+}
+
+func (g serverGroup) services() map[string]config.ServicesConfig {
+	if g.grpc != nil {
+		return g.grpc.Services
+	}
+	return g.http.Services
+}
+
+// orderServers returns the servers in the order they have to be started,
+// so that a server starts after the servers of the services its services
+// depend on, and assigns to each server its startup level.
+// The servers without dependencies keep their relative order.
+// An error is returned if a service depends on an unknown service or if
+// the dependencies are cyclic.
+func orderServers(grpc []*config.GRPC, http []*config.HTTP) ([]serverGroup, error) {
+	groups := make([]serverGroup, 0, len(grpc)+len(http))
+	for _, c := range grpc {
+		groups = append(groups, serverGroup{grpc: c})
+	}
+	for _, c := range http {
+		groups = append(groups, serverGroup{http: c})
+	}
+
+	// the groups providing each service
+	providers := map[string][]int{}
+	// the dependencies of each service
+	services := map[string][]string{}
+	var names []string
+	for i, g := range groups {
+		for name, cfg := range g.services() {
+			providers[name] = append(providers[name], i)
+			if _, ok := services[name]; !ok {
+				names = append(names, name)
+			}
+			for _, c := range cfg {
+				deps, err := dependsOn(name, c.Config)
+				if err != nil {
+					return nil, err
+				}
+				services[name] = append(services[name], deps...)
+			}
+		}
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		for _, d := range services[name] {
+			if _, ok := providers[d]; !ok {
+				return nil, fmt.Errorf("service %s depends on unknown service %s", name, d)
+			}
+		}
+	}
+
+	// services in the same server are started together, still they cannot depend on each other cyclically
+	if _, err := topologicalSort(names, func(name string) []string { return services[name] }); err != nil {
+		return nil, err
+	}
+
+	nodes := make([]string, 0, len(groups))
+	index := make(map[string]int, len(groups))
+	for i, g := range groups {
+		nodes = append(nodes, g.name())
+		index[g.name()] = i
+	}
+	serverDeps := func(node string) []string {
+		var deps []string
+		svcs := groups[index[node]].services()
+		for _, name := range maps.Keys(svcs) {
+			for _, d := range services[name] {
+				for _, p := range providers[d] {
+					if p != index[node] {
+						deps = append(deps, nodes[p])
+					}
+				}
+			}
+		}
+		slices.Sort(deps)
+		return slices.Compact(deps)
+	}
+	sorted, err := topologicalSort(nodes, serverDeps)
+	if err != nil {
+		return nil, err
+	}
+
+	// the dependencies of a node are sorted before it, so their level is already known
+	levels := make(map[string]int, len(sorted))
+	ordered := make([]serverGroup, 0, len(sorted))
+	for _, node := range sorted {
+		for _, d := range serverDeps(node) {
+			levels[node] = max(levels[node], levels[d]+1)
+		}
+		g := groups[index[node]]
+		g.level = levels[node]
+		ordered = append(ordered, g)
+	}
+	return ordered, nil
+}
+
+// dependsOn returns the services listed in the depends_on key of the service configuration.
+func dependsOn(name string, cfg map[string]any) ([]string, error) {
+	v, ok := cfg[dependsOnKey]
+	if !ok {
+		return nil, nil
+	}
+	switch deps := v.(type) {
+	case string:
+		return []string{deps}, nil
+	case []string:
+		return deps, nil
+	case []any:
+		l := make([]string, 0, len(deps))
+		for _, d := range deps {
+			s, ok := d.(string)
+			if !ok {
+				return nil, fmt.Errorf("service %s: %s must be a list of service names, got %T", name, dependsOnKey, d)
+			}
+			l = append(l, s)
+		}
+		return l, nil
+	}
+	return nil, fmt.Errorf("service %s: %s must be a list of service names, got %T", name, dependsOnKey, v)
+}
+
+// topologicalSort sorts the nodes so that each node comes after its dependencies,
+// preserving the given order between independent nodes.
+// It returns an error describing the cycle if the dependencies are cyclic.
+func topologicalSort(nodes []string, deps func(string) []string) ([]string, error) {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int, len(nodes))
+	sorted := make([]string, 0, len(nodes))
+	var path []string
+
+	var visit func(string) error
+	visit = func(n string) error {
+		switch state[n] {
+		case visited:
+			return nil
+		case visiting:
+			cycle := append(path[slices.Index(path, n):], n)
+			return fmt.Errorf("cyclic startup dependency: %s", strings.Join(cycle, " -> "))
+		}
+		state[n] = visiting
+		path = append(path, n)
+		for _, d := range deps(n) {
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[n] = visited
+		sorted = append(sorted, n)
+		return nil
+	}
+
+	for _, n := range nodes {
+		if err := visit(n); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
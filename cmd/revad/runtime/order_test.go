@@ -0,0 +1,265 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package runtime
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cs3org/reva/cmd/revad/pkg/config"
+	"golang.org/x/sync/errgroup"
+)
+
+func grpcServer(address string, services map[string]map[string]any) *config.GRPC {
+	s := make(map[string]config.ServicesConfig, len(services))
+	for name, cfg := range services {
+		s[name] = config.ServicesConfig{{Config: cfg, Address: config.Address(address)}}
+	}
+	return &config.GRPC{Address: config.Address(address), Services: s}
+}
+
+func httpServer(address string, services map[string]map[string]any) *config.HTTP {
+	s := make(map[string]config.ServicesConfig, len(services))
+	for name, cfg := range services {
+		s[name] = config.ServicesConfig{{Config: cfg, Address: config.Address(address)}}
+	}
+	return &config.HTTP{Address: config.Address(address), Services: s}
+}
+
+func serviceNames(groups []serverGroup) []string {
+	var names []string
+	for _, g := range groups {
+		names = append(names, g.name())
+	}
+	return names
+}
+
+func TestOrderServers(t *testing.T) {
+	grpc := []*config.GRPC{
+		grpcServer("localhost:9001", map[string]map[string]any{
+			"storageprovider": {"depends_on": []any{"gateway"}},
+		}),
+		grpcServer("localhost:9000", map[string]map[string]any{
+			"gateway":         {"depends_on": []any{"authregistry"}},
+			"storageregistry": {},
+		}),
+		grpcServer("localhost:9002", map[string]map[string]any{
+			"authregistry": {},
+		}),
+	}
+	http := []*config.HTTP{
+		httpServer("localhost:8080", map[string]map[string]any{
+			"ocdav": {"depends_on": "storageprovider"},
+		}),
+		httpServer("localhost:8081", map[string]map[string]any{
+			"prometheus": {},
+		}),
+	}
+
+	groups, err := orderServers(grpc, http)
+	if err != nil {
+		t.Fatalf("not expected error: %v", err)
+	}
+
+	exp := []string{
+		"grpc server at localhost:9002",
+		"grpc server at localhost:9000",
+		"grpc server at localhost:9001",
+		"http server at localhost:8080",
+		"http server at localhost:8081",
+	}
+	got := serviceNames(groups)
+	if strings.Join(got, ",") != strings.Join(exp, ",") {
+		t.Fatalf("unexpected order: got %v, expected %v", got, exp)
+	}
+
+	expLevels := []int{0, 1, 2, 3, 0}
+	for i, g := range groups {
+		if g.level != expLevels[i] {
+			t.Fatalf("unexpected level for %s: got %d, expected %d", g.name(), g.level, expLevels[i])
+		}
+	}
+}
+
+func TestOrderServersWithoutDependencies(t *testing.T) {
+	grpc := []*config.GRPC{
+		grpcServer("localhost:9001", map[string]map[string]any{"storageprovider": {}}),
+		grpcServer("localhost:9000", map[string]map[string]any{"gateway": {}}),
+	}
+	http := []*config.HTTP{
+		httpServer("localhost:8080", map[string]map[string]any{"ocdav": {}}),
+	}
+
+	groups, err := orderServers(grpc, http)
+	if err != nil {
+		t.Fatalf("not expected error: %v", err)
+	}
+
+	exp := []string{
+		"grpc server at localhost:9001",
+		"grpc server at localhost:9000",
+		"http server at localhost:8080",
+	}
+	got := serviceNames(groups)
+	if strings.Join(got, ",") != strings.Join(exp, ",") {
+		t.Fatalf("unexpected order: got %v, expected %v", got, exp)
+	}
+}
+
+func TestOrderServersErrors(t *testing.T) {
+	tests := map[string]struct {
+		grpc []*config.GRPC
+		err  string
+	}{
+		"cycle between servers": {
+			grpc: []*config.GRPC{
+				grpcServer("localhost:9000", map[string]map[string]any{
+					"gateway": {"depends_on": []any{"storageprovider"}},
+				}),
+				grpcServer("localhost:9001", map[string]map[string]any{
+					"storageprovider": {"depends_on": []any{"gateway"}},
+				}),
+			},
+			err: "cyclic startup dependency: gateway -> storageprovider -> gateway",
+		},
+		"cycle in the same server": {
+			grpc: []*config.GRPC{
+				grpcServer("localhost:9000", map[string]map[string]any{
+					"gateway":         {"depends_on": []any{"storageprovider"}},
+					"storageprovider": {"depends_on": []any{"gateway"}},
+				}),
+			},
+			err: "cyclic startup dependency: gateway -> storageprovider -> gateway",
+		},
+		"unknown service": {
+			grpc: []*config.GRPC{
+				grpcServer("localhost:9000", map[string]map[string]any{
+					"gateway": {"depends_on": []any{"authregistry"}},
+				}),
+			},
+			err: "service gateway depends on unknown service authregistry",
+		},
+		"invalid declaration": {
+			grpc: []*config.GRPC{
+				grpcServer("localhost:9000", map[string]map[string]any{
+					"gateway": {"depends_on": 1},
+				}),
+			},
+			err: "service gateway: depends_on must be a list of service names, got int",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := orderServers(tt.grpc, nil)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if err.Error() != tt.err {
+				t.Fatalf("unexpected error: got %q, expected %q", err.Error(), tt.err)
+			}
+		})
+	}
+}
+
+// recordingServer is a server recording when it is started
+// and when it starts accepting connections.
+type recordingServer struct {
+	name   string
+	delay  time.Duration
+	mu     *sync.Mutex
+	events *[]string
+	ln     net.Listener
+}
+
+func (s *recordingServer) record(event string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*s.events = append(*s.events, event+" "+s.name)
+}
+
+func (s *recordingServer) Start(ln net.Listener) error {
+	s.ln = ln
+	s.record("start")
+	time.Sleep(s.delay)
+	s.record("ready")
+	for {
+		c, err := ln.Accept()
+		if err != nil {
+			return nil
+		}
+		c.Close()
+	}
+}
+
+func (s *recordingServer) Stop() error         { return s.ln.Close() }
+func (s *recordingServer) GracefulStop() error { return s.Stop() }
+func (s *recordingServer) Network() string     { return "tcp" }
+func (s *recordingServer) Address() string     { return s.ln.Addr().String() }
+
+func TestStartServers(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	newServer := func(name string, level int, delay time.Duration) *Server {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("error creating listener: %v", err)
+		}
+		t.Cleanup(func() { ln.Close() })
+		return &Server{
+			server:   &recordingServer{name: name, delay: delay, mu: &mu, events: &events},
+			listener: ln,
+			level:    level,
+		}
+	}
+	servers := []*Server{
+		newServer("authregistry", 0, 50*time.Millisecond),
+		newServer("gateway", 1, 0),
+		newServer("prometheus", 0, 0),
+	}
+
+	var g errgroup.Group
+	if err := startServers(context.Background(), &g, servers); err != nil {
+		t.Fatalf("not expected error: %v", err)
+	}
+
+	mu.Lock()
+	got := slices.Clone(events)
+	mu.Unlock()
+	start := slices.Index(got, "start gateway")
+	for _, dep := range []string{"ready authregistry", "ready prometheus"} {
+		if i := slices.Index(got, dep); i == -1 || i > start {
+			t.Fatalf("gateway started before its dependencies were ready: %v", got)
+		}
+	}
+
+	for _, s := range servers {
+		_ = s.server.Stop()
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatalf("not expected error: %v", err)
+	}
+}
@@ -20,14 +20,18 @@ package runtime
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"os/signal"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/pkg/errors"
@@ -66,6 +70,9 @@ type Reva struct {
 type Server struct {
 	server   grace.Server
 	listener net.Listener
+	// level is the startup level of the server,
+	// see serverGroup for its meaning.
+	level int
 
 	services map[string]any
 }
@@ -90,7 +97,7 @@ func New(config *config.Config, opt ...Option) (*Reva, error) {
 		return nil, errors.New("pid file not provided")
 	}
 
-	watcher, err := initWatcher(opts.PidFile, log)
+	watcher, err := initWatcher(opts.PidFile, config.Core, log)
 	if err != nil {
 		return nil, err
 	}
@@ -109,13 +116,24 @@ func New(config *config.Config, opt ...Option) (*Reva, error) {
 	}
 	initSharedConf(config)
 
-	grpc := groupGRPCByAddress(config)
-	http := groupHTTPByAddress(config)
-	servers, err := newServers(ctx, grpc, http, listeners, log)
+	groups, err := orderServers(groupGRPCByAddress(config), groupHTTPByAddress(config))
 	if err != nil {
 		watcher.Clean()
 		return nil, err
 	}
+	servers, err := newServers(ctx, groups, listeners, opts.Version, log)
+	if err != nil {
+		watcher.Clean()
+		return nil, err
+	}
+	if config.Core.DebugAddress != "" {
+		servers = append(servers, &Server{
+			server:   newDebugServer(servers, config.Core.EnablePprof, log),
+			listener: listeners[debugLabel],
+		})
+	} else if config.Core.EnablePprof {
+		log.Warn().Msg("pprof is enabled but no debug address is configured, pprof will not be exposed")
+	}
 
 	serverless, err := newServerless(ctx, config, log)
 	if err != nil {
@@ -176,6 +194,9 @@ func servicesAddresses(cfg *config.Config) map[string]grace.Addressable {
 	cfg.HTTP.ForEachService(func(s *config.Service) {
 		a[s.Label] = &addr{address: s.Address.String(), network: s.Network}
 	})
+	if cfg.Core.DebugAddress != "" {
+		a[debugLabel] = &addr{address: cfg.Core.DebugAddress, network: "tcp"}
+	}
 	return a
 }
 
@@ -294,13 +315,10 @@ func (r *Reva) Start() error {
 	r.watcher.SetServers(list.Map(r.servers, func(s *Server) grace.Server { return s.server }))
 	r.watcher.SetServerless(r.serverless)
 
-	var g errgroup.Group
-	for _, server := range r.servers {
-		server := server
-		g.Go(func() error {
-			return server.Start()
-		})
-	}
+	g, ctx := errgroup.WithContext(r.ctx)
+	g.Go(func() error {
+		return startServers(ctx, g, r.servers)
+	})
 
 	g.Go(func() error {
 		return r.serverless.Start()
@@ -310,12 +328,75 @@ func (r *Reva) Start() error {
 	return g.Wait()
 }
 
+// startServers starts the servers following the order of their dependencies,
+// one startup level at a time. The servers of a level are started once all
+// the servers of the previous levels accept connections.
+// The servers run in the given group, and their failures are reported by it.
+func startServers(ctx context.Context, g *errgroup.Group, servers []*Server) error {
+	for _, level := range serverLevels(servers) {
+		ready := make([]chan struct{}, 0, len(level))
+		for _, server := range level {
+			ln := newReadyListener(server.listener)
+			ready = append(ready, ln.ready)
+			g.Go(func() error {
+				// a server stopping before accepting connections
+				// must not block the start of the next levels
+				defer ln.setReady()
+				return server.server.Start(ln)
+			})
+		}
+		for _, c := range ready {
+			select {
+			case <-c:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// serverLevels groups the servers by startup level,
+// keeping the relative order of the servers in the same level.
+func serverLevels(servers []*Server) [][]*Server {
+	var levels [][]*Server
+	for _, s := range servers {
+		for len(levels) <= s.level {
+			levels = append(levels, nil)
+		}
+		levels[s.level] = append(levels[s.level], s)
+	}
+	return levels
+}
+
+// readyListener is a listener signaling when the
+// server using it starts accepting connections.
+type readyListener struct {
+	net.Listener
+	once  sync.Once
+	ready chan struct{}
+}
+
+func newReadyListener(ln net.Listener) *readyListener {
+	return &readyListener{Listener: ln, ready: make(chan struct{})}
+}
+
+func (l *readyListener) setReady() {
+	l.once.Do(func() { close(l.ready) })
+}
+
+// Accept signals that the listener is ready and waits for the next connection.
+func (l *readyListener) Accept() (net.Conn, error) {
+	l.setReady()
+	return l.Listener.Accept()
+}
+
 func initSharedConf(config *config.Config) {
 	sharedconf.Init(config.Shared)
 }
 
-func initWatcher(filename string, log *zerolog.Logger) (*grace.Watcher, error) {
-	return handlePIDFlag(log, filename)
+func initWatcher(filename string, conf *config.Core, log *zerolog.Logger) (*grace.Watcher, error) {
+	return handlePIDFlag(log, filename, time.Duration(conf.GracePeriod)*time.Second)
 	// TODO(labkode): maybe pidfile can be created later on? like once a server is going to be created?
 }
 
@@ -332,10 +413,11 @@ func initCPUCount(conf *config.Core, log *zerolog.Logger) error {
 	return nil
 }
 
-func handlePIDFlag(l *zerolog.Logger, pidFile string) (*grace.Watcher, error) {
+func handlePIDFlag(l *zerolog.Logger, pidFile string, gracePeriod time.Duration) (*grace.Watcher, error) {
 	w := grace.NewWatcher(
 		grace.WithPIDFile(pidFile),
 		grace.WithLogger(l.With().Str("pkg", "grace").Logger()),
+		grace.WithGracePeriod(gracePeriod),
 	)
 	err := w.WritePID()
 	if err != nil {
@@ -394,71 +476,118 @@ func listenerFromAddress(lns map[string]net.Listener, network string, address co
 	panic(fmt.Sprintf("listener not found for address %s:%s", network, address))
 }
 
-func newServers(ctx context.Context, grpc []*config.GRPC, http []*config.HTTP, lns map[string]net.Listener, log *zerolog.Logger) ([]*Server, error) {
-	servers := make([]*Server, 0, len(grpc)+len(http))
-	for _, cfg := range grpc {
-		logger := log.With().Str("pkg", "grpc").Logger()
-		ctx := appctx.WithLogger(ctx, &logger)
-		services, err := rgrpc.InitServices(ctx, cfg.Services)
-		if err != nil {
-			return nil, err
-		}
-		unaryChain, streamChain, err := initGRPCInterceptors(cfg.Interceptors, grpcUnprotected(cfg.EnableReflection, services), log)
-		if err != nil {
-			return nil, err
-		}
-		s, err := rgrpc.NewServer(
-			rgrpc.EnableReflection(cfg.EnableReflection),
-			rgrpc.WithShutdownDeadline(cfg.ShutdownDeadline),
-			rgrpc.WithLogger(logger),
-			rgrpc.WithServices(services),
-			rgrpc.WithUnaryServerInterceptors(unaryChain),
-			rgrpc.WithStreamServerInterceptors(streamChain),
+func newServers(ctx context.Context, groups []serverGroup, lns map[string]net.Listener, version string, log *zerolog.Logger) ([]*Server, error) {
+	servers := make([]*Server, 0, len(groups))
+	// a failing server does not stop the initialization of the others,
+	// so that all the broken services are reported together
+	var errs []error
+	for _, g := range groups {
+		var (
+			server *Server
+			err    error
 		)
-		if err != nil {
-			return nil, err
+		if g.grpc != nil {
+			server, err = newGRPCServer(ctx, g.grpc, lns, version, log)
+		} else {
+			server, err = newHTTPServer(ctx, g.http, lns, log)
 		}
-		ln := listenerFromAddress(lns, cfg.Network, cfg.Address)
-		server := &Server{
-			server:   s,
-			listener: ln,
-			services: maps.MapValues(services, func(s rgrpc.Service) any { return s }),
+		if err != nil {
+			errs = append(errs, err)
+			continue
 		}
-		log.Debug().
-			Interface("services", maps.Keys(cfg.Services)).
-			Msgf("spawned grpc server for services listening at %s:%s", ln.Addr().Network(), ln.Addr().String())
+		server.level = g.level
 		servers = append(servers, server)
 	}
-	for _, cfg := range http {
-		logger := log.With().Str("pkg", "http").Logger()
-		ctx := appctx.WithLogger(ctx, &logger)
-		services, err := rhttp.InitServices(ctx, cfg.Services)
-		if err != nil {
-			return nil, err
+	if len(errs) > 0 {
+		// the servers already created will never be started
+		for _, s := range servers {
+			closeServices(s.services, log)
 		}
-		middlewares, err := initHTTPMiddlewares(cfg.Middlewares, httpUnprotected(services), &logger)
-		if err != nil {
-			return nil, err
-		}
-		s, err := rhttp.New(
-			rhttp.WithServices(services),
-			rhttp.WithLogger(logger),
-			rhttp.WithCertAndKeyFiles(cfg.CertFile, cfg.KeyFile),
-			rhttp.WithMiddlewares(middlewares),
-		)
-		if err != nil {
-			return nil, err
+		return nil, stderrors.Join(errs...)
+	}
+	return servers, nil
+}
+
+// closeServices closes the services of a server that is not going to be started.
+func closeServices[T any](services map[string]T, log *zerolog.Logger) {
+	for name, svc := range services {
+		c, ok := any(svc).(io.Closer)
+		if !ok {
+			continue
 		}
-		ln := listenerFromAddress(lns, cfg.Network, cfg.Address)
-		server := &Server{
-			server:   s,
-			listener: ln,
-			services: maps.MapValues(services, func(s global.Service) any { return s }),
+		if err := c.Close(); err != nil {
+			log.Error().Err(err).Msgf("error closing service %s", name)
 		}
-		log.Debug().
-			Interface("services", maps.Keys(cfg.Services)).
-			Msgf("spawned http server for services listening at %s:%s", ln.Addr().Network(), ln.Addr().String())
-		servers = append(servers, server)
 	}
-	return servers, nil
+}
+
+func newGRPCServer(ctx context.Context, cfg *config.GRPC, lns map[string]net.Listener, version string, log *zerolog.Logger) (*Server, error) {
+	logger := log.With().Str("pkg", "grpc").Logger()
+	ctx = appctx.WithLogger(ctx, &logger)
+	services, err := rgrpc.InitServices(ctx, cfg.Services)
+	if err != nil {
+		return nil, err
+	}
+	unaryChain, streamChain, err := initGRPCInterceptors(cfg.Interceptors, grpcUnprotected(cfg.EnableReflection, services), log)
+	if err != nil {
+		closeServices(services, log)
+		return nil, err
+	}
+	s, err := rgrpc.NewServer(
+		rgrpc.EnableReflection(cfg.EnableReflection),
+		rgrpc.WithShutdownDeadline(cfg.ShutdownDeadline),
+		rgrpc.WithLogger(logger),
+		rgrpc.WithServices(services),
+		rgrpc.WithUnaryServerInterceptors(unaryChain),
+		rgrpc.WithStreamServerInterceptors(streamChain),
+		rgrpc.WithVersion(version),
+	)
+	if err != nil {
+		closeServices(services, log)
+		return nil, err
+	}
+	ln := listenerFromAddress(lns, cfg.Network, cfg.Address)
+	server := &Server{
+		server:   s,
+		listener: ln,
+		services: maps.MapValues(services, func(s rgrpc.Service) any { return s }),
+	}
+	log.Debug().
+		Interface("services", maps.Keys(cfg.Services)).
+		Msgf("spawned grpc server for services listening at %s:%s", ln.Addr().Network(), ln.Addr().String())
+	return server, nil
+}
+
+func newHTTPServer(ctx context.Context, cfg *config.HTTP, lns map[string]net.Listener, log *zerolog.Logger) (*Server, error) {
+	logger := log.With().Str("pkg", "http").Logger()
+	ctx = appctx.WithLogger(ctx, &logger)
+	services, err := rhttp.InitServices(ctx, cfg.Services)
+	if err != nil {
+		return nil, err
+	}
+	middlewares, err := initHTTPMiddlewares(cfg.Middlewares, httpUnprotected(services), &logger)
+	if err != nil {
+		closeServices(services, log)
+		return nil, err
+	}
+	s, err := rhttp.New(
+		rhttp.WithServices(services),
+		rhttp.WithLogger(logger),
+		rhttp.WithCertAndKeyFiles(cfg.CertFile, cfg.KeyFile),
+		rhttp.WithMiddlewares(middlewares),
+	)
+	if err != nil {
+		closeServices(services, log)
+		return nil, err
+	}
+	ln := listenerFromAddress(lns, cfg.Network, cfg.Address)
+	server := &Server{
+		server:   s,
+		listener: ln,
+		services: maps.MapValues(services, func(s global.Service) any { return s }),
+	}
+	log.Debug().
+		Interface("services", maps.Keys(cfg.Services)).
+		Msgf("spawned http server for services listening at %s:%s", ln.Addr().Network(), ln.Addr().String())
+	return server, nil
 }
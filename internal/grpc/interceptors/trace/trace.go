@@ -39,6 +39,11 @@ func getContext(ctx context.Context) context.Context {
 				traceID = val[0]
 			}
 		}
+		if val, ok := md[trace.TraceparentHeader]; ok && traceID == "" && len(val) > 0 {
+			if tp, ok := trace.ParseTraceparent(val[0]); ok {
+				traceID = tp.TraceID
+			}
+		}
 	}
 
 	if traceID == "" {
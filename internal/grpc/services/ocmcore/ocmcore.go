@@ -23,12 +23,14 @@ package ocmcore
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	ocmcore "github.com/cs3org/go-cs3apis/cs3/ocm/core/v1beta1"
 	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
 	providerpb "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	typesv1beta1 "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/ocm/share"
 	"github.com/cs3org/reva/pkg/ocm/share/repository/registry"
@@ -52,6 +54,9 @@ func init() {
 type config struct {
 	Driver  string                            `mapstructure:"driver"`
 	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
+	// TrustedProviders are the idps of the partners whose shares are
+	// accepted on arrival, without waiting for the recipient.
+	TrustedProviders []string `mapstructure:"trusted_providers"`
 }
 
 type service struct {
@@ -92,10 +97,20 @@ func New(ctx context.Context, m map[string]interface{}) (rgrpc.Service, error) {
 		conf: &c,
 		repo: repo,
 	}
+	if n, ok := repo.(share.AcceptedShareNotifier); ok {
+		n.OnAcceptedReceivedShare(service.shareAccepted)
+	}
 
 	return service, nil
 }
 
+// shareAccepted is called with the received shares accepted on arrival.
+// They are mounted by the received shares storage like the ones accepted
+// by their recipient, so they are only logged.
+func (s *service) shareAccepted(ctx context.Context, rs *ocm.ReceivedShare) {
+	appctx.GetLogger(ctx).Info().Str("share", rs.Id.GetOpaqueId()).Str("owner", rs.Owner.GetIdp()).Msg("ocmcore: received share accepted on arrival")
+}
+
 func (s *service) Close() error {
 	return nil
 }
@@ -114,6 +129,11 @@ func (s *service) CreateOCMCoreShare(ctx context.Context, req *ocmcore.CreateOCM
 		Seconds: uint64(time.Now().Unix()),
 	}
 
+	state := ocm.ShareState_SHARE_STATE_PENDING
+	if slices.Contains(s.conf.TrustedProviders, req.Owner.GetIdp()) {
+		state = ocm.ShareState_SHARE_STATE_ACCEPTED
+	}
+
 	share, err := s.repo.StoreReceivedShare(ctx, &ocm.ReceivedShare{
 		RemoteShareId: req.ResourceId,
 		Name:          req.Name,
@@ -131,7 +151,7 @@ func (s *service) CreateOCMCoreShare(ctx context.Context, req *ocmcore.CreateOCM
 		Ctime:        now,
 		Mtime:        now,
 		Expiration:   req.Expiration,
-		State:        ocm.ShareState_SHARE_STATE_PENDING,
+		State:        state,
 	})
 	if err != nil {
 		// TODO: identify errors
@@ -300,7 +300,11 @@ func (s *service) CreateOCMShare(ctx context.Context, req *ocm.CreateOCMShareReq
 		AccessMethods: req.AccessMethods,
 	}
 
-	ocmshare, err = s.repo.StoreShare(ctx, ocmshare)
+	if r, ok := s.repo.(share.ResourceTypeRepository); ok {
+		ocmshare, err = r.StoreShareOfType(ctx, ocmshare, info.Type)
+	} else {
+		ocmshare, err = s.repo.StoreShare(ctx, ocmshare)
+	}
 	if err != nil {
 		if errors.Is(err, share.ErrShareAlreadyExisting) {
 			return &ocm.CreateOCMShareResponse{
@@ -452,6 +456,9 @@ func (s *service) ListOCMShares(ctx context.Context, req *ocm.ListOCMSharesReque
 
 func (s *service) UpdateOCMShare(ctx context.Context, req *ocm.UpdateOCMShareRequest) (*ocm.UpdateOCMShareResponse, error) {
 	user := appctx.ContextMustGetUser(ctx)
+	if name, ok := share.GetUpdateName(req); ok {
+		return s.updateShareName(ctx, user, req.Ref, name, req.Field...), nil
+	}
 	if len(req.Field) == 0 {
 		return &ocm.UpdateOCMShareResponse{
 			Status: status.NewOK(ctx),
@@ -475,6 +482,28 @@ func (s *service) UpdateOCMShare(ctx context.Context, req *ocm.UpdateOCMShareReq
 	return res, nil
 }
 
+func (s *service) updateShareName(ctx context.Context, user *userpb.User, ref *ocm.ShareReference, name string, f ...*ocm.UpdateOCMShareRequest_UpdateField) *ocm.UpdateOCMShareResponse {
+	u, ok := s.repo.(share.NameUpdater)
+	if !ok {
+		return &ocm.UpdateOCMShareResponse{
+			Status: status.NewUnimplemented(ctx, nil, "the share repository cannot rename shares"),
+		}
+	}
+	if _, err := u.UpdateShareName(ctx, user, ref, name, f...); err != nil {
+		if errors.Is(err, share.ErrShareNotFound) {
+			return &ocm.UpdateOCMShareResponse{Status: status.NewNotFound(ctx, "share does not exist")}
+		}
+		switch err.(type) {
+		case errtypes.IsPermissionDenied:
+			return &ocm.UpdateOCMShareResponse{Status: status.NewPermissionDenied(ctx, err, "error renaming share")}
+		case errtypes.IsBadRequest:
+			return &ocm.UpdateOCMShareResponse{Status: status.NewInvalid(ctx, err.Error())}
+		}
+		return &ocm.UpdateOCMShareResponse{Status: status.NewInternal(ctx, err, "error renaming share")}
+	}
+	return &ocm.UpdateOCMShareResponse{Status: status.NewOK(ctx)}
+}
+
 func (s *service) ListReceivedOCMShares(ctx context.Context, req *ocm.ListReceivedOCMSharesRequest) (*ocm.ListReceivedOCMSharesResponse, error) {
 	user := appctx.ContextMustGetUser(ctx)
 	shares, err := s.repo.ListReceivedShares(ctx, user)
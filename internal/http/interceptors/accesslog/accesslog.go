@@ -0,0 +1,170 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package accesslog provides an HTTP middleware logging an access
+// log line for each request served by the HTTP services.
+package accesslog
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/trace"
+	netutil "github.com/cs3org/reva/pkg/utils/net"
+	"github.com/mitchellh/mapstructure"
+)
+
+// HeaderRequestID is the header carrying the request id set by the clients or by a proxy.
+const HeaderRequestID = "X-Request-Id"
+
+type config struct {
+	// Prefixes are the prefixes of the services whose requests are logged.
+	// If empty, the requests of all the services are logged.
+	Prefixes []string `mapstructure:"prefixes"`
+	// TrustedProxies are the CIDRs of the proxies whose forwarding headers
+	// are used to log the client ip. Defaults to the shared trusted proxies.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+}
+
+// New returns a new HTTP middleware that writes an access log line for each request
+// through the logger in the request context. The middleware runs before the
+// authentication, which fills in the user logged through a holder in the context:
+// the requests rejected by the authentication are logged with an empty user id.
+func New(m map[string]any) (global.Middleware, error) {
+	var c config
+	if err := mapstructure.Decode(m, &c); err != nil {
+		return nil, err
+	}
+	for i, p := range c.Prefixes {
+		c.Prefixes[i] = "/" + strings.Trim(p, "/")
+	}
+	proxies, err := netutil.ParseTrustedProxies(sharedconf.GetTrustedProxies(c.TrustedProxies))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !c.enabled(r.URL.Path) {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			path := r.URL.Path
+			start := time.Now()
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+			ctx, holder := appctx.ContextWithUserHolder(r.Context())
+			h.ServeHTTP(rw, r.WithContext(ctx))
+			writeLog(r, path, proxies.ClientIP(r), holder, rw, time.Since(start))
+		})
+	}, nil
+}
+
+func (c *config) enabled(path string) bool {
+	if len(c.Prefixes) == 0 {
+		return true
+	}
+	for _, p := range c.Prefixes {
+		if p == "/" || path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func writeLog(r *http.Request, path, clientIP string, holder *appctx.UserHolder, rw *responseWriter, duration time.Duration) {
+	ctx := r.Context()
+	log := appctx.GetLogger(ctx)
+
+	requestID := r.Header.Get(HeaderRequestID)
+	if requestID == "" {
+		requestID = trace.Get(ctx)
+	}
+	var userID string
+	if u, ok := holder.User(); ok {
+		userID = u.GetId().GetOpaqueId()
+	}
+
+	log.Info().
+		Str("method", r.Method).
+		Str("path", path).
+		Str("client_ip", clientIP).
+		Int("status", rw.status).
+		Int64("bytes", rw.bytes).
+		Dur("duration", duration).
+		Str("user_id", userID).
+		Str("request_id", requestID).
+		Msg("access")
+}
+
+// responseWriter wraps an http.ResponseWriter keeping track
+// of the status code and of the number of bytes written.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements the http.Flusher interface.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		f.Flush()
+	}
+}
+
+// Hijack implements the http.Hijacker interface.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil && !w.wroteHeader {
+		w.status = http.StatusSwitchingProtocols
+		w.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, used by http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,162 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/rs/zerolog"
+)
+
+func serve(t *testing.T, conf map[string]any, path string, h http.HandlerFunc) (*httptest.ResponseRecorder, []map[string]any) {
+	return serveFrom(t, conf, path, "192.0.2.1:1234", nil, h)
+}
+
+func serveFrom(t *testing.T, conf map[string]any, path, remoteAddr string, headers map[string]string, h http.HandlerFunc) (*httptest.ResponseRecorder, []map[string]any) {
+	m, err := New(conf)
+	if err != nil {
+		t.Fatalf("error creating middleware: %v", err)
+	}
+
+	var buf bytes.Buffer
+	log := zerolog.New(&buf)
+	ctx := appctx.WithLogger(context.Background(), &log)
+
+	r := httptest.NewRequest(http.MethodPut, path, nil).WithContext(ctx)
+	r.Header.Set(HeaderRequestID, "request-1")
+	r.RemoteAddr = remoteAddr
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	m(h).ServeHTTP(w, r)
+
+	var lines []map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var line map[string]any
+		if err := dec.Decode(&line); err != nil {
+			t.Fatalf("error decoding log line: %v", err)
+		}
+		lines = append(lines, line)
+	}
+	return w, lines
+}
+
+func TestAccessLog(t *testing.T) {
+	w, lines := serve(t, nil, "/remote.php/webdav/file.txt", func(w http.ResponseWriter, r *http.Request) {
+		// as done by the auth middleware
+		appctx.ContextHoldUser(r.Context(), &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein"}})
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	})
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("unexpected status code: %d", w.Code)
+	}
+	if len(lines) != 1 {
+		t.Fatalf("expected one log line, got %d", len(lines))
+	}
+	line := lines[0]
+	expected := map[string]any{
+		"level":      "info",
+		"message":    "access",
+		"method":     http.MethodPut,
+		"path":       "/remote.php/webdav/file.txt",
+		"client_ip":  "192.0.2.1",
+		"status":     float64(http.StatusCreated),
+		"bytes":      float64(5),
+		"user_id":    "einstein",
+		"request_id": "request-1",
+	}
+	for k, v := range expected {
+		if line[k] != v {
+			t.Errorf("unexpected value for %s: got %v, expected %v", k, line[k], v)
+		}
+	}
+	if _, ok := line["duration"]; !ok {
+		t.Error("expected the duration to be logged")
+	}
+}
+
+func TestAccessLogUnauthenticated(t *testing.T) {
+	_, lines := serve(t, nil, "/remote.php/webdav/file.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	if len(lines) != 1 {
+		t.Fatalf("expected one log line, got %d", len(lines))
+	}
+	if lines[0]["status"] != float64(http.StatusUnauthorized) {
+		t.Errorf("unexpected status: %v", lines[0]["status"])
+	}
+	if lines[0]["user_id"] != "" {
+		t.Errorf("expected an empty user id, got %v", lines[0]["user_id"])
+	}
+}
+
+func TestAccessLogPrefixes(t *testing.T) {
+	conf := map[string]any{"prefixes": []string{"ocs"}}
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	if _, lines := serve(t, conf, "/ocs/v1.php/cloud/capabilities", ok); len(lines) != 1 {
+		t.Errorf("expected the request to a configured service to be logged, got %d lines", len(lines))
+	}
+	if _, lines := serve(t, conf, "/remote.php/webdav", ok); len(lines) != 0 {
+		t.Errorf("expected the request to another service not to be logged, got %d lines", len(lines))
+	}
+}
+
+func TestAccessLogFlush(t *testing.T) {
+	w, lines := serve(t, nil, "/data", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("chunk"))
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("expected the response writer to implement http.Flusher")
+		}
+		f.Flush()
+	})
+
+	if !w.Flushed {
+		t.Error("expected the response to be flushed")
+	}
+	if lines[0]["status"] != float64(http.StatusOK) {
+		t.Errorf("unexpected status: %v", lines[0]["status"])
+	}
+}
+
+func TestAccessLogClientIP(t *testing.T) {
+	conf := map[string]any{"trusted_proxies": []string{"10.0.0.0/8"}}
+	headers := map[string]string{"X-Forwarded-For": "203.0.113.5"}
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	if _, lines := serveFrom(t, conf, "/", "10.0.0.1:1234", headers, ok); lines[0]["client_ip"] != "203.0.113.5" {
+		t.Errorf("expected the forwarded ip behind a trusted proxy, got %v", lines[0]["client_ip"])
+	}
+	if _, lines := serveFrom(t, conf, "/", "198.51.100.1:1234", headers, ok); lines[0]["client_ip"] != "198.51.100.1" {
+		t.Errorf("expected the remote address of an untrusted peer, got %v", lines[0]["client_ip"])
+	}
+}
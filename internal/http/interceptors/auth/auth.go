@@ -296,6 +296,7 @@ func authenticateUser(w http.ResponseWriter, r *http.Request, conf *config, toke
 
 func ctxWithUserInfo(ctx context.Context, r *http.Request, user *userpb.User, token string) context.Context {
 	ctx = appctx.ContextSetUser(ctx, user)
+	appctx.ContextHoldUser(ctx, user)
 	ctx = appctx.ContextSetToken(ctx, token)
 	ctx = metadata.AppendToOutgoingContext(ctx, appctx.TokenHeader, token)
 	ctx = metadata.AppendToOutgoingContext(ctx, appctx.UserAgentHeader, r.UserAgent())
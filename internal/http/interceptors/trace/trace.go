@@ -43,6 +43,11 @@ func handler(h http.Handler) http.Handler {
 		// we set the outgoing context so the trace information is
 		// passed through the two protocols.
 		ctx = metadata.AppendToOutgoingContext(ctx, "revad-grpc-trace-id", traceID)
+		// the W3C trace context is propagated as well, with the
+		// span of this request as parent of the downstream calls
+		if tp, ok := trace.ParseTraceparent(r.Header.Get(trace.TraceparentHeader)); ok {
+			ctx = metadata.AppendToOutgoingContext(ctx, trace.TraceparentHeader, tp.Child().String())
+		}
 
 		r = r.WithContext(ctx)
 		h.ServeHTTP(w, r)
@@ -54,8 +59,13 @@ func getTraceID(r *http.Request) (string, context.Context) {
 	// try to get trace from context
 	traceID := trace.Get(ctx)
 	if traceID == "" {
-		// check if traceID is coming from header
-		traceID = r.Header.Get("X-Trace-ID")
+		// check if traceID is coming from header,
+		// giving precedence to the W3C trace context
+		if tp, ok := trace.ParseTraceparent(r.Header.Get(trace.TraceparentHeader)); ok {
+			traceID = tp.TraceID
+		} else {
+			traceID = r.Header.Get("X-Trace-ID")
+		}
 		if traceID == "" {
 			traceID = r.Header.Get("X-Request-ID")
 			if traceID == "" {
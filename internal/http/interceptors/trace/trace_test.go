@@ -21,9 +21,11 @@ package trace
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/cs3org/reva/pkg/trace"
+	"google.golang.org/grpc/metadata"
 )
 
 type testPair struct {
@@ -45,6 +47,20 @@ func TestGetTrace(t *testing.T) {
 			r: newRequest(trace.Set(context.Background(), "fgh"), nil),
 			e: "fgh",
 		},
+		{
+			r: newRequest(context.Background(), map[string]string{
+				"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+				"X-Trace-ID":  "def",
+			}),
+			e: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			r: newRequest(context.Background(), map[string]string{
+				"traceparent": "invalid",
+				"X-Trace-ID":  "def",
+			}),
+			e: "def",
+		},
 	}
 
 	for _, p := range pairs {
@@ -72,3 +88,31 @@ func TestGenerateTrace(t *testing.T) {
 		return
 	}
 }
+
+func TestTraceparentPropagation(t *testing.T) {
+	var md metadata.MD
+	h := handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the outgoing metadata is what a grpc call to the gateway carries
+		md, _ = metadata.FromOutgoingContext(r.Context())
+	}))
+
+	r := newRequest(context.Background(), map[string]string{
+		"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	})
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if got := md.Get("revad-grpc-trace-id"); len(got) != 1 || got[0] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("unexpected trace id in the outgoing metadata: %v", got)
+	}
+	got := md.Get("traceparent")
+	if len(got) != 1 {
+		t.Fatalf("expected a traceparent in the outgoing metadata, got %v", got)
+	}
+	tp, ok := trace.ParseTraceparent(got[0])
+	if !ok {
+		t.Fatalf("invalid traceparent in the outgoing metadata: %s", got[0])
+	}
+	if tp.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || tp.ParentID == "00f067aa0ba902b7" {
+		t.Errorf("unexpected traceparent in the outgoing metadata: %s", got[0])
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var hrefRegexp = regexp.MustCompile(`<d:href>([^<]*)</d:href>`)
+
+func TestExternalBasePath(t *testing.T) {
+	for _, target := range []string{
+		"/webdav/",
+		// a proxy forwarding the external base path
+		"/cloud/webdav/",
+	} {
+		_, s := newCountingGateway(t, 2)
+		s.c.ExternalBasePath = "/cloud"
+		s.webDavHandler = new(WebDavHandler)
+		if err := s.webDavHandler.init("/", false); err != nil {
+			t.Fatal(err)
+		}
+
+		r := httptest.NewRequest(MethodPropfind, target, nil)
+		r.Header.Set(HeaderDepth, "1")
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, r)
+		if w.Code != http.StatusMultiStatus {
+			t.Fatalf("%s: expected status %d, got %d: %s", target, http.StatusMultiStatus, w.Code, w.Body.String())
+		}
+
+		hrefs := hrefRegexp.FindAllStringSubmatch(w.Body.String(), -1)
+		if len(hrefs) != 3 {
+			t.Fatalf("%s: expected 3 hrefs, got %d: %s", target, len(hrefs), w.Body.String())
+		}
+		for _, href := range hrefs {
+			if !strings.HasPrefix(href[1], "/cloud/webdav/") {
+				t.Errorf("%s: expected the href %s to start with the external base path", target, href[1])
+			}
+		}
+	}
+}
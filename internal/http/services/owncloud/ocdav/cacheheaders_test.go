@@ -0,0 +1,154 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/httpclient"
+	"google.golang.org/grpc"
+)
+
+// downloadGatewayClient serves a single file, through a public link
+// expiring at expiration and to any user, from the dataEndpoint.
+type downloadGatewayClient struct {
+	gateway.GatewayAPIClient
+	expiration   time.Time
+	dataEndpoint string
+}
+
+func (c *downloadGatewayClient) Authenticate(_ context.Context, _ *gateway.AuthenticateRequest, _ ...grpc.CallOption) (*gateway.AuthenticateResponse, error) {
+	return &gateway.AuthenticateResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		Token:  "token",
+		User: &userpb.User{
+			Id: &userpb.UserId{OpaqueId: "public"},
+			Opaque: &types.Opaque{Map: map[string]*types.OpaqueEntry{
+				"public-share-expiration": {Decoder: "plain", Value: []byte(strconv.FormatInt(c.expiration.Unix(), 10))},
+			}},
+		},
+	}, nil
+}
+
+func (c *downloadGatewayClient) Stat(_ context.Context, _ *provider.StatRequest, _ ...grpc.CallOption) (*provider.StatResponse, error) {
+	return &provider.StatResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		Info: &provider.ResourceInfo{
+			Type:     provider.ResourceType_RESOURCE_TYPE_FILE,
+			Id:       &provider.ResourceId{StorageId: "provider-1", OpaqueId: "file"},
+			Path:     "/file.png",
+			MimeType: "image/png",
+			Etag:     `"etag"`,
+			Mtime:    &types.Timestamp{Seconds: 1000},
+			Size:     4,
+		},
+	}, nil
+}
+
+func (c *downloadGatewayClient) GetPath(_ context.Context, _ *provider.GetPathRequest, _ ...grpc.CallOption) (*provider.GetPathResponse, error) {
+	return &provider.GetPathResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, Path: "/file.png"}, nil
+}
+
+func (c *downloadGatewayClient) InitiateFileDownload(_ context.Context, _ *provider.InitiateFileDownloadRequest, _ ...grpc.CallOption) (*gateway.InitiateFileDownloadResponse, error) {
+	return &gateway.InitiateFileDownloadResponse{
+		Status:    &rpc.Status{Code: rpc.Code_CODE_OK},
+		Protocols: []*gateway.FileDownloadProtocol{{Protocol: "simple", DownloadEndpoint: c.dataEndpoint}},
+	}, nil
+}
+
+func TestPublicDownloadCacheHeaders(t *testing.T) {
+	data := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("data"))
+	}))
+	t.Cleanup(data.Close)
+
+	c := &Config{PublicDownloadMaxAge: 3600}
+	client := &downloadGatewayClient{expiration: time.Now().Add(24 * time.Hour), dataEndpoint: data.URL}
+	s := &svc{c: c, davHandler: new(DavHandler), webDavHandler: new(WebDavHandler), gatewayClient: client, client: httpclient.New()}
+	if err := s.davHandler.init(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.webDavHandler.init(c.WebdavNamespace, true); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(url string, header http.Header, basicAuth bool) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, url, nil)
+		for k, v := range header {
+			r.Header[k] = v
+		}
+		if basicAuth {
+			r.SetBasicAuth("public", "password")
+		}
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, r)
+		return w
+	}
+
+	w := get("/remote.php/dav/public-files/token/file.png", nil, false)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if cc := w.Header().Get(HeaderCacheControl); cc != "public, max-age=3600" {
+		t.Errorf("expected a public max age of an hour, got %q", cc)
+	}
+	if w.Header().Get(HeaderExpires) == "" || w.Header().Get(HeaderETag) == "" || w.Header().Get(HeaderLastModified) == "" {
+		t.Errorf("expected the Expires, ETag and Last-Modified headers, got %v", w.Header())
+	}
+
+	w = get("/remote.php/dav/public-files/token/file.png", http.Header{HeaderIfNoneMatch: {`"etag"`}}, false)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expected status %d for a matching etag, got %d", http.StatusNotModified, w.Code)
+	}
+
+	// the password protected links are not cached by the shared caches
+	w = get("/remote.php/dav/public-files/token/file.png", nil, true)
+	if cc := w.Header().Get(HeaderCacheControl); cc != "private, max-age=3600" {
+		t.Errorf("expected a private max age of an hour, got %q", cc)
+	}
+
+	// the links expiring before the max age are cached until they expire
+	client.expiration = time.Now().Add(10 * time.Minute)
+	w = get("/remote.php/dav/public-files/token/file.png", nil, false)
+	maxAge, err := strconv.Atoi(w.Header().Get(HeaderCacheControl)[len("public, max-age="):])
+	if err != nil || maxAge > 600 || maxAge < 590 {
+		t.Errorf("expected the max age to be bounded by the link expiration, got %q", w.Header().Get(HeaderCacheControl))
+	}
+
+	w = get("/remote.php/webdav/file.png", nil, false)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if cc := w.Header().Get(HeaderCacheControl); cc != "no-store" {
+		t.Errorf("expected the user downloads not to be stored, got %q", cc)
+	}
+	if e := w.Header().Get(HeaderExpires); e != "" {
+		t.Errorf("expected no Expires header on a user download, got %q", e)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/httpclient"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"google.golang.org/grpc"
+)
+
+// uploadGateway accepts the uploads to the space "space", with a storage
+// that does not know the type of the uploaded files.
+type uploadGateway struct {
+	gateway.UnimplementedGatewayAPIServer
+	endpoint string
+	uploaded atomic.Bool
+	// contentType is the type received in the opaque of the upload.
+	contentType atomic.Value
+}
+
+func (g *uploadGateway) ListStorageSpaces(context.Context, *provider.ListStorageSpacesRequest) (*provider.ListStorageSpacesResponse, error) {
+	return &provider.ListStorageSpacesResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		StorageSpaces: []*provider.StorageSpace{
+			{Root: &provider.ResourceId{StorageId: "storage", OpaqueId: "root"}},
+		},
+	}, nil
+}
+
+func (g *uploadGateway) Stat(context.Context, *provider.StatRequest) (*provider.StatResponse, error) {
+	if !g.uploaded.Load() {
+		return &provider.StatResponse{Status: &rpc.Status{Code: rpc.Code_CODE_NOT_FOUND}}, nil
+	}
+	return &provider.StatResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		Info: &provider.ResourceInfo{
+			Id:       &provider.ResourceId{StorageId: "storage", OpaqueId: "file"},
+			Type:     provider.ResourceType_RESOURCE_TYPE_FILE,
+			MimeType: "application/octet-stream",
+			Etag:     "etag",
+			Mtime:    &types.Timestamp{Seconds: 1},
+		},
+	}, nil
+}
+
+func (g *uploadGateway) InitiateFileUpload(_ context.Context, req *provider.InitiateFileUploadRequest) (*gateway.InitiateFileUploadResponse, error) {
+	if e, ok := req.Opaque.GetMap()[HeaderContentType]; ok {
+		g.contentType.Store(string(e.Value))
+	}
+	return &gateway.InitiateFileUploadResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		Protocols: []*gateway.FileUploadProtocol{
+			{Protocol: "simple", UploadEndpoint: g.endpoint},
+		},
+	}, nil
+}
+
+func TestUploadContentType(t *testing.T) {
+	for _, tc := range []struct {
+		detection string
+		path      string
+		body      string
+		header    string
+		expected  string
+	}{
+		{detection: "", path: "image.png", body: "data", expected: "image/png"},
+		{detection: "extension", path: "image.png", body: "data", header: "text/plain", expected: "text/plain"},
+		{detection: "sniff", path: "image.png", body: "%PDF-1.4", expected: "application/pdf"},
+		{detection: "sniff", path: "image.png", body: "data", expected: "image/png"},
+		{detection: "none", path: "image.png", body: "data", expected: ""},
+	} {
+		gw := &uploadGateway{}
+		gw.contentType.Store("")
+		var forwarded atomic.Value
+		data := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			forwarded.Store(r.Header.Get(HeaderContentType))
+			gw.uploaded.Store(true)
+			w.WriteHeader(http.StatusOK)
+		}))
+		t.Cleanup(data.Close)
+		gw.endpoint = data.URL
+
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := grpc.NewServer()
+		gateway.RegisterGatewayAPIServer(srv, gw)
+		go func() { _ = srv.Serve(lis) }()
+		t.Cleanup(srv.Stop)
+
+		c := &Config{GatewaySvc: lis.Addr().String(), UploadContentType: tc.detection}
+		client, err := pool.GetGatewayServiceClient(pool.Endpoint(c.GatewaySvc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := &svc{c: c, davHandler: new(DavHandler), gatewayClient: client, client: httpclient.New()}
+		if err := s.davHandler.init(c); err != nil {
+			t.Fatal(err)
+		}
+
+		r := httptest.NewRequest(http.MethodPut, "/remote.php/dav/spaces/space/"+tc.path, strings.NewReader(tc.body))
+		r.Header.Set(HeaderContentLength, strconv.Itoa(len(tc.body)))
+		if tc.header != "" {
+			r.Header.Set(HeaderContentType, tc.header)
+		}
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, r)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("%s: expected status %d, got %d: %s", tc.detection, http.StatusCreated, w.Code, w.Body.String())
+		}
+		if got := gw.contentType.Load(); got != tc.expected {
+			t.Errorf("%s: expected the type %q in the upload request, got %q", tc.detection, tc.expected, got)
+		}
+		if got := forwarded.Load(); got != tc.expected {
+			t.Errorf("%s: expected the type %q forwarded to the data server, got %q", tc.detection, tc.expected, got)
+		}
+		expected := tc.expected
+		if expected == "" {
+			expected = "application/octet-stream"
+		}
+		if got := w.Header().Get(HeaderContentType); got != expected {
+			t.Errorf("%s: expected the type %q in the response, got %q", tc.detection, expected, got)
+		}
+	}
+}
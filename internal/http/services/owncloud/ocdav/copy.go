@@ -69,7 +69,7 @@ func (s *svc) handlePathCopy(w http.ResponseWriter, r *http.Request, ns string)
 		return
 	}
 
-	for _, r := range nameRules {
+	for _, r := range s.nameRules(endpointWebDAV) {
 		if !r.Test(dst) {
 			appctx.GetLogger(ctx).Warn().Msgf("HTTP COPY: destination %s failed validation", dst)
 			w.WriteHeader(http.StatusBadRequest)
@@ -126,14 +126,10 @@ func (s *svc) executePathCopy(ctx context.Context, client gateway.GatewayAPIClie
 		}
 		if createRes.Status.Code != rpc.Code_CODE_OK {
 			if createRes.Status.Code == rpc.Code_CODE_PERMISSION_DENIED {
-				w.WriteHeader(http.StatusForbidden)
-				m := fmt.Sprintf("Permission denied to create %v", createReq.Ref.Path)
-				b, err := Marshal(exception{
-					code:    SabredavPermissionDenied,
-					message: m,
-				})
-				HandleWebdavError(log, w, b, err)
+				// TODO path could be empty or relative...
+				createRes.Status.Message = fmt.Sprintf("Permission denied to create %v", createReq.Ref.Path)
 			}
+			HandleErrorStatus(log, w, createRes.Status)
 			return nil
 		}
 
@@ -210,14 +206,8 @@ func (s *svc) executePathCopy(ctx context.Context, client gateway.GatewayAPIClie
 
 		if uRes.Status.Code != rpc.Code_CODE_OK {
 			if uRes.Status.Code == rpc.Code_CODE_PERMISSION_DENIED {
-				w.WriteHeader(http.StatusForbidden)
-				m := fmt.Sprintf("Permissions denied to create %v", uReq.Ref.Path)
-				b, err := Marshal(exception{
-					code:    SabredavPermissionDenied,
-					message: m,
-				})
-				HandleWebdavError(log, w, b, err)
-				return nil
+				// TODO path can be empty or relative
+				uRes.Status.Message = fmt.Sprintf("Permissions denied to create %v", uReq.Ref.Path)
 			}
 			HandleErrorStatus(log, w, uRes.Status)
 			return nil
@@ -275,6 +265,13 @@ func (s *svc) handleSpacesCopy(w http.ResponseWriter, r *http.Request, spaceID s
 		return
 	}
 
+	for _, rule := range s.nameRules(endpointSpaces) {
+		if !rule.Test(dst) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
 	sublog := appctx.GetLogger(ctx).With().Str("spaceid", spaceID).Str("path", r.URL.Path).Str("destination", dst).Logger()
 
 	// retrieve a specific storage space
@@ -345,15 +342,10 @@ func (s *svc) executeSpacesCopy(ctx context.Context, w http.ResponseWriter, clie
 		}
 		if createRes.Status.Code != rpc.Code_CODE_OK {
 			if createRes.Status.Code == rpc.Code_CODE_PERMISSION_DENIED {
-				w.WriteHeader(http.StatusForbidden)
 				// TODO path could be empty or relative...
-				m := fmt.Sprintf("Permission denied to create %v", createReq.Ref.Path)
-				b, err := Marshal(exception{
-					code:    SabredavPermissionDenied,
-					message: m,
-				})
-				HandleWebdavError(log, w, b, err)
+				createRes.Status.Message = fmt.Sprintf("Permission denied to create %v", createReq.Ref.Path)
 			}
+			HandleErrorStatus(log, w, createRes.Status)
 			return nil
 		}
 
@@ -424,15 +416,8 @@ func (s *svc) executeSpacesCopy(ctx context.Context, w http.ResponseWriter, clie
 
 		if uRes.Status.Code != rpc.Code_CODE_OK {
 			if uRes.Status.Code == rpc.Code_CODE_PERMISSION_DENIED {
-				w.WriteHeader(http.StatusForbidden)
 				// TODO path can be empty or relative
-				m := fmt.Sprintf("Permissions denied to create %v", uReq.Ref.Path)
-				b, err := Marshal(exception{
-					code:    SabredavPermissionDenied,
-					message: m,
-				})
-				HandleWebdavError(log, w, b, err)
-				return nil
+				uRes.Status.Message = fmt.Sprintf("Permissions denied to create %v", uReq.Ref.Path)
 			}
 			HandleErrorStatus(log, w, uRes.Status)
 			return nil
@@ -526,13 +511,7 @@ func (s *svc) prepareCopy(ctx context.Context, w http.ResponseWriter, r *http.Re
 
 	if srcStatRes.Status.Code != rpc.Code_CODE_OK {
 		if srcStatRes.Status.Code == rpc.Code_CODE_NOT_FOUND {
-			w.WriteHeader(http.StatusNotFound)
-			m := fmt.Sprintf("Resource %v not found", srcStatReq.Ref.Path)
-			b, err := Marshal(exception{
-				code:    SabredavNotFound,
-				message: m,
-			})
-			HandleWebdavError(log, w, b, err)
+			srcStatRes.Status.Message = fmt.Sprintf("Resource %v not found", srcStatReq.Ref.Path)
 		}
 		HandleErrorStatus(log, w, srcStatRes.Status)
 		return nil
@@ -546,7 +525,7 @@ func (s *svc) prepareCopy(ctx context.Context, w http.ResponseWriter, r *http.Re
 		return nil
 	}
 	if dstStatRes.Status.Code != rpc.Code_CODE_OK && dstStatRes.Status.Code != rpc.Code_CODE_NOT_FOUND {
-		HandleErrorStatus(log, w, srcStatRes.Status)
+		HandleErrorStatus(log, w, dstStatRes.Status)
 		return nil
 	}
 
@@ -24,6 +24,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	gatewayv1beta1 "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
@@ -31,13 +32,26 @@ import (
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
 
-	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/cs3org/reva/pkg/rhttp/router"
+	"github.com/cs3org/reva/pkg/utils"
+	netutil "github.com/cs3org/reva/pkg/utils/net"
+	"github.com/pkg/errors"
 	"google.golang.org/grpc/metadata"
 )
 
 type tokenStatInfoKey struct{}
 
+// publicLinkKey holds the publicLink the request was authenticated with.
+type publicLinkKey struct{}
+
+// publicLink describes how the public link the request was authenticated with can be cached.
+type publicLink struct {
+	// expiration is the expiration of the link, zero when it does not expire
+	expiration time.Time
+	// protected is set when the link was accessed with a password
+	protected bool
+}
+
 // DavHandler routes to the different sub handlers.
 type DavHandler struct {
 	AvatarsHandler      *AvatarsHandler
@@ -49,6 +63,9 @@ type DavHandler struct {
 	PublicFolderHandler *WebDavHandler
 	PublicFileHandler   *PublicFileHandler
 	OCMSharesHandler    *WebDavHandler
+
+	publicFilesLimiter   *rateLimiter
+	disablePublicUploads bool
 }
 
 func (h *DavHandler) init(c *Config) error {
@@ -85,6 +102,13 @@ func (h *DavHandler) init(c *Config) error {
 		return err
 	}
 
+	proxies, err := netutil.ParseTrustedProxies(c.TrustedProxies)
+	if err != nil {
+		return errors.Wrap(err, "ocdav: error parsing trusted proxies")
+	}
+	h.publicFilesLimiter = newRateLimiter(c.PublicFilesRateLimit, proxies)
+	h.disablePublicUploads = c.DisablePublicUploads
+
 	h.OCMSharesHandler = new(WebDavHandler)
 	if err := h.OCMSharesHandler.init(c.OCMNamespace, false); err != nil {
 		return err
@@ -93,6 +117,16 @@ func (h *DavHandler) init(c *Config) error {
 	return h.TrashbinHandler.init(c)
 }
 
+// isUploadMethod reports whether the method creates or writes resources,
+// including the tus creation (POST) and upload (PATCH) requests.
+func isUploadMethod(method string) bool {
+	switch method {
+	case http.MethodPut, MethodMkcol, http.MethodPost, http.MethodPatch:
+		return true
+	}
+	return false
+}
+
 func isOwner(userIDorName string, user *userv1beta1.User) bool {
 	return userIDorName != "" && (userIDorName == user.Id.OpaqueId || strings.EqualFold(userIDorName, user.Username))
 }
@@ -182,7 +216,7 @@ func (h *DavHandler) Handler(s *svc) http.Handler {
 			base := path.Join(ctx.Value(ctxKeyBaseURI).(string), "ocm")
 			ctx := context.WithValue(ctx, ctxKeyBaseURI, base)
 
-			c, err := pool.GetGatewayServiceClient(pool.Endpoint(s.c.GatewaySvc))
+			c, err := s.getClient()
 			if err != nil {
 				w.WriteHeader(http.StatusNotFound)
 				return
@@ -247,16 +281,32 @@ func (h *DavHandler) Handler(s *svc) http.Handler {
 			r = r.WithContext(ctx)
 			h.OCMSharesHandler.Handler(s).ServeHTTP(w, r)
 		case "public-files":
+			limiter := h.publicFilesLimiter
+			if limiter != nil && !limiter.allowRequest(w, r, limiter.clientKey(r)) {
+				return
+			}
+
+			if h.disablePublicUploads && isUploadMethod(r.Method) {
+				w.WriteHeader(http.StatusForbidden)
+				b, err := Marshal(exception{
+					code:    SabredavPermissionDenied,
+					message: "Uploads to public links are disabled",
+				})
+				HandleWebdavError(log, w, b, err)
+				return
+			}
+
 			base := path.Join(ctx.Value(ctxKeyBaseURI).(string), "public-files")
 			ctx = context.WithValue(ctx, ctxKeyBaseURI, base)
-			c, err := pool.GetGatewayServiceClient(pool.Endpoint(s.c.GatewaySvc))
+			c, err := s.getClient()
 			if err != nil {
 				w.WriteHeader(http.StatusNotFound)
 			}
 
 			var res *gatewayv1beta1.AuthenticateResponse
 			token, _ := router.ShiftPath(r.URL.Path)
-			if _, pass, ok := r.BasicAuth(); ok {
+			_, pass, protected := r.BasicAuth()
+			if protected {
 				res, err = handleBasicAuth(r.Context(), c, token, pass)
 			} else {
 				q := r.URL.Query()
@@ -270,6 +320,13 @@ func (h *DavHandler) Handler(s *svc) http.Handler {
 				res, err = handleSignatureAuth(r.Context(), c, token, sig, expiration)
 			}
 
+			// the resolved tokens are limited as well
+			if limiter != nil && limiter.byToken && err == nil && res.Status.Code == rpc.Code_CODE_OK {
+				if !limiter.allowRequest(w, r, tokenKey(token)) {
+					return
+				}
+			}
+
 			switch {
 			case err != nil:
 				w.WriteHeader(http.StatusInternalServerError)
@@ -290,6 +347,8 @@ func (h *DavHandler) Handler(s *svc) http.Handler {
 			ctx = appctx.ContextSetToken(ctx, res.Token)
 			ctx = appctx.ContextSetUser(ctx, res.User)
 			ctx = metadata.AppendToOutgoingContext(ctx, appctx.TokenHeader, res.Token)
+			expiration, _ := utils.PublicShareExpiration(res.User)
+			ctx = context.WithValue(ctx, publicLinkKey{}, publicLink{expiration: expiration, protected: protected})
 
 			r = r.WithContext(ctx)
 
@@ -27,6 +27,7 @@ import (
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/utils"
 	"github.com/rs/zerolog"
 )
 
@@ -53,35 +54,16 @@ func (s *svc) handleDelete(ctx context.Context, w http.ResponseWriter, r *http.R
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	} else if res.Status.Code != rpc.Code_CODE_OK {
-		if res.Status.Code == rpc.Code_CODE_NOT_FOUND {
-			w.WriteHeader(http.StatusNotFound)
+		switch {
+		case res.Status.Code == rpc.Code_CODE_NOT_FOUND:
 			// TODO path might be empty or relative...
-			m := fmt.Sprintf("Resource %v not found", ref.Path)
-			b, err := Marshal(exception{
-				code:    SabredavNotFound,
-				message: m,
-			})
-			HandleWebdavError(&log, w, b, err)
-		}
-		if res.Status.Code == rpc.Code_CODE_PERMISSION_DENIED {
-			w.WriteHeader(http.StatusForbidden)
+			res.Status.Message = fmt.Sprintf("Resource %v not found", ref.Path)
+		case res.Status.Code == rpc.Code_CODE_PERMISSION_DENIED:
 			// TODO path might be empty or relative...
-			m := fmt.Sprintf("Permission denied to delete %v", ref.Path)
-			b, err := Marshal(exception{
-				code:    SabredavPermissionDenied,
-				message: m,
-			})
-			HandleWebdavError(&log, w, b, err)
-		}
-		if res.Status.Code == rpc.Code_CODE_INTERNAL && res.Status.Message == "can't delete mount path" {
-			w.WriteHeader(http.StatusForbidden)
-			b, err := Marshal(exception{
-				code:    SabredavPermissionDenied,
-				message: res.Status.Message,
-			})
-			HandleWebdavError(&log, w, b, err)
+			res.Status.Message = fmt.Sprintf("Permission denied to delete %v", ref.Path)
+		case res.Status.Code == rpc.Code_CODE_INTERNAL && res.Status.Message == "can't delete mount path":
+			res.Status.Code = rpc.Code_CODE_PERMISSION_DENIED
 		}
-
 		HandleErrorStatus(&log, w, res.Status)
 		return
 	}
@@ -106,5 +88,76 @@ func (s *svc) handleSpacesDelete(w http.ResponseWriter, r *http.Request, spaceID
 		return
 	}
 
+	if path.Clean("/"+r.URL.Path) == "/" {
+		s.handleSpaceRootDelete(ctx, w, ref, sublog)
+		return
+	}
+
 	s.handleDelete(ctx, w, r, ref, sublog)
 }
+
+// handleSpaceRootDelete empties the space when enabled, the space itself is never deleted.
+func (s *svc) handleSpaceRootDelete(ctx context.Context, w http.ResponseWriter, ref *provider.Reference, log zerolog.Logger) {
+	if !s.c.EnableSpaceEmptying {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		b, err := Marshal(exception{
+			code:    SabredavMethodNotAllowed,
+			message: "deleting spaces via dav is not allowed",
+		})
+		HandleWebdavError(&log, w, b, err)
+		return
+	}
+
+	client, err := s.getClient()
+	if err != nil {
+		log.Error().Err(err).Msg("error getting grpc client")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	statRes, err := client.Stat(ctx, &provider.StatRequest{Ref: ref})
+	if err != nil {
+		log.Error().Err(err).Msg("error sending a grpc stat request")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if statRes.Status.Code != rpc.Code_CODE_OK {
+		HandleErrorStatus(&log, w, statRes.Status)
+		return
+	}
+	if !statRes.Info.GetPermissionSet().GetDelete() {
+		HandleErrorStatus(&log, w, &rpc.Status{Code: rpc.Code_CODE_PERMISSION_DENIED, Message: "Permission denied to empty the space"})
+		return
+	}
+
+	listRes, err := client.ListContainer(ctx, &provider.ListContainerRequest{Ref: ref})
+	if err != nil {
+		log.Error().Err(err).Msg("error sending a grpc list container request")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if listRes.Status.Code != rpc.Code_CODE_OK {
+		HandleErrorStatus(&log, w, listRes.Status)
+		return
+	}
+
+	for _, info := range listRes.Infos {
+		child := &provider.Reference{ResourceId: ref.ResourceId, Path: utils.MakeRelativePath(path.Base(info.Path))}
+		res, err := client.Delete(ctx, &provider.DeleteRequest{Ref: child})
+		if err != nil {
+			log.Error().Err(err).Str("path", child.Path).Msg("error performing delete grpc request")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		// the space is left partially emptied
+		if res.Status.Code != rpc.Code_CODE_OK && res.Status.Code != rpc.Code_CODE_NOT_FOUND {
+			if res.Status.Code == rpc.Code_CODE_PERMISSION_DENIED {
+				res.Status.Message = fmt.Sprintf("Permission denied to delete %v", child.Path)
+			}
+			HandleErrorStatus(&log, w, res.Status)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -20,7 +20,9 @@ package ocdav
 
 import (
 	"encoding/xml"
+	"io"
 	"net/http"
+	"strings"
 
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	"github.com/pkg/errors"
@@ -45,6 +47,18 @@ const (
 	SabredavNotFound
 	// SabredavConflict maps to HTTP 409.
 	SabredavConflict
+	// SabredavServiceUnavailable maps to HTTP 503 and 504,
+	// sabre does not have an exception for gateway timeouts.
+	SabredavServiceUnavailable
+	// SabredavLocked maps to HTTP 423.
+	SabredavLocked
+	// SabredavNotImplemented maps to HTTP 501.
+	SabredavNotImplemented
+	// SabredavInsufficientStorage maps to HTTP 507.
+	SabredavInsufficientStorage
+	// SabredavEntityTooLarge maps to HTTP 413,
+	// sabre does not have an exception for it, the one of ownCloud is used.
+	SabredavEntityTooLarge
 )
 
 var (
@@ -56,6 +70,11 @@ var (
 		"Sabre\\DAV\\Exception\\PermissionDenied",
 		"Sabre\\DAV\\Exception\\NotFound",
 		"Sabre\\DAV\\Exception\\Conflict",
+		"Sabre\\DAV\\Exception\\ServiceUnavailable",
+		"Sabre\\DAV\\Exception\\Locked",
+		"Sabre\\DAV\\Exception\\NotImplemented",
+		"Sabre\\DAV\\Exception\\InsufficientStorage",
+		"OCA\\DAV\\Connector\\Sabre\\Exception\\EntityTooLarge",
 	}
 )
 
@@ -94,37 +113,63 @@ type errorXML struct {
 
 var errInvalidPropfind = errors.New("webdav: invalid propfind")
 
+// sabreExceptions maps the CS3 status codes to the http status and the
+// sabredav exception returned to the clients. Codes that are not listed
+// are internal errors and do not get an exception body.
+var sabreExceptions = map[rpc.Code]struct {
+	status int
+	code   code
+}{
+	rpc.Code_CODE_INVALID_ARGUMENT:     {http.StatusBadRequest, SabredavBadRequest},
+	rpc.Code_CODE_UNAUTHENTICATED:      {http.StatusUnauthorized, SabredavNotAuthenticated},
+	rpc.Code_CODE_PERMISSION_DENIED:    {http.StatusForbidden, SabredavPermissionDenied},
+	rpc.Code_CODE_NOT_FOUND:            {http.StatusNotFound, SabredavNotFound},
+	rpc.Code_CODE_ALREADY_EXISTS:       {http.StatusMethodNotAllowed, SabredavMethodNotAllowed},
+	rpc.Code_CODE_FAILED_PRECONDITION:  {http.StatusConflict, SabredavConflict},
+	rpc.Code_CODE_ABORTED:              {http.StatusPreconditionFailed, SabredavPreconditionFailed},
+	rpc.Code_CODE_LOCKED:               {http.StatusLocked, SabredavLocked},
+	rpc.Code_CODE_UNIMPLEMENTED:        {http.StatusNotImplemented, SabredavNotImplemented},
+	rpc.Code_CODE_UNAVAILABLE:          {http.StatusServiceUnavailable, SabredavServiceUnavailable},
+	rpc.Code_CODE_DEADLINE_EXCEEDED:    {http.StatusGatewayTimeout, SabredavServiceUnavailable},
+	rpc.Code_CODE_INSUFFICIENT_STORAGE: {http.StatusInsufficientStorage, SabredavInsufficientStorage},
+}
+
+// statusToSabreException returns the http status and the sabredav exception
+// body for a CS3 status. The body is empty for successful requests and
+// internal errors.
+func statusToSabreException(s *rpc.Status) (int, string) {
+	if s.GetCode() == rpc.Code_CODE_OK {
+		return http.StatusOK, ""
+	}
+	e, ok := sabreExceptions[s.GetCode()]
+	if !ok {
+		return http.StatusInternalServerError, ""
+	}
+	m := s.GetMessage()
+	if m == "" {
+		m = http.StatusText(e.status)
+	}
+	b, err := Marshal(exception{code: e.code, message: m})
+	if err != nil {
+		return http.StatusInternalServerError, ""
+	}
+	return e.status, string(b)
+}
+
 // HandleErrorStatus checks the status code, logs a Debug or Error level message
-// and writes an appropriate http status.
+// and writes an appropriate http status and sabredav exception.
 func HandleErrorStatus(log *zerolog.Logger, w http.ResponseWriter, s *rpc.Status) {
-	switch s.Code {
-	case rpc.Code_CODE_OK:
-		log.Debug().Interface("status", s).Msg("ok")
-		w.WriteHeader(http.StatusOK)
-	case rpc.Code_CODE_NOT_FOUND:
-		log.Debug().Interface("status", s).Msg("resource not found")
-		w.WriteHeader(http.StatusNotFound)
-	case rpc.Code_CODE_PERMISSION_DENIED:
-		log.Debug().Interface("status", s).Msg("permission denied")
-		w.WriteHeader(http.StatusForbidden)
-	case rpc.Code_CODE_UNAUTHENTICATED:
-		log.Debug().Interface("status", s).Msg("unauthenticated")
-		w.WriteHeader(http.StatusUnauthorized)
-	case rpc.Code_CODE_INVALID_ARGUMENT:
-		log.Debug().Interface("status", s).Msg("bad request")
-		w.WriteHeader(http.StatusBadRequest)
-	case rpc.Code_CODE_UNIMPLEMENTED:
-		log.Debug().Interface("status", s).Msg("not implemented")
-		w.WriteHeader(http.StatusNotImplemented)
-	case rpc.Code_CODE_INSUFFICIENT_STORAGE:
-		log.Debug().Interface("status", s).Msg("insufficient storage")
-		w.WriteHeader(http.StatusInsufficientStorage)
-	case rpc.Code_CODE_FAILED_PRECONDITION:
-		log.Debug().Interface("status", s).Msg("destination does not exist")
-		w.WriteHeader(http.StatusConflict)
-	default:
+	status, body := statusToSabreException(s)
+	if status == http.StatusInternalServerError {
 		log.Error().Interface("status", s).Msg("grpc request failed")
-		w.WriteHeader(http.StatusInternalServerError)
+	} else {
+		log.Debug().Interface("status", s).Msg(strings.ToLower(http.StatusText(status)))
+	}
+	w.WriteHeader(status)
+	if body != "" {
+		if _, err := io.WriteString(w, body); err != nil {
+			log.Err(err).Msg("error writing response")
+		}
 	}
 }
 
@@ -0,0 +1,87 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/rs/zerolog"
+)
+
+func TestStatusToSabreException(t *testing.T) {
+	tests := []struct {
+		code      rpc.Code
+		status    int
+		exception string
+	}{
+		{rpc.Code_CODE_OK, http.StatusOK, ""},
+		{rpc.Code_CODE_INVALID_ARGUMENT, http.StatusBadRequest, "Sabre\\DAV\\Exception\\BadRequest"},
+		{rpc.Code_CODE_UNAUTHENTICATED, http.StatusUnauthorized, "Sabre\\DAV\\Exception\\NotAuthenticated"},
+		{rpc.Code_CODE_PERMISSION_DENIED, http.StatusForbidden, "Sabre\\DAV\\Exception\\PermissionDenied"},
+		{rpc.Code_CODE_NOT_FOUND, http.StatusNotFound, "Sabre\\DAV\\Exception\\NotFound"},
+		{rpc.Code_CODE_ALREADY_EXISTS, http.StatusMethodNotAllowed, "Sabre\\DAV\\Exception\\MethodNotAllowed"},
+		{rpc.Code_CODE_FAILED_PRECONDITION, http.StatusConflict, "Sabre\\DAV\\Exception\\Conflict"},
+		{rpc.Code_CODE_ABORTED, http.StatusPreconditionFailed, "Sabre\\DAV\\Exception\\PreconditionFailed"},
+		{rpc.Code_CODE_LOCKED, http.StatusLocked, "Sabre\\DAV\\Exception\\Locked"},
+		{rpc.Code_CODE_UNIMPLEMENTED, http.StatusNotImplemented, "Sabre\\DAV\\Exception\\NotImplemented"},
+		{rpc.Code_CODE_UNAVAILABLE, http.StatusServiceUnavailable, "Sabre\\DAV\\Exception\\ServiceUnavailable"},
+		{rpc.Code_CODE_DEADLINE_EXCEEDED, http.StatusGatewayTimeout, "Sabre\\DAV\\Exception\\ServiceUnavailable"},
+		{rpc.Code_CODE_INSUFFICIENT_STORAGE, http.StatusInsufficientStorage, "Sabre\\DAV\\Exception\\InsufficientStorage"},
+		{rpc.Code_CODE_INTERNAL, http.StatusInternalServerError, ""},
+		{rpc.Code_CODE_UNKNOWN, http.StatusInternalServerError, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code.String(), func(t *testing.T) {
+			status, body := statusToSabreException(&rpc.Status{Code: tt.code, Message: "some message"})
+			if status != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, status)
+			}
+			if tt.exception == "" {
+				if body != "" {
+					t.Errorf("expected no body, got %s", body)
+				}
+				return
+			}
+			if !strings.Contains(body, "<s:exception>"+tt.exception+"</s:exception>") {
+				t.Errorf("expected exception %s, got %s", tt.exception, body)
+			}
+			if !strings.Contains(body, "<s:message>some message</s:message>") {
+				t.Errorf("expected the status message in the body, got %s", body)
+			}
+		})
+	}
+}
+
+func TestHandleErrorStatusWritesException(t *testing.T) {
+	log := zerolog.Nop()
+	w := httptest.NewRecorder()
+	HandleErrorStatus(&log, w, &rpc.Status{Code: rpc.Code_CODE_NOT_FOUND})
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "<s:message>Not Found</s:message>") {
+		t.Errorf("expected the default message in the body, got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// unhealthyGatewayBackoff is how long a gateway that was unavailable is skipped.
+const unhealthyGatewayBackoff = 30 * time.Second
+
+// gatewaySelector spreads the gateway calls over several gateways in
+// round robin, skipping the gateways whose last call found them unavailable.
+type gatewaySelector struct {
+	gateways []*gatewayEndpoint
+	next     atomic.Uint64
+	now      func() time.Time
+}
+
+type gatewayEndpoint struct {
+	client gateway.GatewayAPIClient
+	// downUntil is the time, in unix nanoseconds, until which
+	// the gateway is skipped, 0 when it is healthy.
+	downUntil atomic.Int64
+}
+
+// newGatewaySelector creates a client for each of the endpoints,
+// with the given dial options.
+func newGatewaySelector(endpoints []string, dopts ...grpc.DialOption) (*gatewaySelector, error) {
+	s := &gatewaySelector{now: time.Now}
+	for _, endpoint := range endpoints {
+		g := &gatewayEndpoint{}
+		opts := append([]grpc.DialOption{grpc.WithChainUnaryInterceptor(s.healthInterceptor(g))}, dopts...)
+		client, err := pool.NewGatewayServiceClient([]pool.Option{pool.Endpoint(endpoint)}, opts...)
+		if err != nil {
+			return nil, err
+		}
+		g.client = client
+		s.gateways = append(s.gateways, g)
+	}
+	return s, nil
+}
+
+// healthInterceptor marks the gateway as unhealthy when a call finds
+// it unavailable, and as healthy again on the next successful call.
+func (s *gatewaySelector) healthInterceptor(g *gatewayEndpoint) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		switch {
+		case status.Code(err) == codes.Unavailable:
+			g.downUntil.Store(s.now().Add(unhealthyGatewayBackoff).UnixNano())
+		case err == nil:
+			g.downUntil.Store(0)
+		}
+		return err
+	}
+}
+
+// Next returns the client of the next healthy gateway. When none is
+// healthy the next gateway is returned anyway, so that the calls fail
+// with the error of the gateway.
+func (s *gatewaySelector) Next() gateway.GatewayAPIClient {
+	n := uint64(len(s.gateways))
+	start := s.next.Add(1) - 1
+	now := s.now().UnixNano()
+	for i := uint64(0); i < n; i++ {
+		g := s.gateways[(start+i)%n]
+		if g.downUntil.Load() <= now {
+			if i > 0 {
+				// the next call starts after the gateway that was chosen
+				s.next.Store(start + i + 1)
+			}
+			return g.client
+		}
+	}
+	return s.gateways[start%n].client
+}
@@ -0,0 +1,104 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"google.golang.org/grpc"
+)
+
+// statGateway counts the stats it answers.
+type statGateway struct {
+	gateway.UnimplementedGatewayAPIServer
+	stats atomic.Int32
+}
+
+func (g *statGateway) Stat(context.Context, *provider.StatRequest) (*provider.StatResponse, error) {
+	g.stats.Add(1)
+	return &provider.StatResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}}, nil
+}
+
+func startStatGateway(t *testing.T) (*statGateway, string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := &statGateway{}
+	srv := grpc.NewServer()
+	gateway.RegisterGatewayAPIServer(srv, gw)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	return gw, lis.Addr().String()
+}
+
+func TestGatewaySelectorRoundRobin(t *testing.T) {
+	gw1, addr1 := startStatGateway(t)
+	gw2, addr2 := startStatGateway(t)
+	s, err := newGatewaySelector([]string{addr1, addr2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err := s.Next().Stat(context.Background(), &provider.StatRequest{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n1, n2 := gw1.stats.Load(), gw2.stats.Load(); n1 != 2 || n2 != 2 {
+		t.Errorf("expected the stats to be spread evenly, got %d and %d", n1, n2)
+	}
+}
+
+func TestGatewaySelectorSkipsUnavailable(t *testing.T) {
+	gw1, addr1 := startStatGateway(t)
+	gw2, addr2 := startStatGateway(t)
+
+	// nothing listens on the address of the unavailable gateway
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	down := lis.Addr().String()
+	_ = lis.Close()
+
+	s, err := newGatewaySelector([]string{addr1, down, addr2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var failed int
+	for i := 0; i < 6; i++ {
+		if _, err := s.Next().Stat(context.Background(), &provider.StatRequest{}); err != nil {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("expected only the first call to the unavailable gateway to fail, got %d failures", failed)
+	}
+	if n := gw1.stats.Load() + gw2.stats.Load(); n != 5 {
+		t.Errorf("expected the other calls to be answered by the healthy gateways, got %d", n)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"google.golang.org/grpc"
+)
+
+type gatewayTimeoutKey struct{}
+
+// gatewayTimeout records whether a gateway call of a request exceeded its deadline.
+type gatewayTimeout struct {
+	exceeded atomic.Bool
+}
+
+// gatewayTimeoutInterceptor applies the timeout as a deadline to each gateway call,
+// and flags the request when the deadline is exceeded.
+func gatewayTimeoutInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		callCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		err := invoker(callCtx, method, req, reply, cc, opts...)
+		if err != nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			if t, ok := ctx.Value(gatewayTimeoutKey{}).(*gatewayTimeout); ok {
+				t.exceeded.Store(true)
+			}
+		}
+		return err
+	}
+}
+
+// withGatewayTimeout tracks the gateway calls of the request, so that the
+// internal server error written by the handlers when a gateway call times out
+// is turned into a 504 Gateway Timeout.
+func withGatewayTimeout(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	t := &gatewayTimeout{}
+	ctx := context.WithValue(r.Context(), gatewayTimeoutKey{}, t)
+	return &gatewayTimeoutWriter{ResponseWriter: w, r: r, t: t}, r.WithContext(ctx)
+}
+
+type gatewayTimeoutWriter struct {
+	http.ResponseWriter
+	r        *http.Request
+	t        *gatewayTimeout
+	timedOut bool
+}
+
+func (w *gatewayTimeoutWriter) WriteHeader(code int) {
+	if code != http.StatusInternalServerError || !w.t.exceeded.Load() {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	log := appctx.GetLogger(w.r.Context())
+	w.timedOut = true
+	w.ResponseWriter.WriteHeader(http.StatusGatewayTimeout)
+	b, err := Marshal(exception{
+		code:    SabredavServiceUnavailable,
+		message: "The gateway did not respond in time",
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("error marshaling xml response")
+		return
+	}
+	if _, err := w.ResponseWriter.Write(b); err != nil {
+		log.Error().Err(err).Msg("error writing response")
+	}
+}
+
+// Write discards what the handlers write after a gateway timeout,
+// as the response already has the exception body.
+func (w *gatewayTimeoutWriter) Write(b []byte) (int, error) {
+	if w.timedOut {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the original writer, for http.ResponseController.
+func (w *gatewayTimeoutWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,87 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"google.golang.org/grpc"
+)
+
+// blockingGateway is a gateway whose calls block until released.
+type blockingGateway struct {
+	gateway.UnimplementedGatewayAPIServer
+	release chan struct{}
+}
+
+func (g *blockingGateway) ListStorageSpaces(ctx context.Context, _ *provider.ListStorageSpacesRequest) (*provider.ListStorageSpacesResponse, error) {
+	select {
+	case <-g.release:
+	case <-ctx.Done():
+	}
+	return nil, ctx.Err()
+}
+
+func TestGatewayTimeout(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := &blockingGateway{release: make(chan struct{})}
+	srv := grpc.NewServer()
+	gateway.RegisterGatewayAPIServer(srv, gw)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(func() {
+		close(gw.release)
+		srv.Stop()
+	})
+
+	c := &Config{GatewaySvc: lis.Addr().String()}
+	client, err := pool.NewGatewayServiceClient(
+		[]pool.Option{pool.Endpoint(c.GatewaySvc)},
+		grpc.WithUnaryInterceptor(gatewayTimeoutInterceptor(100*time.Millisecond)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &svc{c: c, davHandler: new(DavHandler), gatewayClient: client}
+	if err := s.davHandler.init(c); err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(MethodPropfind, "/remote.php/dav/spaces/provider-1$userspace!root", nil)
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, r)
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected status %d, got %d", http.StatusGatewayTimeout, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "Sabre\\DAV\\Exception\\ServiceUnavailable") {
+		t.Fatalf("expected a sabredav exception, got %s", w.Body.String())
+	}
+}
@@ -73,6 +73,13 @@ func (s *svc) handleGet(ctx context.Context, w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	s.setCacheHeaders(ctx, w)
+	if ifNoneMatch := r.Header.Get(HeaderIfNoneMatch); ifNoneMatch != "" && etagInList(ifNoneMatch, sRes.Info.Etag) {
+		w.Header().Set(HeaderETag, sRes.Info.Etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	dReq := &provider.InitiateFileDownloadRequest{Ref: ref}
 	dRes, err := client.InitiateFileDownload(ctx, dReq)
 	if err != nil {
@@ -156,6 +163,34 @@ func (s *svc) handleGet(ctx context.Context, w http.ResponseWriter, r *http.Requ
 	// TODO we need to send the If-Match etag in the GET to the datagateway to prevent race conditions between stating and reading the file
 }
 
+// setCacheHeaders lets the downloads through public links be cached for the configured
+// max age, without outliving the link. The other downloads must not be stored.
+func (s *svc) setCacheHeaders(ctx context.Context, w http.ResponseWriter) {
+	link, ok := ctx.Value(publicLinkKey{}).(publicLink)
+	if !ok || s.c.PublicDownloadMaxAge <= 0 {
+		w.Header().Set(HeaderCacheControl, "no-store")
+		return
+	}
+
+	now := time.Now()
+	maxAge := time.Duration(s.c.PublicDownloadMaxAge) * time.Second
+	if !link.expiration.IsZero() && link.expiration.Sub(now) < maxAge {
+		maxAge = link.expiration.Sub(now)
+	}
+	if maxAge < time.Second {
+		w.Header().Set(HeaderCacheControl, "no-store")
+		return
+	}
+
+	// the shared caches must not serve the password protected links
+	visibility := "public"
+	if link.protected {
+		visibility = "private"
+	}
+	w.Header().Set(HeaderCacheControl, fmt.Sprintf("%s, max-age=%d", visibility, int64(maxAge/time.Second)))
+	w.Header().Set(HeaderExpires, now.Add(maxAge).UTC().Format(http.TimeFormat))
+}
+
 func (s *svc) handleSpacesGet(w http.ResponseWriter, r *http.Request, spaceID string) {
 	ctx := r.Context()
 	sublog := appctx.GetLogger(ctx).With().Str("path", r.URL.Path).Str("spaceid", spaceID).Str("handler", "get").Logger()
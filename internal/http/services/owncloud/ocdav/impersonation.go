@@ -0,0 +1,94 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"net/http"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"google.golang.org/grpc/metadata"
+)
+
+// impersonate runs the request as the user given in the X-Impersonate-User
+// header, when the caller is one of the impersonation admins. The user is
+// authenticated through the machine auth of the gateway. It returns false
+// when the request has been answered, e.g. because the caller is not an admin.
+func (s *svc) impersonate(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	target := r.Header.Get(HeaderImpersonateUser)
+	if target == "" {
+		return r, true
+	}
+
+	ctx := r.Context()
+	log := appctx.GetLogger(ctx)
+	caller, ok := appctx.ContextGetUser(ctx)
+	if !ok || s.c.ImpersonationAPIKey == "" || !s.isImpersonationAdmin(caller.Username) {
+		log.Warn().Str("user", caller.GetUsername()).Str("impersonated", target).Msg("impersonation denied")
+		w.WriteHeader(http.StatusForbidden)
+		b, err := Marshal(exception{
+			code:    SabredavPermissionDenied,
+			message: "Impersonation is not allowed",
+		})
+		HandleWebdavError(log, w, b, err)
+		return nil, false
+	}
+
+	client, err := s.getClient()
+	if err != nil {
+		log.Error().Err(err).Msg("error getting grpc client")
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+	res, err := client.Authenticate(ctx, &gateway.AuthenticateRequest{
+		Type:         "machine",
+		ClientId:     "username:" + target,
+		ClientSecret: s.c.ImpersonationAPIKey,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("error authenticating the impersonated user")
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		HandleErrorStatus(log, w, res.Status)
+		return nil, false
+	}
+
+	log.Info().Str("user", caller.Username).Str("impersonated", target).Str("method", r.Method).Str("path", r.URL.Path).Msg("impersonation")
+
+	// the token of the caller is replaced, not appended to
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Set(appctx.TokenHeader, res.Token)
+	ctx = metadata.NewOutgoingContext(ctx, md)
+	ctx = appctx.ContextSetToken(ctx, res.Token)
+	ctx = appctx.ContextSetUser(ctx, res.User)
+	return r.WithContext(ctx), true
+}
+
+func (s *svc) isImpersonationAdmin(username string) bool {
+	for _, a := range s.c.ImpersonationAdmins {
+		if a == username {
+			return true
+		}
+	}
+	return false
+}
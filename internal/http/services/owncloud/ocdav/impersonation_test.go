@@ -0,0 +1,117 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// machineAuthGateway authenticates any user with the machine auth,
+// and records the tokens of the stats it serves.
+type machineAuthGateway struct {
+	*countingGateway
+	mu     sync.Mutex
+	tokens []string
+}
+
+func (g *machineAuthGateway) Authenticate(_ context.Context, req *gateway.AuthenticateRequest) (*gateway.AuthenticateResponse, error) {
+	if req.Type != "machine" || req.ClientSecret != "api-key" {
+		return &gateway.AuthenticateResponse{Status: &rpc.Status{Code: rpc.Code_CODE_UNAUTHENTICATED}}, nil
+	}
+	username := strings.TrimPrefix(req.ClientId, "username:")
+	return &gateway.AuthenticateResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		Token:  "token-" + username,
+		User:   &userpb.User{Id: &userpb.UserId{OpaqueId: username}, Username: username},
+	}, nil
+}
+
+func (g *machineAuthGateway) Stat(ctx context.Context, req *provider.StatRequest) (*provider.StatResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	g.mu.Lock()
+	g.tokens = append(g.tokens, md.Get(appctx.TokenHeader)...)
+	g.mu.Unlock()
+	return g.countingGateway.Stat(ctx, req)
+}
+
+func TestImpersonation(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := &machineAuthGateway{countingGateway: &countingGateway{}}
+	srv := grpc.NewServer()
+	gateway.RegisterGatewayAPIServer(srv, gw)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	c := &Config{GatewaySvc: lis.Addr().String(), ImpersonationAdmins: []string{"admin"}, ImpersonationAPIKey: "api-key"}
+	client, err := pool.GetGatewayServiceClient(pool.Endpoint(c.GatewaySvc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &svc{c: c, davHandler: new(DavHandler), gatewayClient: client}
+	if err := s.davHandler.init(c); err != nil {
+		t.Fatal(err)
+	}
+
+	propfind := func(caller string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(MethodPropfind, "/remote.php/dav/spaces/space", nil)
+		r.Header.Set(HeaderDepth, "0")
+		r.Header.Set(HeaderImpersonateUser, "einstein")
+		ctx := appctx.ContextSetUser(r.Context(), &userpb.User{Id: &userpb.UserId{OpaqueId: caller}, Username: caller})
+		ctx = metadata.AppendToOutgoingContext(ctx, appctx.TokenHeader, "token-"+caller)
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, r.WithContext(ctx))
+		return w
+	}
+
+	if w := propfind("admin"); w.Code != http.StatusMultiStatus {
+		t.Fatalf("expected status %d for an admin, got %d: %s", http.StatusMultiStatus, w.Code, w.Body.String())
+	}
+	gw.mu.Lock()
+	tokens := gw.tokens
+	gw.mu.Unlock()
+	if len(tokens) == 0 || tokens[len(tokens)-1] != "token-einstein" {
+		t.Errorf("expected the stat to run as the impersonated user, got the tokens %v", tokens)
+	}
+
+	stats := gw.stats.Load()
+	if w := propfind("marie"); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for a non admin, got %d", http.StatusForbidden, w.Code)
+	}
+	if n := gw.stats.Load(); n != stats {
+		t.Errorf("expected the request of a non admin not to reach the storage, got %d stats", n-stats)
+	}
+}
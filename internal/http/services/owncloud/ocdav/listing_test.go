@@ -0,0 +1,63 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"reflect"
+	"testing"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+)
+
+func TestSortChildren(t *testing.T) {
+	newInfos := func() []*provider.ResourceInfo {
+		return []*provider.ResourceInfo{
+			{Path: "/folder", Size: 60},
+			{Path: "/folder/charlie", Size: 10, Mtime: &types.Timestamp{Seconds: 2}},
+			{Path: "/folder/Alpha", Size: 30, Mtime: &types.Timestamp{Seconds: 3}},
+			{Path: "/folder/bravo", Size: 20, Mtime: &types.Timestamp{Seconds: 1}},
+		}
+	}
+
+	tests := []struct {
+		order      string
+		descending bool
+		expected   []string
+	}{
+		{order: "", expected: []string{"/folder", "/folder/charlie", "/folder/Alpha", "/folder/bravo"}},
+		{order: "name", expected: []string{"/folder", "/folder/Alpha", "/folder/bravo", "/folder/charlie"}},
+		{order: "name", descending: true, expected: []string{"/folder", "/folder/charlie", "/folder/bravo", "/folder/Alpha"}},
+		{order: "size", expected: []string{"/folder", "/folder/charlie", "/folder/bravo", "/folder/Alpha"}},
+		{order: "mtime", descending: true, expected: []string{"/folder", "/folder/Alpha", "/folder/charlie", "/folder/bravo"}},
+	}
+
+	for _, tt := range tests {
+		infos := newInfos()
+		sortChildren(infos, tt.order, tt.descending)
+
+		var got []string
+		for _, i := range infos {
+			got = append(got, i.Path)
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("order %q descending %v: got %v expected %v", tt.order, tt.descending, got, tt.expected)
+		}
+	}
+}
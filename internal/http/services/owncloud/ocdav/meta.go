@@ -45,7 +45,11 @@ func (h *MetaHandler) Handler(s *svc) http.Handler {
 			return
 		}
 
-		did := resourceid.OwnCloudResourceIDUnwrap(id)
+		did, err := resourceid.ItemSourceUnwrap(id)
+		if err != nil {
+			http.Error(w, "400 Bad Request", http.StatusBadRequest)
+			return
+		}
 
 		var head string
 		head, r.URL.Path = router.ShiftPath(r.URL.Path)
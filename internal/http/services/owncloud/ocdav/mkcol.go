@@ -34,7 +34,7 @@ import (
 func (s *svc) handlePathMkcol(w http.ResponseWriter, r *http.Request, ns string) {
 	ctx := r.Context()
 	fn := path.Join(ns, r.URL.Path)
-	for _, r := range nameRules {
+	for _, r := range s.nameRules(endpointWebDAV) {
 		if !r.Test(fn) {
 			w.WriteHeader(http.StatusBadRequest)
 			return
@@ -50,6 +50,12 @@ func (s *svc) handlePathMkcol(w http.ResponseWriter, r *http.Request, ns string)
 
 func (s *svc) handleSpacesMkCol(w http.ResponseWriter, r *http.Request, spaceID string) {
 	ctx := r.Context()
+	for _, rule := range s.nameRules(endpointSpaces) {
+		if !rule.Test(r.URL.Path) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
 	sublog := appctx.GetLogger(ctx).With().Str("path", r.URL.Path).Str("spaceid", spaceID).Str("handler", "mkcol").Logger()
 
 	parentRef, rpcStatus, err := s.lookUpStorageSpaceReference(ctx, spaceID, path.Dir(r.URL.Path))
@@ -130,6 +136,14 @@ func (s *svc) handleMkcol(ctx context.Context, w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	if statRes.Status.Code == rpc.Code_CODE_OK || statRes.Status.Code == rpc.Code_CODE_NOT_FOUND {
+		if !checkETagPreconditions(r, statRes.Status.Code == rpc.Code_CODE_OK, statRes.Info.GetEtag()) {
+			log.Debug().Str("server-etag", statRes.Info.GetEtag()).Msg("etag precondition failed")
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+	}
+
 	if statRes.Status.Code != rpc.Code_CODE_NOT_FOUND {
 		if statRes.Status.Code == rpc.Code_CODE_OK {
 			w.WriteHeader(http.StatusMethodNotAllowed) // 405 if it already exists
@@ -158,14 +172,9 @@ func (s *svc) handleMkcol(ctx context.Context, w http.ResponseWriter, r *http.Re
 		log.Debug().Str("path", childRef.Path).Interface("status", statRes.Status).Msg("conflict")
 		w.WriteHeader(http.StatusConflict)
 	case rpc.Code_CODE_PERMISSION_DENIED:
-		w.WriteHeader(http.StatusForbidden)
 		// TODO path could be empty or relative...
-		m := fmt.Sprintf("Permission denied to create %v", childRef.Path)
-		b, err := Marshal(exception{
-			code:    SabredavPermissionDenied,
-			message: m,
-		})
-		HandleWebdavError(&log, w, b, err)
+		res.Status.Message = fmt.Sprintf("Permission denied to create %v", childRef.Path)
+		HandleErrorStatus(&log, w, res.Status)
 	default:
 		HandleErrorStatus(&log, w, res.Status)
 	}
@@ -42,7 +42,7 @@ func (s *svc) handlePathMove(w http.ResponseWriter, r *http.Request, ns string)
 		return
 	}
 
-	for _, r := range nameRules {
+	for _, r := range s.nameRules(endpointWebDAV) {
 		if !r.Test(dstPath) {
 			w.WriteHeader(http.StatusBadRequest)
 			return
@@ -71,6 +71,13 @@ func (s *svc) handleSpacesMove(w http.ResponseWriter, r *http.Request, srcSpaceI
 		return
 	}
 
+	for _, rule := range s.nameRules(endpointSpaces) {
+		if !rule.Test(dst) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
 	sublog := appctx.GetLogger(ctx).With().Str("spaceid", srcSpaceID).Str("path", r.URL.Path).Logger()
 	// retrieve a specific storage space
 	srcRef, status, err := s.lookUpStorageSpaceReference(ctx, srcSpaceID, r.URL.Path)
@@ -138,13 +145,7 @@ func (s *svc) handleMove(ctx context.Context, w http.ResponseWriter, r *http.Req
 	}
 	if srcStatRes.Status.Code != rpc.Code_CODE_OK {
 		if srcStatRes.Status.Code == rpc.Code_CODE_NOT_FOUND {
-			w.WriteHeader(http.StatusNotFound)
-			m := fmt.Sprintf("Resource %v not found", srcStatReq.Ref.Path)
-			b, err := Marshal(exception{
-				code:    SabredavNotFound,
-				message: m,
-			})
-			HandleWebdavError(&log, w, b, err)
+			srcStatRes.Status.Message = fmt.Sprintf("Resource %v not found", srcStatReq.Ref.Path)
 		}
 		HandleErrorStatus(&log, w, srcStatRes.Status)
 		return
@@ -228,13 +229,7 @@ func (s *svc) handleMove(ctx context.Context, w http.ResponseWriter, r *http.Req
 
 	if mRes.Status.Code != rpc.Code_CODE_OK {
 		if mRes.Status.Code == rpc.Code_CODE_PERMISSION_DENIED {
-			w.WriteHeader(http.StatusForbidden)
-			m := fmt.Sprintf("Permission denied to move %v", src.Path)
-			b, err := Marshal(exception{
-				code:    SabredavPermissionDenied,
-				message: m,
-			})
-			HandleWebdavError(&log, w, b, err)
+			mRes.Status.Message = fmt.Sprintf("Permission denied to move %v", src.Path)
 		}
 		HandleErrorStatus(&log, w, mRes.Status)
 		return
@@ -0,0 +1,101 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"google.golang.org/grpc"
+)
+
+// mkcolGatewayClient serves a single space in which every parent exists
+// and no child does, so that any collection can be created.
+type mkcolGatewayClient struct {
+	gateway.GatewayAPIClient
+	created []*provider.Reference
+}
+
+func (c *mkcolGatewayClient) ListStorageSpaces(_ context.Context, _ *provider.ListStorageSpacesRequest, _ ...grpc.CallOption) (*provider.ListStorageSpacesResponse, error) {
+	return &provider.ListStorageSpacesResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		StorageSpaces: []*provider.StorageSpace{
+			{Root: &provider.ResourceId{StorageId: "provider-1", OpaqueId: "space"}},
+		},
+	}, nil
+}
+
+func (c *mkcolGatewayClient) Stat(_ context.Context, req *provider.StatRequest, _ ...grpc.CallOption) (*provider.StatResponse, error) {
+	if req.Ref.Path == "." {
+		return &provider.StatResponse{
+			Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+			Info:   &provider.ResourceInfo{Type: provider.ResourceType_RESOURCE_TYPE_CONTAINER, Path: "/"},
+		}, nil
+	}
+	return &provider.StatResponse{Status: &rpc.Status{Code: rpc.Code_CODE_NOT_FOUND}}, nil
+}
+
+func (c *mkcolGatewayClient) CreateContainer(_ context.Context, req *provider.CreateContainerRequest, _ ...grpc.CallOption) (*provider.CreateContainerResponse, error) {
+	c.created = append(c.created, req.Ref)
+	return &provider.CreateContainerResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}}, nil
+}
+
+func TestNameMaxLengthPerEndpoint(t *testing.T) {
+	c := &Config{
+		NameValidation: NameValidation{
+			MaxLength:            255,
+			MaxLengthPerEndpoint: map[string]int{endpointSpaces: 400},
+		},
+	}
+	client := &mkcolGatewayClient{}
+	s := &svc{c: c, davHandler: new(DavHandler), webDavHandler: new(WebDavHandler), gatewayClient: client}
+	if err := s.davHandler.init(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.webDavHandler.init(c.WebdavNamespace, true); err != nil {
+		t.Fatal(err)
+	}
+
+	name := strings.Repeat("a", 300)
+	tests := []struct {
+		url    string
+		status int
+	}{
+		{url: "/remote.php/webdav/" + name, status: http.StatusBadRequest},
+		{url: "/remote.php/dav/spaces/space/" + name, status: http.StatusCreated},
+		{url: "/remote.php/dav/spaces/space/" + strings.Repeat("a", 401), status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(MethodMkcol, tt.url, http.NoBody)
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, r)
+		if w.Code != tt.status {
+			t.Errorf("MKCOL %s: expected status %d, got %d", tt.url[:40], tt.status, w.Code)
+		}
+	}
+	if len(client.created) != 1 {
+		t.Errorf("expected a single collection to be created, got %d", len(client.created))
+	}
+}
@@ -45,6 +45,7 @@ import (
 	"github.com/cs3org/reva/pkg/storage/utils/templates"
 	"github.com/cs3org/reva/pkg/utils/cfg"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc"
 )
 
 type ctxKey int
@@ -81,6 +82,74 @@ func (r nameDoesNotContain) Test(name string) bool {
 	return !strings.ContainsAny(name, r.chars)
 }
 
+type nameMaxLength struct {
+	max int
+}
+
+func (r nameMaxLength) Test(name string) bool {
+	return len(path.Base(name)) <= r.max
+}
+
+const (
+	// endpointWebDAV names the oc compatible, path based, webdav endpoints.
+	endpointWebDAV = "webdav"
+	// endpointSpaces names the spaces endpoint.
+	endpointSpaces = "spaces"
+)
+
+// defaultNameMaxLength is the maximum length of a name
+// supported by the oc compatible clients.
+const defaultNameMaxLength = 255
+
+// NameValidation holds the validation settings of the names of the created resources.
+type NameValidation struct {
+	// MaxLength is the maximum length in bytes of a name, defaults to 255.
+	// A negative value disables the check.
+	MaxLength int `mapstructure:"max_length"`
+	// MaxLengthPerEndpoint overrides MaxLength for the "webdav" and "spaces" endpoints.
+	MaxLengthPerEndpoint map[string]int `mapstructure:"max_length_per_endpoint"`
+}
+
+// maxLength returns the maximum length of a name on the endpoint.
+func (v *NameValidation) maxLength(endpoint string) int {
+	if max, ok := v.MaxLengthPerEndpoint[endpoint]; ok {
+		return max
+	}
+	return v.MaxLength
+}
+
+// nameRules returns the rules the names created on the endpoint must satisfy.
+func (s *svc) nameRules(endpoint string) []nameRule {
+	rules := append([]nameRule{}, nameRules[:]...)
+	if max := s.c.NameValidation.maxLength(endpoint); max > 0 {
+		rules = append(rules, nameMaxLength{max: max})
+	}
+	return rules
+}
+
+// namespacePrefix returns the static part of a namespace, up to its first template.
+func namespacePrefix(ns string) string {
+	if i := strings.Index(ns, "{{"); i >= 0 {
+		ns = ns[:strings.LastIndex(ns[:i], "/")+1]
+	}
+	return path.Join("/", ns)
+}
+
+// checkNamespaces makes sure that the files and webdav namespaces do not
+// resolve inside the ocm namespace, where the received ocm shares are routed.
+func checkNamespaces(c *Config) error {
+	ocm := namespacePrefix(c.OCMNamespace)
+	for name, ns := range map[string]string{"files_namespace": c.FilesNamespace, "webdav_namespace": c.WebdavNamespace} {
+		if ns == "" {
+			continue
+		}
+		if p := namespacePrefix(ns); p == ocm || strings.HasPrefix(p, strings.TrimSuffix(ocm, "/")+"/") {
+			return errtypes.BadRequest(fmt.Sprintf("ocdav: %s %s overlaps with ocm_namespace %s", name, ns, c.OCMNamespace))
+		}
+	}
+	return nil
+}
+
 func init() {
 	global.Register("ocdav", New)
 }
@@ -91,9 +160,25 @@ type ConfigPublicLinkDownload struct {
 	PublicFolder string `mapstructure:"public_folder"`
 }
 
+// ConfigPublicFilesRateLimit holds the rate limiting settings of the public-files endpoint.
+type ConfigPublicFilesRateLimit struct {
+	// RequestsPerSecond is the rate at which requests are allowed, 0 disables the limiter.
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"`
+	// Burst is the number of requests allowed at once.
+	Burst int `mapstructure:"burst"`
+	// Key selects what requests are limited by: "ip" (default) or "token".
+	// The requests are always limited by ip before being authenticated,
+	// with "token" the resolved tokens are limited as well.
+	Key string `mapstructure:"key"`
+}
+
 // Config holds the config options that need to be passed down to all ocdav handlers.
 type Config struct {
 	Prefix string `mapstructure:"prefix"`
+	// ExternalBasePath is the path under which a reverse proxy exposes reva, e.g. /cloud.
+	// It is prefixed onto the generated hrefs, and stripped from the requests of the
+	// proxies that forward it.
+	ExternalBasePath string `mapstructure:"external_base_path"`
 	// FilesNamespace prefixes the namespace, optionally with user information.
 	// Example: if FilesNamespace is /users/{{substr 0 1 .Username}}/{{.Username}}
 	// and received path is /docs the internal path will be:
@@ -120,12 +205,56 @@ type Config struct {
 	FavoriteStorageDrivers map[string]map[string]interface{} `mapstructure:"favorite_storage_drivers"`
 	PublicLinkDownload     *ConfigPublicLinkDownload         `mapstructure:"publiclink_download"`
 	DisabledOpenInAppPaths []string                          `mapstructure:"disabled_open_in_app_paths"`
-	Notifications          map[string]interface{}            `docs:"nil; settings for the notification helper" mapstructure:"notifications"`
+	// OmittedProperties are the properties left out of the PROPFIND responses,
+	// named with their prefix, e.g. oc:permissions or d:getcontenttype.
+	OmittedProperties    []string                    `mapstructure:"omitted_properties"`
+	Notifications        map[string]interface{}      `docs:"nil; settings for the notification helper" mapstructure:"notifications"`
+	PublicFilesRateLimit *ConfigPublicFilesRateLimit `docs:"nil; rate limiting of the public-files endpoint, disabled by default" mapstructure:"public_files_rate_limit"`
+	// ListingOrder sorts the children in a PROPFIND response by "name", "size" or "mtime".
+	// By default they are returned in the order given by the gateway.
+	ListingOrder           string `mapstructure:"listing_order"`
+	ListingOrderDescending bool   `mapstructure:"listing_order_descending"`
+	// GatewayTimeout is the deadline in seconds of each call to the gateway, 0 means no deadline.
+	// A request whose gateway call times out is answered with a 504 Gateway Timeout.
+	GatewayTimeout int64 `mapstructure:"gateway_timeout"`
+	// GatewaySvcs are the endpoints of several gateways, used in place of the gateway
+	// given by gatewaysvc. The calls are spread over them in round robin, skipping
+	// for a while the gateways found unavailable.
+	GatewaySvcs []string `mapstructure:"gatewaysvcs"`
+	// ImpersonationAdmins are the usernames allowed to run their requests as another user,
+	// given in the X-Impersonate-User header, to diagnose issues. The impersonated user is
+	// authenticated with the machine auth, using ImpersonationAPIKey. Disabled when empty.
+	ImpersonationAdmins []string `mapstructure:"impersonation_admins"`
+	ImpersonationAPIKey string   `mapstructure:"impersonation_api_key"`
+	// UploadContentType is how the type of the uploads without a Content-Type is detected:
+	// from the extension of the file (extension, the default), from the content falling back
+	// to the extension (sniff), or not at all (none).
+	UploadContentType string `mapstructure:"upload_content_type"`
+	// TrustedProxies are the CIDRs of the proxies whose X-Forwarded-For and X-Real-Ip
+	// headers are used to resolve the client ip. Defaults to the shared trusted proxies.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// DisablePublicUploads rejects the uploads (PUT, MKCOL and tus) on the public-files
+	// endpoint with a 403 Forbidden, whatever the permissions of the public link.
+	DisablePublicUploads bool `mapstructure:"disable_public_uploads"`
+	// EnableSpaceEmptying allows a DELETE on the root of a space to delete its contents,
+	// the space itself is kept. Without it such a request is answered with a 405 Method Not Allowed.
+	EnableSpaceEmptying bool `mapstructure:"enable_space_emptying"`
+	// PublicDownloadMaxAge is the time in seconds the downloads through public links can be cached,
+	// bounded by the expiration of the link. The downloads are never cached when 0, the default.
+	PublicDownloadMaxAge int64          `mapstructure:"public_download_max_age"`
+	NameValidation       NameValidation `mapstructure:"name_validation"`
+	// SpaceAliases maps human friendly names, usable in place of the space ids
+	// in the /dav/spaces endpoint, to the ids of the spaces.
+	SpaceAliases map[string]string `mapstructure:"space_aliases"`
+	// MaxUploadSize is the maximum size in bytes of a single file upload, checked against the
+	// Content-Length or Upload-Length before the upload is initiated. Uploads are not limited when 0.
+	MaxUploadSize int64 `mapstructure:"max_upload_size"`
 }
 
 func (c *Config) ApplyDefaults() {
 	// note: default c.Prefix is an empty string
 	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+	c.TrustedProxies = sharedconf.GetTrustedProxies(c.TrustedProxies)
 
 	if c.FavoriteStorageDriver == "" {
 		c.FavoriteStorageDriver = "memory"
@@ -134,6 +263,10 @@ func (c *Config) ApplyDefaults() {
 	if c.OCMNamespace == "" {
 		c.OCMNamespace = "/ocm"
 	}
+
+	if c.NameValidation.MaxLength == 0 {
+		c.NameValidation.MaxLength = defaultNameMaxLength
+	}
 }
 
 type svc struct {
@@ -143,6 +276,11 @@ type svc struct {
 	favoritesManager   favorite.Manager
 	client             *httpclient.Client
 	notificationHelper *notificationhelper.NotificationHelper
+	// gatewayClient is set when the gateway calls have a deadline,
+	// otherwise the client of the pool is used
+	gatewayClient gateway.GatewayAPIClient
+	// gateways is set when several gateways are configured
+	gateways *gatewaySelector
 }
 
 func getFavoritesManager(c *Config) (favorite.Manager, error) {
@@ -159,11 +297,31 @@ func New(ctx context.Context, m map[string]interface{}) (global.Service, error)
 		return nil, err
 	}
 
+	if err := checkNamespaces(&c); err != nil {
+		return nil, err
+	}
+
 	fm, err := getFavoritesManager(&c)
 	if err != nil {
 		return nil, err
 	}
 
+	if _, ok := listingOrders[c.ListingOrder]; !ok {
+		return nil, errtypes.BadRequest("ocdav: unknown listing order " + c.ListingOrder)
+	}
+
+	switch c.UploadContentType {
+	case "", contentTypeByExtension, contentTypeBySniffing, contentTypeNone:
+	default:
+		return nil, errtypes.BadRequest("ocdav: unknown upload content type detection " + c.UploadContentType)
+	}
+
+	for endpoint := range c.NameValidation.MaxLengthPerEndpoint {
+		if endpoint != endpointWebDAV && endpoint != endpointSpaces {
+			return nil, errtypes.BadRequest("ocdav: unknown name validation endpoint " + endpoint)
+		}
+	}
+
 	log := appctx.GetLogger(ctx)
 	tr := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: c.Insecure}}
 	s := &svc{
@@ -178,6 +336,20 @@ func New(ctx context.Context, m map[string]interface{}) (global.Service, error)
 		notificationHelper: notificationhelper.New("ocdav", c.Notifications, log),
 	}
 
+	var dopts []grpc.DialOption
+	if c.GatewayTimeout > 0 {
+		dopts = append(dopts, grpc.WithChainUnaryInterceptor(gatewayTimeoutInterceptor(time.Duration(c.GatewayTimeout)*time.Second)))
+	}
+	switch {
+	case len(c.GatewaySvcs) > 0:
+		s.gateways, err = newGatewaySelector(c.GatewaySvcs, dopts...)
+	case c.GatewayTimeout > 0:
+		s.gatewayClient, err = pool.NewGatewayServiceClient([]pool.Option{pool.Endpoint(c.GatewaySvc)}, dopts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	// initialize handlers and set default cigs
 	if err := s.webDavHandler.init(c.WebdavNamespace, true); err != nil {
 		return nil, err
@@ -203,6 +375,15 @@ func (s *svc) Unprotected() []string {
 
 func (s *svc) Handler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.gatewayClient != nil || (s.gateways != nil && s.c.GatewayTimeout > 0) {
+			w, r = withGatewayTimeout(w, r)
+		}
+
+		var ok bool
+		if r, ok = s.impersonate(w, r); !ok {
+			return
+		}
+
 		ctx := r.Context()
 		log := appctx.GetLogger(ctx)
 
@@ -217,7 +398,12 @@ func (s *svc) Handler() http.Handler {
 
 		// to build correct href prop urls we need to keep track of the base path
 		// always starts with /
-		base := path.Join("/", s.Prefix())
+		base := path.Join("/", s.c.ExternalBasePath, s.Prefix())
+		if ext := strings.TrimSuffix(path.Join("/", s.c.ExternalBasePath), "/"); ext != "" {
+			if r.URL.Path == ext || strings.HasPrefix(r.URL.Path, ext+"/") {
+				r.URL.Path = path.Join("/", strings.TrimPrefix(r.URL.Path, ext))
+			}
+		}
 
 		var head string
 		head, r.URL.Path = router.ShiftPath(r.URL.Path)
@@ -287,6 +473,12 @@ func (s *svc) Handler() http.Handler {
 }
 
 func (s *svc) getClient() (gateway.GatewayAPIClient, error) {
+	if s.gatewayClient != nil {
+		return s.gatewayClient, nil
+	}
+	if s.gateways != nil {
+		return s.gateways.Next(), nil
+	}
 	return pool.GetGatewayServiceClient(pool.Endpoint(s.c.GatewaySvc))
 }
 
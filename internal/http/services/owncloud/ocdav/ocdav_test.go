@@ -159,3 +159,34 @@ func TestNameDoesNotContainRule(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckNamespaces(t *testing.T) {
+	tests := []struct {
+		files, webdav, ocm string
+		overlap            bool
+	}{
+		{files: "/", webdav: "/home", ocm: "/ocm"},
+		{files: "/users/{{.Id.OpaqueId}}", webdav: "/home", ocm: "/public"},
+		{files: "/ocm", webdav: "/home", ocm: "/ocm", overlap: true},
+		{files: "/", webdav: "/ocm/{{.Username}}", ocm: "/ocm/", overlap: true},
+		{files: "/users", webdav: "/home", ocm: "/", overlap: true},
+		{files: "/ocmshares", webdav: "/home", ocm: "/ocm"},
+	}
+
+	for _, tt := range tests {
+		err := checkNamespaces(&Config{FilesNamespace: tt.files, WebdavNamespace: tt.webdav, OCMNamespace: tt.ocm})
+		if tt.overlap != (err != nil) {
+			t.Errorf("files %q, webdav %q, ocm %q: expected overlap %t, got error %v", tt.files, tt.webdav, tt.ocm, tt.overlap, err)
+		}
+	}
+}
+
+func TestNewWithOverlappingNamespaces(t *testing.T) {
+	_, err := New(context.Background(), map[string]interface{}{
+		"files_namespace": "/public/{{.Id.OpaqueId}}",
+		"ocm_namespace":   "/public",
+	})
+	if err == nil {
+		t.Fatal("expected the construction to fail with overlapping namespaces")
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+)
+
+func TestOmittedProperties(t *testing.T) {
+	propfind := func(s *svc, body string) string {
+		r := httptest.NewRequest(MethodPropfind, "/remote.php/dav/spaces/space", strings.NewReader(body))
+		r.Header.Set(HeaderDepth, "1")
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, r)
+		if w.Code != http.StatusMultiStatus {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusMultiStatus, w.Code, w.Body.String())
+		}
+		return w.Body.String()
+	}
+
+	for _, body := range []string{
+		// allprop
+		"",
+		`<?xml version="1.0"?><d:propfind xmlns:d="DAV:" xmlns:oc="http://owncloud.org/ns"><d:prop>` +
+			`<d:getetag/><d:resourcetype/><oc:permissions/></d:prop></d:propfind>`,
+	} {
+		gw, s := newCountingGateway(t, 2)
+		for _, c := range gw.children {
+			c.PermissionSet = &provider.ResourcePermissions{Stat: true}
+		}
+		s.c.OmittedProperties = []string{"oc:permissions"}
+		res := propfind(s, body)
+		if strings.Contains(res, "permissions") {
+			t.Errorf("expected oc:permissions to be omitted, got %s", res)
+		}
+		if !strings.Contains(res, "getetag") || !strings.Contains(res, "resourcetype") {
+			t.Errorf("expected the DAV properties to remain, got %s", res)
+		}
+	}
+}
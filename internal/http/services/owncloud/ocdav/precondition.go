@@ -0,0 +1,180 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ifCondition is a single condition of a list in the WebDAV If header.
+// See http://www.webdav.org/specs/rfc4918.html#HEADER_If
+type ifCondition struct {
+	not   bool
+	etag  string
+	token string
+}
+
+// ifList is a parenthesized list of conditions in the WebDAV If header.
+type ifList []ifCondition
+
+// parseIfHeader parses the lists of conditions of a WebDAV If header.
+// Resource tags of tagged lists are skipped, all the lists are evaluated
+// against the request URI.
+func parseIfHeader(h string) ([]ifList, bool) {
+	var lists []ifList
+	s := strings.TrimSpace(h)
+	for s != "" {
+		switch s[0] {
+		case '<':
+			// resource tag of a tagged list
+			end := strings.IndexByte(s, '>')
+			if end == -1 {
+				return nil, false
+			}
+			s = s[end+1:]
+		case '(':
+			end := strings.IndexByte(s, ')')
+			if end == -1 {
+				return nil, false
+			}
+			l, ok := parseIfList(s[1:end])
+			if !ok {
+				return nil, false
+			}
+			lists = append(lists, l)
+			s = s[end+1:]
+		default:
+			return nil, false
+		}
+		s = strings.TrimSpace(s)
+	}
+	return lists, len(lists) > 0
+}
+
+func parseIfList(s string) (ifList, bool) {
+	var l ifList
+	s = strings.TrimSpace(s)
+	for s != "" {
+		var c ifCondition
+		if strings.HasPrefix(s, "Not") {
+			c.not = true
+			s = strings.TrimSpace(s[len("Not"):])
+		}
+		if s == "" {
+			return nil, false
+		}
+		var closing byte
+		switch s[0] {
+		case '<':
+			closing = '>'
+		case '[':
+			closing = ']'
+		default:
+			return nil, false
+		}
+		end := strings.IndexByte(s, closing)
+		if end == -1 {
+			return nil, false
+		}
+		if closing == '>' {
+			c.token = s[1:end]
+		} else {
+			c.etag = s[1:end]
+		}
+		l = append(l, c)
+		s = strings.TrimSpace(s[end+1:])
+	}
+	return l, len(l) > 0
+}
+
+// normalizeETag strips the weak validator prefix and the quotes of an etag.
+func normalizeETag(etag string) string {
+	return strings.Trim(strings.TrimPrefix(strings.TrimSpace(etag), "W/"), `"`)
+}
+
+// etagInList tells if the etag is contained in a comma separated list of etags.
+func etagInList(list, etag string) bool {
+	for _, e := range strings.Split(list, ",") {
+		if normalizeETag(e) == normalizeETag(etag) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkETagPreconditions evaluates the If-Match and If-None-Match headers and the
+// etag conditions of the If header against the resource. When the resource does not
+// exist, exists is false and etag is ignored. It returns false when a precondition fails.
+// State tokens in the If header are not evaluated here, locks are enforced by the storage.
+func checkETagPreconditions(r *http.Request, exists bool, etag string) bool {
+	if ifMatch := r.Header.Get(HeaderIfMatch); ifMatch != "" {
+		if !exists {
+			return false
+		}
+		if strings.TrimSpace(ifMatch) != "*" && !etagInList(ifMatch, etag) {
+			return false
+		}
+	}
+
+	if ifNoneMatch := r.Header.Get(HeaderIfNoneMatch); ifNoneMatch != "" && exists {
+		if strings.TrimSpace(ifNoneMatch) == "*" || etagInList(ifNoneMatch, etag) {
+			return false
+		}
+	}
+
+	if ifHeader := r.Header.Get(HeaderIf); ifHeader != "" {
+		lists, ok := parseIfHeader(ifHeader)
+		if !ok {
+			// a malformed If header is ignored
+			return true
+		}
+		for _, l := range lists {
+			if evaluateIfList(l, exists, etag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+func evaluateIfList(l ifList, exists bool, etag string) bool {
+	for _, c := range l {
+		if c.token != "" {
+			continue
+		}
+		match := exists && normalizeETag(c.etag) == normalizeETag(etag)
+		if match == c.not {
+			return false
+		}
+	}
+	return true
+}
+
+// forwardableIfMatch returns the etag of the If-Match header when it can be
+// forwarded to the gateway, i.e. when it contains exactly one etag.
+func forwardableIfMatch(r *http.Request) (string, bool) {
+	ifMatch := strings.TrimSpace(r.Header.Get(HeaderIfMatch))
+	if ifMatch == "" || ifMatch == "*" || strings.Contains(ifMatch, ",") {
+		return "", false
+	}
+	return ifMatch, true
+}
@@ -0,0 +1,220 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"google.golang.org/grpc"
+)
+
+func TestCheckETagPreconditionsPut(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		exists  bool
+		etag    string
+		want    bool
+	}{
+		{"no conditions", nil, true, `"abc"`, true},
+		{"if-match matching", map[string]string{HeaderIfMatch: `"abc"`}, true, `"abc"`, true},
+		{"if-match matching unquoted", map[string]string{HeaderIfMatch: "abc"}, true, `"abc"`, true},
+		{"if-match matching in list", map[string]string{HeaderIfMatch: `"xyz", "abc"`}, true, `"abc"`, true},
+		{"if-match mismatching", map[string]string{HeaderIfMatch: `"xyz"`}, true, `"abc"`, false},
+		{"if-match on missing resource", map[string]string{HeaderIfMatch: `"abc"`}, false, "", false},
+		{"if-match star on existing resource", map[string]string{HeaderIfMatch: "*"}, true, `"abc"`, true},
+		{"if-none-match star on existing resource", map[string]string{HeaderIfNoneMatch: "*"}, true, `"abc"`, false},
+		{"if-none-match star on missing resource", map[string]string{HeaderIfNoneMatch: "*"}, false, "", true},
+		{"if-none-match matching", map[string]string{HeaderIfNoneMatch: `"abc"`}, true, `"abc"`, false},
+		{"if-none-match mismatching", map[string]string{HeaderIfNoneMatch: `"xyz"`}, true, `"abc"`, true},
+		{"if etag matching", map[string]string{HeaderIf: `(["abc"])`}, true, `"abc"`, true},
+		{"if etag mismatching", map[string]string{HeaderIf: `(["xyz"])`}, true, `"abc"`, false},
+		{"if not etag mismatching", map[string]string{HeaderIf: `(Not ["xyz"])`}, true, `"abc"`, true},
+		{"if lock token and etag matching", map[string]string{HeaderIf: `(<opaquelocktoken:123> ["abc"])`}, true, `"abc"`, true},
+		{"if lock token and etag mismatching", map[string]string{HeaderIf: `(<opaquelocktoken:123> ["xyz"])`}, true, `"abc"`, false},
+		{"if second list matching", map[string]string{HeaderIf: `(["xyz"]) (["abc"])`}, true, `"abc"`, true},
+		{"if tagged list matching", map[string]string{HeaderIf: `<http://example.org/file> (["abc"])`}, true, `"abc"`, true},
+		{"if malformed", map[string]string{HeaderIf: `["abc"`}, true, `"abc"`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/remote.php/webdav/file", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if got := checkETagPreconditions(r, tt.exists, tt.etag); got != tt.want {
+				t.Errorf("checkETagPreconditions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForwardableIfMatch(t *testing.T) {
+	tests := map[string]bool{
+		"*":            false,
+		`"abc"`:        true,
+		`"abc", "xyz"`: false,
+	}
+	for header, forwardable := range tests {
+		r := httptest.NewRequest(http.MethodPut, "/remote.php/webdav/file", nil)
+		r.Header.Set(HeaderIfMatch, header)
+		if _, ok := forwardableIfMatch(r); ok != forwardable {
+			t.Errorf("forwardableIfMatch(%q) = %v, want %v", header, ok, forwardable)
+		}
+	}
+}
+
+// preconditionGatewayClient serves a single space holding the file file.txt
+// and the folder folder, and counts the uploads and the created folders.
+type preconditionGatewayClient struct {
+	gateway.GatewayAPIClient
+	uploads, mkcols int
+}
+
+func (c *preconditionGatewayClient) ListStorageSpaces(_ context.Context, _ *provider.ListStorageSpacesRequest, _ ...grpc.CallOption) (*provider.ListStorageSpacesResponse, error) {
+	return &provider.ListStorageSpacesResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		StorageSpaces: []*provider.StorageSpace{
+			{Root: &provider.ResourceId{StorageId: "provider-1", OpaqueId: "space"}},
+		},
+	}, nil
+}
+
+func (c *preconditionGatewayClient) Stat(_ context.Context, req *provider.StatRequest, _ ...grpc.CallOption) (*provider.StatResponse, error) {
+	var info *provider.ResourceInfo
+	switch req.Ref.Path {
+	case ".", "":
+		info = &provider.ResourceInfo{Type: provider.ResourceType_RESOURCE_TYPE_CONTAINER, Path: "/", Etag: `"root"`}
+	case "./file.txt":
+		info = &provider.ResourceInfo{Type: provider.ResourceType_RESOURCE_TYPE_FILE, Path: "/file.txt", Etag: `"abc"`}
+	case "./folder":
+		info = &provider.ResourceInfo{Type: provider.ResourceType_RESOURCE_TYPE_CONTAINER, Path: "/folder", Etag: `"def"`}
+	default:
+		return &provider.StatResponse{Status: &rpc.Status{Code: rpc.Code_CODE_NOT_FOUND}}, nil
+	}
+	return &provider.StatResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, Info: info}, nil
+}
+
+func (c *preconditionGatewayClient) InitiateFileUpload(_ context.Context, _ *provider.InitiateFileUploadRequest, _ ...grpc.CallOption) (*gateway.InitiateFileUploadResponse, error) {
+	// the upload itself is not needed, only that it has been initiated
+	c.uploads++
+	return &gateway.InitiateFileUploadResponse{Status: &rpc.Status{Code: rpc.Code_CODE_INTERNAL}}, nil
+}
+
+func (c *preconditionGatewayClient) CreateContainer(_ context.Context, _ *provider.CreateContainerRequest, _ ...grpc.CallOption) (*provider.CreateContainerResponse, error) {
+	c.mkcols++
+	return &provider.CreateContainerResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}}, nil
+}
+
+func TestPutPreconditions(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		headers map[string]string
+		failed  bool
+	}{
+		{"if-match matching", "file.txt", map[string]string{HeaderIfMatch: `"abc"`}, false},
+		{"if-match mismatching", "file.txt", map[string]string{HeaderIfMatch: `"xyz"`}, true},
+		{"if-match on a new file", "new.txt", map[string]string{HeaderIfMatch: `"abc"`}, true},
+		{"if-none-match star on an existing file", "file.txt", map[string]string{HeaderIfNoneMatch: "*"}, true},
+		{"if-none-match star on a new file", "new.txt", map[string]string{HeaderIfNoneMatch: "*"}, false},
+		{"if etag mismatching", "file.txt", map[string]string{HeaderIf: `(["xyz"])`}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{}
+			client := &preconditionGatewayClient{}
+			s := &svc{c: c, davHandler: new(DavHandler), gatewayClient: client}
+			if err := s.davHandler.init(c); err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPut, "/remote.php/dav/spaces/space/"+tt.file, strings.NewReader("0123456789"))
+			r.Header.Set(HeaderContentLength, "10")
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, r)
+
+			if tt.failed {
+				if w.Code != http.StatusPreconditionFailed {
+					t.Errorf("expected status %d, got %d", http.StatusPreconditionFailed, w.Code)
+				}
+				if client.uploads != 0 {
+					t.Errorf("expected no upload to be initiated, got %d", client.uploads)
+				}
+				return
+			}
+			if w.Code == http.StatusPreconditionFailed || client.uploads != 1 {
+				t.Errorf("expected the upload to be initiated, got status %d and %d uploads", w.Code, client.uploads)
+			}
+		})
+	}
+}
+
+func TestMkcolPreconditions(t *testing.T) {
+	tests := []struct {
+		name    string
+		folder  string
+		headers map[string]string
+		status  int
+	}{
+		{"no conditions", "new", nil, http.StatusCreated},
+		{"if-none-match star on a new folder", "new", map[string]string{HeaderIfNoneMatch: "*"}, http.StatusCreated},
+		{"if-none-match star on an existing folder", "folder", map[string]string{HeaderIfNoneMatch: "*"}, http.StatusPreconditionFailed},
+		{"if-match star on a new folder", "new", map[string]string{HeaderIfMatch: "*"}, http.StatusPreconditionFailed},
+		{"if etag on a new folder", "new", map[string]string{HeaderIf: `(["def"])`}, http.StatusPreconditionFailed},
+		{"if-match matching an existing folder", "folder", map[string]string{HeaderIfMatch: `"def"`}, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{}
+			client := &preconditionGatewayClient{}
+			s := &svc{c: c, davHandler: new(DavHandler), gatewayClient: client}
+			if err := s.davHandler.init(c); err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(MethodMkcol, "/remote.php/dav/spaces/space/"+tt.folder, nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
+			}
+			if created := tt.status == http.StatusCreated; created != (client.mkcols == 1) {
+				t.Errorf("expected the folder to be created only on success, got %d creations", client.mkcols)
+			}
+		})
+	}
+}
@@ -21,6 +21,7 @@ package ocdav
 import (
 	"bytes"
 	"context"
+	"crypto/sha1"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
@@ -29,10 +30,13 @@ import (
 	"net/url"
 	"path"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
@@ -49,6 +53,7 @@ import (
 	"github.com/cs3org/reva/pkg/utils"
 	"github.com/cs3org/reva/pkg/utils/resourceid"
 	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -126,6 +131,15 @@ func (s *svc) handleSpacesPropfind(w http.ResponseWriter, r *http.Request, space
 		parentInfo.Path = r.URL.Path
 	} else {
 		parentInfo.Path = "/"
+
+		// the quota of the root entry is the one of the space
+		if requestsQuota(&pf) {
+			if client, err := s.getClient(); err != nil {
+				sublog.Error().Err(err).Msg("error getting grpc client")
+			} else if err := setSpaceQuota(ctx, client, ref, parentInfo); err != nil {
+				sublog.Error().Err(err).Msg("error getting the quota of the space")
+			}
+		}
 	}
 
 	// prefix space id to paths
@@ -187,6 +201,15 @@ func (s *svc) propfindResponse(ctx context.Context, w http.ResponseWriter, r *ht
 		log.Error().Err(err).Msg("propfindResponse: couldn't list user shares")
 	}
 
+	etag := propfindETag(r.Header.Get(HeaderDepth), &pf, resourceInfos, usershares, linkshares)
+	if ifNoneMatch := r.Header.Get(HeaderIfNoneMatch); ifNoneMatch != "" && etagInList(ifNoneMatch, etag) {
+		w.Header().Set(HeaderETag, etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	sortChildren(resourceInfos, s.c.ListingOrder, s.c.ListingOrderDescending)
+
 	propRes, err := s.multistatusResponse(ctx, &pf, resourceInfos, namespace, usershares, linkshares)
 	if err != nil {
 		log.Error().Err(err).Msg("error formatting propfind")
@@ -195,6 +218,7 @@ func (s *svc) propfindResponse(ctx context.Context, w http.ResponseWriter, r *ht
 	}
 	w.Header().Set(HeaderDav, "1, 3, extended-mkcol")
 	w.Header().Set(HeaderContentType, "application/xml; charset=utf-8")
+	w.Header().Set(HeaderETag, etag)
 
 	var disableTus bool
 	// let clients know this collection supports tus.io POST requests to start uploads
@@ -215,6 +239,46 @@ func (s *svc) propfindResponse(ctx context.Context, w http.ResponseWriter, r *ht
 	}
 }
 
+// propfindETag computes the etag of a propfind response from the requested
+// depth and properties and from the state of the listed resources: their
+// paths, etags, permissions, locks, arbitrary metadata and whether they are
+// shared with users or by link. Properties not derived from that state, like
+// the quota of a space root, can change without changing the etag, so a
+// client may get a 304 Not Modified with a stale value for them.
+func propfindETag(depth string, pf *propfindXML, infos []*provider.ResourceInfo, usershares, linkshares map[string]struct{}) string {
+	if depth == "" {
+		depth = "1"
+	}
+	marshal := proto.MarshalOptions{Deterministic: true}
+	entries := make([]string, 0, len(infos))
+	for _, info := range infos {
+		id := resourceid.OwnCloudResourceIDWrap(info.Id)
+		_, userShared := usershares[id]
+		_, linkShared := linkshares[id]
+		var e strings.Builder
+		fmt.Fprintf(&e, "%s\x00%s\x00%t\x00%t", info.Path, info.Etag, userShared, linkShared)
+		for _, m := range []proto.Message{info.PermissionSet, info.Lock, info.ArbitraryMetadata} {
+			b, _ := marshal.Marshal(m)
+			fmt.Fprintf(&e, "\x00%x", b)
+		}
+		entries = append(entries, e.String())
+	}
+	sort.Strings(entries)
+
+	h := sha1.New()
+	fmt.Fprintf(h, "%s\x00%t\x00%t\x00", depth, pf.Allprop != nil, pf.Propname != nil)
+	for _, props := range []propfindProps{pf.Prop, pf.Include} {
+		for _, p := range props {
+			fmt.Fprintf(h, "%s %s\x00", p.Space, p.Local)
+		}
+		h.Write([]byte{0})
+	}
+	for _, e := range entries {
+		fmt.Fprintf(h, "%s\x00", e)
+	}
+	return fmt.Sprintf(`"%x"`, h.Sum(nil))
+}
+
 func (s *svc) getResourceInfos(ctx context.Context, w http.ResponseWriter, r *http.Request, pf propfindXML, ref *provider.Reference, spacesPropfind bool, log zerolog.Logger) (*provider.ResourceInfo, []*provider.ResourceInfo, bool) {
 	depth := r.Header.Get(HeaderDepth)
 	if depth == "" {
@@ -268,14 +332,7 @@ func (s *svc) getResourceInfos(ctx context.Context, w http.ResponseWriter, r *ht
 		return nil, nil, false
 	} else if res.Status.Code != rpc.Code_CODE_OK {
 		if res.Status.Code == rpc.Code_CODE_NOT_FOUND {
-			w.WriteHeader(http.StatusNotFound)
-			m := fmt.Sprintf("Resource %v not found", ref.Path)
-			b, err := Marshal(exception{
-				code:    SabredavNotFound,
-				message: m,
-			})
-			HandleWebdavError(&log, w, b, err)
-			return nil, nil, false
+			res.Status.Message = fmt.Sprintf("Resource %v not found", ref.Path)
 		}
 		HandleErrorStatus(&log, w, res.Status)
 		return nil, nil, false
@@ -308,14 +365,7 @@ func (s *svc) getResourceInfos(ctx context.Context, w http.ResponseWriter, r *ht
 			return nil, nil, false
 		} else if parentRes.Status.Code != rpc.Code_CODE_OK {
 			if parentRes.Status.Code == rpc.Code_CODE_NOT_FOUND {
-				w.WriteHeader(http.StatusNotFound)
-				m := fmt.Sprintf("Resource %v not found", parentPath)
-				b, err := Marshal(exception{
-					code:    SabredavNotFound,
-					message: m,
-				})
-				HandleWebdavError(&log, w, b, err)
-				return nil, nil, false
+				parentRes.Status.Message = fmt.Sprintf("Resource %v not found", parentPath)
 			}
 			HandleErrorStatus(&log, w, parentRes.Status)
 			return nil, nil, false
@@ -323,22 +373,18 @@ func (s *svc) getResourceInfos(ctx context.Context, w http.ResponseWriter, r *ht
 		parentInfo = parentRes.Info
 
 	case parentInfo.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER && depth == "1":
-		req := &provider.ListContainerRequest{
-			Ref:                   ref,
-			ArbitraryMetadataKeys: metadataKeys,
-		}
-		res, err := client.ListContainer(ctx, req)
+		infos, status, err := s.listContainerWithMetadata(ctx, client, ref, metadataKeys, spacesPropfind)
 		if err != nil {
 			log.Error().Err(err).Msg("error sending list container grpc request")
 			w.WriteHeader(http.StatusInternalServerError)
 			return nil, nil, false
 		}
 
-		if res.Status.Code != rpc.Code_CODE_OK {
-			HandleErrorStatus(&log, w, res.Status)
+		if status.Code != rpc.Code_CODE_OK {
+			HandleErrorStatus(&log, w, status)
 			return nil, nil, false
 		}
-		resourceInfos = append(resourceInfos, res.Infos...)
+		resourceInfos = append(resourceInfos, infos...)
 
 	case depth == "infinity":
 		// FIXME: doesn't work cross-storage as the results will have the wrong paths!
@@ -398,6 +444,69 @@ func (s *svc) getResourceInfos(ctx context.Context, w http.ResponseWriter, r *ht
 	return parentInfo, resourceInfos, true
 }
 
+// listContainerWithMetadata lists the children of the container in a single
+// ListContainer call, asking for the metadata keys needed by the propfind.
+// Only the children the storage provider returned incomplete are stat'ed
+// individually.
+func (s *svc) listContainerWithMetadata(ctx context.Context, client gateway.GatewayAPIClient, ref *provider.Reference, metadataKeys []string, spacesPropfind bool) ([]*provider.ResourceInfo, *rpc.Status, error) {
+	res, err := client.ListContainer(ctx, &provider.ListContainerRequest{
+		Ref:                   ref,
+		ArbitraryMetadataKeys: metadataKeys,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, res.Status, nil
+	}
+
+	infos := make([]*provider.ResourceInfo, 0, len(res.Infos))
+	for _, info := range res.Infos {
+		if !isIncompleteInfo(info) {
+			infos = append(infos, info)
+			continue
+		}
+		var childRef *provider.Reference
+		switch {
+		case info.Id != nil:
+			childRef = &provider.Reference{ResourceId: info.Id}
+		case spacesPropfind:
+			childRef = &provider.Reference{
+				ResourceId: ref.ResourceId,
+				Path:       utils.MakeRelativePath(path.Join(ref.Path, path.Base(info.Path))),
+			}
+		default:
+			childRef = &provider.Reference{Path: info.Path}
+		}
+		statRes, err := client.Stat(ctx, &provider.StatRequest{
+			Ref:                   childRef,
+			ArbitraryMetadataKeys: metadataKeys,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		switch statRes.Status.Code {
+		case rpc.Code_CODE_OK:
+			// keep the path as returned by the listing, the stat may have
+			// been done by id
+			statRes.Info.Path = info.Path
+			infos = append(infos, statRes.Info)
+		case rpc.Code_CODE_NOT_FOUND:
+			// the child has been removed since the listing
+		default:
+			// render the child with what the listing returned
+			infos = append(infos, info)
+		}
+	}
+	return infos, res.Status, nil
+}
+
+// isIncompleteInfo reports whether a resource info returned by a listing
+// lacks the fields needed to render it in a propfind response.
+func isIncompleteInfo(info *provider.ResourceInfo) bool {
+	return info.Id == nil || info.Etag == ""
+}
+
 func requiresExplicitFetching(n *xml.Name) bool {
 	switch n.Space {
 	case _nsDav:
@@ -422,6 +531,54 @@ func requiresExplicitFetching(n *xml.Name) bool {
 	return true
 }
 
+// listingOrders are the comparison functions of the supported listing orders.
+var listingOrders = map[string]func(a, b *provider.ResourceInfo) int{
+	"": nil,
+	"name": func(a, b *provider.ResourceInfo) int {
+		return strings.Compare(strings.ToLower(path.Base(a.Path)), strings.ToLower(path.Base(b.Path)))
+	},
+	"size": func(a, b *provider.ResourceInfo) int {
+		switch {
+		case a.Size < b.Size:
+			return -1
+		case a.Size > b.Size:
+			return 1
+		}
+		return 0
+	},
+	"mtime": func(a, b *provider.ResourceInfo) int {
+		ta := time.Unix(int64(a.GetMtime().GetSeconds()), int64(a.GetMtime().GetNanos()))
+		tb := time.Unix(int64(b.GetMtime().GetSeconds()), int64(b.GetMtime().GetNanos()))
+		return ta.Compare(tb)
+	},
+}
+
+// sortChildren sorts the children of a PROPFIND response in the given order.
+// The first info is the requested resource itself and stays first.
+func sortChildren(infos []*provider.ResourceInfo, order string, descending bool) {
+	cmp := listingOrders[order]
+	if cmp == nil || len(infos) < 3 {
+		return
+	}
+	children := infos[1:]
+	sort.SliceStable(children, func(i, j int) bool {
+		if descending {
+			return cmp(children[i], children[j]) > 0
+		}
+		return cmp(children[i], children[j]) < 0
+	})
+}
+
+// requestsQuota tells if the quota properties are requested.
+func requestsQuota(pf *propfindXML) bool {
+	for i := range pf.Prop {
+		if pf.Prop[i].Space == _nsDav && (pf.Prop[i].Local == "quota-available-bytes" || pf.Prop[i].Local == "quota-used-bytes") {
+			return true
+		}
+	}
+	return false
+}
+
 // from https://github.com/golang/net/blob/e514e69ffb8bc3c76a71ae40de0118d794855992/webdav/xml.go#L178-L205
 func readPropfind(r io.Reader) (pf propfindXML, status int, err error) {
 	c := countingReader{r: r}
@@ -557,6 +714,7 @@ func (s *svc) mdToPropResponse(ctx context.Context, pf *propfindXML, md *provide
 	// -3 indicates unlimited
 	quota := _propQuotaUnknown
 	size := fmt.Sprintf("%d", md.Size)
+	used := size
 	// TODO refactor helper functions: GetOpaqueJSONEncoded(opaque, key string, *struct) err, GetOpaquePlainEncoded(opaque, key) value, err
 	// or use ok like pattern and return bool?
 	if md.Opaque != nil && md.Opaque.Map != nil {
@@ -570,6 +728,9 @@ func (s *svc) mdToPropResponse(ctx context.Context, pf *propfindXML, md *provide
 		if md.Opaque.Map["quota"] != nil && md.Opaque.Map["quota"].Decoder == "plain" {
 			quota = string(md.Opaque.Map["quota"].Value)
 		}
+		if md.Opaque.Map["quota-used"] != nil && md.Opaque.Map["quota-used"].Decoder == "plain" {
+			used = string(md.Opaque.Map["quota-used"].Value)
+		}
 	}
 
 	role := conversions.RoleFromResourcePermissions(md.PermissionSet)
@@ -982,7 +1143,7 @@ func (s *svc) mdToPropResponse(ctx context.Context, pf *propfindXML, md *provide
 						// always returns the current usage,
 						// in oc10 there seems to be a bug that makes the size in webdav differ from the one in the user properties, not taking shares into account
 						// in ocis we plan to always mak the quota a property of the storage space
-						propstatOK.Prop = append(propstatOK.Prop, s.newProp("d:quota-used-bytes", size))
+						propstatOK.Prop = append(propstatOK.Prop, s.newProp("d:quota-used-bytes", used))
 					} else {
 						propstatNotFound.Prop = append(propstatNotFound.Prop, s.newProp("d:quota-used-bytes", ""))
 					}
@@ -1033,6 +1194,8 @@ func (s *svc) mdToPropResponse(ctx context.Context, pf *propfindXML, md *provide
 		}
 	}
 
+	propstatOK.Prop = s.omitProps(propstatOK.Prop)
+	propstatNotFound.Prop = s.omitProps(propstatNotFound.Prop)
 	if len(propstatOK.Prop) > 0 {
 		response.Propstat = append(response.Propstat, propstatOK)
 	}
@@ -1043,6 +1206,36 @@ func (s *svc) mdToPropResponse(ctx context.Context, pf *propfindXML, md *provide
 	return &response, nil
 }
 
+// propName returns the prefixed name of a property, e.g. oc:permissions.
+func propName(n xml.Name) string {
+	switch n.Space {
+	case "":
+		// the properties built with newProp carry the prefix in their local name
+		return n.Local
+	case _nsDav:
+		return "d:" + n.Local
+	case _nsOwncloud:
+		return "oc:" + n.Local
+	case _nsOCS:
+		return "ocs:" + n.Local
+	}
+	return n.Space + n.Local
+}
+
+// omitProps drops the properties configured in omitted_properties.
+func (s *svc) omitProps(props []*propertyXML) []*propertyXML {
+	if s.c == nil || len(s.c.OmittedProperties) == 0 {
+		return props
+	}
+	kept := props[:0]
+	for _, p := range props {
+		if !slices.Contains(s.c.OmittedProperties, propName(p.XMLName)) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
 // be defensive about wrong encoded etags.
 func quoteEtag(etag string) string {
 	if strings.HasPrefix(etag, "W/") {
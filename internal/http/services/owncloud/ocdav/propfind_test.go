@@ -0,0 +1,266 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// countingGateway serves a space with a single directory holding a fixed
+// set of files and counts the stat and list calls it receives.
+type countingGateway struct {
+	gateway.UnimplementedGatewayAPIServer
+	children      []*provider.ResourceInfo
+	shared        []*provider.ResourceId
+	statCodes     map[string]rpc.Code
+	stats, listed atomic.Int32
+}
+
+func (g *countingGateway) ListShares(context.Context, *collaboration.ListSharesRequest) (*collaboration.ListSharesResponse, error) {
+	res := &collaboration.ListSharesResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}}
+	for _, id := range g.shared {
+		res.Shares = append(res.Shares, &collaboration.Share{ResourceId: id})
+	}
+	return res, nil
+}
+
+func (g *countingGateway) ListStorageSpaces(_ context.Context, req *provider.ListStorageSpacesRequest) (*provider.ListStorageSpacesResponse, error) {
+	for _, f := range req.Filters {
+		if f.GetId().GetOpaqueId() != "space" {
+			return &provider.ListStorageSpacesResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}}, nil
+		}
+	}
+	return &provider.ListStorageSpacesResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		StorageSpaces: []*provider.StorageSpace{{
+			Id:   &provider.StorageSpaceId{OpaqueId: "space"},
+			Root: &provider.ResourceId{StorageId: "storage", OpaqueId: "root"},
+		}},
+	}, nil
+}
+
+func (g *countingGateway) Stat(_ context.Context, req *provider.StatRequest) (*provider.StatResponse, error) {
+	g.stats.Add(1)
+	if code, ok := g.statCodes[req.Ref.GetResourceId().GetOpaqueId()]; ok {
+		return &provider.StatResponse{Status: &rpc.Status{Code: code}}, nil
+	}
+	if req.Ref.ResourceId != nil && req.Ref.ResourceId.OpaqueId != "root" {
+		for _, c := range g.children {
+			if c.Id.OpaqueId == req.Ref.ResourceId.OpaqueId {
+				info := proto.Clone(c).(*provider.ResourceInfo)
+				info.Etag = "stat-" + c.Id.OpaqueId
+				return &provider.StatResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, Info: info}, nil
+			}
+		}
+	}
+	return &provider.StatResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		Info: &provider.ResourceInfo{
+			Id:   &provider.ResourceId{StorageId: "storage", OpaqueId: "root"},
+			Type: provider.ResourceType_RESOURCE_TYPE_CONTAINER,
+			Path: ".",
+			Etag: "root",
+		},
+	}, nil
+}
+
+func (g *countingGateway) ListContainer(context.Context, *provider.ListContainerRequest) (*provider.ListContainerResponse, error) {
+	g.listed.Add(1)
+	return &provider.ListContainerResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		Infos:  g.children,
+	}, nil
+}
+
+func newCountingGateway(t *testing.T, n int) (*countingGateway, *svc) {
+	gw := &countingGateway{}
+	for i := 0; i < n; i++ {
+		gw.children = append(gw.children, &provider.ResourceInfo{
+			Id:   &provider.ResourceId{StorageId: "storage", OpaqueId: fmt.Sprintf("file-%d", i)},
+			Type: provider.ResourceType_RESOURCE_TYPE_FILE,
+			Path: fmt.Sprintf("file-%d", i),
+			Etag: fmt.Sprintf("etag-%d", i),
+		})
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	gateway.RegisterGatewayAPIServer(srv, gw)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	c := &Config{GatewaySvc: lis.Addr().String()}
+	client, err := pool.GetGatewayServiceClient(pool.Endpoint(c.GatewaySvc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &svc{c: c, davHandler: new(DavHandler), gatewayClient: client}
+	if err := s.davHandler.init(c); err != nil {
+		t.Fatal(err)
+	}
+	return gw, s
+}
+
+func propfindSpace(t *testing.T, s *svc) string {
+	r := httptest.NewRequest(MethodPropfind, "/remote.php/dav/spaces/space", nil)
+	r.Header.Set(HeaderDepth, "1")
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, r)
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusMultiStatus, w.Code, w.Body.String())
+	}
+	return w.Body.String()
+}
+
+func TestPropfindListsChildrenInOneCall(t *testing.T) {
+	const n = 10
+	gw, s := newCountingGateway(t, n)
+
+	body := propfindSpace(t, s)
+
+	if got := gw.listed.Load(); got != 1 {
+		t.Errorf("expected 1 list call, got %d", got)
+	}
+	// only the requested resource itself is stat'ed
+	if got := gw.stats.Load(); got != 1 {
+		t.Errorf("expected 1 stat call, got %d", got)
+	}
+	for i := 0; i < n; i++ {
+		if !strings.Contains(body, fmt.Sprintf("file-%d", i)) {
+			t.Errorf("expected file-%d in the response", i)
+		}
+	}
+}
+
+func TestPropfindStatsIncompleteChildren(t *testing.T) {
+	gw, s := newCountingGateway(t, 3)
+	gw.children[1].Etag = ""
+
+	body := propfindSpace(t, s)
+
+	if got := gw.listed.Load(); got != 1 {
+		t.Errorf("expected 1 list call, got %d", got)
+	}
+	if got := gw.stats.Load(); got != 2 {
+		t.Errorf("expected 2 stat calls, got %d", got)
+	}
+	if !strings.Contains(body, "stat-file-1") {
+		t.Errorf("expected the etag of the stat'ed child in the response, got %s", body)
+	}
+}
+
+func TestPropfindIncompleteChildrenStatFailures(t *testing.T) {
+	gw, s := newCountingGateway(t, 3)
+	gw.children[0].Etag = ""
+	gw.children[1].Etag = ""
+	gw.statCodes = map[string]rpc.Code{
+		"file-0": rpc.Code_CODE_NOT_FOUND,
+		"file-1": rpc.Code_CODE_INTERNAL,
+	}
+
+	body := propfindSpace(t, s)
+
+	// a child removed since the listing is skipped
+	if strings.Contains(body, "file-0") {
+		t.Errorf("expected the removed child not to be in the response, got %s", body)
+	}
+	// a child that cannot be stat'ed is rendered from the listing
+	if !strings.Contains(body, "file-1") || !strings.Contains(body, "file-2") {
+		t.Errorf("expected the other children in the response, got %s", body)
+	}
+}
+
+func TestPropfindIfNoneMatch(t *testing.T) {
+	gw, s := newCountingGateway(t, 3)
+
+	propfind := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(MethodPropfind, "/remote.php/dav/spaces/space", nil)
+		r.Header.Set(HeaderDepth, "1")
+		if ifNoneMatch != "" {
+			r.Header.Set(HeaderIfNoneMatch, ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, r)
+		return w
+	}
+
+	w := propfind("")
+	etag := w.Header().Get(HeaderETag)
+	if w.Code != http.StatusMultiStatus || etag == "" {
+		t.Fatalf("expected a multistatus with an etag, got %d and %q", w.Code, etag)
+	}
+
+	w = propfind(etag)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("expected status %d, got %d", http.StatusNotModified, w.Code)
+	}
+	if w.Body.Len() != 0 || w.Header().Get(HeaderETag) != etag {
+		t.Errorf("expected an empty body and the etag %s, got %q and %s", etag, w.Body.String(), w.Header().Get(HeaderETag))
+	}
+
+	// a changed child changes the etag of the listing
+	gw.children[2].Etag = "changed"
+	w = propfind(etag)
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("expected status %d after a change, got %d", http.StatusMultiStatus, w.Code)
+	}
+	if w.Header().Get(HeaderETag) == etag {
+		t.Errorf("expected the etag to change")
+	}
+
+	// so does a child being shared, which does not change its etag
+	etag = w.Header().Get(HeaderETag)
+	gw.shared = append(gw.shared, gw.children[0].Id)
+	w = propfind(etag)
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("expected status %d after a share, got %d", http.StatusMultiStatus, w.Code)
+	}
+	if w.Header().Get(HeaderETag) == etag {
+		t.Errorf("expected the etag to change after a share")
+	}
+
+	// and a child being locked
+	etag = w.Header().Get(HeaderETag)
+	gw.children[1].Lock = &provider.Lock{LockId: "lock", Type: provider.LockType_LOCK_TYPE_EXCL}
+	w = propfind(etag)
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("expected status %d after a lock, got %d", http.StatusMultiStatus, w.Code)
+	}
+	if w.Header().Get(HeaderETag) == etag {
+		t.Errorf("expected the etag to change after a lock")
+	}
+}
@@ -73,13 +73,7 @@ func (s *svc) handlePathProppatch(w http.ResponseWriter, r *http.Request, ns str
 
 	if statRes.Status.Code != rpc.Code_CODE_OK {
 		if statRes.Status.Code == rpc.Code_CODE_NOT_FOUND {
-			w.WriteHeader(http.StatusNotFound)
-			m := fmt.Sprintf("Resource %v not found", fn)
-			b, err := Marshal(exception{
-				code:    SabredavNotFound,
-				message: m,
-			})
-			HandleWebdavError(&sublog, w, b, err)
+			statRes.Status.Message = fmt.Sprintf("Resource %v not found", fn)
 		}
 		HandleErrorStatus(&sublog, w, statRes.Status)
 		return
@@ -216,14 +210,7 @@ func (s *svc) handleProppatch(ctx context.Context, w http.ResponseWriter, r *htt
 
 				if res.Status.Code != rpc.Code_CODE_OK {
 					if res.Status.Code == rpc.Code_CODE_PERMISSION_DENIED {
-						w.WriteHeader(http.StatusForbidden)
-						m := fmt.Sprintf("Permission denied to remove properties on resource %v", ref.Path)
-						b, err := Marshal(exception{
-							code:    SabredavPermissionDenied,
-							message: m,
-						})
-						HandleWebdavError(&log, w, b, err)
-						return nil, nil, false
+						res.Status.Message = fmt.Sprintf("Permission denied to remove properties on resource %v", ref.Path)
 					}
 					HandleErrorStatus(&log, w, res.Status)
 					return nil, nil, false
@@ -253,14 +240,7 @@ func (s *svc) handleProppatch(ctx context.Context, w http.ResponseWriter, r *htt
 
 				if res.Status.Code != rpc.Code_CODE_OK {
 					if res.Status.Code == rpc.Code_CODE_PERMISSION_DENIED {
-						w.WriteHeader(http.StatusForbidden)
-						m := fmt.Sprintf("Permission denied to set properties on resource %v", ref.Path)
-						b, err := Marshal(exception{
-							code:    SabredavPermissionDenied,
-							message: m,
-						})
-						HandleWebdavError(&log, w, b, err)
-						return nil, nil, false
+						res.Status.Message = fmt.Sprintf("Permission denied to set properties on resource %v", ref.Path)
 					}
 					HandleErrorStatus(&log, w, res.Status)
 					return nil, nil, false
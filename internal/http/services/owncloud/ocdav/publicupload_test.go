@@ -0,0 +1,99 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"google.golang.org/grpc"
+)
+
+// publicLinkGateway authenticates any public link token, counting the
+// authentications. The other calls are not implemented.
+type publicLinkGateway struct {
+	gateway.UnimplementedGatewayAPIServer
+	authenticated atomic.Int32
+}
+
+func (g *publicLinkGateway) Authenticate(context.Context, *gateway.AuthenticateRequest) (*gateway.AuthenticateResponse, error) {
+	g.authenticated.Add(1)
+	return &gateway.AuthenticateResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		Token:  "token",
+		User:   &userpb.User{Id: &userpb.UserId{OpaqueId: "public"}},
+	}, nil
+}
+
+func TestDisablePublicUploads(t *testing.T) {
+	for _, disabled := range []bool{true, false} {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		gw := &publicLinkGateway{}
+		srv := grpc.NewServer()
+		gateway.RegisterGatewayAPIServer(srv, gw)
+		go func() { _ = srv.Serve(lis) }()
+		t.Cleanup(srv.Stop)
+
+		c := &Config{GatewaySvc: lis.Addr().String(), DisablePublicUploads: disabled}
+		client, err := pool.GetGatewayServiceClient(pool.Endpoint(c.GatewaySvc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := &svc{c: c, davHandler: new(DavHandler), gatewayClient: client}
+		if err := s.davHandler.init(c); err != nil {
+			t.Fatal(err)
+		}
+
+		r := httptest.NewRequest(http.MethodPut, "/remote.php/dav/public-files/token/file.txt", strings.NewReader("data"))
+		r.SetBasicAuth("public", "password")
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, r)
+
+		if disabled {
+			if w.Code != http.StatusForbidden {
+				t.Errorf("expected status %d with uploads disabled, got %d", http.StatusForbidden, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), "Sabre\\DAV\\Exception\\PermissionDenied") {
+				t.Errorf("expected a sabredav exception, got %s", w.Body.String())
+			}
+			if n := gw.authenticated.Load(); n != 0 {
+				t.Errorf("expected the upload to be rejected before authenticating the link, got %d authentications", n)
+			}
+			continue
+		}
+		if w.Code == http.StatusForbidden {
+			t.Errorf("expected the upload not to be forbidden with uploads enabled")
+		}
+		if n := gw.authenticated.Load(); n != 1 {
+			t.Errorf("expected the public link to be authenticated, got %d authentications", n)
+		}
+	}
+}
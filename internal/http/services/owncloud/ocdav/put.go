@@ -19,8 +19,11 @@
 package ocdav
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"path"
 	"path/filepath"
@@ -35,6 +38,7 @@ import (
 	"github.com/cs3org/reva/internal/http/services/datagateway"
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/mime"
 	"github.com/cs3org/reva/pkg/notification/trigger"
 	"github.com/cs3org/reva/pkg/storage/utils/chunking"
 	"github.com/cs3org/reva/pkg/user"
@@ -112,6 +116,12 @@ func isContentRange(r *http.Request) bool {
 func (s *svc) handlePathPut(w http.ResponseWriter, r *http.Request, ns string) {
 	ctx := r.Context()
 	fn := path.Join(ns, r.URL.Path)
+	for _, r := range s.nameRules(endpointWebDAV) {
+		if !r.Test(fn) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
 
 	sublog := appctx.GetLogger(ctx).With().Str("path", fn).Logger()
 
@@ -131,6 +141,9 @@ func (s *svc) handlePut(ctx context.Context, w http.ResponseWriter, r *http.Requ
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	if !s.checkUploadSize(w, r, length, log) {
+		return
+	}
 
 	client, err := s.getClient()
 	if err != nil {
@@ -152,21 +165,15 @@ func (s *svc) handlePut(ctx context.Context, w http.ResponseWriter, r *http.Requ
 	}
 
 	info := sRes.Info
-	if info != nil {
-		if info.Type != provider.ResourceType_RESOURCE_TYPE_FILE {
-			log.Debug().Msg("resource is not a file")
-			w.WriteHeader(http.StatusConflict)
-			return
-		}
-		clientETag := r.Header.Get(HeaderIfMatch)
-		serverETag := info.Etag
-		if clientETag != "" {
-			if clientETag != serverETag {
-				log.Debug().Str("client-etag", clientETag).Str("server-etag", serverETag).Msg("etags mismatch")
-				w.WriteHeader(http.StatusPreconditionFailed)
-				return
-			}
-		}
+	if info != nil && info.Type != provider.ResourceType_RESOURCE_TYPE_FILE {
+		log.Debug().Msg("resource is not a file")
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	if !checkETagPreconditions(r, info != nil, info.GetEtag()) {
+		log.Debug().Str("server-etag", info.GetEtag()).Msg("etag precondition failed")
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
 	}
 
 	opaqueMap := map[string]*typespb.OpaqueEntry{
@@ -216,11 +223,34 @@ func (s *svc) handlePut(ctx context.Context, w http.ResponseWriter, r *http.Requ
 		}
 	}
 
+	var body io.Reader = r.Body
+	contentType := r.Header.Get(HeaderContentType)
+	if contentType == "" {
+		contentType, body = s.detectContentType(ref.Path, r.Body)
+	}
+	if contentType != "" {
+		opaqueMap[HeaderContentType] = &typespb.OpaqueEntry{
+			Decoder: "plain",
+			Value:   []byte(contentType),
+		}
+	}
+
 	uReq := &provider.InitiateFileUploadRequest{
 		Ref:    ref,
 		Opaque: &typespb.Opaque{Map: opaqueMap},
 	}
 
+	// forward the etag conditions, so that the storage can enforce them atomically
+	if etag, ok := forwardableIfMatch(r); ok {
+		uReq.Options = &provider.InitiateFileUploadRequest_IfMatch{
+			IfMatch: etag,
+		}
+	} else if strings.TrimSpace(r.Header.Get(HeaderIfNoneMatch)) == "*" {
+		uReq.Options = &provider.InitiateFileUploadRequest_IfNotExist{
+			IfNotExist: true,
+		}
+	}
+
 	if userInCtxHasUploaderRole(ctx) {
 		ref.Path, err = randomizePath(ref.Path)
 		if err != nil {
@@ -244,12 +274,8 @@ func (s *svc) handlePut(ctx context.Context, w http.ResponseWriter, r *http.Requ
 	if uRes.Status.Code != rpc.Code_CODE_OK {
 		switch uRes.Status.Code {
 		case rpc.Code_CODE_PERMISSION_DENIED:
-			w.WriteHeader(http.StatusForbidden)
-			b, err := Marshal(exception{
-				code:    SabredavPermissionDenied,
-				message: "permission denied: you have no permission to upload content",
-			})
-			HandleWebdavError(&log, w, b, err)
+			uRes.Status.Message = "permission denied: you have no permission to upload content"
+			HandleErrorStatus(&log, w, uRes.Status)
 		case rpc.Code_CODE_NOT_FOUND:
 			w.WriteHeader(http.StatusConflict)
 		default:
@@ -265,18 +291,24 @@ func (s *svc) handlePut(ctx context.Context, w http.ResponseWriter, r *http.Requ
 		}
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPut, ep, r.Body)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPut, ep, body)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 	httpReq.Header.Set(datagateway.TokenTransportHeader, token)
+	if contentType != "" {
+		httpReq.Header.Set(HeaderContentType, contentType)
+	}
 	if lockid := r.Header.Get(HeaderLockID); lockid != "" {
 		httpReq.Header.Set(HeaderLockID, lockid)
 	}
 	if lockholder := r.Header.Get(HeaderLockHolder); lockholder != "" {
 		httpReq.Header.Set(HeaderLockHolder, lockholder)
 	}
+	if totalLength := r.Header.Get(HeaderOCTotalLength); totalLength != "" {
+		httpReq.Header.Set(HeaderOCTotalLength, totalLength)
+	}
 
 	httpRes, err := s.client.Do(httpReq)
 	if err != nil {
@@ -341,7 +373,12 @@ func (s *svc) handlePut(ctx context.Context, w http.ResponseWriter, r *http.Requ
 
 	newInfo := sRes.Info
 
-	w.Header().Add(HeaderContentType, newInfo.MimeType)
+	mimeType := newInfo.MimeType
+	if (mimeType == "" || mimeType == defaultContentType) && contentType != "" {
+		// the storage does not know the type of the file
+		mimeType = contentType
+	}
+	w.Header().Add(HeaderContentType, mimeType)
 	w.Header().Set(HeaderETag, newInfo.Etag)
 	w.Header().Set(HeaderOCFileID, resourceid.OwnCloudResourceIDWrap(newInfo.Id))
 	w.Header().Set(HeaderOCETag, newInfo.Etag)
@@ -438,6 +475,12 @@ func split(p string) (string, string) {
 
 func (s *svc) handleSpacesPut(w http.ResponseWriter, r *http.Request, spaceID string) {
 	ctx := r.Context()
+	for _, rule := range s.nameRules(endpointSpaces) {
+		if !rule.Test(r.URL.Path) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
 	sublog := appctx.GetLogger(ctx).With().Str("spaceid", spaceID).Str("path", r.URL.Path).Logger()
 
 	spaceRef, status, err := s.lookUpStorageSpaceReference(ctx, spaceID, r.URL.Path)
@@ -473,6 +516,66 @@ func checkPreconditions(w http.ResponseWriter, r *http.Request, log zerolog.Logg
 	return true
 }
 
+// checkUploadSize rejects with a 413 Request Entity Too Large an upload of length
+// bytes exceeding the configured maximum. The Upload-Length, the size of the
+// whole file in chunked uploads, is checked as well when it is larger.
+func (s *svc) checkUploadSize(w http.ResponseWriter, r *http.Request, length int64, log zerolog.Logger) bool {
+	if s.c.MaxUploadSize <= 0 {
+		return true
+	}
+	if l, err := strconv.ParseInt(r.Header.Get(HeaderUploadLength), 10, 64); err == nil && l > length {
+		length = l
+	}
+	if length <= s.c.MaxUploadSize {
+		return true
+	}
+
+	log.Debug().Int64("length", length).Int64("max", s.c.MaxUploadSize).Msg("upload too large")
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+	b, err := Marshal(exception{
+		code:    SabredavEntityTooLarge,
+		message: fmt.Sprintf("The upload of %d bytes exceeds the maximum size of %d bytes", length, s.c.MaxUploadSize),
+	})
+	HandleWebdavError(&log, w, b, err)
+	return false
+}
+
+// The detections of the type of the uploads without a Content-Type.
+const (
+	contentTypeByExtension = "extension"
+	contentTypeBySniffing  = "sniff"
+	contentTypeNone        = "none"
+)
+
+const defaultContentType = "application/octet-stream"
+
+// detectContentType returns the type of an upload to p without a Content-Type,
+// as configured in upload_content_type, and the body to upload. The content is
+// sniffed from the first bytes of the body, the extension of p is used when
+// the sniffing does not find a specific type.
+func (s *svc) detectContentType(p string, body io.Reader) (string, io.Reader) {
+	if s.c.UploadContentType == contentTypeNone {
+		return "", body
+	}
+
+	if ok, _ := chunking.IsChunked(p); ok {
+		if chunk, err := chunking.GetChunkBLOBInfo(p); err == nil {
+			p = chunk.Path
+		}
+	}
+	byExtension := mime.Detect(false, p)
+
+	if s.c.UploadContentType != contentTypeBySniffing {
+		return byExtension, body
+	}
+	br := bufio.NewReaderSize(body, 512)
+	head, _ := br.Peek(512)
+	if t := http.DetectContentType(head); !strings.HasPrefix(t, defaultContentType) && !strings.HasPrefix(t, "text/plain") {
+		return t, br
+	}
+	return byExtension, br
+}
+
 func getContentLength(w http.ResponseWriter, r *http.Request) (int64, error) {
 	length, err := strconv.ParseInt(r.Header.Get(HeaderContentLength), 10, 64)
 	if err != nil {
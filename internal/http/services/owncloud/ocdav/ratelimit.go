@@ -0,0 +1,136 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"container/list"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	netutil "github.com/cs3org/reva/pkg/utils/net"
+)
+
+// maxRateLimitBuckets is the number of tracked clients, above which
+// the least recently used bucket is evicted.
+const maxRateLimitBuckets = 10000
+
+// bucket is the token bucket of a single client.
+type bucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is a token bucket rate limiter keyed by client IP and, optionally,
+// by public link token.
+// The buckets are kept in a bounded LRU list.
+type rateLimiter struct {
+	rate    float64
+	burst   float64
+	byToken bool
+	proxies netutil.TrustedProxies
+	size    int
+
+	mu      sync.Mutex
+	buckets map[string]*list.Element
+	lru     *list.List
+	now     func() time.Time
+}
+
+func newRateLimiter(c *ConfigPublicFilesRateLimit, proxies netutil.TrustedProxies) *rateLimiter {
+	if c == nil || c.RequestsPerSecond <= 0 {
+		return nil
+	}
+	burst := c.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:    c.RequestsPerSecond,
+		burst:   float64(burst),
+		byToken: c.Key == "token",
+		proxies: proxies,
+		size:    maxRateLimitBuckets,
+		buckets: make(map[string]*list.Element),
+		lru:     list.New(),
+		now:     time.Now,
+	}
+}
+
+// allow consumes a token from the bucket of the given key. When the bucket
+// is empty it returns false and the time after which a token is available.
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var b *bucket
+	if e, ok := l.buckets[key]; ok {
+		l.lru.MoveToFront(e)
+		b = e.Value.(*bucket)
+	} else {
+		if l.lru.Len() >= l.size {
+			oldest := l.lru.Back()
+			l.lru.Remove(oldest)
+			delete(l.buckets, oldest.Value.(*bucket).key)
+		}
+		b = &bucket{key: key, tokens: l.burst, last: now}
+		l.buckets[key] = l.lru.PushFront(b)
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// clientKey returns the bucket key of the client of the request. It is
+// checked before authenticating the request, so that a flood of made up
+// tokens or passwords does not reach the gateway.
+func (l *rateLimiter) clientKey(r *http.Request) string {
+	return "ip:" + l.proxies.ClientIP(r)
+}
+
+// tokenKey returns the bucket key of a resolved public link token, checked
+// on top of the client one when limiting by token.
+func tokenKey(token string) string {
+	return "token:" + token
+}
+
+// allowRequest checks the request against the bucket of the given key and
+// writes a 429 Too Many Requests response when the limit is exceeded.
+func (l *rateLimiter) allowRequest(w http.ResponseWriter, r *http.Request, key string) bool {
+	log := appctx.GetLogger(r.Context())
+
+	ok, wait := l.allow(key)
+	if !ok {
+		log.Debug().Str("key", key).Dur("retry_after", wait).Msg("public files rate limit exceeded")
+		w.Header().Set(HeaderRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+	return ok
+}
@@ -0,0 +1,208 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	netutil "github.com/cs3org/reva/pkg/utils/net"
+	"google.golang.org/grpc"
+)
+
+func TestNewRateLimiterDisabled(t *testing.T) {
+	if l := newRateLimiter(nil, nil); l != nil {
+		t.Error("expected the rate limiter to be disabled without config")
+	}
+	if l := newRateLimiter(&ConfigPublicFilesRateLimit{Burst: 10}, nil); l != nil {
+		t.Error("expected the rate limiter to be disabled without a rate")
+	}
+}
+
+func TestPublicFilesRateLimit(t *testing.T) {
+	l := newRateLimiter(&ConfigPublicFilesRateLimit{RequestsPerSecond: 1, Burst: 3}, nil)
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	do := func(remoteAddr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/token/file.txt", nil)
+		r.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		if ok := l.allowRequest(w, r, l.clientKey(r)); ok != (w.Code == http.StatusOK) {
+			t.Fatalf("allowRequest returned %v with status %d", ok, w.Code)
+		}
+		return w
+	}
+
+	for i := 0; i < 3; i++ {
+		if w := do("192.0.2.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected status 200, got %d", i, w.Code)
+		}
+	}
+
+	w := do("192.0.2.1:1234")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status 429 after the burst, got %d", w.Code)
+	}
+	if got := w.Header().Get(HeaderRetryAfter); got != "1" {
+		t.Errorf("expected Retry-After 1, got %q", got)
+	}
+
+	// other clients have their own bucket
+	if w := do("192.0.2.2:1234"); w.Code != http.StatusOK {
+		t.Errorf("expected status 200 for another client, got %d", w.Code)
+	}
+
+	// the bucket refills over time
+	now = now.Add(time.Second)
+	if w := do("192.0.2.1:1234"); w.Code != http.StatusOK {
+		t.Errorf("expected status 200 after refill, got %d", w.Code)
+	}
+}
+
+func TestPublicFilesRateLimitByToken(t *testing.T) {
+	l := newRateLimiter(&ConfigPublicFilesRateLimit{RequestsPerSecond: 1, Burst: 1, Key: "token"}, nil)
+
+	// as in the public-files handler: by client first, by token once resolved
+	allow := func(token, remoteAddr string, resolved bool) bool {
+		r := httptest.NewRequest(http.MethodGet, "/"+token+"/a", nil)
+		r.RemoteAddr = remoteAddr
+		if !l.allowRequest(httptest.NewRecorder(), r, l.clientKey(r)) {
+			return false
+		}
+		return !resolved || l.allowRequest(httptest.NewRecorder(), r, tokenKey(token))
+	}
+
+	if !allow("token1", "192.0.2.1:1234", true) {
+		t.Fatal("expected first request to be allowed")
+	}
+	if allow("token1", "192.0.2.2:1234", true) {
+		t.Error("expected second request on the same token to be limited")
+	}
+	if allow("token2", "192.0.2.1:1234", true) {
+		t.Error("expected request of the same client on another token to be limited by ip")
+	}
+
+	// the tokens that cannot be resolved do not get a bucket of their own
+	if !allow("unknown1", "192.0.2.3:1234", false) {
+		t.Fatal("expected first unresolved request to be allowed")
+	}
+	if allow("unknown2", "192.0.2.3:1234", false) {
+		t.Error("expected unresolved request on a fresh token to be limited by ip")
+	}
+}
+
+func TestPublicFilesRateLimitBeforeAuthentication(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := &publicLinkGateway{}
+	srv := grpc.NewServer()
+	gateway.RegisterGatewayAPIServer(srv, gw)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	c := &Config{
+		GatewaySvc:           lis.Addr().String(),
+		PublicFilesRateLimit: &ConfigPublicFilesRateLimit{RequestsPerSecond: 0.001, Burst: 1, Key: "token"},
+	}
+	client, err := pool.GetGatewayServiceClient(pool.Endpoint(c.GatewaySvc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &svc{c: c, davHandler: new(DavHandler), gatewayClient: client}
+	if err := s.davHandler.init(c); err != nil {
+		t.Fatal(err)
+	}
+
+	// a client trying made up tokens is limited before reaching the gateway
+	codes := []int{}
+	for _, token := range []string{"guess1", "guess2", "guess3"} {
+		r := httptest.NewRequest(http.MethodGet, "/remote.php/dav/public-files/"+token+"/file.txt", nil)
+		r.SetBasicAuth("public", "password")
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, r)
+		codes = append(codes, w.Code)
+	}
+	if codes[1] != http.StatusTooManyRequests || codes[2] != http.StatusTooManyRequests {
+		t.Errorf("expected the client to be limited after the burst, got %v", codes)
+	}
+	if n := gw.authenticated.Load(); n != 1 {
+		t.Errorf("expected only the first request to be authenticated, got %d authentications", n)
+	}
+}
+
+func TestRateLimitBucketsEviction(t *testing.T) {
+	l := newRateLimiter(&ConfigPublicFilesRateLimit{RequestsPerSecond: 1, Burst: 1}, nil)
+	l.size = 2
+
+	for _, key := range []string{"a", "b", "a", "c"} {
+		l.allow(key)
+	}
+	if l.lru.Len() != 2 || len(l.buckets) != 2 {
+		t.Fatalf("expected 2 buckets, got %d", len(l.buckets))
+	}
+	// b is the least recently used bucket
+	if _, ok := l.buckets["b"]; ok {
+		t.Error("expected the least recently used bucket to be evicted")
+	}
+	if ok, _ := l.allow("a"); ok {
+		t.Error("expected the recently used bucket to be kept")
+	}
+}
+
+func TestPublicFilesRateLimitTrustedProxies(t *testing.T) {
+	proxies, err := netutil.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("not expected error: %v", err)
+	}
+	l := newRateLimiter(&ConfigPublicFilesRateLimit{RequestsPerSecond: 1, Burst: 1}, proxies)
+
+	allow := func(remoteAddr, forwardedFor string) bool {
+		r := httptest.NewRequest(http.MethodGet, "/token/file.txt", nil)
+		r.RemoteAddr = remoteAddr
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+		return l.allowRequest(httptest.NewRecorder(), r, l.clientKey(r))
+	}
+
+	// behind the trusted proxy the clients are told apart by the forwarded ip
+	if !allow("10.0.0.1:1234", "203.0.113.1") {
+		t.Fatal("expected first client to be allowed")
+	}
+	if !allow("10.0.0.1:1234", "203.0.113.2") {
+		t.Error("expected second client behind the proxy to be allowed")
+	}
+	if allow("10.0.0.1:1234", "203.0.113.1") {
+		t.Error("expected first client to be limited")
+	}
+
+	// an untrusted peer cannot escape the limit forging the header
+	if !allow("198.51.100.1:1234", "203.0.113.3") {
+		t.Fatal("expected untrusted client to be allowed")
+	}
+	if allow("198.51.100.1:1234", "203.0.113.4") {
+		t.Error("expected untrusted client to be limited regardless of the forwarded header")
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"google.golang.org/grpc"
+)
+
+// spaceGatewayClient serves a single space holding two files,
+// and records the deleted references.
+type spaceGatewayClient struct {
+	gateway.GatewayAPIClient
+	deleted []*provider.Reference
+}
+
+func (c *spaceGatewayClient) ListStorageSpaces(_ context.Context, _ *provider.ListStorageSpacesRequest, _ ...grpc.CallOption) (*provider.ListStorageSpacesResponse, error) {
+	return &provider.ListStorageSpacesResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		StorageSpaces: []*provider.StorageSpace{
+			{Root: &provider.ResourceId{StorageId: "provider-1", OpaqueId: "space"}},
+		},
+	}, nil
+}
+
+func (c *spaceGatewayClient) Stat(_ context.Context, _ *provider.StatRequest, _ ...grpc.CallOption) (*provider.StatResponse, error) {
+	return &provider.StatResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		Info: &provider.ResourceInfo{
+			Type:          provider.ResourceType_RESOURCE_TYPE_CONTAINER,
+			Path:          "/",
+			PermissionSet: &provider.ResourcePermissions{Delete: true},
+		},
+	}, nil
+}
+
+func (c *spaceGatewayClient) ListContainer(_ context.Context, _ *provider.ListContainerRequest, _ ...grpc.CallOption) (*provider.ListContainerResponse, error) {
+	return &provider.ListContainerResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		Infos: []*provider.ResourceInfo{
+			{Type: provider.ResourceType_RESOURCE_TYPE_FILE, Path: "/a.txt"},
+			{Type: provider.ResourceType_RESOURCE_TYPE_CONTAINER, Path: "/folder"},
+		},
+	}, nil
+}
+
+func (c *spaceGatewayClient) Delete(_ context.Context, req *provider.DeleteRequest, _ ...grpc.CallOption) (*provider.DeleteResponse, error) {
+	c.deleted = append(c.deleted, req.Ref)
+	return &provider.DeleteResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}}, nil
+}
+
+func TestSpaceRootDelete(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		c := &Config{EnableSpaceEmptying: enabled}
+		client := &spaceGatewayClient{}
+		s := &svc{c: c, davHandler: new(DavHandler), gatewayClient: client}
+		if err := s.davHandler.init(c); err != nil {
+			t.Fatal(err)
+		}
+
+		r := httptest.NewRequest(http.MethodDelete, "/remote.php/dav/spaces/space/", nil)
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, r)
+
+		if !enabled {
+			if w.Code != http.StatusMethodNotAllowed {
+				t.Errorf("expected status %d with emptying disabled, got %d", http.StatusMethodNotAllowed, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), "deleting spaces via dav is not allowed") {
+				t.Errorf("expected a sabredav exception, got %s", w.Body.String())
+			}
+			if len(client.deleted) != 0 {
+				t.Errorf("expected nothing to be deleted, got %v", client.deleted)
+			}
+			continue
+		}
+
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusNoContent, w.Code, w.Body.String())
+		}
+		paths := []string{}
+		for _, ref := range client.deleted {
+			if ref.Path == "." || ref.Path == "" {
+				t.Errorf("expected the space root not to be deleted")
+			}
+			paths = append(paths, ref.Path)
+		}
+		if strings.Join(paths, ",") != "./a.txt,./folder" {
+			t.Errorf("expected the contents of the space to be deleted, got %v", paths)
+		}
+	}
+}
@@ -22,23 +22,51 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	storageProvider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/rgrpc/status"
 	"github.com/cs3org/reva/pkg/rhttp/router"
 	"github.com/cs3org/reva/pkg/utils"
+	"github.com/pkg/errors"
 )
 
+// SpaceAliasResolver looks up the id of the space known by an alias.
+type SpaceAliasResolver interface {
+	// ResolveSpaceAlias returns the id of the space, and false when alias is not known.
+	ResolveSpaceAlias(ctx context.Context, alias string) (string, bool, error)
+}
+
+// staticSpaceAliases resolves the aliases configured in space_aliases.
+type staticSpaceAliases map[string]string
+
+func (a staticSpaceAliases) ResolveSpaceAlias(_ context.Context, alias string) (string, bool, error) {
+	id, ok := a[alias]
+	return id, ok, nil
+}
+
 // SpacesHandler handles trashbin requests.
 type SpacesHandler struct {
 	gatewaySvc string
+	aliases    SpaceAliasResolver
 }
 
 func (h *SpacesHandler) init(c *Config) error {
 	h.gatewaySvc = c.GatewaySvc
+	if len(c.SpaceAliases) > 0 {
+		h.aliases = staticSpaceAliases(c.SpaceAliases)
+	}
 	return nil
 }
 
+// SetAliasResolver replaces the lookup of the space aliases.
+func (h *SpacesHandler) SetAliasResolver(r SpaceAliasResolver) {
+	h.aliases = r
+}
+
 // Handler handles requests.
 func (h *SpacesHandler) Handler(s *svc) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -94,7 +122,29 @@ func (h *SpacesHandler) Handler(s *svc) http.Handler {
 	})
 }
 
+// resolveSpaceAlias returns the id of the space known by the alias spaceID,
+// or spaceID itself when it is not an alias. Aliases are resolved in the
+// request path as well as in the destination of a copy or a move.
+func (s *svc) resolveSpaceAlias(ctx context.Context, spaceID string) (string, error) {
+	if s.davHandler == nil || s.davHandler.SpacesHandler == nil || s.davHandler.SpacesHandler.aliases == nil {
+		return spaceID, nil
+	}
+	id, ok, err := s.davHandler.SpacesHandler.aliases.ResolveSpaceAlias(ctx, spaceID)
+	if err != nil {
+		return "", errors.Wrap(err, "error resolving the space alias "+spaceID)
+	}
+	if !ok {
+		return spaceID, nil
+	}
+	return id, nil
+}
+
 func (s *svc) lookUpStorageSpaceReference(ctx context.Context, spaceID string, relativePath string) (*storageProvider.Reference, *rpc.Status, error) {
+	spaceID, err := s.resolveSpaceAlias(ctx, spaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Get the getway client
 	gatewayClient, err := s.getClient()
 	if err != nil {
@@ -116,11 +166,19 @@ func (s *svc) lookUpStorageSpaceReference(ctx context.Context, spaceID string, r
 	}
 
 	lSSRes, err := gatewayClient.ListStorageSpaces(ctx, lSSReq)
-	if err != nil || lSSRes.Status.Code != rpc.Code_CODE_OK {
-		return nil, lSSRes.Status, err
+	if err != nil {
+		return nil, nil, err
+	}
+	if lSSRes.Status.Code != rpc.Code_CODE_OK {
+		return nil, lSSRes.Status, nil
 	}
 
-	if len(lSSRes.StorageSpaces) != 1 {
+	switch len(lSSRes.StorageSpaces) {
+	case 0:
+		// e.g. an unknown alias
+		return nil, status.NewNotFound(ctx, "space not found: "+spaceID), nil
+	case 1:
+	default:
 		return nil, nil, fmt.Errorf("unexpected number of spaces")
 	}
 	space := lSSRes.StorageSpaces[0]
@@ -130,3 +188,38 @@ func (s *svc) lookUpStorageSpaceReference(ctx context.Context, spaceID string, r
 		Path:       utils.MakeRelativePath(relativePath),
 	}, lSSRes.Status, nil
 }
+
+// setSpaceQuota fetches the quota of the space and stores it in the opaque of the
+// root info, so that the quota properties of the root reflect the whole space.
+// A total of 0 means that the quota is unknown, only the usage is set then.
+func setSpaceQuota(ctx context.Context, client gateway.GatewayAPIClient, ref *storageProvider.Reference, info *storageProvider.ResourceInfo) error {
+	res, err := client.GetQuota(ctx, &gateway.GetQuotaRequest{Ref: ref})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return errors.New(res.Status.Message)
+	}
+
+	if info.Opaque == nil {
+		info.Opaque = &types.Opaque{}
+	}
+	if info.Opaque.Map == nil {
+		info.Opaque.Map = map[string]*types.OpaqueEntry{}
+	}
+	info.Opaque.Map["quota-used"] = &types.OpaqueEntry{
+		Decoder: "plain",
+		Value:   []byte(strconv.FormatUint(res.UsedBytes, 10)),
+	}
+	if res.TotalBytes > 0 {
+		var available uint64
+		if res.TotalBytes > res.UsedBytes {
+			available = res.TotalBytes - res.UsedBytes
+		}
+		info.Opaque.Map["quota"] = &types.OpaqueEntry{
+			Decoder: "plain",
+			Value:   []byte(strconv.FormatUint(available, 10)),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"google.golang.org/grpc"
+)
+
+type quotaGatewayClient struct {
+	gateway.GatewayAPIClient
+	res *provider.GetQuotaResponse
+}
+
+func (c *quotaGatewayClient) GetQuota(_ context.Context, _ *gateway.GetQuotaRequest, _ ...grpc.CallOption) (*provider.GetQuotaResponse, error) {
+	return c.res, nil
+}
+
+func TestSpacesRootPropfindQuota(t *testing.T) {
+	client := &quotaGatewayClient{res: &provider.GetQuotaResponse{
+		Status:     &rpc.Status{Code: rpc.Code_CODE_OK},
+		TotalBytes: 1000,
+		UsedBytes:  300,
+	}}
+	root := &provider.ResourceInfo{
+		Type: provider.ResourceType_RESOURCE_TYPE_CONTAINER,
+		Path: "/",
+		Size: 42,
+	}
+	child := &provider.ResourceInfo{
+		Type: provider.ResourceType_RESOURCE_TYPE_CONTAINER,
+		Path: "/folder",
+		Size: 42,
+	}
+
+	ref := &provider.Reference{ResourceId: &provider.ResourceId{StorageId: "provider-1", OpaqueId: "userspace"}, Path: "."}
+	if err := setSpaceQuota(context.Background(), client, ref, root); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pf, _, err := readPropfind(strings.NewReader(`<?xml version="1.0"?><d:propfind xmlns:d="DAV:"><d:prop><d:quota-used-bytes/><d:quota-available-bytes/></d:prop></d:propfind>`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), ctxKeyBaseURI, "/dav/spaces/provider-1$userspace!root")
+	s := &svc{}
+	res, err := s.multistatusResponse(ctx, &pf, []*provider.ResourceInfo{root, child}, "", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	responses := strings.Split(res, "<d:response>")
+	if len(responses) != 3 {
+		t.Fatalf("expected 2 responses, got %s", res)
+	}
+	for _, p := range []string{"<d:quota-used-bytes>300</d:quota-used-bytes>", "<d:quota-available-bytes>700</d:quota-available-bytes>"} {
+		if !strings.Contains(responses[1], p) {
+			t.Errorf("expected %s in the root entry, got %s", p, responses[1])
+		}
+	}
+	// the children keep the folder quota
+	for _, p := range []string{"<d:quota-used-bytes>42</d:quota-used-bytes>", "<d:quota-available-bytes>-2</d:quota-available-bytes>"} {
+		if !strings.Contains(responses[2], p) {
+			t.Errorf("expected %s in the child entry, got %s", p, responses[2])
+		}
+	}
+}
+
+func TestSpaceAliases(t *testing.T) {
+	_, s := newCountingGateway(t, 2)
+	s.c.SpaceAliases = map[string]string{"myproject": "space"}
+	if err := s.davHandler.init(s.c); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		space    string
+		expected int
+	}{
+		{"myproject", http.StatusMultiStatus},
+		{"space", http.StatusMultiStatus},
+		{"unknown", http.StatusNotFound},
+	} {
+		r := httptest.NewRequest(MethodPropfind, "/remote.php/dav/spaces/"+tt.space, nil)
+		r.Header.Set(HeaderDepth, "1")
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, r)
+
+		if w.Code != tt.expected {
+			t.Errorf("%s: expected status %d, got %d", tt.space, tt.expected, w.Code)
+		}
+		if tt.expected == http.StatusMultiStatus && !strings.Contains(w.Body.String(), "/remote.php/dav/spaces/"+tt.space+"/file-1") {
+			t.Errorf("%s: expected the children to be listed under the requested path, got %s", tt.space, w.Body.String())
+		}
+	}
+}
@@ -35,7 +35,6 @@ import (
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
 	"github.com/cs3org/reva/pkg/appctx"
 
-	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/cs3org/reva/pkg/rhttp/router"
 	"github.com/cs3org/reva/pkg/utils"
 	"github.com/cs3org/reva/pkg/utils/resourceid"
@@ -105,7 +104,7 @@ func (h *TrashbinHandler) Handler(s *svc) http.Handler {
 		// If not, we user the user home to route the request
 		basePath := r.URL.Query().Get("base_path")
 		if basePath == "" {
-			gc, err := pool.GetGatewayServiceClient(pool.Endpoint(s.c.GatewaySvc))
+			gc, err := s.getClient()
 			if err != nil {
 				// TODO(jfd) how do we make the user aware that some storages are not available?
 				// opaque response property? Or a list of errors?
@@ -203,7 +202,7 @@ func (h *TrashbinHandler) listTrashbin(w http.ResponseWriter, r *http.Request, s
 		return
 	}
 
-	gc, err := pool.GetGatewayServiceClient(pool.Endpoint(s.c.GatewaySvc))
+	gc, err := s.getClient()
 	if err != nil {
 		// TODO(jfd) how do we make the user aware that some storages are not available?
 		// opaque response property? Or a list of errors?
@@ -563,12 +562,7 @@ func (h *TrashbinHandler) restore(w http.ResponseWriter, r *http.Request, s *svc
 
 	if res.Status.Code != rpc.Code_CODE_OK {
 		if res.Status.Code == rpc.Code_CODE_PERMISSION_DENIED {
-			w.WriteHeader(http.StatusForbidden)
-			b, err := Marshal(exception{
-				code:    SabredavPermissionDenied,
-				message: "Permission denied to restore",
-			})
-			HandleWebdavError(&sublog, w, b, err)
+			res.Status.Message = "Permission denied to restore"
 		}
 		HandleErrorStatus(&sublog, w, res.Status)
 		return
@@ -635,18 +629,12 @@ func (h *TrashbinHandler) delete(w http.ResponseWriter, r *http.Request, s *svc,
 		})
 		HandleWebdavError(&sublog, w, b, err)
 	case rpc.Code_CODE_PERMISSION_DENIED:
-		w.WriteHeader(http.StatusForbidden)
-		var m string
 		if key == "" {
-			m = "Permission denied to purge recycle"
+			res.Status.Message = "Permission denied to purge recycle"
 		} else {
-			m = "Permission denied to delete"
+			res.Status.Message = "Permission denied to delete"
 		}
-		b, err := Marshal(exception{
-			code:    SabredavPermissionDenied,
-			message: m,
-		})
-		HandleWebdavError(&sublog, w, b, err)
+		HandleErrorStatus(&sublog, w, res.Status)
 	default:
 		HandleErrorStatus(&sublog, w, res.Status)
 	}
@@ -0,0 +1,69 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxUploadSize(t *testing.T) {
+	put := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPut, "/remote.php/dav/spaces/space/file.txt", strings.NewReader("0123456789"))
+		r.Header.Set(HeaderContentLength, "10")
+		return r
+	}
+	tus := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/remote.php/dav/spaces/space", nil)
+		r.Header.Set(HeaderTusResumable, "1.0.0")
+		r.Header.Set(HeaderUploadLength, "10")
+		r.Header.Set(HeaderUploadMetadata, "filename "+base64.StdEncoding.EncodeToString([]byte("file.txt")))
+		return r
+	}
+
+	for name, req := range map[string]func() *http.Request{"put": put, "tus": tus} {
+		t.Run(name, func(t *testing.T) {
+			gw, s := newCountingGateway(t, 0)
+			s.c.MaxUploadSize = 5
+
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, req())
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Fatalf("expected status %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), "EntityTooLarge") {
+				t.Errorf("expected a sabredav exception, got %s", w.Body.String())
+			}
+			if n := gw.stats.Load(); n != 0 {
+				t.Errorf("expected the upload to be rejected before any stat, got %d", n)
+			}
+
+			// uploads within the limit are not rejected
+			s.c.MaxUploadSize = 10
+			w = httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, req())
+			if w.Code == http.StatusRequestEntityTooLarge {
+				t.Errorf("expected an upload within the limit to be accepted")
+			}
+		})
+	}
+}
@@ -47,10 +47,15 @@ const (
 	HeaderContentRange               = "Content-Range"
 	HeaderContentType                = "Content-Type"
 	HeaderETag                       = "ETag"
+	HeaderCacheControl               = "Cache-Control"
+	HeaderExpires                    = "Expires"
 	HeaderLastModified               = "Last-Modified"
 	HeaderLocation                   = "Location"
 	HeaderRange                      = "Range"
+	HeaderRetryAfter                 = "Retry-After"
+	HeaderIf                         = "If"
 	HeaderIfMatch                    = "If-Match"
+	HeaderIfNoneMatch                = "If-None-Match"
 	HeaderChecksum                   = "Digest"
 )
 
@@ -60,6 +65,7 @@ const (
 	HeaderOCETag               = "OC-ETag"
 	HeaderOCChecksum           = "OC-Checksum"
 	HeaderOCPermissions        = "OC-Perm"
+	HeaderOCTotalLength        = "OC-Total-Length"
 	HeaderDepth                = "Depth"
 	HeaderDav                  = "DAV"
 	HeaderTusResumable         = "Tus-Resumable"
@@ -78,6 +84,7 @@ const (
 	HeaderTransferAuth         = "TransferHeaderAuthorization"
 	HeaderLockID               = "X-Lock-Id"
 	HeaderLockHolder           = "X-Lock-Holder"
+	HeaderImpersonateUser      = "X-Impersonate-User"
 )
 
 // WebDavHandler implements a dav endpoint.
@@ -36,6 +36,8 @@ type Config struct {
 	SharePrefix              string                            `mapstructure:"share_prefix"`
 	HomeNamespace            string                            `mapstructure:"home_namespace"`
 	AdditionalInfoAttribute  string                            `mapstructure:"additional_info_attribute"`
+	AdditionalInfoSource     string                            `mapstructure:"additional_info_source"`
+	ShareeSearchLimit        int                               `mapstructure:"sharee_search_limit"`
 	CacheWarmupDriver        string                            `mapstructure:"cache_warmup_driver"`
 	CacheWarmupDrivers       map[string]map[string]interface{} `mapstructure:"cache_warmup_drivers"`
 	ResourceInfoCacheDriver  string                            `mapstructure:"resource_info_cache_type"`
@@ -46,6 +48,34 @@ type Config struct {
 	OCMMountPoint            string                            `mapstructure:"ocm_mount_point"`
 	ListOCMShares            bool                              `mapstructure:"list_ocm_shares"`
 	Notifications            map[string]interface{}            `mapstructure:"notifications"`
+	ExpirationTimezone       string                            `mapstructure:"expiration_timezone"`
+	DefaultShareRole         string                            `mapstructure:"default_share_role"`
+	EnabledShareTypes        []string                          `mapstructure:"enabled_share_types"`
+	StatusCodes              map[string]int                    `mapstructure:"status_codes"`
+	MaxRequestBodySize       int64                             `mapstructure:"max_request_body_size"`
+	AllowedStorageProviders  []string                          `mapstructure:"allowed_storage_providers"`
+	PasswordPlaceholder      string                            `mapstructure:"password_placeholder"`
+	PasswordProtectedFlag    bool                              `mapstructure:"password_protected_flag"`
+	// ExpiredShareGracePeriod is how long, in seconds, the expired shares are still
+	// listed and flagged as expired. The shares are listed as returned by the share
+	// managers when 0.
+	ExpiredShareGracePeriod int64 `mapstructure:"expired_share_grace_period"`
+	// MaxPublicLinksPerResource is the number of public links a file or a folder
+	// can have, unlimited when 0.
+	MaxPublicLinksPerResource int `mapstructure:"max_public_links_per_resource"`
+	// IdempotencyKeyTTL is how long, in seconds, the response to a share creation
+	// with an Idempotency-Key header is returned again for the same key.
+	IdempotencyKeyTTL int `mapstructure:"idempotency_key_ttl"`
+	// AllowedPermissions are the OCS permissions that can be granted with each share type,
+	// as a bit mask by share type name: user, group, public or federated.
+	AllowedPermissions map[string]int `mapstructure:"allowed_permissions"`
+	// AllowSelfShares allows the users to share a resource with themselves,
+	// or with the owner of the resource.
+	AllowSelfShares bool `mapstructure:"allow_self_shares"`
+	// ShareNotifier is the registered share notifier called when a user or a group
+	// share is created, configured with ShareNotifiers. No one is notified when empty.
+	ShareNotifier  string                            `mapstructure:"share_notifier"`
+	ShareNotifiers map[string]map[string]interface{} `mapstructure:"share_notifiers"`
 }
 
 // Init sets sane defaults.
@@ -70,9 +100,17 @@ func (c *Config) ApplyDefaults() {
 		c.AdditionalInfoAttribute = "{{.Mail}}"
 	}
 
+	if c.MaxRequestBodySize == 0 {
+		c.MaxRequestBodySize = 10 << 20 // 10 MiB
+	}
+
 	if c.UserIdentifierCacheTTL == 0 {
 		c.UserIdentifierCacheTTL = 60
 	}
 
+	if c.IdempotencyKeyTTL == 0 {
+		c.IdempotencyKeyTTL = 300
+	}
+
 	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
 }
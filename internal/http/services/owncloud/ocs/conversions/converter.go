@@ -0,0 +1,88 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package conversions
+
+import (
+	"time"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
+)
+
+// Converter converts the CS3 shares into the OCS share data model following
+// the settings of an ocs service, so that the services running in the same
+// process do not share them. A nil converter uses the default settings.
+type Converter struct {
+	// expirationLocation is the time zone used to render share expirations.
+	// Timestamps are always stored in UTC, only the representation changes.
+	expirationLocation *time.Location
+	// defaultRole is the role reported for shares that carry no permissions.
+	defaultRole *Role
+	// statusCodes holds the configured overrides of the default
+	// mapping of the CS3 status codes to the OCS ones.
+	statusCodes map[rpc.Code]int
+	// allowedStorageProviders is the set of the storage providers the shared
+	// resources may live in, all providers are allowed when nil.
+	allowedStorageProviders map[string]struct{}
+	// passwordPlaceholder replaces the password of the password-protected
+	// public links, DefaultPasswordPlaceholder when empty.
+	passwordPlaceholder string
+	// passwordFlag reports password_protected in place of the placeholder.
+	passwordFlag bool
+	// allowedPermissions are the permissions that can be granted with each share type,
+	// the share types missing from it can grant all the permissions.
+	allowedPermissions map[ShareType]Permissions
+}
+
+// NewConverter returns a converter with the settings of the ocs configuration.
+func NewConverter(c *config.Config) (*Converter, error) {
+	loc, err := parseExpirationTimezone(c.ExpirationTimezone)
+	if err != nil {
+		return nil, err
+	}
+	role, err := parseDefaultRole(c.DefaultShareRole)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := parseStatusCodes(c.StatusCodes)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := parseAllowedPermissions(c.AllowedPermissions)
+	if err != nil {
+		return nil, err
+	}
+	return &Converter{
+		expirationLocation:      loc,
+		defaultRole:             role,
+		statusCodes:             codes,
+		allowedStorageProviders: parseAllowedStorageProviders(c.AllowedStorageProviders),
+		passwordPlaceholder:     c.PasswordPlaceholder,
+		passwordFlag:            c.PasswordProtectedFlag,
+		allowedPermissions:      allowed,
+	}, nil
+}
+
+// defaultPermissions returns the permissions of the shares that carry none.
+func (c *Converter) defaultPermissions() Permissions {
+	if c == nil || c.defaultRole == nil {
+		return NewViewerRole().OCSPermissions()
+	}
+	return c.defaultRole.OCSPermissions()
+}
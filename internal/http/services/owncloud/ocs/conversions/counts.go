@@ -0,0 +1,37 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package conversions
+
+// ShareTypeCounts holds the number of shares per OCS share type.
+type ShareTypeCounts map[ShareType]int
+
+// CountShareTypes tallies the shares of a listing per OCS share type.
+func CountShareTypes(shares []*ShareData) ShareTypeCounts {
+	counts := ShareTypeCounts{
+		ShareTypeUser:                0,
+		ShareTypeGroup:               0,
+		ShareTypePublicLink:          0,
+		ShareTypeFederatedCloudShare: 0,
+		ShareTypeSpaceMembership:     0,
+	}
+	for _, s := range shares {
+		counts[s.ShareType]++
+	}
+	return counts
+}
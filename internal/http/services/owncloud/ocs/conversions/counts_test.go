@@ -0,0 +1,51 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package conversions
+
+import "testing"
+
+func TestCountShareTypes(t *testing.T) {
+	counts := CountShareTypes([]*ShareData{
+		{ShareType: ShareTypeUser},
+		{ShareType: ShareTypeGroup},
+		{ShareType: ShareTypeUser},
+		{ShareType: ShareTypeUser},
+		{ShareType: ShareTypePublicLink},
+		{ShareType: ShareTypePublicLink},
+		{ShareType: ShareTypeFederatedCloudShare},
+		{ShareType: ShareTypeSpaceMembership},
+	})
+
+	expected := ShareTypeCounts{
+		ShareTypeUser:                3,
+		ShareTypeGroup:               1,
+		ShareTypePublicLink:          2,
+		ShareTypeFederatedCloudShare: 1,
+		ShareTypeSpaceMembership:     1,
+	}
+	for st, n := range expected {
+		if counts[st] != n {
+			t.Errorf("expected %d shares of type %d, got %d", n, st, counts[st])
+		}
+	}
+	if len(counts) != len(expected) {
+		t.Errorf("expected %d share types, got %d", len(expected), len(counts))
+	}
+
+}
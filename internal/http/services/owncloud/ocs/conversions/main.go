@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"strings"
 	"time"
 
 	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
@@ -39,6 +40,8 @@ import (
 	publicsharemgr "github.com/cs3org/reva/pkg/publicshare/manager/registry"
 	"github.com/cs3org/reva/pkg/user"
 	usermgr "github.com/cs3org/reva/pkg/user/manager/registry"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/pkg/errors"
 )
 
 const (
@@ -58,6 +61,28 @@ const (
 	ShareTypeSpaceMembership ShareType = 7
 )
 
+// shareTypeNames are the names of the share types that can be enabled or disabled in the configuration.
+var shareTypeNames = map[string]ShareType{
+	"user":      ShareTypeUser,
+	"group":     ShareTypeGroup,
+	"public":    ShareTypePublicLink,
+	"federated": ShareTypeFederatedCloudShare,
+}
+
+// ParseShareTypes returns the share types given by name, which is
+// one of "user", "group", "public" or "federated".
+func ParseShareTypes(names []string) (map[ShareType]bool, error) {
+	types := make(map[ShareType]bool, len(names))
+	for _, n := range names {
+		t, ok := shareTypeNames[n]
+		if !ok {
+			return nil, errors.Errorf("conversions: unknown share type %s", n)
+		}
+		types[t] = true
+	}
+	return types, nil
+}
+
 // ResourceType indicates the OCS type of the resource.
 type ResourceType int
 
@@ -92,15 +117,22 @@ type ShareData struct {
 	DisplaynameOwner string `json:"displayname_owner" xml:"displayname_owner"`
 	// Additional info to identify the share owner, eg. the email or username
 	AdditionalInfoOwner string `json:"additional_info_owner" xml:"additional_info_owner"`
-	// The permission attribute set on the file.
-	// TODO(jfd) change the default to read only
+	// The permission attribute set on the file. Shares without permissions
+	// report the configured default role, read only unless changed.
 	Permissions Permissions `json:"permissions" xml:"permissions"`
-	// The UNIX timestamp when the share was created.
+	// The UNIX timestamp in seconds when the share was created. The CS3 shares
+	// carry no birth time, so it is the ctime of the share with second precision.
 	STime uint64 `json:"stime" xml:"stime"`
+	// The UNIX timestamp in nanoseconds when the share was created, to order
+	// the shares created in the same second. It is the same instant as stime.
+	STimeNanos uint64 `json:"stime_nanos,omitempty" xml:"stime_nanos,omitempty"`
 	// ?
 	Parent string `json:"parent" xml:"parent"`
 	// The UNIX timestamp when the share expires.
 	Expiration string `json:"expiration" xml:"expiration"`
+	// Whether the share has expired, only reported for the shares listed
+	// in the grace period after their expiration.
+	Expired bool `json:"expired,omitempty" xml:"expired,omitempty"`
 	// The public link to the item being shared.
 	Token string `json:"token" xml:"token"`
 	// The unique id of the user that owns the file or folder being shared.
@@ -144,9 +176,10 @@ type ShareData struct {
 	URL string `json:"url,omitempty" xml:"url,omitempty"`
 	// Attributes associated
 	Attributes string `json:"attributes,omitempty" xml:"attributes,omitempty"`
-	// PasswordProtected represents a public share is password protected
-	// PasswordProtected bool `json:"password_protected,omitempty" xml:"password_protected,omitempty"`
-	Quicklink bool `json:"quicklink,omitempty" xml:"quicklink,omitempty"`
+	// PasswordProtected represents a public share is password protected, it is only
+	// reported in place of the redacted share_with, see Converter.redactPassword.
+	PasswordProtected bool `json:"password_protected,omitempty" xml:"password_protected,omitempty"`
+	Quicklink         bool `json:"quicklink,omitempty" xml:"quicklink,omitempty"`
 	// Description of the public share
 	Description string `json:"description" xml:"description"`
 	// Whether to notify owner of file uploads to the public share
@@ -185,7 +218,11 @@ type MatchValueData struct {
 }
 
 // CS3Share2ShareData converts a cs3api user share into shareData data model.
-func CS3Share2ShareData(ctx context.Context, share *collaboration.Share) (*ShareData, error) {
+func (c *Converter) CS3Share2ShareData(ctx context.Context, share *collaboration.Share) (*ShareData, error) {
+	if err := c.CheckStorageProvider(share.GetResourceId()); err != nil {
+		return nil, err
+	}
+
 	sd := &ShareData{
 		// share.permissions are mapped below
 		// Displaynames are added later
@@ -206,15 +243,15 @@ func CS3Share2ShareData(ctx context.Context, share *collaboration.Share) (*Share
 	}
 	if share.GetPermissions() != nil && share.GetPermissions().GetPermissions() != nil {
 		sd.Permissions = RoleFromResourcePermissions(share.GetPermissions().GetPermissions()).OCSPermissions()
+	} else {
+		sd.Permissions = c.defaultPermissions()
 	}
-	if share.Ctime != nil {
-		sd.STime = share.Ctime.Seconds // TODO CS3 api birth time = btime
-	}
+	setSTime(sd, share.Ctime)
 	return sd, nil
 }
 
 // PublicShare2ShareData converts a cs3api public share into shareData data model.
-func PublicShare2ShareData(share *link.PublicShare, r *http.Request, publicURL string) *ShareData {
+func (c *Converter) PublicShare2ShareData(share *link.PublicShare, r *http.Request, publicURL string) *ShareData {
 	sd := &ShareData{
 		// share.permissions are mapped below
 		// Displaynames are added later
@@ -222,7 +259,7 @@ func PublicShare2ShareData(share *link.PublicShare, r *http.Request, publicURL s
 		Token:                        share.Token,
 		Name:                         share.DisplayName,
 		MailSend:                     0,
-		URL:                          publicURL + path.Join("/", "s/"+share.Token),
+		URL:                          publicShareURL(publicURL, share.Token),
 		UIDOwner:                     LocalUserIDToString(share.Creator),
 		UIDFileOwner:                 LocalUserIDToString(share.Owner),
 		Quicklink:                    share.Quicklink,
@@ -235,23 +272,37 @@ func PublicShare2ShareData(share *link.PublicShare, r *http.Request, publicURL s
 	}
 	if share.GetPermissions() != nil && share.GetPermissions().GetPermissions() != nil {
 		sd.Permissions = RoleFromResourcePermissions(share.GetPermissions().GetPermissions()).OCSPermissions()
+	} else {
+		sd.Permissions = c.defaultPermissions()
 	}
 	if share.Expiration != nil {
-		sd.Expiration = timestampToExpiration(share.Expiration)
-	}
-	if share.Ctime != nil {
-		sd.STime = share.Ctime.Seconds // TODO CS3 api birth time = btime
+		sd.Expiration = c.timestampToExpiration(share.Expiration)
 	}
+	setSTime(sd, share.Ctime)
 
 	// hide password
 	if share.PasswordProtected {
-		sd.ShareWith = "***redacted***"
-		sd.ShareWithDisplayname = "***redacted***"
+		c.redactPassword(sd)
 	}
 
 	return sd
 }
 
+// setSTime sets the creation time of a share from its ctime.
+func setSTime(sd *ShareData, t *types.Timestamp) {
+	if t == nil {
+		return
+	}
+	sd.STime = t.Seconds
+	sd.STimeNanos = utils.TSToUnixNano(t)
+}
+
+// publicShareURL builds the link of a public share, regardless of whether the
+// public url carries a trailing slash or a base path.
+func publicShareURL(publicURL, token string) string {
+	return strings.TrimRight(publicURL, "/") + path.Join("/s", token)
+}
+
 func formatRemoteUser(u *userpb.UserId) string {
 	return fmt.Sprintf("%s@%s", u.OpaqueId, u.Idp)
 }
@@ -266,7 +317,7 @@ func webdavInfo(protocols []*ocm.Protocol) (*ocm.WebDAVProtocol, bool) {
 }
 
 // ReceivedOCMShare2ShareData converts a cs3 ocm received share into a share data model.
-func ReceivedOCMShare2ShareData(share *ocm.ReceivedShare, path string) (*ShareData, error) {
+func (c *Converter) ReceivedOCMShare2ShareData(share *ocm.ReceivedShare, path string) (*ShareData, error) {
 	webdav, ok := webdavInfo(share.Protocols)
 	if !ok {
 		return nil, errtypes.InternalError("webdav endpoint not in share")
@@ -284,12 +335,12 @@ func ReceivedOCMShare2ShareData(share *ocm.ReceivedShare, path string) (*ShareDa
 		MimeType:     mime.Detect(share.ResourceType == provider.ResourceType_RESOURCE_TYPE_CONTAINER, share.Name),
 		ItemType:     ResourceType(share.ResourceType).String(),
 		ItemSource:   path,
-		STime:        share.Ctime.Seconds,
 		Name:         share.Name,
 	}
+	setSTime(s, share.Ctime)
 
 	if share.Expiration != nil {
-		s.Expiration = timestampToExpiration(share.Expiration)
+		s.Expiration = c.timestampToExpiration(share.Expiration)
 	}
 	return s, nil
 }
@@ -304,11 +355,14 @@ func webdavAMInfo(methods []*ocm.AccessMethod) (*ocm.WebDAVAccessMethod, bool) {
 }
 
 // OCMShare2ShareData converts a cs3 ocm share into a share data model.
-func OCMShare2ShareData(share *ocm.Share) (*ShareData, error) {
+func (c *Converter) OCMShare2ShareData(share *ocm.Share) (*ShareData, error) {
 	webdav, ok := webdavAMInfo(share.AccessMethods)
 	if !ok {
 		return nil, errtypes.InternalError("webdav endpoint not in share")
 	}
+	if err := c.CheckStorageProvider(share.GetResourceId()); err != nil {
+		return nil, err
+	}
 
 	s := &ShareData{
 		ID:           share.Id.OpaqueId,
@@ -317,12 +371,12 @@ func OCMShare2ShareData(share *ocm.Share) (*ShareData, error) {
 		ShareWith:    formatRemoteUser(share.Grantee.GetUserId()),
 		Permissions:  RoleFromResourcePermissions(webdav.Permissions).OCSPermissions(),
 		ShareType:    ShareTypeFederatedCloudShare,
-		STime:        share.Ctime.Seconds,
 		Name:         share.Name,
 	}
+	setSTime(s, share.Ctime)
 
 	if share.Expiration != nil {
-		s.Expiration = timestampToExpiration(share.Expiration)
+		s.Expiration = c.timestampToExpiration(share.Expiration)
 	}
 
 	return s, nil
@@ -363,10 +417,104 @@ func GetPublicShareManager(ctx context.Context, manager string, m map[string]map
 	return nil, fmt.Errorf("driver %s not found for public shares manager", manager)
 }
 
-// timestamp is assumed to be UTC ... just human readable ...
-// FIXME and ambiguous / error prone because there is no time zone ...
-func timestampToExpiration(t *types.Timestamp) string {
-	return time.Unix(int64(t.Seconds), int64(t.Nanos)).UTC().Format("2006-01-02 15:05:05")
+// parseDefaultRole returns the role, given by name, reported for shares
+// that carry no permissions. An empty name is the viewer role.
+func parseDefaultRole(name string) (*Role, error) {
+	if name == "" {
+		return NewViewerRole(), nil
+	}
+	role := RoleFromName(name)
+	if role.Name == RoleUnknown {
+		return nil, errors.Errorf("conversions: unknown default role %s", name)
+	}
+	return role, nil
+}
+
+// parseExpirationTimezone returns the time zone, given as an IANA name,
+// in which share expirations are rendered. An empty name is UTC.
+func parseExpirationTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "conversions: invalid expiration timezone %s", name)
+	}
+	return loc, nil
+}
+
+// DefaultPasswordPlaceholder replaces the password of the password-protected public links.
+const DefaultPasswordPlaceholder = "***redacted***"
+
+// placeholder returns the placeholder replacing the
+// password of the password-protected public links.
+func (c *Converter) placeholder() string {
+	if c == nil || c.passwordPlaceholder == "" {
+		return DefaultPasswordPlaceholder
+	}
+	return c.passwordPlaceholder
+}
+
+// IsRedactedPassword tells whether the share_with of a public link is the redacted password.
+func (c *Converter) IsRedactedPassword(shareWith string) bool {
+	if c != nil && c.passwordFlag {
+		return false
+	}
+	return shareWith == c.placeholder()
+}
+
+// redactPassword hides the password of a password-protected public link: share_with
+// and share_with_displayname are set to the placeholder, or, with the password flag,
+// left empty and password_protected is set instead, for the views that need to know
+// whether a password is set.
+func (c *Converter) redactPassword(sd *ShareData) {
+	if c != nil && c.passwordFlag {
+		sd.ShareWith = ""
+		sd.ShareWithDisplayname = ""
+		sd.PasswordProtected = true
+		return
+	}
+	sd.ShareWith = c.placeholder()
+	sd.ShareWithDisplayname = c.placeholder()
+}
+
+// parseAllowedStorageProviders returns the set of the storage providers,
+// given by id, the shared resources may live in, nil when all are allowed.
+func parseAllowedStorageProviders(ids []string) map[string]struct{} {
+	if len(ids) == 0 {
+		return nil
+	}
+	allowed := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		allowed[id] = struct{}{}
+	}
+	return allowed
+}
+
+// CheckStorageProvider returns an error when the resource id
+// references a storage provider that is not allowed.
+func (c *Converter) CheckStorageProvider(id *provider.ResourceId) error {
+	if c == nil || c.allowedStorageProviders == nil || id == nil {
+		return nil
+	}
+	if _, ok := c.allowedStorageProviders[id.StorageId]; !ok {
+		return errtypes.PermissionDenied("conversions: storage provider not allowed: " + id.StorageId)
+	}
+	return nil
+}
+
+// timestamp is rendered in the configured expiration time zone ... just human readable ...
+// FIXME and ambiguous / error prone because there is no time zone in the output ...
+func (c *Converter) timestampToExpiration(t *types.Timestamp) string {
+	loc := time.UTC
+	if c != nil && c.expirationLocation != nil {
+		loc = c.expirationLocation
+	}
+	return timestampToExpirationIn(t, loc)
+}
+
+func timestampToExpirationIn(t *types.Timestamp, loc *time.Location) string {
+	return time.Unix(int64(t.Seconds), int64(t.Nanos)).In(loc).Format("2006-01-02 15:05:05")
 }
 
 // ParseTimestamp tries to parses the ocs expiry into a CS3 Timestamp.
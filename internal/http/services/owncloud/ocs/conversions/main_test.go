@@ -0,0 +1,222 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package conversions
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
+	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
+)
+
+func TestTimestampToExpirationTimezone(t *testing.T) {
+	// 2024-01-15 10:00:00 UTC, Zurich is UTC+1 in winter
+	ts := &types.Timestamp{Seconds: uint64(time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC).Unix())}
+
+	c, err := NewConverter(&config.Config{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	utc := c.timestampToExpiration(ts)
+
+	c, err = NewConverter(&config.Config{ExpirationTimezone: "Europe/Zurich"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zurich := c.timestampToExpiration(ts)
+
+	if utc[:13] != "2024-01-15 10" {
+		t.Errorf("expected UTC expiration to start with 2024-01-15 10, got %s", utc)
+	}
+	if zurich[:13] != "2024-01-15 11" {
+		t.Errorf("expected Europe/Zurich expiration to start with 2024-01-15 11, got %s", zurich)
+	}
+	if nilUTC := (*Converter)(nil).timestampToExpiration(ts); nilUTC != utc {
+		t.Errorf("expected a nil converter to render the expiration in UTC, got %s", nilUTC)
+	}
+}
+
+func TestExpirationTimezoneInvalid(t *testing.T) {
+	if _, err := NewConverter(&config.Config{ExpirationTimezone: "Not/AZone"}); err == nil {
+		t.Error("expected an error for an invalid timezone")
+	}
+}
+
+func TestNilPermissionsDefaultRole(t *testing.T) {
+	c := &Converter{}
+	share := &collaboration.Share{
+		Grantee: &provider.Grantee{Type: provider.GranteeType_GRANTEE_TYPE_USER},
+	}
+	publicShare := &link.PublicShare{Token: "token"}
+
+	sd, err := c.CS3Share2ShareData(context.Background(), share)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sd.Permissions != PermissionRead {
+		t.Errorf("expected share permissions %d, got %d", PermissionRead, sd.Permissions)
+	}
+	if psd := c.PublicShare2ShareData(publicShare, nil, "https://x"); psd.Permissions != PermissionRead {
+		t.Errorf("expected public share permissions %d, got %d", PermissionRead, psd.Permissions)
+	}
+
+	c, err = NewConverter(&config.Config{DefaultShareRole: RoleEditor})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := NewEditorRole().OCSPermissions()
+	sd, err = c.CS3Share2ShareData(context.Background(), share)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sd.Permissions != want {
+		t.Errorf("expected share permissions %d, got %d", want, sd.Permissions)
+	}
+	if psd := c.PublicShare2ShareData(publicShare, nil, "https://x"); psd.Permissions != want {
+		t.Errorf("expected public share permissions %d, got %d", want, psd.Permissions)
+	}
+}
+
+func TestDefaultRoleInvalid(t *testing.T) {
+	if _, err := NewConverter(&config.Config{DefaultShareRole: "no-such-role"}); err == nil {
+		t.Error("expected an error for an unknown role")
+	}
+}
+
+func TestPublicShareURL(t *testing.T) {
+	c := &Converter{}
+	tests := []struct {
+		publicURL string
+		expected  string
+	}{
+		{publicURL: "https://x", expected: "https://x/s/token"},
+		{publicURL: "https://x/", expected: "https://x/s/token"},
+		{publicURL: "https://x/cloud", expected: "https://x/cloud/s/token"},
+		{publicURL: "https://x/cloud/", expected: "https://x/cloud/s/token"},
+	}
+	for _, tt := range tests {
+		sd := c.PublicShare2ShareData(&link.PublicShare{Token: "token"}, nil, tt.publicURL)
+		if sd.URL != tt.expected {
+			t.Errorf("publicURL %q: expected %s, got %s", tt.publicURL, tt.expected, sd.URL)
+		}
+	}
+}
+
+func TestAllowedStorageProviders(t *testing.T) {
+	c, err := NewConverter(&config.Config{AllowedStorageProviders: []string{"allowed"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	share := func(storageID string) *collaboration.Share {
+		return &collaboration.Share{
+			ResourceId: &provider.ResourceId{StorageId: storageID, OpaqueId: "file"},
+			Grantee:    &provider.Grantee{Type: provider.GranteeType_GRANTEE_TYPE_USER},
+		}
+	}
+
+	if _, err := c.CS3Share2ShareData(context.Background(), share("allowed")); err != nil {
+		t.Errorf("unexpected error for an allowed provider: %v", err)
+	}
+	if _, err := c.CS3Share2ShareData(context.Background(), share("unknown")); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+
+	c = &Converter{}
+	if _, err := c.CS3Share2ShareData(context.Background(), share("unknown")); err != nil {
+		t.Errorf("expected all providers to be allowed without a list, got %v", err)
+	}
+}
+
+func TestPasswordRedaction(t *testing.T) {
+	c := &Converter{}
+	share := &link.PublicShare{Token: "token", PasswordProtected: true}
+
+	sd := c.PublicShare2ShareData(share, nil, "")
+	if sd.ShareWith != "***redacted***" || sd.ShareWithDisplayname != "***redacted***" || sd.PasswordProtected {
+		t.Errorf("expected the default placeholder, got %q %q %t", sd.ShareWith, sd.ShareWithDisplayname, sd.PasswordProtected)
+	}
+	if !c.IsRedactedPassword(sd.ShareWith) {
+		t.Error("expected the placeholder to be recognized")
+	}
+
+	c, err := NewConverter(&config.Config{PasswordPlaceholder: "(hidden)"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sd := c.PublicShare2ShareData(share, nil, ""); sd.ShareWith != "(hidden)" || sd.ShareWithDisplayname != "(hidden)" {
+		t.Errorf("expected the configured placeholder, got %q %q", sd.ShareWith, sd.ShareWithDisplayname)
+	}
+
+	c, err = NewConverter(&config.Config{PasswordProtectedFlag: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sd = c.PublicShare2ShareData(share, nil, "")
+	if sd.ShareWith != "" || sd.ShareWithDisplayname != "" || !sd.PasswordProtected {
+		t.Errorf("expected the password to be flagged, got %q %q %t", sd.ShareWith, sd.ShareWithDisplayname, sd.PasswordProtected)
+	}
+	b, err := json.Marshal(sd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"password_protected":true`) {
+		t.Errorf("expected password_protected in %s", b)
+	}
+
+	share.PasswordProtected = false
+	if sd := c.PublicShare2ShareData(share, nil, ""); sd.PasswordProtected {
+		t.Error("expected a link without password not to be flagged")
+	}
+}
+
+func TestShareSTime(t *testing.T) {
+	c := &Converter{}
+	ctime := &types.Timestamp{Seconds: 1700000000, Nanos: 5}
+
+	share := &ocm.Share{
+		Id:            &ocm.ShareId{OpaqueId: "share"},
+		Creator:       &userpb.UserId{OpaqueId: "einstein"},
+		Owner:         &userpb.UserId{OpaqueId: "einstein"},
+		Grantee:       &provider.Grantee{Id: &provider.Grantee_UserId{UserId: &userpb.UserId{OpaqueId: "marie", Idp: "cesnet"}}},
+		AccessMethods: []*ocm.AccessMethod{{Term: &ocm.AccessMethod_WebdavOptions{WebdavOptions: &ocm.WebDAVAccessMethod{}}}},
+		Ctime:         ctime,
+	}
+	sd, err := c.OCMShare2ShareData(share)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sd.STime != ctime.Seconds || sd.STimeNanos != 1700000000*uint64(time.Second)+5 {
+		t.Errorf("expected the stime to come from the ctime, got %d (%d)", sd.STime, sd.STimeNanos)
+	}
+
+	sd = c.PublicShare2ShareData(&link.PublicShare{Ctime: ctime}, nil, "")
+	if sd.STime != ctime.Seconds || sd.STimeNanos != 1700000000*uint64(time.Second)+5 {
+		t.Errorf("expected the stime of the link to keep the nanoseconds, got %d (%d)", sd.STime, sd.STimeNanos)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package conversions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	"github.com/cs3org/reva/pkg/publicshare"
+	"github.com/pkg/errors"
+)
+
+var (
+	publicShareManagersMu sync.Mutex
+	publicShareManagers   = map[string]publicshare.Manager{}
+)
+
+// GetCachedPublicShareManager returns a public share manager like GetPublicShareManager,
+// but each manager is constructed only once for a given driver and configuration,
+// and the same instance is returned to all the callers.
+func GetCachedPublicShareManager(ctx context.Context, manager string, m map[string]map[string]interface{}) (publicshare.Manager, error) {
+	key, err := publicShareManagerKey(manager, m[manager])
+	if err != nil {
+		return nil, err
+	}
+
+	publicShareManagersMu.Lock()
+	defer publicShareManagersMu.Unlock()
+
+	if mgr, ok := publicShareManagers[key]; ok {
+		return mgr, nil
+	}
+	mgr, err := GetPublicShareManager(ctx, manager, m)
+	if err != nil {
+		return nil, err
+	}
+	publicShareManagers[key] = mgr
+	return mgr, nil
+}
+
+// ResetPublicShareManagers drops the cached public share managers.
+// It is meant to be used in tests.
+func ResetPublicShareManagers() {
+	publicShareManagersMu.Lock()
+	defer publicShareManagersMu.Unlock()
+	publicShareManagers = map[string]publicshare.Manager{}
+}
+
+// publicShareManagerKey returns the cache key of a manager, made of
+// its name and of the hash of its configuration.
+func publicShareManagerKey(manager string, c map[string]interface{}) (string, error) {
+	// maps are marshalled with sorted keys, so equal configurations have the same hash
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", errors.Wrapf(err, "conversions: error hashing configuration of public share manager %s", manager)
+	}
+	h := sha256.Sum256(b)
+	return manager + ":" + hex.EncodeToString(h[:]), nil
+}
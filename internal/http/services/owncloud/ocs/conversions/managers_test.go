@@ -0,0 +1,85 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package conversions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cs3org/reva/pkg/publicshare"
+	publicsharemgr "github.com/cs3org/reva/pkg/publicshare/manager/registry"
+)
+
+type fakePublicShareManager struct {
+	publicshare.Manager
+	c map[string]interface{}
+}
+
+func TestGetCachedPublicShareManager(t *testing.T) {
+	var constructed int
+	publicsharemgr.Register("fake", func(_ context.Context, c map[string]interface{}) (publicshare.Manager, error) {
+		constructed++
+		return &fakePublicShareManager{c: c}, nil
+	})
+	t.Cleanup(func() {
+		delete(publicsharemgr.NewFuncs, "fake")
+		ResetPublicShareManagers()
+	})
+
+	ctx := context.Background()
+	conf := map[string]map[string]interface{}{"fake": {"file": "/tmp/a.json", "janitor_run_interval": 60}}
+	sameConf := map[string]map[string]interface{}{"fake": {"janitor_run_interval": 60, "file": "/tmp/a.json"}}
+	otherConf := map[string]map[string]interface{}{"fake": {"file": "/tmp/b.json", "janitor_run_interval": 60}}
+
+	m1, err := GetCachedPublicShareManager(ctx, "fake", conf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m2, err := GetCachedPublicShareManager(ctx, "fake", sameConf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m1 != m2 {
+		t.Fatal("expected the same instance for the same configuration")
+	}
+
+	m3, err := GetCachedPublicShareManager(ctx, "fake", otherConf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m1 == m3 {
+		t.Fatal("expected distinct instances for different configurations")
+	}
+	if constructed != 2 {
+		t.Fatalf("expected 2 managers to be constructed, got %d", constructed)
+	}
+
+	ResetPublicShareManagers()
+	m4, err := GetCachedPublicShareManager(ctx, "fake", conf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m1 == m4 {
+		t.Fatal("expected a new instance after the reset")
+	}
+
+	if _, err := GetCachedPublicShareManager(ctx, "unknown", conf); err == nil {
+		t.Fatal("expected an error for an unknown driver")
+	}
+}
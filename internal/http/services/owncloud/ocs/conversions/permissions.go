@@ -71,3 +71,45 @@ func NewPermissions(val int) (Permissions, error) {
 func (p Permissions) Contain(other Permissions) bool {
 	return p&other == other
 }
+
+// parseAllowedPermissions returns the permissions that can be granted with the share
+// types, given by the name of the share type as in ParseShareTypes. The share types
+// missing from it can grant all the permissions, nil allows all of them with all types.
+func parseAllowedPermissions(masks map[string]int) (map[ShareType]Permissions, error) {
+	if len(masks) == 0 {
+		return nil, nil
+	}
+	allowed := make(map[ShareType]Permissions, len(masks))
+	for name, mask := range masks {
+		t, ok := shareTypeNames[name]
+		if !ok {
+			return nil, fmt.Errorf("conversions: unknown share type %s in the allowed permissions", name)
+		}
+		if mask < int(PermissionInvalid) || int(PermissionMax) < mask {
+			return nil, fmt.Errorf("conversions: allowed permissions %d of share type %s out of range", mask, name)
+		}
+		allowed[t] = Permissions(mask)
+	}
+	return allowed, nil
+}
+
+// RestrictsPermissions tells whether the permissions of any share type are restricted.
+func (c *Converter) RestrictsPermissions() bool {
+	return c != nil && c.allowedPermissions != nil
+}
+
+// CheckPermissions returns an error naming the permissions of p
+// that cannot be granted with the share type t.
+func (c *Converter) CheckPermissions(t ShareType, p Permissions) error {
+	if c == nil {
+		return nil
+	}
+	allowed, ok := c.allowedPermissions[t]
+	if !ok {
+		return nil
+	}
+	if denied := p &^ allowed; denied != PermissionInvalid {
+		return fmt.Errorf("permissions %d are not allowed for this share type, the allowed permissions are %d", denied, allowed)
+	}
+	return nil
+}
@@ -20,6 +20,8 @@ package conversions
 
 import (
 	"testing"
+
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
 )
 
 func TestNewPermissions(t *testing.T) {
@@ -157,3 +159,29 @@ func TestPermissions2Role(t *testing.T) {
 		checkRole(role, actual)
 	}
 }
+
+func TestCheckPermissions(t *testing.T) {
+	if _, err := NewConverter(&config.Config{AllowedPermissions: map[string]int{"unknown": 1}}); err == nil {
+		t.Error("expected an unknown share type to fail")
+	}
+	if _, err := NewConverter(&config.Config{AllowedPermissions: map[string]int{"public": 1000}}); err == nil {
+		t.Error("expected a mask out of range to fail")
+	}
+
+	c, err := NewConverter(&config.Config{AllowedPermissions: map[string]int{"public": int(PermissionRead)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.RestrictsPermissions() {
+		t.Error("expected the permissions to be restricted")
+	}
+	if err := c.CheckPermissions(ShareTypePublicLink, PermissionRead); err != nil {
+		t.Errorf("unexpected error for read only permissions: %v", err)
+	}
+	if err := c.CheckPermissions(ShareTypePublicLink, PermissionRead|PermissionWrite); err == nil {
+		t.Error("expected the write permission to be rejected on public links")
+	}
+	if err := c.CheckPermissions(ShareTypeUser, PermissionAll); err != nil {
+		t.Errorf("expected the user shares not to be restricted, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package conversions
+
+import (
+	"context"
+	"path"
+
+	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/publicshare"
+	"github.com/pkg/errors"
+)
+
+// StatFunc returns the metadata of a shared resource.
+type StatFunc func(ctx context.Context, id *provider.ResourceId) (*provider.ResourceInfo, error)
+
+// PublicLinkShareData resolves a public link token with the manager and returns
+// the share metadata that can be shown to anonymous users, e.g. on the landing
+// page of the link. The ids of the share, of its owner and of the shared resource
+// are left out, and the password is redacted.
+func (c *Converter) PublicLinkShareData(ctx context.Context, mgr publicshare.Manager, stat StatFunc, token string, auth *link.PublicShareAuthentication, publicURL string) (*ShareData, error) {
+	share, err := mgr.GetPublicShareByToken(ctx, token, auth, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "conversions: error resolving public link token")
+	}
+	if err := c.CheckStorageProvider(share.GetResourceId()); err != nil {
+		return nil, err
+	}
+	info, err := stat(ctx, share.ResourceId)
+	if err != nil {
+		return nil, errors.Wrap(err, "conversions: error stating public link resource")
+	}
+
+	sd := &ShareData{
+		ShareType:   ShareTypePublicLink,
+		Token:       share.Token,
+		Name:        share.DisplayName,
+		URL:         publicShareURL(publicURL, share.Token),
+		Description: share.Description,
+		ItemType:    ResourceType(info.GetType()).String(),
+		MimeType:    info.GetMimeType(),
+		FileTarget:  path.Join("/", path.Base(info.GetPath())),
+	}
+	if share.GetPermissions().GetPermissions() != nil {
+		sd.Permissions = RoleFromResourcePermissions(share.GetPermissions().GetPermissions()).OCSPermissions()
+	} else {
+		sd.Permissions = c.defaultPermissions()
+	}
+	if share.Expiration != nil {
+		sd.Expiration = c.timestampToExpiration(share.Expiration)
+	}
+	if share.PasswordProtected {
+		c.redactPassword(sd)
+	}
+	return sd, nil
+}
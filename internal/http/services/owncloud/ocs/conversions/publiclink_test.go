@@ -0,0 +1,88 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package conversions
+
+import (
+	"context"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/publicshare"
+)
+
+type tokenManager struct {
+	publicshare.Manager
+	share *link.PublicShare
+}
+
+func (m *tokenManager) GetPublicShareByToken(_ context.Context, token string, _ *link.PublicShareAuthentication, _ bool) (*link.PublicShare, error) {
+	if token != m.share.Token {
+		return nil, errtypes.NotFound(token)
+	}
+	return m.share, nil
+}
+
+func TestPublicLinkShareData(t *testing.T) {
+	mgr := &tokenManager{share: &link.PublicShare{
+		Id:                &link.PublicShareId{OpaqueId: "share-id"},
+		Token:             "token",
+		ResourceId:        &provider.ResourceId{StorageId: "storage", OpaqueId: "file-id"},
+		Owner:             &userpb.UserId{OpaqueId: "einstein", Idp: "cernbox"},
+		Creator:           &userpb.UserId{OpaqueId: "einstein", Idp: "cernbox"},
+		PasswordProtected: true,
+		DisplayName:       "link",
+	}}
+	stat := func(_ context.Context, id *provider.ResourceId) (*provider.ResourceInfo, error) {
+		return &provider.ResourceInfo{
+			Id:       id,
+			Type:     provider.ResourceType_RESOURCE_TYPE_FILE,
+			Path:     "/home/einstein/docs/report.pdf",
+			MimeType: "application/pdf",
+		}, nil
+	}
+
+	c := &Converter{}
+	sd, err := c.PublicLinkShareData(context.Background(), mgr, stat, "token", nil, "https://cloud.example.org")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if sd.ItemType != "file" || sd.FileTarget != "/report.pdf" || sd.MimeType != "application/pdf" || sd.Name != "link" {
+		t.Errorf("expected the item to be described, got %+v", sd)
+	}
+	if sd.URL != "https://cloud.example.org/s/token" {
+		t.Errorf("expected the link url, got %s", sd.URL)
+	}
+	if sd.ShareWith != "***redacted***" {
+		t.Errorf("expected the password to be redacted, got %q", sd.ShareWith)
+	}
+	if sd.ID != "" || sd.UIDOwner != "" || sd.UIDFileOwner != "" || sd.DisplaynameOwner != "" {
+		t.Errorf("expected the share and its owner not to be disclosed, got %+v", sd)
+	}
+	if sd.Path != "" || sd.StorageID != "" || sd.ItemSource != "" || sd.FileSource != "" {
+		t.Errorf("expected the resource ids not to be disclosed, got %+v", sd)
+	}
+
+	if _, err := c.PublicLinkShareData(context.Background(), mgr, stat, "unknown", nil, ""); err == nil {
+		t.Error("expected an unknown token to fail")
+	}
+}
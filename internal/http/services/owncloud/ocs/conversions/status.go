@@ -0,0 +1,92 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package conversions
+
+import (
+	"net/http"
+	"strings"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
+	"github.com/pkg/errors"
+)
+
+// defaultStatusCodes maps the CS3 status codes to the OCS status codes.
+// The ocs v2 api uses the 2xx, 4xx and 5xx codes as http status codes.
+var defaultStatusCodes = map[rpc.Code]int{
+	rpc.Code_CODE_OK:                   response.MetaOK.StatusCode,
+	rpc.Code_CODE_INVALID:              response.MetaUnknownError.StatusCode,
+	rpc.Code_CODE_UNKNOWN:              response.MetaUnknownError.StatusCode,
+	rpc.Code_CODE_CANCELLED:            response.MetaServerError.StatusCode,
+	rpc.Code_CODE_INTERNAL:             response.MetaServerError.StatusCode,
+	rpc.Code_CODE_DATA_LOSS:            response.MetaServerError.StatusCode,
+	rpc.Code_CODE_REDIRECTION:          response.MetaServerError.StatusCode,
+	rpc.Code_CODE_NOT_FOUND:            response.MetaNotFound.StatusCode,
+	rpc.Code_CODE_PERMISSION_DENIED:    response.MetaUnauthorized.StatusCode,
+	rpc.Code_CODE_UNAUTHENTICATED:      response.MetaUnauthorized.StatusCode,
+	rpc.Code_CODE_INVALID_ARGUMENT:     response.MetaBadRequest.StatusCode,
+	rpc.Code_CODE_FAILED_PRECONDITION:  response.MetaBadRequest.StatusCode,
+	rpc.Code_CODE_OUT_OF_RANGE:         response.MetaBadRequest.StatusCode,
+	rpc.Code_CODE_ALREADY_EXISTS:       http.StatusConflict,
+	rpc.Code_CODE_ABORTED:              http.StatusConflict,
+	rpc.Code_CODE_TOO_EARLY:            http.StatusTooEarly,
+	rpc.Code_CODE_LOCKED:               http.StatusLocked,
+	rpc.Code_CODE_RESOURCE_EXHAUSTED:   http.StatusTooManyRequests,
+	rpc.Code_CODE_UNIMPLEMENTED:        http.StatusNotImplemented,
+	rpc.Code_CODE_UNAVAILABLE:          http.StatusServiceUnavailable,
+	rpc.Code_CODE_DEADLINE_EXCEEDED:    http.StatusGatewayTimeout,
+	rpc.Code_CODE_INSUFFICIENT_STORAGE: http.StatusInsufficientStorage,
+}
+
+// parseStatusCodes returns the overrides of the OCS status codes returned for the
+// given CS3 status codes, named with or without the CODE_ prefix, e.g. "aborted".
+func parseStatusCodes(overrides map[string]int) (map[rpc.Code]int, error) {
+	codes := make(map[rpc.Code]int, len(overrides))
+	for name, sc := range overrides {
+		key := strings.ToUpper(name)
+		if !strings.HasPrefix(key, "CODE_") {
+			key = "CODE_" + key
+		}
+		c, ok := rpc.Code_value[key]
+		if !ok {
+			return nil, errors.Errorf("conversions: unknown cs3 status code %s", name)
+		}
+		if rpc.Code(c) == rpc.Code_CODE_OK {
+			return nil, errors.New("conversions: the status code of CODE_OK cannot be overridden")
+		}
+		if sc <= response.MetaOK.StatusCode {
+			return nil, errors.Errorf("conversions: invalid ocs status code %d for %s", sc, name)
+		}
+		codes[rpc.Code(c)] = sc
+	}
+	return codes, nil
+}
+
+// OCSStatusCode returns the OCS status code for the given CS3 status code.
+func (c *Converter) OCSStatusCode(code rpc.Code) int {
+	if c != nil {
+		if sc, ok := c.statusCodes[code]; ok {
+			return sc
+		}
+	}
+	if sc, ok := defaultStatusCodes[code]; ok {
+		return sc
+	}
+	return response.MetaServerError.StatusCode
+}
@@ -0,0 +1,99 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package conversions
+
+import (
+	"net/http"
+	"testing"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
+)
+
+func TestOCSStatusCode(t *testing.T) {
+	tests := map[rpc.Code]int{
+		rpc.Code_CODE_OK:                   100,
+		rpc.Code_CODE_INVALID:              999,
+		rpc.Code_CODE_UNKNOWN:              999,
+		rpc.Code_CODE_CANCELLED:            996,
+		rpc.Code_CODE_INTERNAL:             996,
+		rpc.Code_CODE_DATA_LOSS:            996,
+		rpc.Code_CODE_REDIRECTION:          996,
+		rpc.Code_CODE_NOT_FOUND:            998,
+		rpc.Code_CODE_PERMISSION_DENIED:    997,
+		rpc.Code_CODE_UNAUTHENTICATED:      997,
+		rpc.Code_CODE_INVALID_ARGUMENT:     400,
+		rpc.Code_CODE_FAILED_PRECONDITION:  400,
+		rpc.Code_CODE_OUT_OF_RANGE:         400,
+		rpc.Code_CODE_ALREADY_EXISTS:       409,
+		rpc.Code_CODE_ABORTED:              409,
+		rpc.Code_CODE_TOO_EARLY:            425,
+		rpc.Code_CODE_LOCKED:               423,
+		rpc.Code_CODE_RESOURCE_EXHAUSTED:   429,
+		rpc.Code_CODE_UNIMPLEMENTED:        501,
+		rpc.Code_CODE_UNAVAILABLE:          503,
+		rpc.Code_CODE_DEADLINE_EXCEEDED:    504,
+		rpc.Code_CODE_INSUFFICIENT_STORAGE: 507,
+		rpc.Code(1000):                     996,
+	}
+	for name := range rpc.Code_value {
+		if _, ok := tests[rpc.Code(rpc.Code_value[name])]; !ok {
+			t.Errorf("no expected ocs status code for %s", name)
+		}
+	}
+
+	c := &Converter{}
+	for code, expected := range tests {
+		if sc := c.OCSStatusCode(code); sc != expected {
+			t.Errorf("expected ocs status code %d for %s, got %d", expected, code, sc)
+		}
+	}
+}
+
+func TestStatusCodesOverrides(t *testing.T) {
+	c, err := NewConverter(&config.Config{StatusCodes: map[string]int{"aborted": 996, "CODE_LOCKED": 400}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sc := c.OCSStatusCode(rpc.Code_CODE_ABORTED); sc != response.MetaServerError.StatusCode {
+		t.Errorf("expected the overridden status code %d, got %d", response.MetaServerError.StatusCode, sc)
+	}
+	if sc := c.OCSStatusCode(rpc.Code_CODE_LOCKED); sc != http.StatusBadRequest {
+		t.Errorf("expected the overridden status code %d, got %d", http.StatusBadRequest, sc)
+	}
+	if sc := c.OCSStatusCode(rpc.Code_CODE_UNIMPLEMENTED); sc != http.StatusNotImplemented {
+		t.Errorf("expected the default status code %d, got %d", http.StatusNotImplemented, sc)
+	}
+
+	for _, overrides := range []map[string]int{
+		{"no_such_code": 400},
+		{"ok": 400},
+		{"aborted": 100},
+	} {
+		if _, err := NewConverter(&config.Config{StatusCodes: overrides}); err == nil {
+			t.Errorf("expected an error for %v", overrides)
+		}
+	}
+
+	// the overrides of a converter do not leak into the others
+	if sc := (&Converter{}).OCSStatusCode(rpc.Code_CODE_ABORTED); sc != http.StatusConflict {
+		t.Errorf("expected the default status code %d, got %d", http.StatusConflict, sc)
+	}
+}
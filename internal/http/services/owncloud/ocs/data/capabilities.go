@@ -193,7 +193,9 @@ type CapabilitiesFilesSharingPublicPasswordEnforcedFor struct {
 
 // CapabilitiesFilesSharingPublicExpireDate TODO document.
 type CapabilitiesFilesSharingPublicExpireDate struct {
-	Enabled ocsBool `json:"enabled" xml:"enabled"`
+	Enabled  ocsBool `json:"enabled"        xml:"enabled"`
+	Enforced ocsBool `json:"enforced"       xml:"enforced"`
+	Days     int     `json:"days,omitempty" mapstructure:"days" xml:"days,omitempty"`
 }
 
 // CapabilitiesFilesSharingUser TODO document.
@@ -19,28 +19,45 @@
 package sharees
 
 import (
+	"context"
 	"net/http"
+	"strings"
 
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	invitepb "github.com/cs3org/go-cs3apis/cs3/ocm/invite/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/cs3org/reva/pkg/storage/utils/templates"
+	"github.com/pkg/errors"
+)
+
+// Sources of the additional info shown next to sharees.
+const (
+	additionalInfoEmail    = "email"
+	additionalInfoUsername = "username"
+	additionalInfoUID      = "uid"
 )
 
 // Handler implements the ownCloud sharing API.
 type Handler struct {
 	gatewayAddr             string
 	additionalInfoAttribute string
+	additionalInfoSource    string
+	searchLimit             int
 }
 
 // Init initializes this and any contained handlers.
 func (h *Handler) Init(c *config.Config) {
 	h.gatewayAddr = c.GatewaySvc
 	h.additionalInfoAttribute = c.AdditionalInfoAttribute
+	h.additionalInfoSource = strings.ToLower(c.AdditionalInfoSource)
+	h.searchLimit = c.ShareeSearchLimit
 }
 
 // FindSharees implements the /apps/files_sharing/api/v1/sharees endpoint.
@@ -66,12 +83,25 @@ func (h *Handler) FindSharees(w http.ResponseWriter, r *http.Request) {
 	log.Debug().Int("count", len(usersRes.GetUsers())).Str("search", term).Msg("users found")
 
 	userMatches := make([]*conversions.MatchData, 0, len(usersRes.GetUsers()))
+	remoteMatches := []*conversions.MatchData{}
 	for _, user := range usersRes.GetUsers() {
 		match := h.userAsMatch(user)
 		log.Debug().Interface("user", user).Interface("match", match).Msg("mapped")
+		if match.Value.ShareType == int(conversions.ShareTypeFederatedCloudShare) {
+			remoteMatches = append(remoteMatches, match)
+			continue
+		}
 		userMatches = append(userMatches, match)
 	}
 
+	contacts, err := h.findRemotes(r.Context(), gwc, term)
+	if err != nil {
+		// federated sharing may not be available, only local sharees are returned
+		log.Debug().Err(err).Str("search", term).Msg("error searching federated contacts")
+	}
+	remoteMatches = dedupRemotes(append(remoteMatches, contacts...), userMatches)
+	log.Debug().Int("count", len(remoteMatches)).Str("search", term).Msg("remotes found")
+
 	groupsRes, err := gwc.FindGroups(r.Context(), &grouppb.FindGroupsRequest{Filter: term, SkipFetchingMembers: true})
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error searching groups", err)
@@ -86,16 +116,48 @@ func (h *Handler) FindSharees(w http.ResponseWriter, r *http.Request) {
 		groupMatches = append(groupMatches, match)
 	}
 
-	response.WriteOCSSuccess(w, r, &conversions.ShareeData{
+	response.WriteOCSSuccess(w, r, h.shareeData(term, userMatches, groupMatches, remoteMatches))
+}
+
+// shareeData moves the matches equal to the search term to the exact
+// matches and caps the remaining ones to the configured search limit.
+// Exact matches are never capped.
+func (h *Handler) shareeData(term string, users, groups, remotes []*conversions.MatchData) *conversions.ShareeData {
+	exactUsers, users := splitExactMatches(term, users)
+	exactGroups, groups := splitExactMatches(term, groups)
+	exactRemotes, remotes := splitExactMatches(term, remotes)
+	return &conversions.ShareeData{
 		Exact: &conversions.ExactMatchesData{
-			Users:   []*conversions.MatchData{},
-			Groups:  []*conversions.MatchData{},
-			Remotes: []*conversions.MatchData{},
+			Users:   exactUsers,
+			Groups:  exactGroups,
+			Remotes: exactRemotes,
 		},
-		Users:   userMatches,
-		Groups:  groupMatches,
-		Remotes: []*conversions.MatchData{},
-	})
+		Users:   h.capMatches(users),
+		Groups:  h.capMatches(groups),
+		Remotes: h.capMatches(remotes),
+	}
+}
+
+// splitExactMatches separates the matches whose share with or label
+// equal the search term, ignoring the case, from the other ones.
+func splitExactMatches(term string, matches []*conversions.MatchData) (exact, fuzzy []*conversions.MatchData) {
+	exact = []*conversions.MatchData{}
+	fuzzy = make([]*conversions.MatchData, 0, len(matches))
+	for _, m := range matches {
+		if strings.EqualFold(m.Value.ShareWith, term) || strings.EqualFold(m.Label, term) {
+			exact = append(exact, m)
+		} else {
+			fuzzy = append(fuzzy, m)
+		}
+	}
+	return exact, fuzzy
+}
+
+func (h *Handler) capMatches(matches []*conversions.MatchData) []*conversions.MatchData {
+	if h.searchLimit > 0 && len(matches) > h.searchLimit {
+		return matches[:h.searchLimit]
+	}
+	return matches
 }
 
 func (h *Handler) userAsMatch(u *userpb.User) *conversions.MatchData {
@@ -121,17 +183,98 @@ func (h *Handler) userAsMatch(u *userpb.User) *conversions.MatchData {
 	}
 }
 
+// findRemotes looks up the federated contacts of the user matching the term.
+// A term of the form user@idp only matches the contacts of that provider.
+func (h *Handler) findRemotes(ctx context.Context, gwc gateway.GatewayAPIClient, term string) ([]*conversions.MatchData, error) {
+	filter, idp := term, ""
+	if i := strings.LastIndex(term, "@"); i > 0 && i < len(term)-1 {
+		filter, idp = term[:i], term[i+1:]
+	}
+
+	res, err := gwc.FindAcceptedUsers(ctx, &invitepb.FindAcceptedUsersRequest{Filter: filter})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, errors.New(res.Status.Message)
+	}
+
+	matches := make([]*conversions.MatchData, 0, len(res.AcceptedUsers))
+	for _, u := range res.AcceptedUsers {
+		if idp != "" && !strings.Contains(strings.ToLower(u.GetId().GetIdp()), strings.ToLower(idp)) && !strings.EqualFold(u.Mail, term) {
+			continue
+		}
+		match := h.userAsMatch(u)
+		match.Value.ShareType = int(conversions.ShareTypeFederatedCloudShare)
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+// dedupRemotes removes the remote matches listed twice
+// or referring to a user already matched locally.
+func dedupRemotes(remotes, users []*conversions.MatchData) []*conversions.MatchData {
+	seen := make(map[string]bool, len(remotes)+len(users))
+	key := func(m *conversions.MatchData) string {
+		return m.Value.ShareWith + "@" + m.Value.ShareWithProvider
+	}
+	for _, u := range users {
+		seen[key(u)] = true
+	}
+
+	deduped := make([]*conversions.MatchData, 0, len(remotes))
+	for _, m := range remotes {
+		if seen[key(m)] {
+			continue
+		}
+		seen[key(m)] = true
+		deduped = append(deduped, m)
+	}
+	return deduped
+}
+
 func (h *Handler) groupAsMatch(g *grouppb.Group) *conversions.MatchData {
+	additionalInfo := g.Mail
+	if h.additionalInfoSource != "" {
+		additionalInfo = h.additionalInfo(g.Mail, g.GroupName, g.GetId().GetOpaqueId())
+	}
 	return &conversions.MatchData{
 		Label: g.DisplayName,
 		Value: &conversions.MatchValueData{
 			ShareType:               int(conversions.ShareTypeGroup),
 			ShareWith:               g.GroupName,
-			ShareWithAdditionalInfo: g.Mail,
+			ShareWithAdditionalInfo: additionalInfo,
 		},
 	}
 }
 
 func (h *Handler) getAdditionalInfoAttribute(u *userpb.User) string {
-	return templates.WithUser(u, h.additionalInfoAttribute)
+	if h.additionalInfoSource == "" {
+		return templates.WithUser(u, h.additionalInfoAttribute)
+	}
+	return h.additionalInfo(u.Mail, u.Username, u.GetId().GetOpaqueId())
+}
+
+// additionalInfo returns the attribute selected by the configured source.
+// When it is empty the first non empty one among the email, the username
+// and the uid is used instead.
+func (h *Handler) additionalInfo(email, username, uid string) string {
+	var selected string
+	switch h.additionalInfoSource {
+	case additionalInfoUsername:
+		selected = username
+	case additionalInfoUID:
+		selected = uid
+	default:
+		selected = email
+	}
+	if selected != "" {
+		return selected
+	}
+	for _, v := range []string{email, username, uid} {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
 }
@@ -0,0 +1,171 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sharees
+
+import (
+	"context"
+	"testing"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	invitepb "github.com/cs3org/go-cs3apis/cs3/ocm/invite/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
+	"google.golang.org/grpc"
+)
+
+func TestAdditionalInfoSource(t *testing.T) {
+	einstein := &userpb.User{
+		Id:       &userpb.UserId{OpaqueId: "4c510ada", Idp: "cernbox.cern.ch"},
+		Username: "einstein",
+		Mail:     "einstein@example.org",
+	}
+	marie := &userpb.User{
+		Id:       &userpb.UserId{OpaqueId: "f7fbf8c8", Idp: "cernbox.cern.ch"},
+		Username: "marie",
+	}
+	physics := &grouppb.Group{
+		Id:        &grouppb.GroupId{OpaqueId: "a1726108"},
+		GroupName: "physics-lovers",
+	}
+
+	for _, tt := range []struct {
+		source string
+		user   *userpb.User
+		group  *grouppb.Group
+		want   string
+	}{
+		{source: "email", user: einstein, want: "einstein@example.org"},
+		{source: "username", user: einstein, want: "einstein"},
+		{source: "uid", user: einstein, want: "4c510ada"},
+		{source: "Username", user: einstein, want: "einstein"},
+		// marie has no mail, the username is used instead
+		{source: "email", user: marie, want: "marie"},
+		{source: "uid", group: physics, want: "a1726108"},
+		{source: "email", group: physics, want: "physics-lovers"},
+		// without a source the template is used for users and the mail for groups
+		{source: "", user: einstein, want: "einstein@example.org"},
+		{source: "", group: physics, want: ""},
+	} {
+		c := &config.Config{AdditionalInfoSource: tt.source}
+		c.ApplyDefaults()
+		h := &Handler{}
+		h.Init(c)
+
+		var got string
+		if tt.user != nil {
+			got = h.userAsMatch(tt.user).Value.ShareWithAdditionalInfo
+		} else {
+			got = h.groupAsMatch(tt.group).Value.ShareWithAdditionalInfo
+		}
+		if got != tt.want {
+			t.Errorf("source %q: expected additional info %q, got %q", tt.source, tt.want, got)
+		}
+	}
+}
+
+func TestShareeDataExactMatches(t *testing.T) {
+	c := &config.Config{ShareeSearchLimit: 2}
+	c.ApplyDefaults()
+	h := &Handler{}
+	h.Init(c)
+
+	users := []*conversions.MatchData{}
+	for _, name := range []string{"einstein-junior", "Einstein", "einsteinium", "einstein2", "einstein3"} {
+		users = append(users, h.userAsMatch(&userpb.User{
+			Id:       &userpb.UserId{OpaqueId: name, Idp: "cernbox.cern.ch"},
+			Username: name,
+		}))
+	}
+	groups := []*conversions.MatchData{h.groupAsMatch(&grouppb.Group{GroupName: "einstein-fans"})}
+
+	data := h.shareeData("einstein", users, groups, nil)
+
+	if len(data.Exact.Users) != 1 || data.Exact.Users[0].Value.ShareWith != "Einstein" {
+		t.Fatalf("expected Einstein as the only exact user match, got %+v", data.Exact.Users)
+	}
+	if len(data.Users) != 2 {
+		t.Fatalf("expected the fuzzy users to be capped to 2, got %d", len(data.Users))
+	}
+	for _, m := range data.Users {
+		if m.Value.ShareWith == "Einstein" {
+			t.Errorf("expected Einstein not to be listed in the fuzzy matches")
+		}
+	}
+	if len(data.Exact.Groups) != 0 || len(data.Groups) != 1 {
+		t.Errorf("expected one fuzzy group match, got %d exact and %d fuzzy", len(data.Exact.Groups), len(data.Groups))
+	}
+}
+
+type contactsGatewayClient struct {
+	gateway.GatewayAPIClient
+	contacts []*userpb.User
+	filter   string
+}
+
+func (c *contactsGatewayClient) FindAcceptedUsers(_ context.Context, req *invitepb.FindAcceptedUsersRequest, _ ...grpc.CallOption) (*invitepb.FindAcceptedUsersResponse, error) {
+	c.filter = req.Filter
+	return &invitepb.FindAcceptedUsersResponse{
+		Status:        &rpc.Status{Code: rpc.Code_CODE_OK},
+		AcceptedUsers: c.contacts,
+	}, nil
+}
+
+func TestFindRemotes(t *testing.T) {
+	c := &config.Config{}
+	c.ApplyDefaults()
+	h := &Handler{}
+	h.Init(c)
+
+	gwc := &contactsGatewayClient{contacts: []*userpb.User{
+		{Id: &userpb.UserId{OpaqueId: "marie", Idp: "cesnet.cz", Type: userpb.UserType_USER_TYPE_FEDERATED}, DisplayName: "Marie Curie"},
+		{Id: &userpb.UserId{OpaqueId: "marie", Idp: "surfsara.nl", Type: userpb.UserType_USER_TYPE_FEDERATED}, DisplayName: "Marie Curie"},
+	}}
+
+	remotes, err := h.findRemotes(context.Background(), gwc, "marie@cesnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gwc.filter != "marie" {
+		t.Errorf("expected the contacts to be searched by the user part, got %q", gwc.filter)
+	}
+	if len(remotes) != 1 || remotes[0].Value.ShareWithProvider != "cesnet.cz" {
+		t.Fatalf("expected only the cesnet.cz contact, got %+v", remotes)
+	}
+	if remotes[0].Value.ShareType != int(conversions.ShareTypeFederatedCloudShare) {
+		t.Errorf("expected share type %d, got %d", conversions.ShareTypeFederatedCloudShare, remotes[0].Value.ShareType)
+	}
+
+	remotes, err = h.findRemotes(context.Background(), gwc, "marie")
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := []*conversions.MatchData{{Value: &conversions.MatchValueData{ShareWith: "marie", ShareWithProvider: "surfsara.nl"}}}
+	remotes = dedupRemotes(append(remotes, remotes[0]), local)
+	if len(remotes) != 1 || remotes[0].Value.ShareWithProvider != "cesnet.cz" {
+		t.Fatalf("expected the duplicated and the local contacts to be removed, got %+v", remotes)
+	}
+
+	data := h.shareeData("marie", nil, nil, remotes)
+	if len(data.Exact.Remotes) != 1 || data.Exact.Remotes[0].Value.ShareType != int(conversions.ShareTypeFederatedCloudShare) {
+		t.Errorf("expected marie as exact remote match, got %+v", data.Exact.Remotes)
+	}
+}
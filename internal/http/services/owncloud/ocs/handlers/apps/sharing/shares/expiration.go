@@ -0,0 +1,45 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package shares
+
+import (
+	"time"
+
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/utils"
+)
+
+// checkExpiration tells whether a share expiring at exp is listed, and whether
+// it is flagged as expired. Without a grace period the shares are listed as
+// returned by the share managers. With one, the shares expired for less than
+// the grace period are listed and flagged, the older ones are left out.
+func (h *Handler) checkExpiration(exp *types.Timestamp) (listed, expired bool) {
+	if h.expiredShareGrace <= 0 || exp == nil {
+		return true, false
+	}
+	now := time.Now
+	if h.now != nil {
+		now = h.now
+	}
+	since := now().Sub(utils.TSToTime(exp))
+	if since <= 0 {
+		return true, false
+	}
+	return since <= h.expiredShareGrace, true
+}
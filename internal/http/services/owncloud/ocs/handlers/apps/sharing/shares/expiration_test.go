@@ -0,0 +1,142 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package shares
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ReneKroon/ttlcache/v2"
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"google.golang.org/grpc"
+)
+
+// linksGateway lists a fixed set of public links, all on the same file.
+type linksGateway struct {
+	gateway.UnimplementedGatewayAPIServer
+	mu    sync.Mutex
+	links []*link.PublicShare
+}
+
+func (g *linksGateway) ListPublicShares(context.Context, *link.ListPublicSharesRequest) (*link.ListPublicSharesResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return &link.ListPublicSharesResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, Share: g.links}, nil
+}
+
+func (g *linksGateway) CreatePublicShare(_ context.Context, req *link.CreatePublicShareRequest) (*link.CreatePublicShareResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	token := fmt.Sprintf("link-%d", len(g.links))
+	share := &link.PublicShare{
+		Id:         &link.PublicShareId{OpaqueId: token},
+		Token:      token,
+		ResourceId: req.ResourceInfo.Id,
+	}
+	g.links = append(g.links, share)
+	return &link.CreatePublicShareResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, Share: share}, nil
+}
+
+func (g *linksGateway) Stat(_ context.Context, req *provider.StatRequest) (*provider.StatResponse, error) {
+	return &provider.StatResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		Info: &provider.ResourceInfo{
+			Id:   req.Ref.ResourceId,
+			Type: provider.ResourceType_RESOURCE_TYPE_FILE,
+			Path: "/home/file.txt",
+		},
+	}, nil
+}
+
+func TestExpiredShareGracePeriod(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	expiring := func(token string, offset time.Duration) *link.PublicShare {
+		return &link.PublicShare{
+			Id:         &link.PublicShareId{OpaqueId: token},
+			Token:      token,
+			ResourceId: &provider.ResourceId{StorageId: "storage", OpaqueId: "file"},
+			Expiration: &types.Timestamp{Seconds: uint64(now.Add(offset).Unix())},
+		}
+	}
+	gw := &linksGateway{links: []*link.PublicShare{
+		{Id: &link.PublicShareId{OpaqueId: "never"}, Token: "never", ResourceId: &provider.ResourceId{StorageId: "storage", OpaqueId: "file"}},
+		expiring("future", time.Hour),
+		expiring("just-expired", -time.Minute),
+		expiring("in-grace", -59*time.Minute),
+		expiring("past-grace", -2*time.Hour),
+	}}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	gateway.RegisterGatewayAPIServer(srv, gw)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	for _, tt := range []struct {
+		grace    time.Duration
+		expected map[string]bool
+	}{
+		{
+			// the shares are listed as returned by the share manager
+			grace:    0,
+			expected: map[string]bool{"never": false, "future": false, "just-expired": false, "in-grace": false, "past-grace": false},
+		},
+		{
+			grace:    time.Hour,
+			expected: map[string]bool{"never": false, "future": false, "just-expired": true, "in-grace": true},
+		},
+	} {
+		h := &Handler{
+			gatewayAddr:         lis.Addr().String(),
+			userIdentifierCache: ttlcache.NewCache(),
+			expiredShareGrace:   tt.grace,
+			now:                 func() time.Time { return now },
+		}
+
+		shares, status, err := h.listPublicShares(httptest.NewRequest("GET", "/", nil), nil)
+		if err != nil || status != nil {
+			t.Fatalf("grace %s: unexpected error: %v %v", tt.grace, status, err)
+		}
+
+		listed := map[string]bool{}
+		for _, s := range shares {
+			listed[s.Token] = s.Expired
+		}
+		if len(listed) != len(tt.expected) {
+			t.Errorf("grace %s: expected the links %v, got %v", tt.grace, tt.expected, listed)
+		}
+		for token, expired := range tt.expected {
+			if got, ok := listed[token]; !ok || got != expired {
+				t.Errorf("grace %s: expected %s to be listed with expired %t, got listed %t expired %t", tt.grace, token, expired, ok, got)
+			}
+		}
+	}
+}
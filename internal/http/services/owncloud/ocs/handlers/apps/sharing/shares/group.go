@@ -83,7 +83,7 @@ func (h *Handler) createGroupShare(w http.ResponseWriter, r *http.Request, statI
 		},
 	}
 
-	if shareID, ok := h.createCs3Share(ctx, w, r, c, createShareReq, statInfo); ok {
+	if shareID, ok := h.createCs3Share(ctx, w, r, c, createShareReq, statInfo, groupRes.Group.Mail); ok {
 		notify, _ := strconv.ParseBool(r.FormValue("notify"))
 		if notify {
 			granter, ok := appctx.ContextGetUser(ctx)
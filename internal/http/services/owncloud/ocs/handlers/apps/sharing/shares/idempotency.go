@@ -0,0 +1,125 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package shares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"net/http"
+
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
+	"github.com/cs3org/reva/pkg/appctx"
+)
+
+// HeaderIdempotencyKey is the header with which the clients retrying a share
+// creation get the response to their first attempt instead of a second share.
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// recordedResponse is the response to a share creation, kept for its idempotency key.
+type recordedResponse struct {
+	// request is the hash of the request that got the response
+	request [sha256.Size]byte
+	status  int
+	header  http.Header
+	body    []byte
+}
+
+func (rr *recordedResponse) write(w http.ResponseWriter) {
+	for k, v := range rr.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(rr.status)
+	_, _ = w.Write(rr.body)
+}
+
+// responseRecorder records the response written by a handler.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header { return r.header }
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+// createShareOnce creates a share for the first request with the idempotency key,
+// and returns the same response to the later ones, and to the concurrent ones, of
+// the same user until the key expires. The server errors are not kept, so that the
+// creation can be retried. A request reusing the key with different parameters is
+// rejected.
+func (h *Handler) createShareOnce(w http.ResponseWriter, r *http.Request, key string) {
+	if u, ok := appctx.ContextGetUser(r.Context()); ok {
+		key = u.GetId().GetIdp() + "!" + u.GetId().GetOpaqueId() + "!" + key
+	}
+	if err := r.ParseForm(); err != nil {
+		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "error parsing the request", err)
+		return
+	}
+	request := requestHash(r)
+
+	if v, err := h.idempotencyKeys.Get(key); err == nil {
+		writeRecorded(w, r, v.(*recordedResponse), request)
+		return
+	}
+
+	v, _, _ := h.idempotencyCalls.Do(key, func() (interface{}, error) {
+		// a call with the same key may have completed since the lookup above
+		if v, err := h.idempotencyKeys.Get(key); err == nil {
+			return v, nil
+		}
+		rec := &responseRecorder{header: http.Header{}}
+		h.createShare(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		res := &recordedResponse{request: request, status: rec.status, header: rec.header, body: rec.body.Bytes()}
+		if res.status < http.StatusInternalServerError {
+			_ = h.idempotencyKeys.Set(key, res)
+		}
+		return res, nil
+	})
+	writeRecorded(w, r, v.(*recordedResponse), request)
+}
+
+// writeRecorded writes the recorded response, if it was given to the same request.
+func writeRecorded(w http.ResponseWriter, r *http.Request, res *recordedResponse, request [sha256.Size]byte) {
+	if res.request != request {
+		response.WriteOCSError(w, r, http.StatusUnprocessableEntity, "the idempotency key was already used with a different request", nil)
+		return
+	}
+	res.write(w)
+}
+
+// requestHash returns the hash of the parameters of a parsed request,
+// regardless of their order and of their encoding in the query or body.
+func requestHash(r *http.Request) [sha256.Size]byte {
+	return sha256.Sum256([]byte(r.Form.Encode()))
+}
@@ -0,0 +1,113 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package shares
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ReneKroon/ttlcache/v2"
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
+	"google.golang.org/grpc"
+)
+
+// shareableGateway is a linksGateway whose resources can be shared.
+type shareableGateway struct {
+	linksGateway
+}
+
+func (g *shareableGateway) Stat(_ context.Context, req *provider.StatRequest) (*provider.StatResponse, error) {
+	return &provider.StatResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		Info: &provider.ResourceInfo{
+			Id:            &provider.ResourceId{StorageId: "storage", OpaqueId: "folder"},
+			Type:          provider.ResourceType_RESOURCE_TYPE_CONTAINER,
+			Path:          req.Ref.Path,
+			PermissionSet: conversions.NewManagerRole().CS3ResourcePermissions(),
+		},
+	}, nil
+}
+
+func TestCreateShareIdempotencyKey(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := &shareableGateway{}
+	srv := grpc.NewServer()
+	gateway.RegisterGatewayAPIServer(srv, gw)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	h := &Handler{
+		gatewayAddr:         lis.Addr().String(),
+		homeNamespace:       "/home",
+		userIdentifierCache: ttlcache.NewCache(),
+		idempotencyKeys:     ttlcache.NewCache(),
+	}
+
+	createWith := func(key, path string) *httptest.ResponseRecorder {
+		form := url.Values{"shareType": {"3"}, "path": {path}}
+		r := httptest.NewRequest(http.MethodPost, "/apps/files_sharing/api/v1/shares?format=json", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if key != "" {
+			r.Header.Set(HeaderIdempotencyKey, key)
+		}
+		w := httptest.NewRecorder()
+		h.CreateShare(w, r)
+		return w
+	}
+	create := func(key string) *httptest.ResponseRecorder {
+		return createWith(key, "/folder")
+	}
+
+	first := create("key")
+	if !strings.Contains(first.Body.String(), `"statuscode":100`) {
+		t.Fatalf("expected the share to be created, got %s", first.Body.String())
+	}
+	second := create("key")
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Errorf("expected the same response for the same key, got %d %s and %d %s", first.Code, first.Body.String(), second.Code, second.Body.String())
+	}
+	if n := len(gw.links); n != 1 {
+		t.Errorf("expected a single share for the same key, got %d", n)
+	}
+
+	mismatch := createWith("key", "/other")
+	if mismatch.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected a different request with the same key to be rejected, got %d %s", mismatch.Code, mismatch.Body.String())
+	}
+	if n := len(gw.links); n != 1 {
+		t.Errorf("expected no share for a different request with the same key, got %d shares", n)
+	}
+
+	create("other")
+	create("")
+	if n := len(gw.links); n != 3 {
+		t.Errorf("expected a share for another key and for no key, got %d shares", n)
+	}
+}
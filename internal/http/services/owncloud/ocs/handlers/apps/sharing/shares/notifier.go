@@ -0,0 +1,93 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package shares
+
+import (
+	"context"
+	"time"
+
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/rs/zerolog"
+)
+
+// ShareNotifier is notified of the shares created through the OCS api,
+// e.g. to mail the recipient a message rendered from a custom template.
+type ShareNotifier interface {
+	ShareCreated(ctx context.Context, share *conversions.ShareData, recipient string) error
+}
+
+// NewShareNotifierFunc is the function that share notifier implementations
+// should register at init time.
+type NewShareNotifierFunc func(map[string]interface{}) (ShareNotifier, error)
+
+var shareNotifiers = map[string]NewShareNotifierFunc{}
+
+// RegisterShareNotifier registers a new share notifier function.
+// Not safe for concurrent use. Safe for use from package init.
+func RegisterShareNotifier(name string, f NewShareNotifierFunc) {
+	shareNotifiers[name] = f
+}
+
+type noopShareNotifier struct{}
+
+func (noopShareNotifier) ShareCreated(context.Context, *conversions.ShareData, string) error {
+	return nil
+}
+
+// getShareNotifier returns the configured share notifier, falling back to
+// a no-op one when none is configured or it cannot be initialized.
+func getShareNotifier(c *config.Config, l *zerolog.Logger) ShareNotifier {
+	if c.ShareNotifier == "" {
+		return noopShareNotifier{}
+	}
+	f, ok := shareNotifiers[c.ShareNotifier]
+	if !ok {
+		l.Error().Str("notifier", c.ShareNotifier).Msg("share notifier not found")
+		return noopShareNotifier{}
+	}
+	n, err := f(c.ShareNotifiers[c.ShareNotifier])
+	if err != nil {
+		l.Error().Err(err).Str("notifier", c.ShareNotifier).Msg("error initializing the share notifier")
+		return noopShareNotifier{}
+	}
+	return n
+}
+
+// shareNotifierTimeout bounds the notification of a share creation.
+const shareNotifierTimeout = 30 * time.Second
+
+// notifyShareCreated calls the share notifier in the background, so that
+// a slow notifier does not delay the response. Its failures are only
+// logged as the share has already been created.
+func (h *Handler) notifyShareCreated(ctx context.Context, s *conversions.ShareData, recipient string) {
+	if h.shareNotifier == nil {
+		return
+	}
+	// the notification outlives the request, it keeps the values
+	// of its context but not its cancellation
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shareNotifierTimeout)
+	go func() {
+		defer cancel()
+		if err := h.shareNotifier.ShareCreated(ctx, s, recipient); err != nil {
+			appctx.GetLogger(ctx).Error().Err(err).Str("share", s.ID).Msg("error notifying the share creation")
+		}
+	}()
+}
@@ -0,0 +1,119 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package shares
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/ReneKroon/ttlcache/v2"
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
+	"github.com/cs3org/reva/pkg/appctx"
+	"google.golang.org/grpc"
+)
+
+type shareNotification struct {
+	share     *conversions.ShareData
+	recipient string
+	deadline  bool
+}
+
+// recordingNotifier sends the notifications on notified and fails with err.
+type recordingNotifier struct {
+	notified chan shareNotification
+	err      error
+}
+
+func (n *recordingNotifier) ShareCreated(ctx context.Context, share *conversions.ShareData, recipient string) error {
+	_, deadline := ctx.Deadline()
+	err := n.err
+	n.notified <- shareNotification{share: share, recipient: recipient, deadline: deadline}
+	return err
+}
+
+func TestShareNotifier(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := &usersGateway{}
+	srv := grpc.NewServer()
+	gateway.RegisterGatewayAPIServer(srv, gw)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	notifier := &recordingNotifier{notified: make(chan shareNotification, 1)}
+	h := &Handler{
+		gatewayAddr:            lis.Addr().String(),
+		homeNamespace:          "/home",
+		userIdentifierCache:    ttlcache.NewCache(),
+		additionalInfoTemplate: template.Must(template.New("additionalInfo").Parse("{{.Mail}}")),
+		shareNotifier:          notifier,
+	}
+
+	create := func() string {
+		form := url.Values{"shareType": {"0"}, "path": {"/folder"}, "shareWith": {"marie"}, "permissions": {"1"}}
+		r := httptest.NewRequest(http.MethodPost, "/apps/files_sharing/api/v1/shares?format=json", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		ctx := appctx.ContextSetUser(r.Context(), &userpb.User{Id: &userpb.UserId{Idp: "idp", OpaqueId: "einstein"}, Username: "einstein"})
+		w := httptest.NewRecorder()
+		h.CreateShare(w, r.WithContext(ctx))
+		return w.Body.String()
+	}
+
+	if body := create(); !strings.Contains(body, `"statuscode":100`) {
+		t.Fatalf("expected the share to be created, got %s", body)
+	}
+	var n shareNotification
+	select {
+	case n = <-notifier.notified:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a notification")
+	}
+	if n.recipient != "marie@example.org" {
+		t.Errorf("expected the grantee to be notified, got %s", n.recipient)
+	}
+	if n.share.ID != "share" || n.share.ShareType != conversions.ShareTypeUser || n.share.Path != "/folder" {
+		t.Errorf("expected the created share data, got %+v", n.share)
+	}
+	if !n.deadline {
+		t.Error("expected the notification to be bounded by a deadline")
+	}
+
+	// a failed notification does not fail the share creation
+	notifier.err = errors.New("smtp server unavailable")
+	if body := create(); !strings.Contains(body, `"statuscode":100`) {
+		t.Errorf("expected the share to be created, got %s", body)
+	}
+	select {
+	case <-notifier.notified:
+	case <-time.After(5 * time.Second):
+		t.Error("expected a second notification")
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package shares
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
+)
+
+// defaultSharesPerPage is the page size used when only the page is requested.
+const defaultSharesPerPage = 100
+
+// pagination holds the paging parameters of a shares listing.
+type pagination struct {
+	page    int
+	perPage int
+}
+
+// getPagination parses the page and per_page parameters of the request.
+// It returns nil when none of them is set, meaning the full list is requested.
+func getPagination(r *http.Request) (*pagination, error) {
+	q := r.URL.Query()
+	page, perPage := q.Get("page"), q.Get("per_page")
+	if page == "" && perPage == "" {
+		return nil, nil
+	}
+
+	p := &pagination{page: 1, perPage: defaultSharesPerPage}
+	if page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid page %q", page)
+		}
+		p.page = n
+	}
+	if perPage != "" {
+		n, err := strconv.Atoi(perPage)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid per_page %q", perPage)
+		}
+		p.perPage = n
+	}
+	return p, nil
+}
+
+// paginate returns the requested page of the shares, sorted by id to keep
+// the pages stable across requests, and whether more pages follow.
+func (p *pagination) paginate(shares []*conversions.ShareData) ([]*conversions.ShareData, bool) {
+	sort.SliceStable(shares, func(i, j int) bool {
+		return shares[i].ID < shares[j].ID
+	})
+
+	start := (p.page - 1) * p.perPage
+	if start >= len(shares) {
+		return []*conversions.ShareData{}, false
+	}
+	end := start + p.perPage
+	if end >= len(shares) {
+		return shares[start:], false
+	}
+	return shares[start:end], true
+}
+
+// writeSharesPage writes the shares, or only the requested page of them
+// together with the pagination hints when the request is paginated.
+// The link to the next page is sent in the Link header, its absence
+// signals that there are no more results.
+func writeSharesPage(w http.ResponseWriter, r *http.Request, p *pagination, shares []*conversions.ShareData) {
+	if p == nil {
+		response.WriteOCSSuccess(w, r, shares)
+		return
+	}
+
+	page, more := p.paginate(shares)
+	if more {
+		next := *r.URL
+		q := next.Query()
+		q.Set("page", strconv.Itoa(p.page+1))
+		q.Set("per_page", strconv.Itoa(p.perPage))
+		next.RawQuery = q.Encode()
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.String()))
+	}
+
+	meta := response.MetaOK
+	meta.TotalItems = strconv.Itoa(len(shares))
+	meta.ItemsPerPage = strconv.Itoa(p.perPage)
+	response.WriteOCSData(w, r, meta, page, nil)
+}
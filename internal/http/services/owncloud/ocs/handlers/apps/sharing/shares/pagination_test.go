@@ -0,0 +1,149 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package shares
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
+)
+
+func sharesWithIDs(n int) []*conversions.ShareData {
+	shares := make([]*conversions.ShareData, 0, n)
+	// add them in reverse order, pages are sorted by id
+	for i := n; i > 0; i-- {
+		shares = append(shares, &conversions.ShareData{ID: fmt.Sprintf("%02d", i)})
+	}
+	return shares
+}
+
+func ids(shares []*conversions.ShareData) []string {
+	ids := make([]string, 0, len(shares))
+	for _, s := range shares {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		page, perPage int
+		expected      []string
+		more          bool
+	}{
+		{page: 1, perPage: 2, expected: []string{"01", "02"}, more: true},
+		{page: 2, perPage: 2, expected: []string{"03", "04"}, more: true},
+		{page: 3, perPage: 2, expected: []string{"05"}, more: false},
+		{page: 4, perPage: 2, expected: []string{}, more: false},
+		{page: 1, perPage: 5, expected: []string{"01", "02", "03", "04", "05"}, more: false},
+	}
+
+	for _, tt := range tests {
+		p := &pagination{page: tt.page, perPage: tt.perPage}
+		page, more := p.paginate(sharesWithIDs(5))
+		if got := ids(page); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("page %d of %d: expected %v, got %v", tt.page, tt.perPage, tt.expected, got)
+		}
+		if more != tt.more {
+			t.Errorf("page %d of %d: expected more=%v, got %v", tt.page, tt.perPage, tt.more, more)
+		}
+	}
+}
+
+func TestGetPagination(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected *pagination
+		err      bool
+	}{
+		{query: "", expected: nil},
+		{query: "page=2&per_page=10", expected: &pagination{page: 2, perPage: 10}},
+		{query: "page=3", expected: &pagination{page: 3, perPage: defaultSharesPerPage}},
+		{query: "per_page=10", expected: &pagination{page: 1, perPage: 10}},
+		{query: "page=0", err: true},
+		{query: "per_page=abc", err: true},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/shares?"+tt.query, nil)
+		p, err := getPagination(r)
+		if (err != nil) != tt.err {
+			t.Errorf("query %q: unexpected error %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(p, tt.expected) {
+			t.Errorf("query %q: expected %+v, got %+v", tt.query, tt.expected, p)
+		}
+	}
+}
+
+func TestWriteSharesPage(t *testing.T) {
+	type ocs struct {
+		OCS struct {
+			Meta struct {
+				TotalItems   string `json:"totalitems"`
+				ItemsPerPage string `json:"itemsperpage"`
+			} `json:"meta"`
+			Data []*conversions.ShareData `json:"data"`
+		} `json:"ocs"`
+	}
+
+	get := func(query string) (ocs, string) {
+		r := httptest.NewRequest("GET", "/shares?format=json&"+query, nil)
+		w := httptest.NewRecorder()
+		p, err := getPagination(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeSharesPage(w, r, p, sharesWithIDs(5))
+		var res ocs
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		return res, w.Header().Get("Link")
+	}
+
+	res, link := get("page=1&per_page=2")
+	if got := ids(res.OCS.Data); !reflect.DeepEqual(got, []string{"01", "02"}) {
+		t.Errorf("expected the first page, got %v", got)
+	}
+	if res.OCS.Meta.TotalItems != "5" || res.OCS.Meta.ItemsPerPage != "2" {
+		t.Errorf("unexpected pagination hints %+v", res.OCS.Meta)
+	}
+	if link == "" {
+		t.Error("expected a link to the next page")
+	}
+
+	res, link = get("page=3&per_page=2")
+	if got := ids(res.OCS.Data); !reflect.DeepEqual(got, []string{"05"}) {
+		t.Errorf("expected the last page, got %v", got)
+	}
+	if link != "" {
+		t.Errorf("expected no link on the last page, got %s", link)
+	}
+
+	res, link = get("")
+	if len(res.OCS.Data) != 5 || res.OCS.Meta.TotalItems != "" || link != "" {
+		t.Errorf("expected the full list without pagination hints, got %+v", res.OCS)
+	}
+}
@@ -88,7 +88,7 @@ func (h *Handler) updateReceivedShare(w http.ResponseWriter, r *http.Request, sh
 			response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "not found", nil)
 			return
 		}
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "grpc update received share request failed", errors.Errorf("code: %d, message: %s", shareRes.Status.Code, shareRes.Status.Message))
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(shareRes.Status.Code), "grpc update received share request failed", errors.Errorf("code: %d, message: %s", shareRes.Status.Code, shareRes.Status.Message))
 		return
 	}
 
@@ -99,7 +99,7 @@ func (h *Handler) updateReceivedShare(w http.ResponseWriter, r *http.Request, sh
 		h.logProblems(status, err, "could not stat, skipping", logger)
 	}
 
-	data, err := conversions.CS3Share2ShareData(r.Context(), rs.Share)
+	data, err := h.converter.CS3Share2ShareData(r.Context(), rs.Share)
 	if err != nil {
 		logger.Debug().Interface("share", rs.Share).Interface("shareData", data).Err(err).Msg("could not CS3Share2ShareData, skipping")
 	}
@@ -146,7 +146,7 @@ func (h *Handler) updateReceivedFederatedShare(w http.ResponseWriter, r *http.Re
 			response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "not found", nil)
 			return
 		}
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "grpc update received share request failed", errors.Errorf("code: %d, message: %s", share.Status.Code, share.Status.Message))
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(share.Status.Code), "grpc update received share request failed", errors.Errorf("code: %d, message: %s", share.Status.Code, share.Status.Message))
 		return
 	}
 
@@ -175,11 +175,11 @@ func (h *Handler) updateReceivedFederatedShare(w http.ResponseWriter, r *http.Re
 			response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "not found", nil)
 			return
 		}
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "grpc update received share request failed", errors.Errorf("code: %d, message: %s", updateRes.Status.Code, updateRes.Status.Message))
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(updateRes.Status.Code), "grpc update received share request failed", errors.Errorf("code: %d, message: %s", updateRes.Status.Code, updateRes.Status.Message))
 		return
 	}
 
-	data, err := conversions.ReceivedOCMShare2ShareData(share.Share, h.ocmLocalMount(share.Share))
+	data, err := h.converter.ReceivedOCMShare2ShareData(share.Share, h.ocmLocalMount(share.Share))
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "grpc update received share request failed", err)
 		return
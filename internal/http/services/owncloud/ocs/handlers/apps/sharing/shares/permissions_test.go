@@ -0,0 +1,83 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package shares
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ReneKroon/ttlcache/v2"
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
+	"google.golang.org/grpc"
+)
+
+func TestAllowedPermissionsPublicLink(t *testing.T) {
+	converter, err := conversions.NewConverter(&config.Config{AllowedPermissions: map[string]int{"public": int(conversions.PermissionRead)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := &shareableGateway{}
+	srv := grpc.NewServer()
+	gateway.RegisterGatewayAPIServer(srv, gw)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	h := &Handler{
+		gatewayAddr:         lis.Addr().String(),
+		homeNamespace:       "/home",
+		userIdentifierCache: ttlcache.NewCache(),
+		converter:           converter,
+	}
+
+	create := func(form url.Values) string {
+		r := httptest.NewRequest(http.MethodPost, "/apps/files_sharing/api/v1/shares?format=json", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.CreateShare(w, r)
+		return w.Body.String()
+	}
+
+	for _, form := range []url.Values{
+		{"shareType": {"3"}, "path": {"/folder"}, "permissions": {"15"}},
+		{"shareType": {"3"}, "path": {"/folder"}, "publicUpload": {"true"}},
+	} {
+		body := create(form)
+		if !strings.Contains(body, `"statuscode":403`) || !strings.Contains(body, "not allowed for this share type") {
+			t.Errorf("expected an editable link to be rejected, got %s", body)
+		}
+	}
+	if len(gw.links) != 0 {
+		t.Fatalf("expected no link to be created, got %d", len(gw.links))
+	}
+
+	if body := create(url.Values{"shareType": {"3"}, "path": {"/folder"}, "permissions": {"1"}}); !strings.Contains(body, `"statuscode":100`) {
+		t.Errorf("expected a read only link to be created, got %s", body)
+	}
+}
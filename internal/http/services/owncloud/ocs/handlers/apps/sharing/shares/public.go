@@ -69,13 +69,13 @@ func (h *Handler) createPublicLinkShare(w http.ResponseWriter, r *http.Request,
 			return
 		}
 		if res.Status.Code != rpc.Code_CODE_OK {
-			response.WriteOCSError(w, r, int(res.Status.GetCode()), "could not list public links", nil)
+			response.WriteOCSError(w, r, h.converter.OCSStatusCode(res.Status.Code), "could not list public links", nil)
 			return
 		}
 
 		for _, l := range res.GetShare() {
 			if l.Quicklink {
-				s := conversions.PublicShare2ShareData(l, r, h.publicURL)
+				s := h.converter.PublicShare2ShareData(l, r, h.publicURL)
 				err = h.addFileInfo(ctx, s, statInfo)
 				if err != nil {
 					response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error enhancing response with share data", err)
@@ -88,6 +88,26 @@ func (h *Handler) createPublicLinkShare(w http.ResponseWriter, r *http.Request,
 		}
 	}
 
+	if h.maxPublicLinks > 0 {
+		res, err := c.ListPublicShares(ctx, &link.ListPublicSharesRequest{
+			Filters: []*link.ListPublicSharesRequest_Filter{
+				publicshare.ResourceIDFilter(statInfo.Id),
+			},
+		})
+		if err != nil {
+			response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "could not list public links", err)
+			return
+		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			response.WriteOCSError(w, r, h.converter.OCSStatusCode(res.Status.Code), "could not list public links", nil)
+			return
+		}
+		if len(res.GetShare()) >= h.maxPublicLinks {
+			response.WriteOCSError(w, r, http.StatusForbidden, fmt.Sprintf("the resource already has the maximum number of %d public links", h.maxPublicLinks), nil)
+			return
+		}
+	}
+
 	newPermissions, err := permissionFromRequest(r, h)
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "Could not read permission from request", err)
@@ -113,6 +133,11 @@ func (h *Handler) createPublicLinkShare(w http.ResponseWriter, r *http.Request,
 		newPermissions = conversions.RoleFromOCSPermissions(permissions).CS3ResourcePermissions()
 	}
 
+	if err := h.converter.CheckPermissions(conversions.ShareTypePublicLink, conversions.RoleFromResourcePermissions(newPermissions).OCSPermissions()); err != nil {
+		response.WriteOCSError(w, r, http.StatusForbidden, err.Error(), nil)
+		return
+	}
+
 	internal, _ := strconv.ParseBool(r.FormValue("internal"))
 	notifyUploads, _ := strconv.ParseBool(r.FormValue("notifyUploads"))
 	notifyUploadsExtraRecipients := r.FormValue("notifyUploadsExtraRecipients")
@@ -163,11 +188,11 @@ func (h *Handler) createPublicLinkShare(w http.ResponseWriter, r *http.Request,
 
 	if createRes.Status.Code != rpc.Code_CODE_OK {
 		log.Debug().Err(errors.New("create public share failed")).Str("shares", "createShare").Msgf("create public share failed with status code: %v", createRes.Status.Code.String())
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "grpc create public share request failed", err)
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(createRes.Status.Code), "grpc create public share request failed", err)
 		return
 	}
 
-	s := conversions.PublicShare2ShareData(createRes.Share, r, h.publicURL)
+	s := h.converter.PublicShare2ShareData(createRes.Share, r, h.publicURL)
 	err = h.addFileInfo(ctx, s, statInfo)
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error enhancing response with share data", err)
@@ -211,22 +236,25 @@ func (h *Handler) listPublicShares(r *http.Request, filters []*link.ListPublicSh
 			defer wg.Done()
 
 			for share := range input {
+				listed, expired := h.checkExpiration(share.Expiration)
+				if !listed {
+					continue
+				}
 				info, status, err := h.getResourceInfoByID(ctx, client, share.ResourceId)
 				if err != nil || status.Code != rpc.Code_CODE_OK {
 					log.Debug().Interface("share", share.Id).Interface("status", status).Err(err).Msg("could not stat share, skipping")
 					return
 				}
 
-				sData := conversions.PublicShare2ShareData(share, r, h.publicURL)
+				sData := h.converter.PublicShare2ShareData(share, r, h.publicURL)
 
 				sData.Name = share.DisplayName
+				sData.Expired = expired
 
 				if err := h.addFileInfo(ctx, sData, info); err != nil {
 					log.Debug().Interface("share", share.Id).Err(err).Msg("could not add file info, skipping")
 					return
 				}
-				h.mapUserIds(ctx, client, sData)
-
 				log.Debug().Interface("share", share.Id).Msg("mapped")
 				output <- sData
 			}
@@ -243,6 +271,7 @@ func (h *Handler) listPublicShares(r *http.Request, filters []*link.ListPublicSh
 	for s := range output {
 		ocsDataPayload = append(ocsDataPayload, s)
 	}
+	h.mapUserIdsBatch(ctx, client, ocsDataPayload)
 
 	return ocsDataPayload, nil, nil
 }
@@ -328,6 +357,10 @@ func (h *Handler) updatePublicShare(w http.ResponseWriter, r *http.Request, shar
 
 	// update permissions if given
 	if newPermissions != nil {
+		if err := h.converter.CheckPermissions(conversions.ShareTypePublicLink, conversions.RoleFromResourcePermissions(newPermissions).OCSPermissions()); err != nil {
+			response.WriteOCSError(w, r, http.StatusForbidden, err.Error(), nil)
+			return
+		}
 		updatesFound = true
 		publicSharePermissions := &link.PublicSharePermissions{
 			Permissions: newPermissions,
@@ -511,7 +544,7 @@ func (h *Handler) updatePublicShare(w http.ResponseWriter, r *http.Request, shar
 		return
 	}
 
-	s := conversions.PublicShare2ShareData(publicShare, r, h.publicURL)
+	s := h.converter.PublicShare2ShareData(publicShare, r, h.publicURL)
 	err = h.addFileInfo(r.Context(), s, statRes.Info)
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error enhancing response with share data", err)
@@ -551,7 +584,7 @@ func (h *Handler) removePublicShare(w http.ResponseWriter, r *http.Request, shar
 			response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "not found", nil)
 			return
 		}
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "grpc delete share request failed", err)
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(res.Status.Code), "grpc delete share request failed", err)
 		return
 	}
 
@@ -0,0 +1,78 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package shares
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ReneKroon/ttlcache/v2"
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"google.golang.org/grpc"
+)
+
+func TestMaxPublicLinksPerResource(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := &linksGateway{}
+	srv := grpc.NewServer()
+	gateway.RegisterGatewayAPIServer(srv, gw)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	h := &Handler{
+		gatewayAddr:         lis.Addr().String(),
+		userIdentifierCache: ttlcache.NewCache(),
+		maxPublicLinks:      2,
+	}
+
+	create := func() string {
+		info := &provider.ResourceInfo{
+			Id:   &provider.ResourceId{StorageId: "storage", OpaqueId: "folder"},
+			Type: provider.ResourceType_RESOURCE_TYPE_CONTAINER,
+			Path: "/home/folder",
+		}
+		form := url.Values{"shareType": {"3"}, "path": {"/folder"}}
+		r := httptest.NewRequest(http.MethodPost, "/apps/files_sharing/api/v1/shares?format=json", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.createPublicLinkShare(w, r, info)
+		return w.Body.String()
+	}
+
+	for i := 0; i < 2; i++ {
+		if body := create(); !strings.Contains(body, `"statuscode":100`) {
+			t.Fatalf("expected link %d to be created, got %s", i+1, body)
+		}
+	}
+	body := create()
+	if !strings.Contains(body, `"statuscode":403`) || !strings.Contains(body, "maximum number of 2 public links") {
+		t.Errorf("expected the third link to be rejected, got %s", body)
+	}
+	if len(gw.links) != 2 {
+		t.Errorf("expected 2 links, got %d", len(gw.links))
+	}
+}
@@ -68,7 +68,7 @@ func (h *Handler) createFederatedCloudShare(w http.ResponseWriter, r *http.Reque
 
 	if providerInfoResp.Status.Code != rpc.Code_CODE_OK {
 		// return proper error
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error from provider info response", errors.New(providerInfoResp.Status.Message))
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(providerInfoResp.Status.Code), "error from provider info response", errors.New(providerInfoResp.Status.Message))
 		return
 	}
 
@@ -124,12 +124,12 @@ func (h *Handler) createFederatedCloudShare(w http.ResponseWriter, r *http.Reque
 			response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "not found", nil)
 			return
 		}
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "grpc create ocm share request failed", err)
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(createShareResponse.Status.Code), "grpc create ocm share request failed", err)
 		return
 	}
 
 	s := createShareResponse.Share
-	data, err := conversions.OCMShare2ShareData(s)
+	data, err := h.converter.OCMShare2ShareData(s)
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error converting share", err)
 		return
@@ -142,7 +142,7 @@ func (h *Handler) createFederatedCloudShare(w http.ResponseWriter, r *http.Reque
 		return
 	}
 	if status.Code != rpc.Code_CODE_OK {
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error statting resource id", errors.New(status.Message))
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(status.Code), "error statting resource id", errors.New(status.Message))
 		return
 	}
 
@@ -217,7 +217,7 @@ func (h *Handler) listReceivedFederatedShares(ctx context.Context, gw gatewayv1b
 		if state != ocsStateUnknown && s.State != state {
 			continue
 		}
-		sd, err := conversions.ReceivedOCMShare2ShareData(s, h.ocmLocalMount(s))
+		sd, err := h.converter.ReceivedOCMShare2ShareData(s, h.ocmLocalMount(s))
 		if err != nil {
 			continue
 		}
@@ -319,7 +319,7 @@ func (h *Handler) listOutcomingFederatedShares(ctx context.Context, gw gatewayv1
 
 	shares := []*conversions.ShareData{}
 	for _, s := range listRes.Shares {
-		sd, err := conversions.OCMShare2ShareData(s)
+		sd, err := h.converter.OCMShare2ShareData(s)
 		if err != nil {
 			continue
 		}
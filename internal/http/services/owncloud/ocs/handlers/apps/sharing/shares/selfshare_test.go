@@ -0,0 +1,108 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package shares
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/ReneKroon/ttlcache/v2"
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"google.golang.org/grpc"
+)
+
+// usersGateway is a shareableGateway that resolves any username and records the user shares.
+type usersGateway struct {
+	shareableGateway
+	shares []*collaboration.Share
+}
+
+func (g *usersGateway) GetUserByClaim(_ context.Context, req *userpb.GetUserByClaimRequest) (*userpb.GetUserByClaimResponse, error) {
+	return &userpb.GetUserByClaimResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		User:   &userpb.User{Id: &userpb.UserId{Idp: "idp", OpaqueId: req.Value}, Username: req.Value, Mail: req.Value + "@example.org"},
+	}, nil
+}
+
+func (g *usersGateway) CreateShare(_ context.Context, req *collaboration.CreateShareRequest) (*collaboration.CreateShareResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	share := &collaboration.Share{
+		Id:          &collaboration.ShareId{OpaqueId: "share"},
+		ResourceId:  req.ResourceInfo.Id,
+		Grantee:     req.Grant.Grantee,
+		Permissions: req.Grant.Permissions,
+	}
+	g.shares = append(g.shares, share)
+	return &collaboration.CreateShareResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, Share: share}, nil
+}
+
+func TestCreateSelfShare(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := &usersGateway{}
+	srv := grpc.NewServer()
+	gateway.RegisterGatewayAPIServer(srv, gw)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	h := &Handler{
+		gatewayAddr:            lis.Addr().String(),
+		homeNamespace:          "/home",
+		userIdentifierCache:    ttlcache.NewCache(),
+		additionalInfoTemplate: template.Must(template.New("additionalInfo").Parse("{{.Mail}}")),
+	}
+
+	create := func() string {
+		form := url.Values{"shareType": {"0"}, "path": {"/folder"}, "shareWith": {"einstein"}, "permissions": {"1"}}
+		r := httptest.NewRequest(http.MethodPost, "/apps/files_sharing/api/v1/shares?format=json", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		ctx := appctx.ContextSetUser(r.Context(), &userpb.User{Id: &userpb.UserId{Idp: "idp", OpaqueId: "einstein"}, Username: "einstein"})
+		w := httptest.NewRecorder()
+		h.CreateShare(w, r.WithContext(ctx))
+		return w.Body.String()
+	}
+
+	if body := create(); !strings.Contains(body, `"statuscode":400`) || !strings.Contains(body, "cannot share with yourself") {
+		t.Errorf("expected the self share to be rejected, got %s", body)
+	}
+	if len(gw.shares) != 0 {
+		t.Fatalf("expected no share to be created, got %d", len(gw.shares))
+	}
+
+	h.allowSelfShares = true
+	if body := create(); !strings.Contains(body, `"statuscode":100`) {
+		t.Errorf("expected the self share to be created, got %s", body)
+	}
+	if len(gw.shares) != 1 {
+		t.Errorf("expected a share to be created, got %d", len(gw.shares))
+	}
+}
@@ -52,6 +52,7 @@ import (
 	"github.com/cs3org/reva/pkg/notification"
 	"github.com/cs3org/reva/pkg/notification/notificationhelper"
 	"github.com/cs3org/reva/pkg/notification/trigger"
+	ocmshare "github.com/cs3org/reva/pkg/ocm/share"
 	"github.com/cs3org/reva/pkg/publicshare"
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/cs3org/reva/pkg/share"
@@ -63,6 +64,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
+	"golang.org/x/sync/singleflight"
 )
 
 const (
@@ -82,7 +84,16 @@ type Handler struct {
 	resourceInfoCache      cache.ResourceInfoCache
 	resourceInfoCacheTTL   time.Duration
 	listOCMShares          bool
+	expiredShareGrace      time.Duration
+	maxPublicLinks         int
+	allowSelfShares        bool
+	idempotencyKeys        *ttlcache.Cache
+	idempotencyCalls       singleflight.Group
+	now                    func() time.Time
+	enabledShareTypes      map[conversions.ShareType]bool
+	converter              *conversions.Converter
 	notificationHelper     *notificationhelper.NotificationHelper
+	shareNotifier          ShareNotifier
 	Log                    *zerolog.Logger
 }
 
@@ -116,14 +127,27 @@ func (h *Handler) Init(c *config.Config, l *zerolog.Logger) {
 	h.homeNamespace = c.HomeNamespace
 	h.ocmMountPoint = c.OCMMountPoint
 	h.listOCMShares = c.ListOCMShares
+	h.expiredShareGrace = time.Second * time.Duration(c.ExpiredShareGracePeriod)
+	h.maxPublicLinks = c.MaxPublicLinksPerResource
+	h.allowSelfShares = c.AllowSelfShares
+	if len(c.EnabledShareTypes) > 0 {
+		// the share types are validated when the capabilities are initialized
+		h.enabledShareTypes, _ = conversions.ParseShareTypes(c.EnabledShareTypes)
+	}
+	// the conversion settings are validated when the ocs service is created
+	h.converter, _ = conversions.NewConverter(c)
 	h.Log = l
 	h.notificationHelper = notificationhelper.New("ocs", c.Notifications, l)
+	h.shareNotifier = getShareNotifier(c, l)
 	h.additionalInfoTemplate, _ = template.New("additionalInfo").Parse(c.AdditionalInfoAttribute)
 	h.resourceInfoCacheTTL = time.Second * time.Duration(c.ResourceInfoCacheTTL)
 
 	h.userIdentifierCache = ttlcache.NewCache()
 	_ = h.userIdentifierCache.SetTTL(time.Second * time.Duration(c.UserIdentifierCacheTTL))
 
+	h.idempotencyKeys = ttlcache.NewCache()
+	_ = h.idempotencyKeys.SetTTL(time.Second * time.Duration(c.IdempotencyKeyTTL))
+
 	cache, err := getCacheManager(c)
 	if err == nil {
 		h.resourceInfoCache = cache
@@ -165,12 +189,28 @@ func (h *Handler) extractReference(r *http.Request) (provider.Reference, error)
 
 // CreateShare handles POST requests on /apps/files_sharing/api/v1/shares.
 func (h *Handler) CreateShare(w http.ResponseWriter, r *http.Request) {
+	if key := r.Header.Get(HeaderIdempotencyKey); key != "" && h.idempotencyKeys != nil {
+		h.createShareOnce(w, r, key)
+		return
+	}
+	h.createShare(w, r)
+}
+
+func (h *Handler) createShare(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	shareType, err := strconv.Atoi(r.FormValue("shareType"))
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "shareType must be an integer", nil)
 		return
 	}
+	if !h.shareTypeEnabled(conversions.ShareType(shareType)) {
+		response.WriteOCSError(w, r, http.StatusForbidden, "share type is disabled", nil)
+		return
+	}
+	if ferr := validateCreateShare(r, conversions.ShareType(shareType)); ferr != nil {
+		response.WriteOCSError(w, r, ferr.code, ferr.Error(), nil)
+		return
+	}
 	// get user permissions on the shared file
 
 	client, err := pool.GetGatewayServiceClient(pool.Endpoint(h.gatewayAddr))
@@ -212,26 +252,26 @@ func (h *Handler) CreateShare(w http.ResponseWriter, r *http.Request) {
 	switch shareType {
 	case int(conversions.ShareTypeUser):
 		// user collaborations default to collab
-		if role, val, err := h.extractPermissions(w, r, statRes.Info, conversions.NewCollaboratorRole()); err == nil {
+		if role, val, err := h.extractPermissions(w, r, statRes.Info, conversions.ShareTypeUser, conversions.NewCollaboratorRole()); err == nil {
 			h.createUserShare(w, r, statRes.Info, role, val)
 		}
 	case int(conversions.ShareTypeGroup):
 		// group collaborations default to collab
-		if role, val, err := h.extractPermissions(w, r, statRes.Info, conversions.NewCollaboratorRole()); err == nil {
+		if role, val, err := h.extractPermissions(w, r, statRes.Info, conversions.ShareTypeGroup, conversions.NewCollaboratorRole()); err == nil {
 			h.createGroupShare(w, r, statRes.Info, role, val)
 		}
 	case int(conversions.ShareTypePublicLink):
 		// public links default to read only
-		if _, _, err := h.extractPermissions(w, r, statRes.Info, conversions.NewReaderRole()); err == nil {
+		if _, _, err := h.extractPermissions(w, r, statRes.Info, conversions.ShareTypePublicLink, conversions.NewReaderRole()); err == nil {
 			h.createPublicLinkShare(w, r, statRes.Info)
 		}
 	case int(conversions.ShareTypeFederatedCloudShare):
 		// federated shares default to read only
-		if role, val, err := h.extractPermissions(w, r, statRes.Info, conversions.NewReaderRole()); err == nil {
+		if role, val, err := h.extractPermissions(w, r, statRes.Info, conversions.ShareTypeFederatedCloudShare, conversions.NewReaderRole()); err == nil {
 			h.createFederatedCloudShare(w, r, statRes.Info, role, val)
 		}
 	case int(conversions.ShareTypeSpaceMembership):
-		if role, val, err := h.extractPermissions(w, r, statRes.Info, conversions.NewViewerRole()); err == nil {
+		if role, val, err := h.extractPermissions(w, r, statRes.Info, conversions.ShareTypeSpaceMembership, conversions.NewViewerRole()); err == nil {
 			switch role.Name {
 			case conversions.RoleManager, conversions.RoleEditor, conversions.RoleViewer:
 				h.addSpaceMember(w, r, statRes.Info, role, val)
@@ -245,6 +285,16 @@ func (h *Handler) CreateShare(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// shareTypeEnabled tells whether shares of the given type can be created,
+// all the types are enabled when none is configured.
+// The space memberships are not subject to the configuration.
+func (h *Handler) shareTypeEnabled(t conversions.ShareType) bool {
+	if h.enabledShareTypes == nil || t == conversions.ShareTypeSpaceMembership {
+		return true
+	}
+	return h.enabledShareTypes[t]
+}
+
 // NotifyShare handles GET requests on /apps/files_sharing/api/v1/shares/(shareid)/notify.
 func (h *Handler) NotifyShare(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -364,7 +414,7 @@ func (h *Handler) SendShareNotification(opaqueID string, granter *userpb.User, g
 	return recipient
 }
 
-func (h *Handler) extractPermissions(w http.ResponseWriter, r *http.Request, ri *provider.ResourceInfo, defaultPermissions *conversions.Role) (*conversions.Role, []byte, error) {
+func (h *Handler) extractPermissions(w http.ResponseWriter, r *http.Request, ri *provider.ResourceInfo, shareType conversions.ShareType, defaultPermissions *conversions.Role) (*conversions.Role, []byte, error) {
 	reqRole, reqPermissions := r.FormValue("role"), r.FormValue("permissions")
 	var role *conversions.Role
 
@@ -419,6 +469,11 @@ func (h *Handler) extractPermissions(w http.ResponseWriter, r *http.Request, ri
 		}
 	}
 
+	if err := h.converter.CheckPermissions(shareType, permissions); err != nil {
+		response.WriteOCSError(w, r, http.StatusForbidden, err.Error(), nil)
+		return nil, nil, err
+	}
+
 	role = conversions.RoleFromOCSPermissions(permissions)
 	roleMap := map[string]string{"name": role.Name}
 	val, err := json.Marshal(roleMap)
@@ -476,7 +531,7 @@ func (h *Handler) GetShare(w http.ResponseWriter, r *http.Request) {
 	*/
 
 	if err == nil && psRes.GetShare() != nil {
-		share = conversions.PublicShare2ShareData(psRes.Share, r, h.publicURL)
+		share = h.converter.PublicShare2ShareData(psRes.Share, r, h.publicURL)
 		resourceID = psRes.Share.ResourceId
 	}
 
@@ -511,7 +566,7 @@ func (h *Handler) GetShare(w http.ResponseWriter, r *http.Request) {
 
 		if err == nil && uRes.GetShare() != nil {
 			resourceID = uRes.Share.ResourceId
-			share, err = conversions.CS3Share2ShareData(ctx, uRes.Share)
+			share, err = h.converter.CS3Share2ShareData(ctx, uRes.Share)
 			if err != nil {
 				response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error mapping share data", err)
 				return
@@ -534,7 +589,7 @@ func (h *Handler) GetShare(w http.ResponseWriter, r *http.Request) {
 
 	if status.Code != rpc.Code_CODE_OK {
 		log.Error().Err(err).Str("status", status.Code.String()).Msg("error mapping share data")
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error mapping share data", err)
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(status.Code), "error mapping share data", err)
 		return
 	}
 
@@ -591,6 +646,31 @@ func (h *Handler) updateShare(w http.ResponseWriter, r *http.Request, shareID st
 		return
 	}
 
+	if h.converter.RestrictsPermissions() {
+		// the share type is needed to check the permissions
+		gRes, err := client.GetShare(ctx, &collaboration.GetShareRequest{
+			Ref: &collaboration.ShareReference{
+				Spec: &collaboration.ShareReference_Id{Id: &collaboration.ShareId{OpaqueId: shareID}},
+			},
+		})
+		if err != nil {
+			response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error sending a grpc get share request", err)
+			return
+		}
+		if gRes.Status.Code != rpc.Code_CODE_OK {
+			response.WriteOCSError(w, r, h.converter.OCSStatusCode(gRes.Status.Code), "grpc get share request failed", nil)
+			return
+		}
+		shareType := conversions.ShareTypeUser
+		if gRes.Share.GetGrantee().GetType() == provider.GranteeType_GRANTEE_TYPE_GROUP {
+			shareType = conversions.ShareTypeGroup
+		}
+		if err := h.converter.CheckPermissions(shareType, permissions); err != nil {
+			response.WriteOCSError(w, r, http.StatusForbidden, err.Error(), nil)
+			return
+		}
+	}
+
 	uReq := &collaboration.UpdateShareRequest{
 		Ref: &collaboration.ShareReference{
 			Spec: &collaboration.ShareReference_Id{
@@ -619,11 +699,11 @@ func (h *Handler) updateShare(w http.ResponseWriter, r *http.Request, shareID st
 			response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "not found", nil)
 			return
 		}
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "grpc update share request failed", err)
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(uRes.Status.Code), "grpc update share request failed", err)
 		return
 	}
 
-	share, err := conversions.CS3Share2ShareData(ctx, uRes.Share)
+	share, err := h.converter.CS3Share2ShareData(ctx, uRes.Share)
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error mapping share data", err)
 		return
@@ -646,7 +726,7 @@ func (h *Handler) updateShare(w http.ResponseWriter, r *http.Request, shareID st
 			return
 		}
 
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "grpc stat request failed for stat after updating user share", err)
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(statRes.Status.Code), "grpc stat request failed for stat after updating user share", err)
 		return
 	}
 
@@ -671,29 +751,13 @@ func (h *Handler) updateFederatedShare(w http.ResponseWriter, r *http.Request, s
 	ctx := r.Context()
 
 	pval := r.FormValue("permissions")
-	if pval == "" {
+	name := r.FormValue("name")
+	if pval == "" && name == "" {
 		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "permissions missing", nil)
 		return
 	}
 
-	pint, err := strconv.Atoi(pval)
-	if err != nil {
-		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "permissions must be an integer", nil)
-		return
-	}
-	permissions, err := conversions.NewPermissions(pint)
-	if err != nil {
-		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, err.Error(), nil)
-		return
-	}
-
-	client, err := pool.GetGatewayServiceClient(pool.Endpoint(h.gatewayAddr))
-	if err != nil {
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error getting grpc gateway client", err)
-		return
-	}
-
-	updateRes, err := client.UpdateOCMShare(ctx, &ocmv1beta1.UpdateOCMShareRequest{
+	req := &ocmv1beta1.UpdateOCMShareRequest{
 		Ref: &ocmv1beta1.ShareReference{
 			Spec: &ocmv1beta1.ShareReference_Id{
 				Id: &ocmv1beta1.ShareId{
@@ -701,7 +765,19 @@ func (h *Handler) updateFederatedShare(w http.ResponseWriter, r *http.Request, s
 				},
 			},
 		},
-		Field: []*ocmv1beta1.UpdateOCMShareRequest_UpdateField{
+	}
+	if pval != "" {
+		pint, err := strconv.Atoi(pval)
+		if err != nil {
+			response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "permissions must be an integer", nil)
+			return
+		}
+		permissions, err := conversions.NewPermissions(pint)
+		if err != nil {
+			response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, err.Error(), nil)
+			return
+		}
+		req.Field = []*ocmv1beta1.UpdateOCMShareRequest_UpdateField{
 			{
 				Field: &ocmv1beta1.UpdateOCMShareRequest_UpdateField_AccessMethods{
 					AccessMethods: &ocmv1beta1.AccessMethod{
@@ -724,8 +800,21 @@ func (h *Handler) updateFederatedShare(w http.ResponseWriter, r *http.Request, s
 					},
 				},
 			},
-		},
-	})
+		}
+	}
+	// the share is renamed along with the other updates,
+	// e.g. after the shared resource has been renamed
+	if name != "" {
+		ocmshare.SetUpdateName(req, name)
+	}
+
+	client, err := pool.GetGatewayServiceClient(pool.Endpoint(h.gatewayAddr))
+	if err != nil {
+		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error getting grpc gateway client", err)
+		return
+	}
+
+	updateRes, err := client.UpdateOCMShare(ctx, req)
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error sending a grpc update share request", err)
 		return
@@ -736,7 +825,7 @@ func (h *Handler) updateFederatedShare(w http.ResponseWriter, r *http.Request, s
 			response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "not found", nil)
 			return
 		}
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "grpc update share request failed", err)
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(updateRes.Status.Code), "grpc update share request failed", err)
 		return
 	}
 
@@ -787,6 +876,12 @@ func (h *Handler) listSharesWithMe(w http.ResponseWriter, r *http.Request) {
 	state := r.FormValue("state")
 	stateFilter := getStateFilter(state)
 
+	pagination, err := getPagination(r)
+	if err != nil {
+		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, err.Error(), nil)
+		return
+	}
+
 	log := appctx.GetLogger(r.Context())
 	client, err := pool.GetGatewayServiceClient(pool.Endpoint(h.gatewayAddr))
 	if err != nil {
@@ -816,7 +911,7 @@ func (h *Handler) listSharesWithMe(w http.ResponseWriter, r *http.Request) {
 			case rpc.Code_CODE_PERMISSION_DENIED:
 				response.WriteOCSError(w, r, response.MetaUnauthorized.StatusCode, "permission denied", nil)
 			default:
-				response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "grpc stat request failed", nil)
+				response.WriteOCSError(w, r, h.converter.OCSStatusCode(status.Code), "grpc stat request failed", nil)
 			}
 			return
 		}
@@ -862,7 +957,7 @@ func (h *Handler) listSharesWithMe(w http.ResponseWriter, r *http.Request) {
 			response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "not found", nil)
 			return
 		}
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "grpc ListReceivedShares request failed", err)
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(lrsRes.Status.Code), "grpc ListReceivedShares request failed", err)
 		return
 	}
 
@@ -924,7 +1019,7 @@ func (h *Handler) listSharesWithMe(w http.ResponseWriter, r *http.Request) {
 					}
 				}
 
-				data, err := conversions.CS3Share2ShareData(r.Context(), rs.Share)
+				data, err := h.converter.CS3Share2ShareData(r.Context(), rs.Share)
 				if err != nil {
 					log.Debug().Interface("share", rs.Share.Id).Err(err).Msg("CS3Share2ShareData call failes, skipping")
 					output <- nil
@@ -932,14 +1027,18 @@ func (h *Handler) listSharesWithMe(w http.ResponseWriter, r *http.Request) {
 				}
 
 				data.State = mapState(rs.GetState())
+				listed, expired := h.checkExpiration(rs.Share.Expiration)
+				if !listed {
+					output <- nil
+					continue
+				}
+				data.Expired = expired
 
 				if err := h.addFileInfo(ctx, data, info); err != nil {
 					log.Debug().Interface("received_share", rs.Share.Id).Err(err).Msg("could not add file info, skipping")
 					output <- nil
 					return
 				}
-				h.mapUserIds(r.Context(), client, data)
-
 				if data.State == ocsStateAccepted {
 					// only accepted shares can be accessed when jailing users into their home.
 					// in this case we cannot stat shared resources that are outside the users home (/home),
@@ -997,6 +1096,7 @@ func (h *Handler) listSharesWithMe(w http.ResponseWriter, r *http.Request) {
 			shares = append(shares, s)
 		}
 	}
+	h.mapUserIdsBatch(ctx, client, shares)
 
 	if h.listOCMShares {
 		// include ocm shares in the response
@@ -1010,7 +1110,7 @@ func (h *Handler) listSharesWithMe(w http.ResponseWriter, r *http.Request) {
 		shares = append(shares, lst...)
 	}
 
-	response.WriteOCSSuccess(w, r, shares)
+	writeSharesPage(w, r, pagination, shares)
 }
 
 func findMatch(shareJailInfos []*provider.ResourceInfo, id *provider.ResourceId) *provider.ResourceInfo {
@@ -1130,7 +1230,7 @@ func (h *Handler) addFilters(w http.ResponseWriter, r *http.Request, prefix stri
 			response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "not found", err)
 			return nil, nil, err
 		}
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "grpc stat request failed", err)
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(status.Code), "grpc stat request failed", err)
 		return nil, nil, err
 	}
 
@@ -1144,6 +1244,9 @@ func (h *Handler) addFilters(w http.ResponseWriter, r *http.Request, prefix stri
 func (h *Handler) addFileInfo(ctx context.Context, s *conversions.ShareData, info *provider.ResourceInfo) error {
 	log := appctx.GetLogger(ctx)
 	if info != nil {
+		if err := h.converter.CheckStorageProvider(info.Id); err != nil {
+			return err
+		}
 		// TODO The owner is not set in the storage stat metadata ...
 		parsedMt, _, err := mime.ParseMediaType(info.MimeType)
 		if err != nil {
@@ -1228,6 +1331,9 @@ func (h *Handler) mustGetIdentifiers(ctx context.Context, client gateway.Gateway
 			Username:    res.Group.GroupName,
 			Mail:        res.Group.Mail,
 		}
+		if ui.DisplayName == "" {
+			ui.DisplayName = res.Group.GroupName
+		}
 	} else {
 		res, err := client.GetUser(ctx, &userpb.GetUserRequest{
 			UserId: &userpb.UserId{
@@ -1265,8 +1371,81 @@ func (h *Handler) mustGetIdentifiers(ctx context.Context, client gateway.Gateway
 }
 
 func (h *Handler) mapUserIds(ctx context.Context, client gateway.GatewayAPIClient, s *conversions.ShareData) {
+	h.mapUserIdsWith(ctx, s, func(id string, isGroup bool) *userIdentifiers {
+		return h.mustGetIdentifiers(ctx, client, id, isGroup)
+	})
+}
+
+// identifierKey is a user or a group to resolve.
+type identifierKey struct {
+	id      string
+	isGroup bool
+}
+
+// identifierWorkers is the number of users and groups resolved at the same time
+// by mapUserIdsBatch.
+const identifierWorkers = 10
+
+// mapUserIdsBatch maps the ids of a list of shares like mapUserIds, looking up
+// every distinct user and group once before mapping them back to the shares.
+// The shares of a listing mostly have the same few owners, resolving them share
+// by share would cause a burst of concurrent lookups of the same ids.
+func (h *Handler) mapUserIdsBatch(ctx context.Context, client gateway.GatewayAPIClient, shares []*conversions.ShareData) {
+	resolved := map[identifierKey]*userIdentifiers{}
+	for _, s := range shares {
+		for _, k := range h.shareIdentifiers(s) {
+			resolved[k] = nil
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	keys := make(chan identifierKey, len(resolved))
+	for k := range resolved {
+		keys <- k
+	}
+	close(keys)
+	for i := 0; i < identifierWorkers && i < cap(keys); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range keys {
+				ui := h.mustGetIdentifiers(ctx, client, k.id, k.isGroup)
+				mu.Lock()
+				resolved[k] = ui
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, s := range shares {
+		h.mapUserIdsWith(ctx, s, func(id string, isGroup bool) *userIdentifiers {
+			return resolved[identifierKey{id, isGroup}]
+		})
+	}
+}
+
+// shareIdentifiers returns the users and groups of a share mapped by mapUserIdsWith.
+func (h *Handler) shareIdentifiers(s *conversions.ShareData) []identifierKey {
+	var keys []identifierKey
 	if s.UIDOwner != "" {
-		owner := h.mustGetIdentifiers(ctx, client, s.UIDOwner, false)
+		keys = append(keys, identifierKey{s.UIDOwner, false})
+	}
+	if s.UIDFileOwner != "" {
+		keys = append(keys, identifierKey{s.UIDFileOwner, false})
+	}
+	if s.ShareWith != "" && !h.converter.IsRedactedPassword(s.ShareWith) {
+		keys = append(keys, identifierKey{s.ShareWith, s.ShareType == conversions.ShareTypeGroup})
+	}
+	return keys
+}
+
+// mapUserIdsWith replaces the user and group ids of a share with their names,
+// looked up with lookup.
+func (h *Handler) mapUserIdsWith(ctx context.Context, s *conversions.ShareData, lookup func(id string, isGroup bool) *userIdentifiers) {
+	if s.UIDOwner != "" {
+		owner := lookup(s.UIDOwner, false)
 		s.UIDOwner = owner.Username
 		if s.DisplaynameOwner == "" {
 			s.DisplaynameOwner = owner.DisplayName
@@ -1277,7 +1456,7 @@ func (h *Handler) mapUserIds(ctx context.Context, client gateway.GatewayAPIClien
 	}
 
 	if s.UIDFileOwner != "" {
-		fileOwner := h.mustGetIdentifiers(ctx, client, s.UIDFileOwner, false)
+		fileOwner := lookup(s.UIDFileOwner, false)
 		s.UIDFileOwner = fileOwner.Username
 		if s.DisplaynameFileOwner == "" {
 			s.DisplaynameFileOwner = fileOwner.DisplayName
@@ -1287,8 +1466,13 @@ func (h *Handler) mapUserIds(ctx context.Context, client gateway.GatewayAPIClien
 		}
 	}
 
-	if s.ShareWith != "" && s.ShareWith != "***redacted***" {
-		shareWith := h.mustGetIdentifiers(ctx, client, s.ShareWith, s.ShareType == conversions.ShareTypeGroup)
+	if s.ShareWith != "" && !h.converter.IsRedactedPassword(s.ShareWith) {
+		isGroup := s.ShareType == conversions.ShareTypeGroup
+		shareWith := lookup(s.ShareWith, isGroup)
+		if isGroup && shareWith.Username == "" {
+			// the group could not be resolved, show its id instead
+			shareWith = &userIdentifiers{DisplayName: s.ShareWith, Username: s.ShareWith}
+		}
 		s.ShareWith = shareWith.Username
 		if s.ShareWithDisplayname == "" {
 			s.ShareWithDisplayname = shareWith.DisplayName
@@ -1360,7 +1544,7 @@ func (h *Handler) getResourceInfo(ctx context.Context, client gateway.GatewayAPI
 	return pinfo, status, nil
 }
 
-func (h *Handler) createCs3Share(ctx context.Context, w http.ResponseWriter, r *http.Request, client gateway.GatewayAPIClient, req *collaboration.CreateShareRequest, info *provider.ResourceInfo) (*collaboration.ShareId, bool) {
+func (h *Handler) createCs3Share(ctx context.Context, w http.ResponseWriter, r *http.Request, client gateway.GatewayAPIClient, req *collaboration.CreateShareRequest, info *provider.ResourceInfo, recipient string) (*collaboration.ShareId, bool) {
 	createShareResponse, err := client.CreateShare(ctx, req)
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error sending a grpc create share request", err)
@@ -1371,10 +1555,10 @@ func (h *Handler) createCs3Share(ctx context.Context, w http.ResponseWriter, r *
 			response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "not found", nil)
 			return nil, false
 		}
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "grpc create share request failed", err)
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(createShareResponse.Status.Code), "grpc create share request failed", err)
 		return nil, false
 	}
-	s, err := conversions.CS3Share2ShareData(ctx, createShareResponse.Share)
+	s, err := h.converter.CS3Share2ShareData(ctx, createShareResponse.Share)
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error mapping share data", err)
 		return nil, false
@@ -1387,6 +1571,7 @@ func (h *Handler) createCs3Share(ctx context.Context, w http.ResponseWriter, r *
 	h.mapUserIds(ctx, client, s)
 
 	response.WriteOCSSuccess(w, r, s)
+	h.notifyShareCreated(ctx, s, recipient)
 	return createShareResponse.Share.Id, true
 }
 
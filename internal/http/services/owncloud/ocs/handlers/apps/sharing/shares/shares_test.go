@@ -19,9 +19,26 @@
 package shares
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"text/template"
 
+	"github.com/ReneKroon/ttlcache/v2"
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
 )
 
 func TestGetStateFilter(t *testing.T) {
@@ -71,3 +88,214 @@ func TestMapState(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateShareDisabledShareType(t *testing.T) {
+	log := zerolog.Nop()
+	h := &Handler{}
+	h.Init(&config.Config{EnabledShareTypes: []string{"user", "group", "federated"}}, &log)
+
+	form := url.Values{"shareType": {"3"}, "path": {"/file.txt"}}
+	r := httptest.NewRequest(http.MethodPost, "/apps/files_sharing/api/v1/shares?format=json", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.CreateShare(w, r)
+
+	if !strings.Contains(w.Body.String(), `"statuscode":403`) {
+		t.Errorf("expected the public link creation to be forbidden, got %s", w.Body.String())
+	}
+
+	for _, tt := range []struct {
+		shareType conversions.ShareType
+		enabled   bool
+	}{
+		{conversions.ShareTypeUser, true},
+		{conversions.ShareTypeGroup, true},
+		{conversions.ShareTypeFederatedCloudShare, true},
+		{conversions.ShareTypeSpaceMembership, true},
+		{conversions.ShareTypePublicLink, false},
+	} {
+		if enabled := h.shareTypeEnabled(tt.shareType); enabled != tt.enabled {
+			t.Errorf("share type %d: expected enabled %t, got %t", tt.shareType, tt.enabled, enabled)
+		}
+	}
+
+	if !(&Handler{}).shareTypeEnabled(conversions.ShareTypePublicLink) {
+		t.Error("expected all the share types to be enabled when none is configured")
+	}
+}
+
+// groupGatewayClient resolves the groups and users it knows about.
+type groupGatewayClient struct {
+	gateway.GatewayAPIClient
+	groups map[string]*grouppb.Group
+	users  map[string]*userpb.User
+}
+
+func (c *groupGatewayClient) GetGroup(_ context.Context, req *grouppb.GetGroupRequest, _ ...grpc.CallOption) (*grouppb.GetGroupResponse, error) {
+	g, ok := c.groups[req.GroupId.OpaqueId]
+	if !ok {
+		return &grouppb.GetGroupResponse{Status: &rpc.Status{Code: rpc.Code_CODE_NOT_FOUND}}, nil
+	}
+	return &grouppb.GetGroupResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, Group: g}, nil
+}
+
+func (c *groupGatewayClient) GetUser(_ context.Context, req *userpb.GetUserRequest, _ ...grpc.CallOption) (*userpb.GetUserResponse, error) {
+	u, ok := c.users[req.UserId.OpaqueId]
+	if !ok {
+		return &userpb.GetUserResponse{Status: &rpc.Status{Code: rpc.Code_CODE_NOT_FOUND}}, nil
+	}
+	return &userpb.GetUserResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, User: u}, nil
+}
+
+func TestMapUserIdsGroupShare(t *testing.T) {
+	client := &groupGatewayClient{
+		groups: map[string]*grouppb.Group{
+			"g1": {GroupName: "physics", DisplayName: "Physics Department"},
+			"g2": {GroupName: "chemistry"},
+		},
+		users: map[string]*userpb.User{
+			"u1": {Username: "einstein", DisplayName: "Albert Einstein"},
+		},
+	}
+	h := &Handler{
+		userIdentifierCache:    ttlcache.NewCache(),
+		additionalInfoTemplate: template.Must(template.New("additionalInfo").Parse("{{.Mail}}")),
+	}
+
+	for _, tt := range []struct {
+		group       string
+		shareWith   string
+		displayName string
+	}{
+		{"g1", "physics", "Physics Department"},
+		{"g2", "chemistry", "chemistry"},
+		{"missing", "missing", "missing"},
+	} {
+		share := &collaboration.Share{
+			Creator: &userpb.UserId{OpaqueId: "u1"},
+			Owner:   &userpb.UserId{OpaqueId: "u1"},
+			Grantee: &provider.Grantee{
+				Type: provider.GranteeType_GRANTEE_TYPE_GROUP,
+				Id:   &provider.Grantee_GroupId{GroupId: &grouppb.GroupId{OpaqueId: tt.group}},
+			},
+		}
+		s, err := h.converter.CS3Share2ShareData(context.Background(), share)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.mapUserIds(context.Background(), client, s)
+
+		if s.ShareWith != tt.shareWith || s.ShareWithDisplayname != tt.displayName {
+			t.Errorf("group %s: expected share with %q (%q), got %q (%q)", tt.group, tt.shareWith, tt.displayName, s.ShareWith, s.ShareWithDisplayname)
+		}
+		if s.DisplaynameOwner != "Albert Einstein" {
+			t.Errorf("group %s: expected the owner display name to be resolved, got %q", tt.group, s.DisplaynameOwner)
+		}
+	}
+}
+
+// countingUserClient resolves any user, counting the lookups.
+type countingUserClient struct {
+	gateway.GatewayAPIClient
+	calls atomic.Int32
+}
+
+func (c *countingUserClient) GetUser(_ context.Context, req *userpb.GetUserRequest, _ ...grpc.CallOption) (*userpb.GetUserResponse, error) {
+	c.calls.Add(1)
+	return &userpb.GetUserResponse{
+		Status: &rpc.Status{Code: rpc.Code_CODE_OK},
+		User:   &userpb.User{Username: req.UserId.OpaqueId, DisplayName: strings.ToUpper(req.UserId.OpaqueId)},
+	}, nil
+}
+
+func TestMapUserIdsBatch(t *testing.T) {
+	client := &countingUserClient{}
+	h := &Handler{
+		userIdentifierCache:    ttlcache.NewCache(),
+		additionalInfoTemplate: template.Must(template.New("additionalInfo").Parse("{{.Mail}}")),
+	}
+
+	owners := []string{"einstein", "marie", "richard"}
+	recipients := []string{"feynman", "curie"}
+	shares := make([]*conversions.ShareData, 0, 100)
+	for i := 0; i < 100; i++ {
+		shares = append(shares, &conversions.ShareData{
+			ShareType:    conversions.ShareTypeUser,
+			UIDOwner:     owners[i%len(owners)],
+			UIDFileOwner: owners[i%len(owners)],
+			ShareWith:    recipients[i%len(recipients)],
+		})
+	}
+
+	h.mapUserIdsBatch(context.Background(), client, shares)
+
+	if n := client.calls.Load(); n > int32(len(owners)+len(recipients)) {
+		t.Errorf("expected at most %d user lookups, got %d", len(owners)+len(recipients), n)
+	}
+	for i, s := range shares {
+		owner, recipient := owners[i%len(owners)], recipients[i%len(recipients)]
+		if s.UIDOwner != owner || s.DisplaynameOwner != strings.ToUpper(owner) || s.DisplaynameFileOwner != strings.ToUpper(owner) {
+			t.Errorf("share %d: expected the owner %s to be resolved, got %+v", i, owner, s)
+		}
+		if s.ShareWith != recipient || s.ShareWithDisplayname != strings.ToUpper(recipient) {
+			t.Errorf("share %d: expected the recipient %s to be resolved, got %+v", i, recipient, s)
+		}
+	}
+}
+
+func TestCreateShareValidation(t *testing.T) {
+	log := zerolog.Nop()
+	h := &Handler{}
+	h.Init(&config.Config{}, &log)
+
+	for _, tt := range []struct {
+		name   string
+		form   url.Values
+		status string
+		msg    string
+	}{
+		{
+			name:   "missing shareWith on a user share",
+			form:   url.Values{"shareType": {"0"}, "path": {"/file.txt"}, "permissions": {"1"}},
+			status: `"statuscode":400`,
+			msg:    "shareWith: required for this share type",
+		},
+		{
+			name:   "non integer permissions",
+			form:   url.Values{"shareType": {"0"}, "path": {"/file.txt"}, "shareWith": {"einstein"}, "permissions": {"read"}},
+			status: `"statuscode":400`,
+			msg:    "permissions: must be an integer",
+		},
+		{
+			name:   "permissions out of range",
+			form:   url.Values{"shareType": {"0"}, "path": {"/file.txt"}, "shareWith": {"einstein"}, "permissions": {"128"}},
+			status: `"statuscode":404`,
+			msg:    "permissions: " + conversions.ErrPermissionNotInRange.Error(),
+		},
+		{
+			name:   "unparseable expiration",
+			form:   url.Values{"shareType": {"3"}, "path": {"/file.txt"}, "expireDate": {"tomorrow"}},
+			status: `"statuscode":400`,
+			msg:    "expireDate: datetime format invalid",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/apps/files_sharing/api/v1/shares?format=json", strings.NewReader(tt.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+			h.CreateShare(w, r)
+
+			body := w.Body.String()
+			if !strings.Contains(body, tt.status) || !strings.Contains(body, tt.msg) {
+				t.Errorf("expected %s with message %q, got %s", tt.status, tt.msg, body)
+			}
+		})
+	}
+
+	form := url.Values{"shareType": {"3"}, "path": {"/file.txt"}, "expireDate": {"2030-01-01"}}
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if ferr := validateCreateShare(r, conversions.ShareTypePublicLink); ferr != nil {
+		t.Errorf("expected a public link without shareWith to be valid, got %v", ferr)
+	}
+}
@@ -159,7 +159,7 @@ func (h *Handler) removeSpaceMember(w http.ResponseWriter, r *http.Request, spac
 		return
 	}
 	if removeGrantRes.Status.Code != rpc.Code_CODE_OK {
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error removing grant", err)
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(removeGrantRes.Status.Code), "error removing grant", err)
 		return
 	}
 
@@ -36,6 +36,7 @@ import (
 	"github.com/cs3org/reva/pkg/appctx"
 
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/utils"
 )
 
 func (h *Handler) createUserShare(w http.ResponseWriter, r *http.Request, statInfo *provider.ResourceInfo, role *conversions.Role, roleVal []byte) {
@@ -67,6 +68,11 @@ func (h *Handler) createUserShare(w http.ResponseWriter, r *http.Request, statIn
 		return
 	}
 
+	if !h.allowSelfShares && isSelfShare(ctx, userRes.User.GetId(), statInfo) {
+		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "cannot share with yourself", nil)
+		return
+	}
+
 	createShareReq := &collaboration.CreateShareRequest{
 		Opaque: &types.Opaque{
 			Map: map[string]*types.OpaqueEntry{
@@ -88,7 +94,7 @@ func (h *Handler) createUserShare(w http.ResponseWriter, r *http.Request, statIn
 		},
 	}
 
-	if shareID, ok := h.createCs3Share(ctx, w, r, c, createShareReq, statInfo); ok {
+	if shareID, ok := h.createCs3Share(ctx, w, r, c, createShareReq, statInfo, userRes.User.Mail); ok {
 		notify, _ := strconv.ParseBool(r.FormValue("notify"))
 		if notify {
 			granter, ok := appctx.ContextGetUser(ctx)
@@ -99,6 +105,14 @@ func (h *Handler) createUserShare(w http.ResponseWriter, r *http.Request, statIn
 	}
 }
 
+// isSelfShare tells whether the grantee is the current user or the owner of the resource.
+func isSelfShare(ctx context.Context, grantee *userpb.UserId, info *provider.ResourceInfo) bool {
+	if u, ok := appctx.ContextGetUser(ctx); ok && utils.UserEqual(u.GetId(), grantee) {
+		return true
+	}
+	return utils.UserEqual(info.GetOwner(), grantee)
+}
+
 func (h *Handler) isUserShare(r *http.Request, oid string) bool {
 	logger := appctx.GetLogger(r.Context())
 	client, err := pool.GetGatewayServiceClient(pool.Endpoint(h.gatewayAddr))
@@ -149,11 +163,11 @@ func (h *Handler) removeUserShare(w http.ResponseWriter, r *http.Request, shareI
 			response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "not found", nil)
 			return
 		}
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "deleting share failed", err)
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(getShareResp.Status.Code), "deleting share failed", err)
 		return
 	}
 
-	data, err := conversions.CS3Share2ShareData(ctx, getShareResp.Share)
+	data, err := h.converter.CS3Share2ShareData(ctx, getShareResp.Share)
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "deleting share failed", err)
 		return
@@ -173,7 +187,7 @@ func (h *Handler) removeUserShare(w http.ResponseWriter, r *http.Request, shareI
 			response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "not found", nil)
 			return
 		}
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "grpc delete share request failed", err)
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(uRes.Status.Code), "grpc delete share request failed", err)
 		return
 	}
 	response.WriteOCSSuccess(w, r, data)
@@ -250,11 +264,11 @@ func (h *Handler) removeFederatedShare(w http.ResponseWriter, r *http.Request, s
 			response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "not found", nil)
 			return
 		}
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "deleting share failed", err)
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(getShareResp.Status.Code), "deleting share failed", err)
 		return
 	}
 
-	data, err := conversions.OCMShare2ShareData(getShareResp.Share)
+	data, err := h.converter.OCMShare2ShareData(getShareResp.Share)
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "deleting share failed", err)
 		return
@@ -273,7 +287,7 @@ func (h *Handler) removeFederatedShare(w http.ResponseWriter, r *http.Request, s
 			response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "not found", nil)
 			return
 		}
-		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "grpc delete share request failed", err)
+		response.WriteOCSError(w, r, h.converter.OCSStatusCode(uRes.Status.Code), "grpc delete share request failed", err)
 		return
 	}
 	response.WriteOCSSuccess(w, r, data)
@@ -319,11 +333,16 @@ func (h *Handler) listUserShares(r *http.Request, filters []*collaboration.Filte
 
 			// build OCS response payload
 			for s := range input {
-				data, err := conversions.CS3Share2ShareData(ctx, s)
+				data, err := h.converter.CS3Share2ShareData(ctx, s)
 				if err != nil {
 					log.Debug().Interface("share", s.Id).Err(err).Msg("CS3Share2ShareData returned error, skipping")
 					return
 				}
+				listed, expired := h.checkExpiration(s.Expiration)
+				if !listed {
+					continue
+				}
+				data.Expired = expired
 
 				info, status, err := h.getResourceInfoByID(ctx, client, s.ResourceId)
 				if err != nil || status.Code != rpc.Code_CODE_OK {
@@ -335,8 +354,6 @@ func (h *Handler) listUserShares(r *http.Request, filters []*collaboration.Filte
 					log.Debug().Interface("share", s.Id).Err(err).Msg("could not add file info, skipping")
 					return
 				}
-				h.mapUserIds(ctx, client, data)
-
 				log.Debug().Interface("share", s.Id).Msg("mapped")
 				output <- data
 			}
@@ -353,6 +370,7 @@ func (h *Handler) listUserShares(r *http.Request, filters []*collaboration.Filte
 	for s := range output {
 		ocsDataPayload = append(ocsDataPayload, s)
 	}
+	h.mapUserIdsBatch(ctx, client, ocsDataPayload)
 
 	if h.listOCMShares {
 		// include the ocm shares
@@ -0,0 +1,91 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package shares
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
+)
+
+// fieldError reports an invalid field of a share request.
+type fieldError struct {
+	field  string
+	reason string
+	// code is the OCS status code returned to the client.
+	code int
+}
+
+func (e *fieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.field, e.reason)
+}
+
+// requiredFields lists the fields a share creation request
+// must carry for every share type.
+var requiredFields = map[conversions.ShareType][]string{
+	conversions.ShareTypeUser:                {"shareWith"},
+	conversions.ShareTypeGroup:               {"shareWith"},
+	conversions.ShareTypeSpaceMembership:     {"shareWith"},
+	conversions.ShareTypeFederatedCloudShare: {"shareWithUser", "shareWithProvider"},
+}
+
+// validateCreateShare checks the fields of a share creation request
+// before any resource is looked up, so that malformed requests are
+// rejected with an error naming the offending field.
+func validateCreateShare(r *http.Request, shareType conversions.ShareType) *fieldError {
+	if r.FormValue("path") == "" && r.FormValue("space_ref") == "" {
+		return &fieldError{field: "path", reason: "either path or space_ref is required", code: response.MetaBadRequest.StatusCode}
+	}
+
+	for _, f := range requiredFields[shareType] {
+		if r.FormValue(f) == "" {
+			return &fieldError{field: f, reason: "required for this share type", code: response.MetaBadRequest.StatusCode}
+		}
+	}
+
+	if role := r.FormValue("role"); role != "" {
+		if conversions.RoleFromName(role).Name == conversions.RoleUnknown {
+			return &fieldError{field: "role", reason: fmt.Sprintf("unknown role %q", role), code: response.MetaBadRequest.StatusCode}
+		}
+	} else if p := r.FormValue("permissions"); p != "" {
+		pint, err := strconv.Atoi(p)
+		if err != nil {
+			return &fieldError{field: "permissions", reason: "must be an integer", code: response.MetaBadRequest.StatusCode}
+		}
+		if _, err := conversions.NewPermissions(pint); err != nil {
+			code := response.MetaBadRequest.StatusCode
+			if err == conversions.ErrPermissionNotInRange {
+				// kept for compatibility with ownCloud clients
+				code = http.StatusNotFound
+			}
+			return &fieldError{field: "permissions", reason: err.Error(), code: code}
+		}
+	}
+
+	if e := r.FormValue("expireDate"); e != "" {
+		if _, err := conversions.ParseTimestamp(e); err != nil {
+			return &fieldError{field: "expireDate", reason: err.Error(), code: response.MetaBadRequest.StatusCode}
+		}
+	}
+
+	return nil
+}
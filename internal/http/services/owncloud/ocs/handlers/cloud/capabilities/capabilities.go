@@ -35,187 +35,201 @@ type Handler struct {
 }
 
 // Init initializes this and any contained handlers.
-func (h *Handler) Init(c *config.Config) {
-	h.c = c.Capabilities
+func (h *Handler) Init(c *config.Config) error {
+	cd, err := newCapabilities(c)
+	if err != nil {
+		return err
+	}
+	h.c = cd
 	h.defaultUploadProtocol = c.DefaultUploadProtocol
 	h.userAgentChunkingMap = c.UserAgentChunkingMap
+	return nil
+}
+
+// newCapabilities assembles the capabilities advertised to the clients from the
+// configured ones, the enabled share types and the default upload protocol.
+func newCapabilities(c *config.Config) (data.CapabilitiesData, error) {
+	cd := c.Capabilities
 
 	// capabilities
-	if h.c.Capabilities == nil {
-		h.c.Capabilities = &data.Capabilities{}
+	if cd.Capabilities == nil {
+		cd.Capabilities = &data.Capabilities{}
 	}
 
 	// core
 
-	if h.c.Capabilities.Core == nil {
-		h.c.Capabilities.Core = &data.CapabilitiesCore{}
+	if cd.Capabilities.Core == nil {
+		cd.Capabilities.Core = &data.CapabilitiesCore{}
 	}
-	if h.c.Capabilities.Core.PollInterval == 0 {
-		h.c.Capabilities.Core.PollInterval = 60
+	if cd.Capabilities.Core.PollInterval == 0 {
+		cd.Capabilities.Core.PollInterval = 60
 	}
-	if h.c.Capabilities.Core.WebdavRoot == "" {
-		h.c.Capabilities.Core.WebdavRoot = "remote.php/webdav"
+	if cd.Capabilities.Core.WebdavRoot == "" {
+		cd.Capabilities.Core.WebdavRoot = "remote.php/webdav"
 	}
-	// h.c.Capabilities.Core.SupportURLSigning is boolean
+	// cd.Capabilities.Core.SupportURLSigning is boolean
 
-	if h.c.Capabilities.Core.Status == nil {
-		h.c.Capabilities.Core.Status = &data.Status{}
+	if cd.Capabilities.Core.Status == nil {
+		cd.Capabilities.Core.Status = &data.Status{}
 	}
-	// h.c.Capabilities.Core.Status.Installed is boolean
-	// h.c.Capabilities.Core.Status.Maintenance is boolean
-	// h.c.Capabilities.Core.Status.NeedsDBUpgrade is boolean
-	if h.c.Capabilities.Core.Status.Version == "" {
-		h.c.Capabilities.Core.Status.Version = "10.0.11.5" // TODO make build determined
+	// cd.Capabilities.Core.Status.Installed is boolean
+	// cd.Capabilities.Core.Status.Maintenance is boolean
+	// cd.Capabilities.Core.Status.NeedsDBUpgrade is boolean
+	if cd.Capabilities.Core.Status.Version == "" {
+		cd.Capabilities.Core.Status.Version = "10.0.11.5" // TODO make build determined
 	}
-	if h.c.Capabilities.Core.Status.VersionString == "" {
-		h.c.Capabilities.Core.Status.VersionString = "10.0.11" // TODO make build determined
+	if cd.Capabilities.Core.Status.VersionString == "" {
+		cd.Capabilities.Core.Status.VersionString = "10.0.11" // TODO make build determined
 	}
-	if h.c.Capabilities.Core.Status.Edition == "" {
-		h.c.Capabilities.Core.Status.Edition = "community" // TODO make build determined
+	if cd.Capabilities.Core.Status.Edition == "" {
+		cd.Capabilities.Core.Status.Edition = "community" // TODO make build determined
 	}
-	if h.c.Capabilities.Core.Status.ProductName == "" {
-		h.c.Capabilities.Core.Status.ProductName = "reva" // TODO make build determined
+	if cd.Capabilities.Core.Status.ProductName == "" {
+		cd.Capabilities.Core.Status.ProductName = "reva" // TODO make build determined
 	}
-	if h.c.Capabilities.Core.Status.Product == "" {
-		h.c.Capabilities.Core.Status.Product = "reva" // TODO make build determined
+	if cd.Capabilities.Core.Status.Product == "" {
+		cd.Capabilities.Core.Status.Product = "reva" // TODO make build determined
 	}
-	if h.c.Capabilities.Core.Status.Hostname == "" {
-		h.c.Capabilities.Core.Status.Hostname = "" // TODO get from context?
+	if cd.Capabilities.Core.Status.Hostname == "" {
+		cd.Capabilities.Core.Status.Hostname = "" // TODO get from context?
 	}
 
 	// checksums
 
-	if h.c.Capabilities.Checksums == nil {
-		h.c.Capabilities.Checksums = &data.CapabilitiesChecksums{}
+	if cd.Capabilities.Checksums == nil {
+		cd.Capabilities.Checksums = &data.CapabilitiesChecksums{}
 	}
-	if h.c.Capabilities.Checksums.SupportedTypes == nil {
-		h.c.Capabilities.Checksums.SupportedTypes = []string{"SHA256"}
+	if cd.Capabilities.Checksums.SupportedTypes == nil {
+		cd.Capabilities.Checksums.SupportedTypes = []string{"SHA256"}
 	}
-	if h.c.Capabilities.Checksums.PreferredUploadType == "" {
-		h.c.Capabilities.Checksums.PreferredUploadType = "SHA1"
+	if cd.Capabilities.Checksums.PreferredUploadType == "" {
+		cd.Capabilities.Checksums.PreferredUploadType = "SHA1"
 	}
 
 	// files
 
-	if h.c.Capabilities.Files == nil {
-		h.c.Capabilities.Files = &data.CapabilitiesFiles{}
+	if cd.Capabilities.Files == nil {
+		cd.Capabilities.Files = &data.CapabilitiesFiles{}
 	}
 
-	if h.c.Capabilities.Files.BlacklistedFiles == nil {
-		h.c.Capabilities.Files.BlacklistedFiles = []string{}
+	if cd.Capabilities.Files.BlacklistedFiles == nil {
+		cd.Capabilities.Files.BlacklistedFiles = []string{}
 	}
-	// h.c.Capabilities.Files.Undelete is boolean
-	// h.c.Capabilities.Files.Versioning is boolean
-	// h.c.Capabilities.Files.Favorites is boolean
+	// cd.Capabilities.Files.Undelete is boolean
+	// cd.Capabilities.Files.Versioning is boolean
+	// cd.Capabilities.Files.Favorites is boolean
 
-	if h.c.Capabilities.Files.Archivers == nil {
-		h.c.Capabilities.Files.Archivers = []*data.CapabilitiesArchiver{}
+	if cd.Capabilities.Files.Archivers == nil {
+		cd.Capabilities.Files.Archivers = []*data.CapabilitiesArchiver{}
 	}
 
-	if h.c.Capabilities.Files.AppProviders == nil {
-		h.c.Capabilities.Files.AppProviders = []*data.CapabilitiesAppProvider{}
+	if cd.Capabilities.Files.AppProviders == nil {
+		cd.Capabilities.Files.AppProviders = []*data.CapabilitiesAppProvider{}
 	}
 
 	// dav
 
-	if h.c.Capabilities.Dav == nil {
-		h.c.Capabilities.Dav = &data.CapabilitiesDav{}
+	if cd.Capabilities.Dav == nil {
+		cd.Capabilities.Dav = &data.CapabilitiesDav{}
 	}
-	if h.c.Capabilities.Dav.Trashbin == "" {
-		h.c.Capabilities.Dav.Trashbin = "1.0"
+	if cd.Capabilities.Dav.Trashbin == "" {
+		cd.Capabilities.Dav.Trashbin = "1.0"
 	}
-	if h.c.Capabilities.Dav.Reports == nil {
-		h.c.Capabilities.Dav.Reports = []string{}
+	if cd.Capabilities.Dav.Reports == nil {
+		cd.Capabilities.Dav.Reports = []string{}
 	}
 
 	// sharing
 
-	if h.c.Capabilities.FilesSharing == nil {
-		h.c.Capabilities.FilesSharing = &data.CapabilitiesFilesSharing{}
+	if cd.Capabilities.FilesSharing == nil {
+		cd.Capabilities.FilesSharing = &data.CapabilitiesFilesSharing{}
 	}
 
-	// h.c.Capabilities.FilesSharing.APIEnabled is boolean
+	// cd.Capabilities.FilesSharing.APIEnabled is boolean
 
-	if h.c.Capabilities.FilesSharing.Public == nil {
-		h.c.Capabilities.FilesSharing.Public = &data.CapabilitiesFilesSharingPublic{}
+	if cd.Capabilities.FilesSharing.Public == nil {
+		cd.Capabilities.FilesSharing.Public = &data.CapabilitiesFilesSharingPublic{}
 	}
 
-	// h.c.Capabilities.FilesSharing.IsPublic.Enabled is boolean
-	h.c.Capabilities.FilesSharing.Public.Enabled = true
+	// cd.Capabilities.FilesSharing.IsPublic.Enabled is boolean
+	cd.Capabilities.FilesSharing.Public.Enabled = true
 
-	if h.c.Capabilities.FilesSharing.Public.Password == nil {
-		h.c.Capabilities.FilesSharing.Public.Password = &data.CapabilitiesFilesSharingPublicPassword{}
+	if cd.Capabilities.FilesSharing.Public.Password == nil {
+		cd.Capabilities.FilesSharing.Public.Password = &data.CapabilitiesFilesSharingPublicPassword{}
 	}
 
-	if h.c.Capabilities.FilesSharing.Public.Password.EnforcedFor == nil {
-		h.c.Capabilities.FilesSharing.Public.Password.EnforcedFor = &data.CapabilitiesFilesSharingPublicPasswordEnforcedFor{}
+	if cd.Capabilities.FilesSharing.Public.Password.EnforcedFor == nil {
+		cd.Capabilities.FilesSharing.Public.Password.EnforcedFor = &data.CapabilitiesFilesSharingPublicPasswordEnforcedFor{}
 	}
 
-	// h.c.Capabilities.FilesSharing.IsPublic.Password.EnforcedFor.ReadOnly is boolean
-	// h.c.Capabilities.FilesSharing.IsPublic.Password.EnforcedFor.ReadWrite is boolean
-	// h.c.Capabilities.FilesSharing.IsPublic.Password.EnforcedFor.UploadOnly is boolean
+	// cd.Capabilities.FilesSharing.IsPublic.Password.EnforcedFor.ReadOnly is boolean
+	// cd.Capabilities.FilesSharing.IsPublic.Password.EnforcedFor.ReadWrite is boolean
+	// cd.Capabilities.FilesSharing.IsPublic.Password.EnforcedFor.UploadOnly is boolean
 
-	// h.c.Capabilities.FilesSharing.IsPublic.Password.Enforced is boolean
+	// cd.Capabilities.FilesSharing.IsPublic.Password.Enforced is boolean
 
-	if h.c.Capabilities.FilesSharing.Public.ExpireDate == nil {
-		h.c.Capabilities.FilesSharing.Public.ExpireDate = &data.CapabilitiesFilesSharingPublicExpireDate{}
+	if cd.Capabilities.FilesSharing.Public.ExpireDate == nil {
+		cd.Capabilities.FilesSharing.Public.ExpireDate = &data.CapabilitiesFilesSharingPublicExpireDate{}
+	}
+	// cd.Capabilities.FilesSharing.IsPublic.ExpireDate.Enabled is boolean
+	if cd.Capabilities.FilesSharing.Public.ExpireDate.Days > 0 {
+		cd.Capabilities.FilesSharing.Public.ExpireDate.Enabled = true
 	}
-	// h.c.Capabilities.FilesSharing.IsPublic.ExpireDate.Enabled is boolean
 
-	// h.c.Capabilities.FilesSharing.IsPublic.SendMail is boolean
-	// h.c.Capabilities.FilesSharing.IsPublic.SocialShare is boolean
-	// h.c.Capabilities.FilesSharing.IsPublic.Upload is boolean
-	// h.c.Capabilities.FilesSharing.IsPublic.Multiple is boolean
-	// h.c.Capabilities.FilesSharing.IsPublic.SupportsUploadOnly is boolean
+	// cd.Capabilities.FilesSharing.IsPublic.SendMail is boolean
+	// cd.Capabilities.FilesSharing.IsPublic.SocialShare is boolean
+	// cd.Capabilities.FilesSharing.IsPublic.Upload is boolean
+	// cd.Capabilities.FilesSharing.IsPublic.Multiple is boolean
+	// cd.Capabilities.FilesSharing.IsPublic.SupportsUploadOnly is boolean
 
-	if h.c.Capabilities.FilesSharing.User == nil {
-		h.c.Capabilities.FilesSharing.User = &data.CapabilitiesFilesSharingUser{}
+	if cd.Capabilities.FilesSharing.User == nil {
+		cd.Capabilities.FilesSharing.User = &data.CapabilitiesFilesSharingUser{}
 	}
 
-	// h.c.Capabilities.FilesSharing.User.SendMail is boolean
+	// cd.Capabilities.FilesSharing.User.SendMail is boolean
 
-	// h.c.Capabilities.FilesSharing.Resharing is boolean
-	// h.c.Capabilities.FilesSharing.ResharingDefault is boolean
-	// h.c.Capabilities.FilesSharing.DenyAccess is boolean
-	// h.c.Capabilities.FilesSharing.GroupSharing is boolean
-	// h.c.Capabilities.FilesSharing.AutoAcceptShare is boolean
-	// h.c.Capabilities.FilesSharing.ShareWithGroupMembersOnly is boolean
-	// h.c.Capabilities.FilesSharing.ShareWithMembershipGroupsOnly is boolean
+	// cd.Capabilities.FilesSharing.Resharing is boolean
+	// cd.Capabilities.FilesSharing.ResharingDefault is boolean
+	// cd.Capabilities.FilesSharing.DenyAccess is boolean
+	// cd.Capabilities.FilesSharing.GroupSharing is boolean
+	// cd.Capabilities.FilesSharing.AutoAcceptShare is boolean
+	// cd.Capabilities.FilesSharing.ShareWithGroupMembersOnly is boolean
+	// cd.Capabilities.FilesSharing.ShareWithMembershipGroupsOnly is boolean
 
-	if h.c.Capabilities.FilesSharing.UserEnumeration == nil {
-		h.c.Capabilities.FilesSharing.UserEnumeration = &data.CapabilitiesFilesSharingUserEnumeration{}
+	if cd.Capabilities.FilesSharing.UserEnumeration == nil {
+		cd.Capabilities.FilesSharing.UserEnumeration = &data.CapabilitiesFilesSharingUserEnumeration{}
 	}
 
-	// h.c.Capabilities.FilesSharing.UserEnumeration.Enabled is boolean
-	// h.c.Capabilities.FilesSharing.UserEnumeration.GroupMembersOnly is boolean
+	// cd.Capabilities.FilesSharing.UserEnumeration.Enabled is boolean
+	// cd.Capabilities.FilesSharing.UserEnumeration.GroupMembersOnly is boolean
 
-	if h.c.Capabilities.FilesSharing.DefaultPermissions == 0 {
-		h.c.Capabilities.FilesSharing.DefaultPermissions = 31
+	if cd.Capabilities.FilesSharing.DefaultPermissions == 0 {
+		cd.Capabilities.FilesSharing.DefaultPermissions = 31
 	}
-	if h.c.Capabilities.FilesSharing.Federation == nil {
-		h.c.Capabilities.FilesSharing.Federation = &data.CapabilitiesFilesSharingFederation{}
+	if cd.Capabilities.FilesSharing.Federation == nil {
+		cd.Capabilities.FilesSharing.Federation = &data.CapabilitiesFilesSharingFederation{}
 	}
 
-	// h.c.Capabilities.FilesSharing.Federation.Outgoing is boolean
-	// h.c.Capabilities.FilesSharing.Federation.Incoming is boolean
+	// cd.Capabilities.FilesSharing.Federation.Outgoing is boolean
+	// cd.Capabilities.FilesSharing.Federation.Incoming is boolean
 
-	if h.c.Capabilities.FilesSharing.SearchMinLength == 0 {
-		h.c.Capabilities.FilesSharing.SearchMinLength = 2
+	if cd.Capabilities.FilesSharing.SearchMinLength == 0 {
+		cd.Capabilities.FilesSharing.SearchMinLength = 2
 	}
 
 	// notifications
 
-	// if h.c.Capabilities.Notifications == nil {
-	// 	 h.c.Capabilities.Notifications = &data.CapabilitiesNotifications{}
+	// if cd.Capabilities.Notifications == nil {
+	// 	 cd.Capabilities.Notifications = &data.CapabilitiesNotifications{}
 	// }
-	// if h.c.Capabilities.Notifications.Endpoints == nil {
-	//    h.c.Capabilities.Notifications.Endpoints = []string{"list", "get", "delete"}
+	// if cd.Capabilities.Notifications.Endpoints == nil {
+	//    cd.Capabilities.Notifications.Endpoints = []string{"list", "get", "delete"}
 	//  }
 
 	// version
 
-	if h.c.Version == nil {
-		h.c.Version = &data.Version{
+	if cd.Version == nil {
+		cd.Version = &data.Version{
 			// TODO get from build env
 			Major:   10,
 			Minor:   0,
@@ -227,7 +241,12 @@ func (h *Handler) Init(c *config.Config) {
 	}
 
 	// upload protocol-specific details
-	setCapabilitiesForChunkProtocol(chunkProtocol(h.defaultUploadProtocol), h.c.Capabilities)
+	setCapabilitiesForChunkProtocol(chunkProtocol(c.DefaultUploadProtocol), cd.Capabilities)
+
+	if err := setCapabilitiesForShareTypes(c.EnabledShareTypes, cd.Capabilities.FilesSharing); err != nil {
+		return data.CapabilitiesData{}, err
+	}
+	return cd, nil
 }
 
 // GetCapabilities renders the capabilities.
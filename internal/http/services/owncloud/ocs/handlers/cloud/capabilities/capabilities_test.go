@@ -23,6 +23,7 @@ import (
 	"encoding/xml"
 	"testing"
 
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/data"
 )
 
@@ -56,3 +57,67 @@ func TestMarshal(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestNewCapabilities(t *testing.T) {
+	c := &config.Config{
+		DefaultUploadProtocol: "tus",
+		EnabledShareTypes:     []string{"user", "group"},
+		Capabilities: data.CapabilitiesData{
+			Capabilities: &data.Capabilities{
+				FilesSharing: &data.CapabilitiesFilesSharing{GroupSharing: true},
+			},
+		},
+	}
+	cd, err := newCapabilities(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := cd.Capabilities.FilesSharing
+	if fs.Public != nil {
+		t.Errorf("expected no public link capabilities when public links are disabled, got %+v", fs.Public)
+	}
+	if fs.Federation != nil {
+		t.Errorf("expected no federation capabilities when federated shares are disabled, got %+v", fs.Federation)
+	}
+	if fs.User == nil || !fs.GroupSharing {
+		t.Errorf("expected user and group sharing to be advertised")
+	}
+	if cd.Capabilities.Dav.Chunking != "" || cd.Capabilities.Files.BigFileChunking {
+		t.Errorf("expected tus chunking to be advertised")
+	}
+}
+
+func TestNewCapabilitiesPublicLinks(t *testing.T) {
+	c := &config.Config{
+		Capabilities: data.CapabilitiesData{
+			Capabilities: &data.Capabilities{
+				FilesSharing: &data.CapabilitiesFilesSharing{
+					Public: &data.CapabilitiesFilesSharingPublic{
+						Password:   &data.CapabilitiesFilesSharingPublicPassword{Enforced: true},
+						ExpireDate: &data.CapabilitiesFilesSharingPublicExpireDate{Days: 7},
+					},
+				},
+			},
+		},
+	}
+	cd, err := newCapabilities(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public := cd.Capabilities.FilesSharing.Public
+	if !public.Enabled {
+		t.Errorf("expected public links to be enabled by default")
+	}
+	if !public.Password.Enforced {
+		t.Errorf("expected the password to be enforced")
+	}
+	if !public.ExpireDate.Enabled || public.ExpireDate.Days != 7 {
+		t.Errorf("expected a max expiration of 7 days, got %+v", public.ExpireDate)
+	}
+}
+
+func TestNewCapabilitiesUnknownShareType(t *testing.T) {
+	if _, err := newCapabilities(&config.Config{EnabledShareTypes: []string{"room"}}); err == nil {
+		t.Error("expected an error for an unknown share type")
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package capabilities
+
+import (
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/data"
+	"github.com/cs3org/reva/pkg/errtypes"
+)
+
+// setCapabilitiesForShareTypes removes the capabilities of the share types
+// that are not enabled. All the share types are enabled when none is configured.
+func setCapabilitiesForShareTypes(shareTypes []string, c *data.CapabilitiesFilesSharing) error {
+	if len(shareTypes) == 0 {
+		return nil
+	}
+
+	enabled, err := conversions.ParseShareTypes(shareTypes)
+	if err != nil {
+		return errtypes.BadRequest(err.Error())
+	}
+
+	if !enabled[conversions.ShareTypeUser] {
+		c.User = nil
+	}
+	if !enabled[conversions.ShareTypeGroup] {
+		c.GroupSharing = false
+	}
+	if !enabled[conversions.ShareTypePublicLink] {
+		c.Public = nil
+	}
+	if !enabled[conversions.ShareTypeFederatedCloudShare] {
+		c.Federation = nil
+	}
+	return nil
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/ReneKroon/ttlcache/v2"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/handlers/apps/sharing/sharees"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/handlers/apps/sharing/shares"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/handlers/cloud/capabilities"
@@ -55,6 +56,12 @@ func New(ctx context.Context, m map[string]interface{}) (global.Service, error)
 		return nil, err
 	}
 
+	// the settings of the conversions are validated here, their
+	// converter is created when the handlers are initialized
+	if _, err := conversions.NewConverter(&c); err != nil {
+		return nil, err
+	}
+
 	r := chi.NewRouter()
 	s := &svc{
 		c:      &c,
@@ -93,7 +100,9 @@ func (s *svc) routerInit(l *zerolog.Logger) error {
 	configHandler := new(configHandler.Handler)
 	sharesHandler := new(shares.Handler)
 	shareesHandler := new(sharees.Handler)
-	capabilitiesHandler.Init(s.c)
+	if err := capabilitiesHandler.Init(s.c); err != nil {
+		return err
+	}
 	usersHandler.Init(s.c)
 	userHandler.Init(s.c)
 	configHandler.Init(s.c)
@@ -102,6 +111,7 @@ func (s *svc) routerInit(l *zerolog.Logger) error {
 
 	s.router.Route("/v{version:(1|2)}.php", func(r chi.Router) {
 		r.Use(response.VersionCtx)
+		r.Use(response.LimitBody(s.c.MaxRequestBodySize))
 		r.Route("/apps/files_sharing/api/v1", func(r chi.Router) {
 			r.Route("/shares", func(r chi.Router) {
 				r.Get("/", sharesHandler.ListShares)
@@ -0,0 +1,127 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
+)
+
+func TestMaxRequestBodySize(t *testing.T) {
+	s, err := New(context.Background(), map[string]interface{}{
+		"max_request_body_size": 1024,
+		"enabled_share_types":   []string{"user"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		form     url.Values
+		expected string
+	}{
+		{
+			name:     "oversized body",
+			form:     url.Values{"shareType": {"0"}, "path": {"/file.txt"}, "attributes": {strings.Repeat("a", 2048)}},
+			expected: `"statuscode":413`,
+		},
+		{
+			// stops at the disabled share type, past the limit
+			name:     "small body",
+			form:     url.Values{"shareType": {"3"}, "path": {"/file.txt"}},
+			expected: `"statuscode":403`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			body := tt.form.Encode()
+			for _, length := range []int64{int64(len(body)), -1} {
+				r := httptest.NewRequest(http.MethodPost, "/v2.php/apps/files_sharing/api/v1/shares?format=json", strings.NewReader(body))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				// an unknown length is only caught while reading the body
+				r.ContentLength = length
+				w := httptest.NewRecorder()
+				s.Handler().ServeHTTP(w, r)
+
+				if !strings.Contains(w.Body.String(), tt.expected) {
+					t.Errorf("content length %d: expected %s, got %s", length, tt.expected, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestResponseEnvelope(t *testing.T) {
+	share := &conversions.ShareData{ID: "1", ShareType: conversions.ShareTypeUser, UIDOwner: "einstein"}
+
+	for _, tt := range []struct {
+		path       string
+		write      func(w http.ResponseWriter, r *http.Request)
+		httpStatus int
+		meta       string
+	}{
+		{
+			path:       "/v1.php/apps/files_sharing/api/v1/shares/1?format=json",
+			write:      func(w http.ResponseWriter, r *http.Request) { response.WriteOCSSuccess(w, r, share) },
+			httpStatus: http.StatusOK,
+			meta:       `"statuscode":100`,
+		},
+		{
+			path:       "/v2.php/apps/files_sharing/api/v1/shares/1?format=json",
+			write:      func(w http.ResponseWriter, r *http.Request) { response.WriteOCSSuccess(w, r, share) },
+			httpStatus: http.StatusOK,
+			meta:       `"statuscode":200`,
+		},
+		{
+			path: "/v1.php/apps/files_sharing/api/v1/shares/1?format=json",
+			write: func(w http.ResponseWriter, r *http.Request) {
+				response.WriteOCSData(w, r, response.MetaNotFound, share, nil)
+			},
+			httpStatus: http.StatusOK,
+			meta:       `"statuscode":998`,
+		},
+		{
+			path: "/v2.php/apps/files_sharing/api/v1/shares/1?format=json",
+			write: func(w http.ResponseWriter, r *http.Request) {
+				response.WriteOCSData(w, r, response.MetaNotFound, share, nil)
+			},
+			httpStatus: http.StatusNotFound,
+			meta:       `"statuscode":404`,
+		},
+	} {
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		w := httptest.NewRecorder()
+		tt.write(w, r)
+
+		if w.Code != tt.httpStatus {
+			t.Errorf("%s: expected http status %d, got %d", r.URL.Path, tt.httpStatus, w.Code)
+		}
+		body := w.Body.String()
+		if !strings.Contains(body, tt.meta) || !strings.Contains(body, `"uid_owner":"einstein"`) {
+			t.Errorf("%s: expected %s and the share data, got %s", r.URL.Path, tt.meta, body)
+		}
+	}
+}
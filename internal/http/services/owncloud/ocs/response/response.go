@@ -23,8 +23,11 @@ import (
 	"context"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
+	"fmt"
 	"net/http"
 	"reflect"
+	"strings"
 
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/go-chi/chi/v5"
@@ -153,12 +156,8 @@ func WriteOCSResponse(w http.ResponseWriter, r *http.Request, res Response, err
 		appctx.GetLogger(r.Context()).Error().Err(err).Msg(res.OCS.Meta.Message)
 	}
 
-	version := APIVersion(r.Context())
-	m := statusCodeMapper(version)
-	statusCode := m(res.OCS.Meta)
-	if version == "2" && statusCode == http.StatusOK {
-		res.OCS.Meta.StatusCode = statusCode
-	}
+	var statusCode int
+	res.OCS.Meta, statusCode = Envelope(requestVersion(r), res.OCS.Meta)
 
 	var encoder func(Response) ([]byte, error)
 	if r.URL.Query().Get("format") == "json" {
@@ -233,6 +232,18 @@ func OcsV2StatusCodes(meta Meta) int {
 	return http.StatusOK
 }
 
+// Envelope returns the meta data and the http status code of a response
+// for the given ocs api version. The v1 api always replies with 200 and
+// keeps the ocs status code in the meta data, the v2 api replies with the
+// mapped http status code and reports it in the meta data as well.
+func Envelope(version string, m Meta) (Meta, int) {
+	statusCode := statusCodeMapper(version)(m)
+	if version == "2" {
+		m.StatusCode = statusCode
+	}
+	return m, statusCode
+}
+
 // WithAPIVersion puts the api version in the context.
 func VersionCtx(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -249,6 +260,35 @@ func VersionCtx(next http.Handler) http.Handler {
 	})
 }
 
+// LimitBody rejects the requests whose body is larger than max bytes.
+// Form bodies are parsed upfront, as the handlers ignore the parsing errors
+// of FormValue, other bodies fail when read past the limit.
+func LimitBody(max int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if max <= 0 || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.ContentLength > max {
+				WriteOCSError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body larger than %d bytes", max), nil)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, max)
+			if err := r.ParseForm(); err != nil {
+				var maxErr *http.MaxBytesError
+				if errors.As(err, &maxErr) {
+					WriteOCSError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body larger than %d bytes", max), nil)
+				} else {
+					WriteOCSError(w, r, MetaBadRequest.StatusCode, "error parsing the request body", err)
+				}
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // APIVersion retrieves the api version from the context.
 func APIVersion(ctx context.Context) string {
 	value := ctx.Value(apiVersionKey)
@@ -258,6 +298,21 @@ func APIVersion(ctx context.Context) string {
 	return ""
 }
 
+// requestVersion returns the api version of the request, falling back to
+// the version in the path when the request did not go through VersionCtx.
+func requestVersion(r *http.Request) string {
+	if version := APIVersion(r.Context()); version != "" {
+		return version
+	}
+	switch {
+	case strings.Contains(r.URL.Path, "/v1.php/"):
+		return "1"
+	case strings.Contains(r.URL.Path, "/v2.php/"):
+		return "2"
+	}
+	return ""
+}
+
 func statusCodeMapper(version string) func(Meta) int {
 	var mapper func(Meta) int
 	switch version {
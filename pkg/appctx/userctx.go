@@ -20,6 +20,7 @@ package appctx
 
 import (
 	"context"
+	"sync"
 
 	authpb "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
@@ -33,6 +34,7 @@ const (
 	scopeKey
 	idKey
 	pathKey
+	userHolderKey
 )
 
 // ContextGetUser returns the user if set in the given context.
@@ -55,6 +57,35 @@ func ContextSetUser(ctx context.Context, u *userpb.User) context.Context {
 	return context.WithValue(ctx, userKey, u)
 }
 
+// UserHolder holds the user authenticated while serving a request,
+// for the middlewares running before the authentication.
+type UserHolder struct {
+	mu   sync.RWMutex
+	user *userpb.User
+}
+
+// User returns the user held, if any.
+func (h *UserHolder) User() (*userpb.User, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.user, h.user != nil
+}
+
+// ContextWithUserHolder stores a new empty user holder in the context.
+func ContextWithUserHolder(ctx context.Context) (context.Context, *UserHolder) {
+	h := &UserHolder{}
+	return context.WithValue(ctx, userHolderKey, h), h
+}
+
+// ContextHoldUser stores the user in the holder of the context, if any.
+func ContextHoldUser(ctx context.Context, u *userpb.User) {
+	if h, ok := ctx.Value(userHolderKey).(*UserHolder); ok {
+		h.mu.Lock()
+		h.user = u
+		h.mu.Unlock()
+	}
+}
+
 // ContextGetUserID returns the user if set in the given context.
 func ContextGetUserID(ctx context.Context) (*userpb.UserId, bool) {
 	u, ok := ctx.Value(idKey).(*userpb.UserId)
@@ -20,6 +20,7 @@ package publicshares
 
 import (
 	"context"
+	"strconv"
 	"strings"
 	"time"
 
@@ -157,6 +158,12 @@ func (m *manager) Authenticate(ctx context.Context, token, secret string) (*user
 			},
 		},
 	}
+	if share.Expiration != nil {
+		u.Opaque.Map["public-share-expiration"] = &types.OpaqueEntry{
+			Decoder: "plain",
+			Value:   []byte(strconv.FormatUint(share.Expiration.Seconds, 10)),
+		}
+	}
 
 	return u, scope, nil
 }
@@ -0,0 +1,106 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CircuitOpenError is returned when a request is short-circuited
+// because the circuit breaker is open.
+type CircuitOpenError struct {
+	// Until is the time at which the circuit breaker lets a request through again.
+	Until time.Time
+}
+
+func (e CircuitOpenError) Error() string {
+	return fmt.Sprintf("httpclient: circuit breaker open until %s", e.Until.Format(time.RFC3339))
+}
+
+// circuitBreaker is a round tripper that opens after a number of consecutive
+// failures and short-circuits all the requests for a cooldown period.
+// After the cooldown a single request is let through: if it succeeds
+// the breaker closes, otherwise it opens again.
+type circuitBreaker struct {
+	rt        http.RoundTripper
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	open     bool
+	probing  bool
+}
+
+func newCircuitBreaker(rt http.RoundTripper, threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		rt:        rt,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+func (cb *circuitBreaker) RoundTrip(r *http.Request) (*http.Response, error) {
+	if err := cb.before(); err != nil {
+		return nil, err
+	}
+	res, err := cb.rt.RoundTrip(r)
+	cb.after(err == nil && res.StatusCode < http.StatusInternalServerError)
+	return res, err
+}
+
+func (cb *circuitBreaker) before() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if !cb.open {
+		return nil
+	}
+	until := cb.openedAt.Add(cb.cooldown)
+	if cb.probing || cb.now().Before(until) {
+		return CircuitOpenError{Until: until}
+	}
+	// half-open: let a single request through
+	cb.probing = true
+	return nil
+}
+
+func (cb *circuitBreaker) after(success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.probing = false
+	if success {
+		cb.failures = 0
+		cb.open = false
+		return
+	}
+
+	cb.failures++
+	if cb.open || cb.failures >= cb.threshold {
+		cb.open = true
+		cb.openedAt = cb.now()
+	}
+}
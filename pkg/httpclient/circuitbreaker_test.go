@@ -0,0 +1,106 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var calls int
+	fail := true
+	rt := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		if fail {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+
+	now := time.Now()
+	cb := newCircuitBreaker(rt, 3, time.Minute)
+	cb.now = func() time.Time { return now }
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.org", nil)
+
+	// repeated failures trip the breaker
+	for i := 0; i < 3; i++ {
+		if _, err := cb.RoundTrip(req); err == nil {
+			t.Fatalf("request %d: expected an error", i)
+		}
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls)
+	}
+
+	_, err := cb.RoundTrip(req)
+	var openErr CircuitOpenError
+	if !errors.As(err, &openErr) {
+		t.Fatalf("expected a CircuitOpenError, got %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected the request to be short-circuited, got %d calls", calls)
+	}
+
+	// a failed probe after the cooldown opens the breaker again
+	now = now.Add(time.Minute)
+	if _, err := cb.RoundTrip(req); errors.As(err, &openErr) {
+		t.Fatal("expected the probe request to go through")
+	}
+	if _, err := cb.RoundTrip(req); !errors.As(err, &openErr) {
+		t.Fatalf("expected a CircuitOpenError after a failed probe, got %v", err)
+	}
+
+	// a successful probe after the cooldown closes the breaker
+	now = now.Add(time.Minute)
+	fail = false
+	if _, err := cb.RoundTrip(req); err != nil {
+		t.Fatalf("expected the probe request to succeed, got %v", err)
+	}
+	if _, err := cb.RoundTrip(req); err != nil {
+		t.Fatalf("expected the breaker to be closed, got %v", err)
+	}
+}
+
+func TestCircuitBreakerServerErrors(t *testing.T) {
+	rt := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusServiceUnavailable}, nil
+	})
+	cb := newCircuitBreaker(rt, 2, time.Minute)
+	req := httptest.NewRequest(http.MethodGet, "http://example.org", nil)
+
+	for i := 0; i < 2; i++ {
+		if _, err := cb.RoundTrip(req); err != nil {
+			t.Fatalf("request %d: unexpected error %v", i, err)
+		}
+	}
+	if _, err := cb.RoundTrip(req); !errors.As(err, &CircuitOpenError{}) {
+		t.Fatalf("expected a CircuitOpenError, got %v", err)
+	}
+}
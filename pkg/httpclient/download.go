@@ -0,0 +1,136 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ResumableDownload downloads the resource at url into the local file at path.
+// If the file already exists, the download resumes from its size using a ranged GET,
+// provided that the server supports ranges, otherwise the file is downloaded again from
+// the beginning. A failed attempt is retried up to retries times, each time resuming
+// from what has been written so far. It returns the number of bytes downloaded.
+func (c *Client) ResumableDownload(ctx context.Context, url, path string, retries int) (int64, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var downloaded int64
+	rangesSupported := true
+	for attempt := 0; ; attempt++ {
+		n, supported, err := c.downloadFrom(ctx, url, f, rangesSupported)
+		downloaded += n
+		rangesSupported = supported
+		if err == nil {
+			return downloaded, nil
+		}
+		if attempt >= retries || ctx.Err() != nil {
+			return downloaded, err
+		}
+	}
+}
+
+// downloadFrom does a single download attempt, appending to the file when resume is true.
+// It returns the bytes written and whether the server supports ranges.
+func (c *Client) downloadFrom(ctx context.Context, url string, f *os.File, resume bool) (int64, bool, error) {
+	var offset int64
+	if resume {
+		info, err := f.Stat()
+		if err != nil {
+			return 0, resume, err
+		}
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, resume, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return 0, resume, err
+	}
+	defer res.Body.Close()
+
+	supported := res.Header.Get("Accept-Ranges") == "bytes"
+	switch res.StatusCode {
+	case http.StatusOK:
+		// full content, either no range was requested or the server ignored it
+		offset = 0
+	case http.StatusPartialContent:
+		supported = true
+		if start, ok := contentRangeStart(res.Header.Get("Content-Range")); !ok || start != offset {
+			return 0, false, fmt.Errorf("httpclient: unexpected content range %q for offset %d", res.Header.Get("Content-Range"), offset)
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the file is already complete if the range starts at its size
+		if size, ok := contentRangeSize(res.Header.Get("Content-Range")); ok && size == offset {
+			return 0, true, nil
+		}
+		return 0, false, fmt.Errorf("httpclient: range not satisfiable for offset %d", offset)
+	default:
+		return 0, supported, fmt.Errorf("httpclient: unexpected status code %d downloading %s", res.StatusCode, url)
+	}
+
+	if err := f.Truncate(offset); err != nil {
+		return 0, supported, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, supported, err
+	}
+	n, err := io.Copy(f, res.Body)
+	return n, supported, err
+}
+
+// contentRangeStart parses the start of a "bytes start-end/size" Content-Range header.
+func contentRangeStart(h string) (int64, bool) {
+	r, ok := strings.CutPrefix(h, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(r, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	return n, err == nil
+}
+
+// contentRangeSize parses the size of a "bytes */size" Content-Range header.
+func contentRangeSize(h string) (int64, bool) {
+	_, size, ok := strings.Cut(h, "/")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(size, 10, 64)
+	return n, err == nil
+}
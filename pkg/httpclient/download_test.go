@@ -0,0 +1,101 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestResumableDownload(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+
+	var requests, ranged int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Range") != "" {
+			ranged++
+		}
+		if requests == 1 {
+			// interrupt the first download half way
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			_, _ = w.Write(content[:len(content)/2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "file", time.Now(), bytes.NewReader(content))
+	}))
+	defer s.Close()
+
+	path := filepath.Join(t.TempDir(), "file")
+	n, err := New().ResumableDownload(context.Background(), s.URL, path, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if requests != 2 || ranged != 1 {
+		t.Errorf("expected a full and a ranged request, got %d requests with %d ranged", requests, ranged)
+	}
+	if n != int64(len(content)) {
+		t.Errorf("expected %d bytes downloaded, got %d", len(content), n)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Error("downloaded file does not match the content")
+	}
+}
+
+func TestResumableDownloadWithoutRanges(t *testing.T) {
+	content := []byte("the full content")
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer s.Close()
+
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte("the full"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := New().ResumableDownload(context.Background(), s.URL, path, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != int64(len(content)) {
+		t.Errorf("expected %d bytes downloaded, got %d", len(content), n)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("expected %q, got %q", content, got)
+	}
+}
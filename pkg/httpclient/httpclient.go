@@ -19,7 +19,10 @@
 package httpclient
 
 import (
+	"crypto/tls"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"time"
 
@@ -34,10 +37,25 @@ func New(opts ...Option) *Client {
 
 	var tr http.RoundTripper
 	if options.RoundTripper == nil {
-		tr = &injectTransport{rt: http.DefaultTransport}
+		tr = http.DefaultTransport
 	} else {
-		tr = &injectTransport{rt: options.RoundTripper}
+		tr = options.RoundTripper
 	}
+	if options.DialTimeout > 0 || options.TLSHandshakeTimeout > 0 || options.ResponseHeaderTimeout > 0 {
+		// the timeouts can only be applied to the standard transport
+		if t, ok := tr.(*http.Transport); ok {
+			tr = withTransportTimeouts(t, options)
+		}
+	}
+	if options.HTTP2 != nil {
+		if t, ok := tr.(*http.Transport); ok {
+			tr = withHTTP2(t, *options.HTTP2)
+		}
+	}
+	if options.CircuitBreakerThreshold > 0 {
+		tr = newCircuitBreaker(tr, options.CircuitBreakerThreshold, options.CircuitBreakerCooldown)
+	}
+	tr = &injectTransport{rt: tr, interceptors: options.RequestInterceptors}
 
 	httpClient := &http.Client{
 		Timeout:   options.Timeout,
@@ -56,8 +74,24 @@ type Options struct {
 	CheckRedirect func(req *http.Request, via []*http.Request) error
 	Timeout       time.Duration
 	RoundTripper  http.RoundTripper
+
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+
+	// HTTP2 enables or disables HTTP/2, nil keeps the behavior of the transport.
+	HTTP2 *bool
+
+	CircuitBreakerThreshold int
+	CircuitBreakerCooldown  time.Duration
+
+	RequestInterceptors []RequestInterceptor
 }
 
+// RequestInterceptor is called before a request is sent. It can modify
+// the request, e.g. to add authentication headers. An error aborts the request.
+type RequestInterceptor func(*http.Request) error
+
 // newOptions initializes the available default options.
 func newOptions(opts ...Option) Options {
 	opt := Options{}
@@ -76,13 +110,68 @@ func Timeout(t time.Duration) Option {
 	}
 }
 
+// DialTimeout provides a function to set the maximum time to wait for a connection to be established.
+// Like the other transport timeouts, it only applies to an *http.Transport and
+// is ignored when a custom RoundTripper of another type is set.
+func DialTimeout(t time.Duration) Option {
+	return func(o *Options) {
+		o.DialTimeout = t
+	}
+}
+
+// TLSHandshakeTimeout provides a function to set the maximum time to wait for a TLS handshake.
+// It is ignored when a custom RoundTripper other than an *http.Transport is set.
+func TLSHandshakeTimeout(t time.Duration) Option {
+	return func(o *Options) {
+		o.TLSHandshakeTimeout = t
+	}
+}
+
+// ResponseHeaderTimeout provides a function to set the maximum time to wait
+// for the response headers after the request has been written.
+// It is ignored when a custom RoundTripper other than an *http.Transport is set.
+func ResponseHeaderTimeout(t time.Duration) Option {
+	return func(o *Options) {
+		o.ResponseHeaderTimeout = t
+	}
+}
+
+// HTTP2 provides a function to explicitly enable or disable HTTP/2.
+// When disabled, only HTTP/1.1 is used, even if the server supports HTTP/2.
+// It is ignored when a custom RoundTripper other than an *http.Transport is set.
+func HTTP2(enabled bool) Option {
+	return func(o *Options) {
+		o.HTTP2 = &enabled
+	}
+}
+
 // RoundTripper provides a function to set a custom RoundTripper.
+// An *http.Transport is cloned to apply the transport timeouts and the HTTP2
+// option, any other RoundTripper is used as is and those options are ignored.
 func RoundTripper(rt http.RoundTripper) Option {
 	return func(o *Options) {
 		o.RoundTripper = rt
 	}
 }
 
+// CircuitBreaker provides a function to enable a circuit breaker that opens
+// after threshold consecutive failed requests, i.e. transport errors or 5xx responses,
+// and fails the requests with a CircuitOpenError for the cooldown duration.
+func CircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(o *Options) {
+		o.CircuitBreakerThreshold = threshold
+		o.CircuitBreakerCooldown = cooldown
+	}
+}
+
+// WithRequestInterceptor provides a function to add an interceptor that runs before each request.
+// Interceptors run in the order they are added.
+func WithRequestInterceptor(i RequestInterceptor) Option {
+	return func(o *Options) {
+		o.RequestInterceptors = append(o.RequestInterceptors, i)
+	}
+}
+
 // CheckRedirect provides a function to set a custom CheckRedirect.
 func CheckRedirect(cr func(req *http.Request, via []*http.Request) error) Option {
 	return func(o *Options) {
@@ -97,6 +186,44 @@ func Jar(j http.CookieJar) Option {
 	}
 }
 
+// withTransportTimeouts returns a copy of the transport with the configured timeouts applied.
+func withTransportTimeouts(t *http.Transport, o Options) *http.Transport {
+	t = t.Clone()
+	if o.DialTimeout > 0 {
+		t.DialContext = (&net.Dialer{
+			Timeout:   o.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+	}
+	if o.TLSHandshakeTimeout > 0 {
+		t.TLSHandshakeTimeout = o.TLSHandshakeTimeout
+	}
+	if o.ResponseHeaderTimeout > 0 {
+		t.ResponseHeaderTimeout = o.ResponseHeaderTimeout
+	}
+	return t
+}
+
+// withHTTP2 returns a copy of the transport with HTTP/2 enabled or disabled.
+func withHTTP2(t *http.Transport, enabled bool) *http.Transport {
+	t = t.Clone()
+	t.ForceAttemptHTTP2 = enabled
+	if !enabled {
+		// a non-nil empty map disables HTTP/2
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		if t.TLSClientConfig != nil {
+			protos := make([]string, 0, len(t.TLSClientConfig.NextProtos))
+			for _, p := range t.TLSClientConfig.NextProtos {
+				if p != "h2" {
+					protos = append(protos, p)
+				}
+			}
+			t.TLSClientConfig.NextProtos = protos
+		}
+	}
+	return t
+}
+
 // Client wraps a http.Client but only exposes the Do method
 // to force consumers to always create a request with http.NewRequestWithContext().
 type Client struct {
@@ -116,7 +243,8 @@ func (c *Client) GetNativeHTTP() *http.Client {
 }
 
 type injectTransport struct {
-	rt http.RoundTripper
+	rt           http.RoundTripper
+	interceptors []RequestInterceptor
 }
 
 func (t injectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
@@ -131,5 +259,11 @@ func (t injectTransport) RoundTrip(r *http.Request) (*http.Response, error) {
 		r.Header.Set(appctx.TokenHeader, tkn)
 	}
 
+	for _, i := range t.interceptors {
+		if err := i(r); err != nil {
+			return nil, fmt.Errorf("httpclient: request interceptor failed: %w", err)
+		}
+	}
+
 	return t.rt.RoundTrip(r)
 }
@@ -0,0 +1,155 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTLSHandshakeTimeout(t *testing.T) {
+	// a server that accepts connections but never completes the TLS handshake
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, c := range conns {
+				c.Close()
+			}
+		}()
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, c)
+		}
+	}()
+
+	c := New(Timeout(10*time.Second), TLSHandshakeTimeout(100*time.Millisecond))
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "https://"+l.Addr().String(), nil)
+
+	start := time.Now()
+	if _, err := c.Do(req); err == nil {
+		t.Fatal("expected a TLS handshake timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the TLS handshake timeout to trip before the request timeout, took %s", elapsed)
+	}
+}
+
+func TestResponseHeaderTimeout(t *testing.T) {
+	done := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer s.Close()
+	defer close(done)
+
+	c := New(Timeout(10*time.Second), ResponseHeaderTimeout(100*time.Millisecond))
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, s.URL, nil)
+
+	start := time.Now()
+	if _, err := c.Do(req); err == nil {
+		t.Fatal("expected a response header timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the response header timeout to trip before the request timeout, took %s", elapsed)
+	}
+}
+
+func TestRequestInterceptor(t *testing.T) {
+	var received string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("Authorization")
+	}))
+	defer s.Close()
+
+	c := New(WithRequestInterceptor(func(r *http.Request) error {
+		r.Header.Set("Authorization", "Bearer secret")
+		return nil
+	}))
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, s.URL, nil)
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	res.Body.Close()
+
+	if received != "Bearer secret" {
+		t.Errorf("expected the server to receive the interceptor header, got %q", received)
+	}
+}
+
+func TestRequestInterceptorError(t *testing.T) {
+	var sent bool
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = true
+	}))
+	defer s.Close()
+
+	interceptorErr := errors.New("no token")
+	c := New(WithRequestInterceptor(func(r *http.Request) error {
+		return interceptorErr
+	}))
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, s.URL, nil)
+	if _, err := c.Do(req); !errors.Is(err, interceptorErr) {
+		t.Fatalf("expected the interceptor error, got %v", err)
+	}
+	if sent {
+		t.Error("expected the request not to be sent")
+	}
+}
+
+func TestHTTP2(t *testing.T) {
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	s.EnableHTTP2 = true
+	s.StartTLS()
+	defer s.Close()
+
+	tests := map[bool]int{
+		true:  2,
+		false: 1,
+	}
+	for enabled, major := range tests {
+		tr := s.Client().Transport.(*http.Transport)
+		c := New(RoundTripper(tr), HTTP2(enabled))
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, s.URL, nil)
+		res, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		res.Body.Close()
+		if res.ProtoMajor != major {
+			t.Errorf("HTTP2(%v): expected protocol HTTP/%d, got %s", enabled, major, res.Proto)
+		}
+	}
+}
@@ -20,10 +20,12 @@ package sql
 
 import (
 	"database/sql"
+	"fmt"
 	"strconv"
 	"strings"
 
 	appprovider "github.com/cs3org/go-cs3apis/cs3/app/provider/v1beta1"
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
@@ -101,6 +103,16 @@ func convertFromCS3OCMShareType(shareType ocm.ShareType) ShareType {
 	return -1
 }
 
+func convertToCS3OCMShareType(t ShareType) ocm.ShareType {
+	switch t {
+	case ShareTypeUser:
+		return ocm.ShareType_SHARE_TYPE_USER
+	case ShareTypeGroup:
+		return ocm.ShareType_SHARE_TYPE_GROUP
+	}
+	return ocm.ShareType_SHARE_TYPE_INVALID
+}
+
 func convertFromCS3OCMShareState(shareState ocm.ShareState) ShareState {
 	switch shareState {
 	case ocm.ShareState_SHARE_STATE_ACCEPTED:
@@ -176,19 +188,94 @@ type dbProtocol struct {
 	TransferSize         *int
 }
 
-func convertFederatedUserID(s string) *userpb.UserId {
-	split := strings.Split(s, "@")
-	if len(split) < 2 {
-		panic("not in the form <id>@<provider>")
+// splitFederatedID splits a federated id in the form <id>@<provider>.
+// The provider cannot contain a @, unlike the id.
+func splitFederatedID(s string) (string, string, error) {
+	i := strings.LastIndex(s, "@")
+	if i <= 0 || i == len(s)-1 {
+		return "", "", fmt.Errorf("sql: federated id %q not in the form <id>@<provider>", s)
+	}
+	return s[:i], s[i+1:], nil
+}
+
+func convertFederatedUserID(s string) (*userpb.UserId, error) {
+	id, idp, err := splitFederatedID(s)
+	if err != nil {
+		return nil, err
 	}
 	return &userpb.UserId{
-		OpaqueId: split[0],
-		Idp:      split[1],
+		OpaqueId: id,
+		Idp:      idp,
 		Type:     userpb.UserType_USER_TYPE_FEDERATED,
+	}, nil
+}
+
+func convertFederatedGroupID(s string) (*grouppb.GroupId, error) {
+	id, idp, err := splitFederatedID(s)
+	if err != nil {
+		return nil, err
 	}
+	return &grouppb.GroupId{
+		OpaqueId: id,
+		Idp:      idp,
+	}, nil
 }
 
-func convertToCS3OCMShare(s *dbShare, am []*ocm.AccessMethod) *ocm.Share {
+// convertToCS3Grantee converts the share_with column of a share,
+// in the form <id>@<provider>, to a grantee of the given share type.
+func convertToCS3Grantee(shareWith string, t ShareType) (*provider.Grantee, error) {
+	if t == ShareTypeGroup {
+		id, err := convertFederatedGroupID(shareWith)
+		if err != nil {
+			return nil, err
+		}
+		return &provider.Grantee{
+			Type: provider.GranteeType_GRANTEE_TYPE_GROUP,
+			Id: &provider.Grantee_GroupId{
+				GroupId: id,
+			},
+		}, nil
+	}
+	id, err := convertFederatedUserID(shareWith)
+	if err != nil {
+		return nil, err
+	}
+	return &provider.Grantee{
+		Type: provider.GranteeType_GRANTEE_TYPE_USER,
+		Id: &provider.Grantee_UserId{
+			UserId: id,
+		},
+	}, nil
+}
+
+// convertToCS3LocalGrantee converts the share_with column of a received share,
+// holding the local id of the recipient, to a grantee of the given share type.
+func convertToCS3LocalGrantee(shareWith string, t ShareType) *provider.Grantee {
+	if t == ShareTypeGroup {
+		return &provider.Grantee{
+			Type: provider.GranteeType_GRANTEE_TYPE_GROUP,
+			Id: &provider.Grantee_GroupId{
+				GroupId: &grouppb.GroupId{
+					OpaqueId: shareWith,
+				},
+			},
+		}
+	}
+	return &provider.Grantee{
+		Type: provider.GranteeType_GRANTEE_TYPE_USER,
+		Id: &provider.Grantee_UserId{
+			UserId: &userpb.UserId{
+				OpaqueId: shareWith,
+			},
+		},
+	}
+}
+
+func convertToCS3OCMShare(s *dbShare, am []*ocm.AccessMethod) (*ocm.Share, error) {
+	grantee, err := convertToCS3Grantee(s.ShareWith, s.ShareType)
+	if err != nil {
+		return nil, err
+	}
 	share := &ocm.Share{
 		Id: &ocm.ShareId{
 			OpaqueId: strconv.Itoa(s.ID),
@@ -197,14 +284,9 @@ func convertToCS3OCMShare(s *dbShare, am []*ocm.AccessMethod) *ocm.Share {
 			StorageId: s.Prefix,
 			OpaqueId:  s.ItemSource,
 		},
-		Name:  s.Name,
-		Token: s.Token,
-		Grantee: &provider.Grantee{
-			Type: provider.GranteeType_GRANTEE_TYPE_USER,
-			Id: &provider.Grantee_UserId{
-				UserId: convertFederatedUserID(s.ShareWith),
-			},
-		},
+		Name:    s.Name,
+		Token:   s.Token,
+		Grantee: grantee,
 		Owner: &userpb.UserId{
 			OpaqueId: s.Owner,
 		},
@@ -217,7 +299,7 @@ func convertToCS3OCMShare(s *dbShare, am []*ocm.AccessMethod) *ocm.Share {
 		Mtime: &types.Timestamp{
 			Seconds: uint64(s.Mtime),
 		},
-		ShareType:     ocm.ShareType_SHARE_TYPE_USER,
+		ShareType:     convertToCS3OCMShareType(s.ShareType),
 		AccessMethods: am,
 	}
 	if s.Expiration.Valid {
@@ -225,26 +307,27 @@ func convertToCS3OCMShare(s *dbShare, am []*ocm.AccessMethod) *ocm.Share {
 			Seconds: uint64(s.Expiration.Int64),
 		}
 	}
-	return share
+	return share, nil
 }
 
-func convertToCS3OCMReceivedShare(s *dbReceivedShare, p []*ocm.Protocol) *ocm.ReceivedShare {
+func convertToCS3OCMReceivedShare(s *dbReceivedShare, p []*ocm.Protocol) (*ocm.ReceivedShare, error) {
+	owner, err := convertFederatedUserID(s.Owner)
+	if err != nil {
+		return nil, err
+	}
+	creator, err := convertFederatedUserID(s.Initiator)
+	if err != nil {
+		return nil, err
+	}
 	share := &ocm.ReceivedShare{
 		Id: &ocm.ShareId{
 			OpaqueId: strconv.Itoa(s.ID),
 		},
 		RemoteShareId: s.RemoteShareID,
 		Name:          s.Name,
-		Grantee: &provider.Grantee{
-			Type: provider.GranteeType_GRANTEE_TYPE_USER,
-			Id: &provider.Grantee_UserId{
-				UserId: &userpb.UserId{
-					OpaqueId: s.ShareWith,
-				},
-			},
-		},
-		Owner:   convertFederatedUserID(s.Owner),
-		Creator: convertFederatedUserID(s.Initiator),
+		Grantee:       convertToCS3LocalGrantee(s.ShareWith, s.Type),
+		Owner:         owner,
+		Creator:       creator,
 		Ctime: &types.Timestamp{
 			Seconds: uint64(s.Ctime),
 		},
@@ -252,7 +335,7 @@ func convertToCS3OCMReceivedShare(s *dbReceivedShare, p []*ocm.Protocol) *ocm.Re
 			Seconds: uint64(s.Mtime),
 		},
 		ResourceType: convertToCS3ResourceType(s.ItemType),
-		ShareType:    ocm.ShareType_SHARE_TYPE_USER,
+		ShareType:    convertToCS3OCMShareType(s.Type),
 		State:        convertToCS3OCMShareState(s.State),
 		Protocols:    p,
 	}
@@ -261,7 +344,7 @@ func convertToCS3OCMReceivedShare(s *dbReceivedShare, p []*ocm.Protocol) *ocm.Re
 			Seconds: uint64(s.Expiration.Int64),
 		}
 	}
-	return share
+	return share, nil
 }
 
 func convertToCS3AccessMethod(m *dbAccessMethod) *ocm.AccessMethod {
@@ -11,6 +11,7 @@ CREATE TABLE IF NOT EXISTS ocm_shares (
     mtime INTEGER NOT NULL,
     expiration INTEGER DEFAULT NULL,
     type TINYINT NOT NULL,
+    item_type TINYINT NOT NULL DEFAULT 1,
     UNIQUE(fileid_prefix, item_source, share_with, owner)
 );
 
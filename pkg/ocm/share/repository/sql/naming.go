@@ -0,0 +1,97 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sql
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+
+	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/pkg/errors"
+)
+
+// The strategies making the names of the received shares of a user unique.
+const (
+	// MountNamingIdp appends the idp of the owner to the colliding names.
+	MountNamingIdp = "idp"
+	// MountNamingCounter appends a counter to the colliding names.
+	MountNamingCounter = "counter"
+)
+
+func validateMountNaming(strategy string) error {
+	switch strategy {
+	case "", MountNamingIdp, MountNamingCounter:
+		return nil
+	}
+	return errors.Errorf("sql: unknown mount naming strategy %s", strategy)
+}
+
+// uniqueNames renames the received shares whose names collide, following
+// the strategy. The first share received keeps its name. Only the returned
+// shares are renamed, the stored names are not changed.
+func uniqueNames(shares []*ocm.ReceivedShare, strategy string) {
+	if strategy == "" {
+		return
+	}
+
+	// the oldest share keeps its name, so that the names are stable
+	sorted := make([]*ocm.ReceivedShare, len(shares))
+	copy(sorted, shares)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, _ := strconv.Atoi(sorted[i].Id.GetOpaqueId())
+		b, _ := strconv.Atoi(sorted[j].Id.GetOpaqueId())
+		return a < b
+	})
+
+	taken := make(map[string]bool, len(shares))
+	for _, s := range sorted {
+		taken[s.Name] = true
+	}
+
+	seen := make(map[string]bool, len(shares))
+	for _, s := range sorted {
+		if !seen[s.Name] {
+			seen[s.Name] = true
+			continue
+		}
+
+		name := s.Name
+		if strategy == MountNamingIdp {
+			name = decorateName(s, s.Owner.GetIdp())
+		}
+		for i := 2; taken[name]; i++ {
+			name = decorateName(s, strconv.Itoa(i))
+		}
+		taken[name] = true
+		s.Name = name
+	}
+}
+
+// decorateName appends the suffix to the name of the share,
+// before the extension for files.
+func decorateName(s *ocm.ReceivedShare, suffix string) string {
+	ext := ""
+	if s.ResourceType == provider.ResourceType_RESOURCE_TYPE_FILE {
+		ext = path.Ext(s.Name)
+	}
+	return fmt.Sprintf("%s (%s)%s", s.Name[:len(s.Name)-len(ext)], suffix, ext)
+}
@@ -0,0 +1,106 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+// requiredSchema lists the tables of the OCM shares and the columns
+// the queries rely on. See init.sql and db_changes.sql.
+var requiredSchema = map[string][]string{
+	"ocm_shares":                   {"id", "token", "fileid_prefix", "item_source", "name", "share_with", "owner", "initiator", "ctime", "mtime", "expiration", "type", "item_type"},
+	"ocm_shares_access_methods":    {"id", "ocm_share_id", "type"},
+	"ocm_access_method_webdav":     {"ocm_access_method_id", "permissions"},
+	"ocm_access_method_webapp":     {"ocm_access_method_id", "view_mode"},
+	"ocm_received_shares":          {"id", "name", "remote_share_id", "item_type", "share_with", "owner", "initiator", "ctime", "mtime", "expiration", "type", "state"},
+	"ocm_received_share_protocols": {"id", "ocm_received_share_id", "type"},
+	"ocm_protocol_webdav":          {"ocm_protocol_id", "uri", "shared_secret", "permissions"},
+	"ocm_protocol_webapp":          {"ocm_protocol_id", "uri_template", "view_mode"},
+	"ocm_protocol_transfer":        {"ocm_protocol_id", "source_uri", "shared_secret", "size"},
+}
+
+// checkSchema verifies that the database holds the required tables and
+// columns, so that a database that is not migrated is reported at startup
+// instead of failing at the first query.
+func checkSchema(ctx context.Context, db *sql.DB, dbName string) error {
+	if err := db.PingContext(ctx); err != nil {
+		return errors.Wrap(err, "sql: error connecting to mysql database")
+	}
+
+	var missingTables, missingColumns []string
+	for table, cols := range requiredSchema {
+		existing, err := tableColumns(ctx, db, table)
+		if err != nil {
+			var mysqlErr *mysql.MySQLError
+			if errors.As(err, &mysqlErr) && mysqlErr.Number == errNoSuchTable {
+				missingTables = append(missingTables, table)
+				continue
+			}
+			return errors.Wrap(err, "sql: error reading the schema of the table "+table)
+		}
+		for _, c := range cols {
+			if !existing[c] {
+				missingColumns = append(missingColumns, table+"."+c)
+			}
+		}
+	}
+	if len(missingTables) == 0 && len(missingColumns) == 0 {
+		return nil
+	}
+
+	sort.Strings(missingTables)
+	sort.Strings(missingColumns)
+	var missing []string
+	if len(missingTables) > 0 {
+		missing = append(missing, "tables "+strings.Join(missingTables, ", "))
+	}
+	if len(missingColumns) > 0 {
+		missing = append(missing, "columns "+strings.Join(missingColumns, ", "))
+	}
+	return errors.Errorf("sql: the database %s is missing the %s, are the migrations applied?", dbName, strings.Join(missing, " and the "))
+}
+
+// errNoSuchTable is the mysql error of a query on a table that does not exist.
+const errNoSuchTable = 1146
+
+// tableColumns returns the columns of the table, without reading any row.
+func tableColumns(ctx context.Context, db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, "SELECT * FROM "+table+" LIMIT 0")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]bool, len(names))
+	for _, n := range names {
+		columns[strings.ToLower(n)] = true
+	}
+	return columns, nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/cs3org/reva/pkg/appctx"
+)
+
+// execer is the part of *sql.DB and *sql.Tx the repository runs its
+// statements with.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// slowLogger runs the statements on e, logging the ones taking longer
+// than the slow query threshold.
+type slowLogger struct {
+	m *mgr
+	e execer
+}
+
+// timed returns e logging its slow statements, or e itself when the
+// logging is disabled.
+func (m *mgr) timed(e execer) execer {
+	if m.c.SlowQueryMs <= 0 {
+		return e
+	}
+	return slowLogger{m: m, e: e}
+}
+
+func (l slowLogger) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	defer l.logSlow(ctx, query, l.m.now())
+	return l.e.ExecContext(ctx, query, args...)
+}
+
+func (l slowLogger) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	defer l.logSlow(ctx, query, l.m.now())
+	return l.e.QueryContext(ctx, query, args...)
+}
+
+func (l slowLogger) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	defer l.logSlow(ctx, query, l.m.now())
+	return l.e.QueryRowContext(ctx, query, args...)
+}
+
+// logSlow logs the query started at start when it took longer than the
+// slow query threshold. The parameters are not logged, as they may carry
+// secrets. It is meant to be deferred.
+func (l slowLogger) logSlow(ctx context.Context, query string, start time.Time) {
+	elapsed := l.m.now().Sub(start)
+	if elapsed < time.Duration(l.m.c.SlowQueryMs)*time.Millisecond {
+		return
+	}
+	appctx.GetLogger(ctx).Warn().Str("query", query).Dur("elapsed", elapsed).Msg("sql: slow query")
+}
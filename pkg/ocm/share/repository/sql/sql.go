@@ -22,12 +22,16 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"path"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	appprovider "github.com/cs3org/go-cs3apis/cs3/app/provider/v1beta1"
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	typesv1beta1 "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
 	"github.com/cs3org/reva/pkg/cbox/utils"
@@ -35,6 +39,7 @@ import (
 	"github.com/cs3org/reva/pkg/ocm/share"
 	"github.com/cs3org/reva/pkg/ocm/share/repository/registry"
 	"github.com/cs3org/reva/pkg/utils/cfg"
+	"github.com/cs3org/reva/pkg/utils/resourceid"
 	"github.com/go-sql-driver/mysql"
 	"github.com/pkg/errors"
 	"google.golang.org/genproto/protobuf/field_mask"
@@ -56,7 +61,19 @@ func New(ctx context.Context, m map[string]interface{}) (share.Repository, error
 type mgr struct {
 	c   *config
 	db  *sql.DB
+	q   execer // db logging the slow queries
 	now func() time.Time
+
+	hookMu     sync.RWMutex
+	onAccepted share.ReceivedShareHook
+}
+
+// OnAcceptedReceivedShare registers a hook fired after a received share is
+// stored directly in the accepted state. A nil hook disables it.
+func (m *mgr) OnAcceptedReceivedShare(h share.ReceivedShareHook) {
+	m.hookMu.Lock()
+	defer m.hookMu.Unlock()
+	m.onAccepted = h
 }
 
 // NewFromConfig creates a Repository with a SQL driver using the given config.
@@ -64,17 +81,27 @@ func NewFromConfig(ctx context.Context, conf *config) (share.Repository, error)
 	if conf.now == nil {
 		conf.now = time.Now
 	}
+	if err := validateMountNaming(conf.MountNaming); err != nil {
+		return nil, err
+	}
 
 	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s)/%s", conf.DBUsername, conf.DBPassword, conf.DBAddress, conf.DBName))
 	if err != nil {
 		return nil, errors.Wrap(err, "sql: error opening connection to mysql database")
 	}
+	if !conf.SkipSchemaCheck {
+		if err := checkSchema(ctx, db, conf.DBName); err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+	}
 
 	m := &mgr{
 		c:   conf,
 		db:  db,
 		now: conf.now,
 	}
+	m.q = m.timed(db)
 	return m, nil
 }
 
@@ -83,6 +110,23 @@ type config struct {
 	DBPassword string `mapstructure:"db_password"`
 	DBAddress  string `mapstructure:"db_address"`
 	DBName     string `mapstructure:"db_name"`
+	// MaxExpiration is the maximum lifetime in seconds of a share, 0 means no limit.
+	MaxExpiration int64 `mapstructure:"max_expiration"`
+	// RejectExceedingExpiration rejects the shares with an expiration beyond
+	// the maximum lifetime, instead of clamping the expiration to it.
+	RejectExceedingExpiration bool `mapstructure:"reject_exceeding_expiration"`
+	// MountNaming is the strategy making the names of the received shares
+	// of a user unique, either idp or counter. Names are kept as they are when empty.
+	MountNaming string `mapstructure:"mount_naming"`
+	// SkipSchemaCheck disables the check of the tables and columns of the database at startup.
+	SkipSchemaCheck bool `mapstructure:"skip_schema_check"`
+	// SlowQueryMs is the duration in milliseconds above which the calls to the
+	// database are logged as slow, 0 disables the logging.
+	SlowQueryMs int64 `mapstructure:"slow_query_ms"`
+	// CaseInsensitiveShareWith compares the federated grantees of the shares looked up
+	// by key in lower case, for the partners sending mixed case user names.
+	// The local grantees of the received shares are always compared as they are.
+	CaseInsensitiveShareWith bool `mapstructure:"case_insensitive_share_with"`
 
 	now func() time.Time // set only from tests
 }
@@ -91,8 +135,40 @@ func formatUserID(u *userpb.UserId) string {
 	return fmt.Sprintf("%s@%s", u.OpaqueId, u.Idp)
 }
 
-func storeWebDAVAccessMethod(tx *sql.Tx, shareID int64, o *ocm.AccessMethod_WebdavOptions) error {
-	amID, err := storeAccessMethod(tx, shareID, WebDAVAccessMethod)
+// formatGrantee formats a federated grantee, user or group, as <id>@<provider>.
+func formatGrantee(g *provider.Grantee) (string, error) {
+	var id, idp string
+	if g.GetType() == provider.GranteeType_GRANTEE_TYPE_GROUP {
+		id, idp = g.GetGroupId().GetOpaqueId(), g.GetGroupId().GetIdp()
+	} else {
+		id, idp = g.GetUserId().GetOpaqueId(), g.GetUserId().GetIdp()
+	}
+	if id == "" {
+		return "", errtypes.BadRequest("sql: the grantee of the share has no id")
+	}
+	return fmt.Sprintf("%s@%s", id, idp), nil
+}
+
+// formatLocalGrantee returns the local id of a grantee, user or group.
+func formatLocalGrantee(g *provider.Grantee) string {
+	if g.GetType() == provider.GranteeType_GRANTEE_TYPE_GROUP {
+		return g.GetGroupId().OpaqueId
+	}
+	return g.GetUserId().OpaqueId
+}
+
+// shareWith returns the condition matching the share_with column of ocm_shares
+// with the given federated grantee, compared in lower case when configured so.
+// The column is normalized in the query, not relying on its collation.
+func (m *mgr) shareWith(grantee string) (string, any) {
+	if m.c.CaseInsensitiveShareWith {
+		return "LOWER(share_with)=?", strings.ToLower(grantee)
+	}
+	return "share_with=?", grantee
+}
+
+func storeWebDAVAccessMethod(ctx context.Context, tx execer, shareID int64, o *ocm.AccessMethod_WebdavOptions) error {
+	amID, err := storeAccessMethod(ctx, tx, shareID, WebDAVAccessMethod)
 	if err != nil {
 		return err
 	}
@@ -100,12 +176,12 @@ func storeWebDAVAccessMethod(tx *sql.Tx, shareID int64, o *ocm.AccessMethod_Webd
 	query := "INSERT INTO ocm_access_method_webdav SET ocm_access_method_id=?, permissions=?"
 	params := []any{amID, conversions.RoleFromResourcePermissions(o.WebdavOptions.Permissions).OCSPermissions()}
 
-	_, err = tx.Exec(query, params...)
+	_, err = tx.ExecContext(ctx, query, params...)
 	return err
 }
 
-func storeWebappAccessMethod(tx *sql.Tx, shareID int64, o *ocm.AccessMethod_WebappOptions) error {
-	amID, err := storeAccessMethod(tx, shareID, WebappAccessMethod)
+func storeWebappAccessMethod(ctx context.Context, tx execer, shareID int64, o *ocm.AccessMethod_WebappOptions) error {
+	amID, err := storeAccessMethod(ctx, tx, shareID, WebappAccessMethod)
 	if err != nil {
 		return err
 	}
@@ -113,39 +189,80 @@ func storeWebappAccessMethod(tx *sql.Tx, shareID int64, o *ocm.AccessMethod_Weba
 	query := "INSERT INTO ocm_access_method_webapp SET ocm_access_method_id=?, view_mode=?"
 	params := []any{amID, int(o.WebappOptions.ViewMode)}
 
-	_, err = tx.Exec(query, params...)
+	_, err = tx.ExecContext(ctx, query, params...)
 	return err
 }
 
-func storeTransferAccessMethod(tx *sql.Tx, shareID int64, _ *ocm.AccessMethod_TransferOptions) error {
-	_, err := storeAccessMethod(tx, shareID, TransferAccessMethod)
+func storeTransferAccessMethod(ctx context.Context, tx execer, shareID int64, _ *ocm.AccessMethod_TransferOptions) error {
+	_, err := storeAccessMethod(ctx, tx, shareID, TransferAccessMethod)
 	return err
 }
 
-func storeAccessMethod(tx *sql.Tx, shareID int64, t AccessMethod) (int64, error) {
+func storeAccessMethod(ctx context.Context, tx execer, shareID int64, t AccessMethod) (int64, error) {
 	query := "INSERT INTO ocm_shares_access_methods SET ocm_share_id=?, type=?"
 	params := []any{shareID, int(t)}
 
-	res, err := tx.Exec(query, params...)
+	res, err := tx.ExecContext(ctx, query, params...)
 	if err != nil {
 		return 0, err
 	}
 	return res.LastInsertId()
 }
 
+// enforceMaxExpiration clamps the given expiration to the configured maximum lifetime,
+// or rejects it if configured so. A nil expiration is returned as is.
+func (m *mgr) enforceMaxExpiration(exp *typesv1beta1.Timestamp) (*typesv1beta1.Timestamp, error) {
+	if exp == nil || m.c.MaxExpiration <= 0 {
+		return exp, nil
+	}
+	limit := uint64(m.now().Unix() + m.c.MaxExpiration)
+	if exp.Seconds <= limit {
+		return exp, nil
+	}
+	if m.c.RejectExceedingExpiration {
+		return nil, errtypes.BadRequest(fmt.Sprintf("expiration exceeds the maximum lifetime of %d seconds", m.c.MaxExpiration))
+	}
+	return &typesv1beta1.Timestamp{Seconds: limit}, nil
+}
+
 // StoreShare stores a share.
 func (m *mgr) StoreShare(ctx context.Context, s *ocm.Share) (*ocm.Share, error) {
-	if err := transaction(ctx, m.db, func(tx *sql.Tx) error {
+	// the shares not carrying the type of their resource are assumed to be folders
+	return m.storeShare(ctx, s, ItemTypeFolder)
+}
+
+// StoreShareOfType stores a share of a resource of the given type.
+func (m *mgr) StoreShareOfType(ctx context.Context, s *ocm.Share, t provider.ResourceType) (*ocm.Share, error) {
+	itemType := convertFromCS3ResourceType(t)
+	if itemType == -1 {
+		return nil, errtypes.BadRequest("sql: unknown resource type " + t.String())
+	}
+	return m.storeShare(ctx, s, itemType)
+}
+
+func (m *mgr) storeShare(ctx context.Context, s *ocm.Share, itemType ItemType) (*ocm.Share, error) {
+	exp, err := m.enforceMaxExpiration(s.Expiration)
+	if err != nil {
+		return nil, err
+	}
+	s.Expiration = exp
+
+	grantee, err := formatGrantee(s.Grantee)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.transaction(ctx, func(tx execer) error {
 		// store the share
-		query := "INSERT INTO ocm_shares SET token=?,fileid_prefix=?,item_source=?,name=?,share_with=?,owner=?,initiator=?,ctime=?,mtime=?,type=?"
-		params := []any{s.Token, s.ResourceId.StorageId, s.ResourceId.OpaqueId, s.Name, formatUserID(s.Grantee.GetUserId()), s.Owner.OpaqueId, s.Creator.OpaqueId, s.Ctime.Seconds, s.Mtime.Seconds, convertFromCS3OCMShareType(s.ShareType)}
+		query := "INSERT INTO ocm_shares SET token=?,fileid_prefix=?,item_source=?,name=?,share_with=?,owner=?,initiator=?,ctime=?,mtime=?,type=?,item_type=?"
+		params := []any{s.Token, s.ResourceId.StorageId, s.ResourceId.OpaqueId, s.Name, grantee, s.Owner.OpaqueId, s.Creator.OpaqueId, s.Ctime.Seconds, s.Mtime.Seconds, convertFromCS3OCMShareType(s.ShareType), itemType}
 
 		if s.Expiration != nil {
 			query += ",expiration=?"
 			params = append(params, s.Expiration.Seconds)
 		}
 
-		res, err := tx.Exec(query, params...)
+		res, err := tx.ExecContext(ctx, query, params...)
 		if err != nil {
 			return err
 		}
@@ -159,15 +276,15 @@ func (m *mgr) StoreShare(ctx context.Context, s *ocm.Share) (*ocm.Share, error)
 		for _, m := range s.AccessMethods {
 			switch r := m.Term.(type) {
 			case *ocm.AccessMethod_WebdavOptions:
-				if err := storeWebDAVAccessMethod(tx, id, r); err != nil {
+				if err := storeWebDAVAccessMethod(ctx, tx, id, r); err != nil {
 					return err
 				}
 			case *ocm.AccessMethod_WebappOptions:
-				if err := storeWebappAccessMethod(tx, id, r); err != nil {
+				if err := storeWebappAccessMethod(ctx, tx, id, r); err != nil {
 					return err
 				}
 			case *ocm.AccessMethod_TransferOptions:
-				if err := storeTransferAccessMethod(tx, id, r); err != nil {
+				if err := storeTransferAccessMethod(ctx, tx, id, r); err != nil {
 					return err
 				}
 			}
@@ -190,23 +307,17 @@ func (m *mgr) StoreShare(ctx context.Context, s *ocm.Share) (*ocm.Share, error)
 
 // this func will run f in a transaction, committing if no errors
 // rolling back if there were error running f.
-func transaction(ctx context.Context, db *sql.DB, f func(*sql.Tx) error) error {
-	tx, err := db.BeginTx(ctx, nil)
+func (m *mgr) transaction(ctx context.Context, f func(execer) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	var txErr error
-	defer func() {
-		if txErr == nil {
-			_ = tx.Commit()
-		} else {
-			_ = tx.Rollback()
-		}
-	}()
-
-	txErr = f(tx)
-	return txErr
+	if err := f(m.timed(tx)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
 }
 
 // GetShare gets the information for a share by the given ref.
@@ -233,7 +344,7 @@ func (m *mgr) getByID(ctx context.Context, user *userpb.User, id *ocm.ShareId) (
 	query := "SELECT id, token, fileid_prefix, item_source, name, share_with, owner, initiator, ctime, mtime, expiration, type FROM ocm_shares WHERE id=? AND (initiator=? OR owner=?)"
 
 	var s dbShare
-	if err := m.db.QueryRowContext(ctx, query, id.OpaqueId, user.Id.OpaqueId, user.Id.OpaqueId).Scan(&s.ID, &s.Token, &s.Prefix, &s.ItemSource, &s.Name, &s.ShareWith, &s.Owner, &s.Initiator, &s.Ctime, &s.Mtime, &s.Expiration, &s.ShareType); err != nil {
+	if err := m.q.QueryRowContext(ctx, query, id.OpaqueId, user.Id.OpaqueId, user.Id.OpaqueId).Scan(&s.ID, &s.Token, &s.Prefix, &s.ItemSource, &s.Name, &s.ShareWith, &s.Owner, &s.Initiator, &s.Ctime, &s.Mtime, &s.Expiration, &s.ShareType); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, share.ErrShareNotFound
 		}
@@ -245,14 +356,19 @@ func (m *mgr) getByID(ctx context.Context, user *userpb.User, id *ocm.ShareId) (
 		return nil, err
 	}
 
-	return convertToCS3OCMShare(&s, am), nil
+	return convertToCS3OCMShare(&s, am)
 }
 
 func (m *mgr) getByKey(ctx context.Context, user *userpb.User, key *ocm.ShareKey) (*ocm.Share, error) {
-	query := "SELECT id, token, fileid_prefix, item_source, name, share_with, owner, initiator, ctime, mtime, expiration, type FROM ocm_shares WHERE owner=? AND fileid_prefix=? AND item_source=? AND share_with=? AND (initiator=? OR owner=?)"
+	grantee, err := formatGrantee(key.Grantee)
+	if err != nil {
+		return nil, err
+	}
+	cond, shareWith := m.shareWith(grantee)
+	query := "SELECT id, token, fileid_prefix, item_source, name, share_with, owner, initiator, ctime, mtime, expiration, type FROM ocm_shares WHERE owner=? AND fileid_prefix=? AND item_source=? AND " + cond + " AND (initiator=? OR owner=?)"
 
 	var s dbShare
-	if err := m.db.QueryRowContext(ctx, query, key.Owner.OpaqueId, key.ResourceId.StorageId, key.ResourceId.OpaqueId, formatUserID(key.Grantee.GetUserId()), user.Id.OpaqueId, user.Id.OpaqueId).Scan(&s.ID, &s.Token, &s.Prefix, &s.ItemSource, &s.Name, &s.ShareWith, &s.Owner, &s.Initiator, &s.Ctime, &s.Mtime, &s.Expiration, &s.ShareType); err != nil {
+	if err := m.q.QueryRowContext(ctx, query, key.Owner.OpaqueId, key.ResourceId.StorageId, key.ResourceId.OpaqueId, shareWith, user.Id.OpaqueId, user.Id.OpaqueId).Scan(&s.ID, &s.Token, &s.Prefix, &s.ItemSource, &s.Name, &s.ShareWith, &s.Owner, &s.Initiator, &s.Ctime, &s.Mtime, &s.Expiration, &s.ShareType); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, share.ErrShareNotFound
 		}
@@ -263,14 +379,14 @@ func (m *mgr) getByKey(ctx context.Context, user *userpb.User, key *ocm.ShareKey
 		return nil, err
 	}
 
-	return convertToCS3OCMShare(&s, am), nil
+	return convertToCS3OCMShare(&s, am)
 }
 
 func (m *mgr) getByToken(ctx context.Context, token string) (*ocm.Share, error) {
 	query := "SELECT id, token, fileid_prefix, item_source, name, share_with, owner, initiator, ctime, mtime, expiration, type FROM ocm_shares WHERE token=?"
 
 	var s dbShare
-	if err := m.db.QueryRowContext(ctx, query, token).Scan(&s.ID, &s.Token, &s.Prefix, &s.ItemSource, &s.Name, &s.ShareWith, &s.Owner, &s.Initiator, &s.Ctime, &s.Mtime, &s.Expiration, &s.ShareType); err != nil {
+	if err := m.q.QueryRowContext(ctx, query, token).Scan(&s.ID, &s.Token, &s.Prefix, &s.ItemSource, &s.Name, &s.ShareWith, &s.Owner, &s.Initiator, &s.Ctime, &s.Mtime, &s.Expiration, &s.ShareType); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, share.ErrShareNotFound
 		}
@@ -281,14 +397,14 @@ func (m *mgr) getByToken(ctx context.Context, token string) (*ocm.Share, error)
 		return nil, err
 	}
 
-	return convertToCS3OCMShare(&s, am), nil
+	return convertToCS3OCMShare(&s, am)
 }
 
 func (m *mgr) getAccessMethods(ctx context.Context, id int) ([]*ocm.AccessMethod, error) {
 	query := "SELECT m.type, dav.permissions, app.view_mode FROM ocm_shares_access_methods as m LEFT JOIN ocm_access_method_webdav as dav ON m.id=dav.ocm_access_method_id LEFT JOIN ocm_access_method_webapp as app ON m.id=app.ocm_access_method_id WHERE m.ocm_share_id=?"
 
 	var methods []*ocm.AccessMethod
-	rows, err := m.db.QueryContext(ctx, query, id)
+	rows, err := m.q.QueryContext(ctx, query, id)
 	if err != nil {
 		return nil, err
 	}
@@ -322,14 +438,21 @@ func (m *mgr) DeleteShare(ctx context.Context, user *userpb.User, ref *ocm.Share
 
 func (m *mgr) deleteByID(ctx context.Context, user *userpb.User, id *ocm.ShareId) error {
 	query := "DELETE FROM ocm_shares WHERE id=? AND (owner=? OR initiator=?)"
-	_, err := m.db.ExecContext(ctx, query, id.OpaqueId, user.Id.OpaqueId, user.Id.OpaqueId)
+	_, err := m.q.ExecContext(ctx, query, id.OpaqueId, user.Id.OpaqueId, user.Id.OpaqueId)
 	return err
 }
 
 func (m *mgr) deleteByKey(ctx context.Context, user *userpb.User, key *ocm.ShareKey) error {
-	query := "DELETE FROM ocm_shares WHERE owner=? AND fileid_prefix=? AND item_source=? AND share_with=? AND (initiator=? OR owner=?)"
-	_, err := m.db.ExecContext(ctx, query, key.Owner.OpaqueId, key.ResourceId.StorageId, key.ResourceId.OpaqueId, formatUserID(key.Grantee.GetUserId()), user.Id.OpaqueId, user.Id.OpaqueId)
-	return err
+	// the share is resolved first, so that a case insensitive grantee
+	// never deletes more than the single share returned by getByKey
+	s, err := m.getByKey(ctx, user, key)
+	if err != nil {
+		if errors.Is(err, share.ErrShareNotFound) {
+			return nil
+		}
+		return err
+	}
+	return m.deleteByID(ctx, user, s.Id)
 }
 
 // UpdateShare updates the mode of the given share.
@@ -344,6 +467,22 @@ func (m *mgr) UpdateShare(ctx context.Context, user *userpb.User, ref *ocm.Share
 	}
 }
 
+// UpdateShareName sets the name of the share and applies the given updates
+// in a single transaction. Only the owner of the share can rename it.
+func (m *mgr) UpdateShareName(ctx context.Context, user *userpb.User, ref *ocm.ShareReference, name string, f ...*ocm.UpdateOCMShareRequest_UpdateField) (*ocm.Share, error) {
+	if name == "" {
+		return nil, errtypes.BadRequest("sql: the name of a share cannot be empty")
+	}
+	s, err := m.GetShare(ctx, user, ref)
+	if err != nil {
+		return nil, err
+	}
+	if s.Owner.GetOpaqueId() != user.Id.OpaqueId {
+		return nil, errtypes.PermissionDenied("sql: only the owner can rename the share " + s.Id.OpaqueId)
+	}
+	return m.updateShare(ctx, user, s.Id, name, f...)
+}
+
 func (m *mgr) queriesUpdatesOnShare(ctx context.Context, id *ocm.ShareId, f ...*ocm.UpdateOCMShareRequest_UpdateField) (string, []string, []any, [][]any, error) {
 	var qi strings.Builder
 	params := []any{}
@@ -354,17 +493,29 @@ func (m *mgr) queriesUpdatesOnShare(ctx context.Context, id *ocm.ShareId, f ...*
 	for _, field := range f {
 		switch u := field.Field.(type) {
 		case *ocm.UpdateOCMShareRequest_UpdateField_Expiration:
+			exp, err := m.enforceMaxExpiration(u.Expiration)
+			if err != nil {
+				return "", nil, nil, nil, err
+			}
 			qi.WriteString("expiration=?")
-			params = append(params, u.Expiration.Seconds)
+			params = append(params, exp.Seconds)
 		case *ocm.UpdateOCMShareRequest_UpdateField_AccessMethods:
 			// TODO: access method can be added or removed as well
 			// now they can only be updated
 			switch t := u.AccessMethods.Term.(type) {
 			case *ocm.AccessMethod_WebdavOptions:
+				// the values are checked before any update, as the updates
+				// are only consistent if all of them succeed
+				if t.WebdavOptions.GetPermissions() == nil {
+					return "", nil, nil, nil, errtypes.BadRequest("sql: missing webdav permissions")
+				}
 				q := "UPDATE ocm_access_method_webdav SET permissions=? WHERE ocm_access_method_id=(SELECT id FROM ocm_shares_access_methods WHERE ocm_share_id=? AND type=?)"
 				qe = append(qe, q)
 				eparams = append(eparams, []any{utils.SharePermToInt(t.WebdavOptions.Permissions), id.OpaqueId, WebDAVAccessMethod})
 			case *ocm.AccessMethod_WebappOptions:
+				if _, ok := appprovider.ViewMode_name[int32(t.WebappOptions.ViewMode)]; !ok {
+					return "", nil, nil, nil, errtypes.BadRequest(fmt.Sprintf("sql: invalid view mode %d", t.WebappOptions.ViewMode))
+				}
 				q := "UPDATE ocm_access_method_webapp SET view_mode=? WHERE ocm_access_method_id=(SELECT id FROM ocm_shares_access_methods WHERE ocm_share_id=? AND type=?)"
 				qe = append(qe, q)
 				eparams = append(eparams, []any{t.WebappOptions.ViewMode, id.OpaqueId, WebappAccessMethod})
@@ -375,6 +526,12 @@ func (m *mgr) queriesUpdatesOnShare(ctx context.Context, id *ocm.ShareId, f ...*
 }
 
 func (m *mgr) updateShareByID(ctx context.Context, user *userpb.User, id *ocm.ShareId, f ...*ocm.UpdateOCMShareRequest_UpdateField) (*ocm.Share, error) {
+	return m.updateShare(ctx, user, id, "", f...)
+}
+
+// updateShare applies the updates to the share, renaming it when name is
+// not empty, in which case only the owner can update it.
+func (m *mgr) updateShare(ctx context.Context, user *userpb.User, id *ocm.ShareId, name string, f ...*ocm.UpdateOCMShareRequest_UpdateField) (*ocm.Share, error) {
 	var query strings.Builder
 
 	now := m.now().Unix()
@@ -390,16 +547,24 @@ func (m *mgr) updateShareByID(ctx context.Context, user *userpb.User, id *ocm.Sh
 		query.WriteString(squery)
 		query.WriteString(", ")
 	}
-
-	query.WriteString("mtime=? WHERE id=? AND (initiator=? OR owner=?)")
 	params = append(params, sparams...)
-	params = append(params, now, id.OpaqueId, user.Id.OpaqueId, user.Id.OpaqueId)
 
-	if err := transaction(ctx, m.db, func(tx *sql.Tx) error {
-		if _, err := tx.ExecContext(ctx, query.String(), params...); err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				return share.ErrShareNotFound
-			}
+	if name != "" {
+		query.WriteString("name=?, mtime=? WHERE id=? AND owner=?")
+		params = append(params, name, now, id.OpaqueId, user.Id.OpaqueId)
+	} else {
+		query.WriteString("mtime=? WHERE id=? AND (initiator=? OR owner=?)")
+		params = append(params, now, id.OpaqueId, user.Id.OpaqueId, user.Id.OpaqueId)
+	}
+
+	if err := m.transaction(ctx, func(tx execer) error {
+		res, err := tx.ExecContext(ctx, query.String(), params...)
+		if err != nil {
+			return err
+		}
+		// the access methods are only updated on the shares the user can update
+		if n, _ := res.RowsAffected(); n == 0 {
+			return share.ErrShareNotFound
 		}
 
 		for i, q := range am {
@@ -472,6 +637,24 @@ func groupFiltersByType(filters []*ocm.ListOCMSharesRequest_Filter) map[ocm.List
 // ListShares returns the shares created by the user. If md is provided is not nil,
 // it returns only shares attached to the given resource.
 func (m *mgr) ListShares(ctx context.Context, user *userpb.User, filters []*ocm.ListOCMSharesRequest_Filter) ([]*ocm.Share, error) {
+	return m.listShares(ctx, user, filters, nil)
+}
+
+// ListSharesOfTypes is like ListShares, returning only the shares of
+// the resources of one of the given types.
+func (m *mgr) ListSharesOfTypes(ctx context.Context, user *userpb.User, filters []*ocm.ListOCMSharesRequest_Filter, types []provider.ResourceType) ([]*ocm.Share, error) {
+	itemTypes := make([]ItemType, 0, len(types))
+	for _, t := range types {
+		itemType := convertFromCS3ResourceType(t)
+		if itemType == -1 {
+			return nil, errtypes.BadRequest("sql: unknown resource type " + t.String())
+		}
+		itemTypes = append(itemTypes, itemType)
+	}
+	return m.listShares(ctx, user, filters, itemTypes)
+}
+
+func (m *mgr) listShares(ctx context.Context, user *userpb.User, filters []*ocm.ListOCMSharesRequest_Filter, itemTypes []ItemType) ([]*ocm.Share, error) {
 	query := "SELECT id, token, fileid_prefix, item_source, name, share_with, owner, initiator, ctime, mtime, expiration, type FROM ocm_shares WHERE (initiator=? OR owner=?)"
 	params := []any{user.Id.OpaqueId, user.Id.OpaqueId}
 
@@ -485,7 +668,14 @@ func (m *mgr) ListShares(ctx context.Context, user *userpb.User, filters []*ocm.
 		params = append(params, filterParams...)
 	}
 
-	rows, err := m.db.QueryContext(ctx, query, params...)
+	if len(itemTypes) != 0 {
+		query = fmt.Sprintf("%s AND item_type IN (?%s)", query, strings.Repeat(",?", len(itemTypes)-1))
+		for _, t := range itemTypes {
+			params = append(params, t)
+		}
+	}
+
+	rows, err := m.q.QueryContext(ctx, query, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -497,7 +687,11 @@ func (m *mgr) ListShares(ctx context.Context, user *userpb.User, filters []*ocm.
 		if err := rows.Scan(&s.ID, &s.Token, &s.Prefix, &s.ItemSource, &s.Name, &s.ShareWith, &s.Owner, &s.Initiator, &s.Ctime, &s.Mtime, &s.Expiration, &s.ShareType); err != nil {
 			continue
 		}
-		shares = append(shares, convertToCS3OCMShare(&s, nil))
+		share, err := convertToCS3OCMShare(&s, nil)
+		if err != nil {
+			continue
+		}
+		shares = append(shares, share)
 		ids = append(ids, s.ID)
 	}
 
@@ -521,6 +715,76 @@ func (m *mgr) ListShares(ctx context.Context, user *userpb.User, filters []*ocm.
 	return shares, nil
 }
 
+// resourceIDsBatchSize is the maximum number of resource ids looked up in a
+// single query, keeping the number of placeholders well below the limits of mysql.
+var resourceIDsBatchSize = 500
+
+// ListSharesByResources returns the shares created by the user on the given
+// resources, grouped by the resource ids as wrapped by resourceid.OwnCloudResourceIDWrap.
+// The resources are looked up in batches of resourceIDsBatchSize ids.
+func (m *mgr) ListSharesByResources(ctx context.Context, user *userpb.User, ids []*provider.ResourceId) (map[string][]*ocm.Share, error) {
+	grouped := make(map[string][]*ocm.Share)
+	for start := 0; start < len(ids); start += resourceIDsBatchSize {
+		end := min(start+resourceIDsBatchSize, len(ids))
+		shares, err := m.listSharesByResources(ctx, user, ids[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range shares {
+			key := resourceid.OwnCloudResourceIDWrap(s.ResourceId)
+			grouped[key] = append(grouped[key], s)
+		}
+	}
+	return grouped, nil
+}
+
+func (m *mgr) listSharesByResources(ctx context.Context, user *userpb.User, ids []*provider.ResourceId) ([]*ocm.Share, error) {
+	query := "SELECT id, token, fileid_prefix, item_source, name, share_with, owner, initiator, ctime, mtime, expiration, type FROM ocm_shares WHERE (initiator=? OR owner=?) AND (fileid_prefix, item_source) IN "
+	params := []any{user.Id.OpaqueId, user.Id.OpaqueId}
+	in := strings.Repeat("(?,?),", len(ids))
+	query += "(" + in[:len(in)-1] + ")"
+	for _, id := range ids {
+		params = append(params, id.StorageId, id.OpaqueId)
+	}
+
+	rows, err := m.q.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var s dbShare
+	shares := []*ocm.Share{}
+	var shareIDs []any
+	for rows.Next() {
+		if err := rows.Scan(&s.ID, &s.Token, &s.Prefix, &s.ItemSource, &s.Name, &s.ShareWith, &s.Owner, &s.Initiator, &s.Ctime, &s.Mtime, &s.Expiration, &s.ShareType); err != nil {
+			continue
+		}
+		share, err := convertToCS3OCMShare(&s, nil)
+		if err != nil {
+			continue
+		}
+		shares = append(shares, share)
+		shareIDs = append(shareIDs, s.ID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	am, err := m.getAccessMethodsIds(ctx, shareIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, share := range shares {
+		if methods, ok := am[share.Id.OpaqueId]; ok {
+			share.AccessMethods = methods
+		}
+	}
+
+	return shares, nil
+}
+
 func (m *mgr) getAccessMethodsIds(ctx context.Context, ids []any) (map[string][]*ocm.AccessMethod, error) {
 	methods := make(map[string][]*ocm.AccessMethod)
 	if len(ids) == 0 {
@@ -531,7 +795,7 @@ func (m *mgr) getAccessMethodsIds(ctx context.Context, ids []any) (map[string][]
 	in := strings.Repeat("?,", len(ids))
 	query += "(" + in[:len(in)-1] + ")"
 
-	rows, err := m.db.QueryContext(ctx, query, ids...)
+	rows, err := m.q.QueryContext(ctx, query, ids...)
 	if err != nil {
 		return nil, err
 	}
@@ -552,8 +816,8 @@ func (m *mgr) getAccessMethodsIds(ctx context.Context, ids []any) (map[string][]
 	return methods, nil
 }
 
-func storeWebDAVProtocol(tx *sql.Tx, shareID int64, o *ocm.Protocol_WebdavOptions) error {
-	pID, err := storeProtocol(tx, shareID, WebDAVProtocol)
+func storeWebDAVProtocol(ctx context.Context, tx execer, shareID int64, o *ocm.Protocol_WebdavOptions) error {
+	pID, err := storeProtocol(ctx, tx, shareID, WebDAVProtocol)
 	if err != nil {
 		return err
 	}
@@ -561,12 +825,12 @@ func storeWebDAVProtocol(tx *sql.Tx, shareID int64, o *ocm.Protocol_WebdavOption
 	query := "INSERT INTO ocm_protocol_webdav SET ocm_protocol_id=?, uri=?, shared_secret=?, permissions=?"
 	params := []any{pID, o.WebdavOptions.Uri, o.WebdavOptions.SharedSecret, utils.SharePermToInt(o.WebdavOptions.Permissions.Permissions)}
 
-	_, err = tx.Exec(query, params...)
+	_, err = tx.ExecContext(ctx, query, params...)
 	return err
 }
 
-func storeWebappProtocol(tx *sql.Tx, shareID int64, o *ocm.Protocol_WebappOptions) error {
-	pID, err := storeProtocol(tx, shareID, WebappProtocol)
+func storeWebappProtocol(ctx context.Context, tx execer, shareID int64, o *ocm.Protocol_WebappOptions) error {
+	pID, err := storeProtocol(ctx, tx, shareID, WebappProtocol)
 	if err != nil {
 		return err
 	}
@@ -574,12 +838,12 @@ func storeWebappProtocol(tx *sql.Tx, shareID int64, o *ocm.Protocol_WebappOption
 	query := "INSERT INTO ocm_protocol_webapp SET ocm_protocol_id=?, uri_template=?, view_mode=?"
 	params := []any{pID, o.WebappOptions.UriTemplate, o.WebappOptions.ViewMode}
 
-	_, err = tx.Exec(query, params...)
+	_, err = tx.ExecContext(ctx, query, params...)
 	return err
 }
 
-func storeTransferProtocol(tx *sql.Tx, shareID int64, o *ocm.Protocol_TransferOptions) error {
-	pID, err := storeProtocol(tx, shareID, TransferProtocol)
+func storeTransferProtocol(ctx context.Context, tx execer, shareID int64, o *ocm.Protocol_TransferOptions) error {
+	pID, err := storeProtocol(ctx, tx, shareID, TransferProtocol)
 	if err != nil {
 		return err
 	}
@@ -587,15 +851,15 @@ func storeTransferProtocol(tx *sql.Tx, shareID int64, o *ocm.Protocol_TransferOp
 	query := "INSERT INTO ocm_protocol_transfer SET ocm_protocol_id=?, source_uri=?, shared_secret=?, size=?"
 	params := []any{pID, o.TransferOptions.SourceUri, o.TransferOptions.SharedSecret, o.TransferOptions.Size}
 
-	_, err = tx.Exec(query, params...)
+	_, err = tx.ExecContext(ctx, query, params...)
 	return err
 }
 
-func storeProtocol(tx *sql.Tx, shareID int64, p Protocol) (int64, error) {
+func storeProtocol(ctx context.Context, tx execer, shareID int64, p Protocol) (int64, error) {
 	query := "INSERT INTO ocm_received_share_protocols SET ocm_received_share_id=?, type=?"
 	params := []any{shareID, int(p)}
 
-	res, err := tx.Exec(query, params...)
+	res, err := tx.ExecContext(ctx, query, params...)
 	if err != nil {
 		return 0, err
 	}
@@ -604,16 +868,16 @@ func storeProtocol(tx *sql.Tx, shareID int64, p Protocol) (int64, error) {
 
 // StoreReceivedShare stores a received share.
 func (m *mgr) StoreReceivedShare(ctx context.Context, s *ocm.ReceivedShare) (*ocm.ReceivedShare, error) {
-	if err := transaction(ctx, m.db, func(tx *sql.Tx) error {
+	if err := m.transaction(ctx, func(tx execer) error {
 		query := "INSERT INTO ocm_received_shares SET name=?,remote_share_id=?,item_type=?,share_with=?,owner=?,initiator=?,ctime=?,mtime=?,type=?,state=?"
-		params := []any{s.Name, s.RemoteShareId, convertFromCS3ResourceType(s.ResourceType), s.Grantee.GetUserId().OpaqueId, formatUserID(s.Owner), formatUserID(s.Creator), s.Ctime.Seconds, s.Mtime.Seconds, convertFromCS3OCMShareType(s.ShareType), convertFromCS3OCMShareState(s.State)}
+		params := []any{s.Name, s.RemoteShareId, convertFromCS3ResourceType(s.ResourceType), formatLocalGrantee(s.Grantee), formatUserID(s.Owner), formatUserID(s.Creator), s.Ctime.Seconds, s.Mtime.Seconds, convertFromCS3OCMShareType(s.ShareType), convertFromCS3OCMShareState(s.State)}
 
 		if s.Expiration != nil {
 			query += ",expiration=?"
 			params = append(params, s.Expiration.Seconds)
 		}
 
-		res, err := tx.Exec(query, params...)
+		res, err := tx.ExecContext(ctx, query, params...)
 		if err != nil {
 			return err
 		}
@@ -626,15 +890,15 @@ func (m *mgr) StoreReceivedShare(ctx context.Context, s *ocm.ReceivedShare) (*oc
 		for _, p := range s.Protocols {
 			switch r := p.Term.(type) {
 			case *ocm.Protocol_WebdavOptions:
-				if err := storeWebDAVProtocol(tx, id, r); err != nil {
+				if err := storeWebDAVProtocol(ctx, tx, id, r); err != nil {
 					return err
 				}
 			case *ocm.Protocol_WebappOptions:
-				if err := storeWebappProtocol(tx, id, r); err != nil {
+				if err := storeWebappProtocol(ctx, tx, id, r); err != nil {
 					return err
 				}
 			case *ocm.Protocol_TransferOptions:
-				if err := storeTransferProtocol(tx, id, r); err != nil {
+				if err := storeTransferProtocol(ctx, tx, id, r); err != nil {
 					return err
 				}
 			}
@@ -652,6 +916,13 @@ func (m *mgr) StoreReceivedShare(ctx context.Context, s *ocm.ReceivedShare) (*oc
 		return nil, err
 	}
 
+	m.hookMu.RLock()
+	onAccepted := m.onAccepted
+	m.hookMu.RUnlock()
+	if onAccepted != nil && s.State == ocm.ShareState_SHARE_STATE_ACCEPTED {
+		onAccepted(ctx, s)
+	}
+
 	return s, nil
 }
 
@@ -659,7 +930,7 @@ func (m *mgr) StoreReceivedShare(ctx context.Context, s *ocm.ReceivedShare) (*oc
 func (m *mgr) ListReceivedShares(ctx context.Context, user *userpb.User) ([]*ocm.ReceivedShare, error) {
 	query := "SELECT id, name, remote_share_id, item_type, share_with, owner, initiator, ctime, mtime, expiration, type, state FROM ocm_received_shares WHERE share_with=?"
 
-	rows, err := m.db.QueryContext(ctx, query, user.Id.OpaqueId)
+	rows, err := m.q.QueryContext(ctx, query, user.Id.OpaqueId)
 	if err != nil {
 		return nil, err
 	}
@@ -671,7 +942,11 @@ func (m *mgr) ListReceivedShares(ctx context.Context, user *userpb.User) ([]*ocm
 		if err := rows.Scan(&s.ID, &s.Name, &s.RemoteShareID, &s.ItemType, &s.ShareWith, &s.Owner, &s.Initiator, &s.Ctime, &s.Mtime, &s.Expiration, &s.Type, &s.State); err != nil {
 			continue
 		}
-		shares = append(shares, convertToCS3OCMReceivedShare(&s, nil))
+		share, err := convertToCS3OCMReceivedShare(&s, nil)
+		if err != nil {
+			continue
+		}
+		shares = append(shares, share)
 		ids = append(ids, s.ID)
 	}
 
@@ -688,6 +963,7 @@ func (m *mgr) ListReceivedShares(ctx context.Context, user *userpb.User) ([]*ocm
 		}
 	}
 
+	uniqueNames(shares, m.c.MountNaming)
 	return shares, nil
 }
 
@@ -700,7 +976,7 @@ func (m *mgr) getProtocolsIds(ctx context.Context, ids []any) (map[string][]*ocm
 	in := strings.Repeat("?,", len(ids))
 	query += "(" + in[:len(in)-1] + ")"
 
-	rows, err := m.db.QueryContext(ctx, query, ids...)
+	rows, err := m.q.QueryContext(ctx, query, ids...)
 	if err != nil {
 		return nil, err
 	}
@@ -728,8 +1004,14 @@ func (m *mgr) GetReceivedShare(ctx context.Context, user *userpb.User, ref *ocm.
 	default:
 		err = errtypes.NotFound(ref.String())
 	}
+	if err != nil {
+		return nil, err
+	}
 
-	return s, err
+	if err := m.uniqueName(ctx, user, s); err != nil {
+		return nil, err
+	}
+	return s, nil
 }
 
 func (m *mgr) getReceivedByID(ctx context.Context, user *userpb.User, id *ocm.ShareId) (*ocm.ReceivedShare, error) {
@@ -737,7 +1019,7 @@ func (m *mgr) getReceivedByID(ctx context.Context, user *userpb.User, id *ocm.Sh
 	params := []any{id.OpaqueId, user.Id.OpaqueId}
 
 	var s dbReceivedShare
-	if err := m.db.QueryRowContext(ctx, query, params...).Scan(&s.ID, &s.Name, &s.RemoteShareID, &s.ItemType, &s.ShareWith, &s.Owner, &s.Initiator, &s.Ctime, &s.Mtime, &s.Expiration, &s.Type, &s.State); err != nil {
+	if err := m.q.QueryRowContext(ctx, query, params...).Scan(&s.ID, &s.Name, &s.RemoteShareID, &s.ItemType, &s.ShareWith, &s.Owner, &s.Initiator, &s.Ctime, &s.Mtime, &s.Expiration, &s.Type, &s.State); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, share.ErrShareNotFound
 		}
@@ -749,14 +1031,70 @@ func (m *mgr) getReceivedByID(ctx context.Context, user *userpb.User, id *ocm.Sh
 		return nil, err
 	}
 
-	return convertToCS3OCMReceivedShare(&s, p), nil
+	return convertToCS3OCMReceivedShare(&s, p)
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// uniqueName gives the received share s the name it is listed with among
+// the received shares of the user. Only the shares whose names can collide
+// with the stored name of s are looked up: the ones with the same name and
+// the ones with a name decorated by the naming strategy.
+func (m *mgr) uniqueName(ctx context.Context, user *userpb.User, s *ocm.ReceivedShare) error {
+	if m.c.MountNaming == "" {
+		return nil
+	}
+
+	var name string
+	if err := m.q.QueryRowContext(ctx, "SELECT name FROM ocm_received_shares WHERE id=?", s.Id.OpaqueId).Scan(&name); err != nil {
+		if err == sql.ErrNoRows {
+			return share.ErrShareNotFound
+		}
+		return err
+	}
+
+	query := "SELECT id, name, remote_share_id, item_type, share_with, owner, initiator, ctime, mtime, expiration, type, state FROM ocm_received_shares WHERE share_with=? AND (name=? OR name LIKE ?)"
+	stem := strings.TrimSuffix(name, path.Ext(name))
+	rows, err := m.q.QueryContext(ctx, query, user.Id.OpaqueId, name, likeEscaper.Replace(stem)+" (%")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		r      dbReceivedShare
+		shares []*ocm.ReceivedShare
+	)
+	for rows.Next() {
+		if err := rows.Scan(&r.ID, &r.Name, &r.RemoteShareID, &r.ItemType, &r.ShareWith, &r.Owner, &r.Initiator, &r.Ctime, &r.Mtime, &r.Expiration, &r.Type, &r.State); err != nil {
+			continue
+		}
+		l, err := convertToCS3OCMReceivedShare(&r, nil)
+		if err != nil {
+			continue
+		}
+		shares = append(shares, l)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	uniqueNames(shares, m.c.MountNaming)
+	for _, l := range shares {
+		if l.Id.OpaqueId == s.Id.OpaqueId {
+			s.Name = l.Name
+			return nil
+		}
+	}
+	return nil
 }
 
 func (m *mgr) getProtocols(ctx context.Context, id int) ([]*ocm.Protocol, error) {
 	query := "SELECT p.type, dav.uri, dav.shared_secret, dav.permissions, app.uri_template, app.view_mode, tx.source_uri, tx.shared_secret, tx.size FROM ocm_received_share_protocols as p LEFT JOIN ocm_protocol_webdav as dav ON p.id=dav.ocm_protocol_id LEFT JOIN ocm_protocol_webapp as app ON p.id=app.ocm_protocol_id LEFT JOIN ocm_protocol_transfer as tx ON p.id=tx.ocm_protocol_id WHERE p.ocm_received_share_id=?"
 
 	var protocols []*ocm.Protocol
-	rows, err := m.db.QueryContext(ctx, query, id)
+	rows, err := m.q.QueryContext(ctx, query, id)
 	if err != nil {
 		return nil, err
 	}
@@ -785,13 +1123,17 @@ func (m *mgr) UpdateReceivedShare(ctx context.Context, user *userpb.User, s *ocm
 	params = append(params, fparams...)
 	params = append(params, s.Id.OpaqueId)
 
-	res, err := m.db.ExecContext(ctx, query, params...)
+	res, err := m.q.ExecContext(ctx, query, params...)
 	if err != nil {
 		return nil, err
 	}
 	if n, _ := res.RowsAffected(); n == 0 {
 		return nil, share.ErrShareNotFound
 	}
+
+	if err := m.uniqueName(ctx, user, updatedShare); err != nil {
+		return nil, err
+	}
 	return updatedShare, nil
 }
 
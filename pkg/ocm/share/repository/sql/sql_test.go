@@ -19,20 +19,26 @@
 package sql
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	appprovider "github.com/cs3org/go-cs3apis/cs3/app/provider/v1beta1"
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
 	providerv1beta1 "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	typesv1beta1 "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/utils/resourceid"
+	"github.com/rs/zerolog"
 	"google.golang.org/genproto/protobuf/field_mask"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/fieldmaskpb"
@@ -97,6 +103,18 @@ func startDatabase(ctx *sql.Context, tables map[string]*memory.Table) (engine *s
 	return
 }
 
+// testConfig completes the configuration c with the access to the test
+// database listening on p. The schema check is turned off, the tests
+// exercising it turn it back on explicitly.
+func testConfig(p int, c *config) *config {
+	c.DBUsername = "root"
+	c.DBPassword = ""
+	c.DBAddress = fmt.Sprintf("%s:%d", address, p)
+	c.DBName = dbName
+	c.SkipSchemaCheck = true
+	return c
+}
+
 func getIDFunc() func() int64 {
 	var i int64
 	return func() int64 {
@@ -123,6 +141,7 @@ func createShareTables(ctx *sql.Context, initData []*ocm.Share) map[string]*memo
 		{Name: "mtime", Type: sql.Uint64, Nullable: false, Source: ocmShareTable},
 		{Name: "expiration", Type: sql.Uint64, Nullable: true, Source: ocmShareTable},
 		{Name: "type", Type: sql.Int8, Nullable: false, Source: ocmShareTable},
+		{Name: "item_type", Type: sql.Int8, Nullable: false, Source: ocmShareTable},
 	}), &memory.ForeignKeyCollection{})
 
 	must(tableShares.CreateIndex(ctx, "test", sql.IndexUsing_BTree, sql.IndexConstraint_Unique, []sql.IndexColumn{
@@ -175,12 +194,15 @@ func createShareTables(ctx *sql.Context, initData []*ocm.Share) map[string]*memo
 	tables[ocmAMWebappTable] = webapp
 
 	for _, share := range initData {
-		shareWith := share.Grantee.GetUserId()
 		var expiration uint64
 		if share.Expiration != nil {
 			expiration = share.Expiration.Seconds
 		}
-		must(tableShares.Insert(ctx, sql.NewRow(mustInt(share.Id.OpaqueId), share.Token, share.ResourceId.StorageId, share.ResourceId.OpaqueId, share.Name, fmt.Sprintf("%s@%s", shareWith.OpaqueId, shareWith.Idp), share.Owner.OpaqueId, share.Creator.OpaqueId, share.Ctime.Seconds, share.Mtime.Seconds, expiration, int8(ShareTypeUser))))
+		shareType := ShareTypeUser
+		if share.ShareType == ocm.ShareType_SHARE_TYPE_GROUP {
+			shareType = ShareTypeGroup
+		}
+		must(tableShares.Insert(ctx, sql.NewRow(mustInt(share.Id.OpaqueId), share.Token, share.ResourceId.StorageId, share.ResourceId.OpaqueId, share.Name, mustGrantee(share.Grantee), share.Owner.OpaqueId, share.Creator.OpaqueId, share.Ctime.Seconds, share.Mtime.Seconds, expiration, int8(shareType), int8(ItemTypeFolder))))
 
 		for _, m := range share.AccessMethods {
 			i := id()
@@ -276,7 +298,7 @@ func createReceivedShareTables(ctx *sql.Context, initData []*ocm.ReceivedShare)
 			expiration = share.Expiration.Seconds
 		}
 
-		must(tableShares.Insert(ctx, sql.NewRow(mustInt(share.Id.OpaqueId), share.Name, share.RemoteShareId, int8(convertFromCS3ResourceType(share.ResourceType)), share.Grantee.GetUserId().OpaqueId, fmt.Sprintf("%s@%s", share.Owner.OpaqueId, share.Owner.Idp), fmt.Sprintf("%s@%s", share.Creator.OpaqueId, share.Creator.Idp), share.Ctime.Seconds, share.Mtime.Seconds, expiration, int8(convertFromCS3OCMShareType(share.ShareType)), int8(convertFromCS3OCMShareState(share.State)))))
+		must(tableShares.Insert(ctx, sql.NewRow(mustInt(share.Id.OpaqueId), share.Name, share.RemoteShareId, int8(convertFromCS3ResourceType(share.ResourceType)), formatLocalGrantee(share.Grantee), fmt.Sprintf("%s@%s", share.Owner.OpaqueId, share.Owner.Idp), fmt.Sprintf("%s@%s", share.Creator.OpaqueId, share.Creator.Idp), share.Ctime.Seconds, share.Mtime.Seconds, expiration, int8(convertFromCS3OCMShareType(share.ShareType)), int8(convertFromCS3OCMShareState(share.State)))))
 
 		for _, p := range share.Protocols {
 			i := id()
@@ -303,6 +325,14 @@ func must(err error) {
 	}
 }
 
+func mustGrantee(g *providerv1beta1.Grantee) string {
+	s, err := formatGrantee(g)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
 func mustInt(s string) int64 {
 	i, err := strconv.ParseInt(s, 10, 64)
 	if err != nil {
@@ -616,12 +646,7 @@ func TestGetShare(t *testing.T) {
 			_, port, cleanup := startDatabase(ctx, tables)
 			t.Cleanup(cleanup)
 
-			r, err := New(context.Background(), map[string]interface{}{
-				"db_username": "root",
-				"db_password": "",
-				"db_address":  fmt.Sprintf("%s:%d", address, port),
-				"db_name":     dbName,
-			})
+			r, err := NewFromConfig(context.Background(), testConfig(port, &config{}))
 
 			if err != nil {
 				t.Fatalf("not expected error while creating share repository driver: %+v", err)
@@ -1055,12 +1080,7 @@ func TestListShares(t *testing.T) {
 			_, port, cleanup := startDatabase(ctx, tables)
 			t.Cleanup(cleanup)
 
-			r, err := New(context.Background(), map[string]interface{}{
-				"db_username": "root",
-				"db_password": "",
-				"db_address":  fmt.Sprintf("%s:%d", address, port),
-				"db_name":     dbName,
-			})
+			r, err := NewFromConfig(context.Background(), testConfig(port, &config{}))
 
 			if err != nil {
 				t.Fatalf("not expected error while creating share repository driver: %+v", err)
@@ -1145,7 +1165,7 @@ func TestStoreShare(t *testing.T) {
 				},
 			},
 			expected: storeShareExpected{
-				shares: []sql.Row{{int64(1), "qwerty", "storage", "resource-id1", "file-name", "richard@cesnet", "einstein", "marie", uint64(1670859468), uint64(1670859468), nil, int8(0)}},
+				shares: []sql.Row{{int64(1), "qwerty", "storage", "resource-id1", "file-name", "richard@cesnet", "einstein", "marie", uint64(1670859468), uint64(1670859468), nil, int8(0), int8(ItemTypeFolder)}},
 				accessmethods: []sql.Row{
 					{int64(1), int64(1), int8(0)},
 					{int64(2), int64(1), int8(1)},
@@ -1189,8 +1209,8 @@ func TestStoreShare(t *testing.T) {
 			},
 			expected: storeShareExpected{
 				shares: []sql.Row{
-					{int64(10), "qwerty", "storage", "resource-id1", "file-name", "richard@cesnet", "einstein", "marie", uint64(1670859468), uint64(1670859468), uint64(0), int8(0)},
-					{int64(11), "qwerty", "storage", "other-resource", "file-name", "richard@cesnet", "einstein", "marie", uint64(1670859468), uint64(1670859468), nil, int8(0)},
+					{int64(10), "qwerty", "storage", "resource-id1", "file-name", "richard@cesnet", "einstein", "marie", uint64(1670859468), uint64(1670859468), uint64(0), int8(0), int8(ItemTypeFolder)},
+					{int64(11), "qwerty", "storage", "other-resource", "file-name", "richard@cesnet", "einstein", "marie", uint64(1670859468), uint64(1670859468), nil, int8(0), int8(ItemTypeFolder)},
 				},
 				accessmethods: []sql.Row{
 					{int64(1), int64(10), int8(0)},
@@ -1247,12 +1267,7 @@ func TestStoreShare(t *testing.T) {
 			engine, port, cleanup := startDatabase(ctx, tables)
 			t.Cleanup(cleanup)
 
-			r, err := New(context.Background(), map[string]interface{}{
-				"db_username": "root",
-				"db_password": "",
-				"db_address":  fmt.Sprintf("%s:%d", address, port),
-				"db_name":     dbName,
-			})
+			r, err := NewFromConfig(context.Background(), testConfig(port, &config{}))
 
 			if err != nil {
 				t.Fatalf("not expected error while creating share repository driver: %+v", err)
@@ -1306,7 +1321,7 @@ func TestUpdateShare(t *testing.T) {
 			ref:    &ocm.ShareReference{Spec: &ocm.ShareReference_Id{Id: &ocm.ShareId{OpaqueId: "10"}}},
 			fields: []*ocm.UpdateOCMShareRequest_UpdateField{{Field: &ocm.UpdateOCMShareRequest_UpdateField_Expiration{Expiration: &typesv1beta1.Timestamp{Seconds: uint64(fixedTime.Unix())}}}},
 			expected: storeShareExpected{
-				shares: []sql.Row{{int64(10), "qwerty", "storage", "resource-id1", "file-name", "richard@cesnet", "einstein", "marie", uint64(1686061921), uint64(fixedTime.Unix()), uint64(fixedTime.Unix()), int8(0)}},
+				shares: []sql.Row{{int64(10), "qwerty", "storage", "resource-id1", "file-name", "richard@cesnet", "einstein", "marie", uint64(1686061921), uint64(fixedTime.Unix()), uint64(fixedTime.Unix()), int8(0), int8(ItemTypeFolder)}},
 				accessmethods: []sql.Row{
 					{int64(1), int64(10), int8(0)},
 					{int64(2), int64(10), int8(1)},
@@ -1350,7 +1365,7 @@ func TestUpdateShare(t *testing.T) {
 				},
 			},
 			expected: storeShareExpected{
-				shares: []sql.Row{{int64(10), "qwerty", "storage", "resource-id1", "file-name", "richard@cesnet", "einstein", "marie", uint64(1686061921), uint64(fixedTime.Unix()), uint64(0), int8(0)}},
+				shares: []sql.Row{{int64(10), "qwerty", "storage", "resource-id1", "file-name", "richard@cesnet", "einstein", "marie", uint64(1686061921), uint64(fixedTime.Unix()), uint64(0), int8(0), int8(ItemTypeFolder)}},
 				accessmethods: []sql.Row{
 					{int64(1), int64(10), int8(0)},
 					{int64(2), int64(10), int8(1)},
@@ -1387,7 +1402,7 @@ func TestUpdateShare(t *testing.T) {
 			}}},
 			fields: []*ocm.UpdateOCMShareRequest_UpdateField{{Field: &ocm.UpdateOCMShareRequest_UpdateField_Expiration{Expiration: &typesv1beta1.Timestamp{Seconds: uint64(fixedTime.Unix())}}}},
 			expected: storeShareExpected{
-				shares: []sql.Row{{int64(10), "qwerty", "storage", "resource-id1", "file-name", "richard@cesnet", "einstein", "marie", uint64(1686061921), uint64(fixedTime.Unix()), uint64(fixedTime.Unix()), int8(0)}},
+				shares: []sql.Row{{int64(10), "qwerty", "storage", "resource-id1", "file-name", "richard@cesnet", "einstein", "marie", uint64(1686061921), uint64(fixedTime.Unix()), uint64(fixedTime.Unix()), int8(0), int8(ItemTypeFolder)}},
 				accessmethods: []sql.Row{
 					{int64(1), int64(10), int8(0)},
 					{int64(2), int64(10), int8(1)},
@@ -1435,7 +1450,7 @@ func TestUpdateShare(t *testing.T) {
 				},
 			},
 			expected: storeShareExpected{
-				shares: []sql.Row{{int64(10), "qwerty", "storage", "resource-id1", "file-name", "richard@cesnet", "einstein", "marie", uint64(1686061921), uint64(fixedTime.Unix()), uint64(0), int8(0)}},
+				shares: []sql.Row{{int64(10), "qwerty", "storage", "resource-id1", "file-name", "richard@cesnet", "einstein", "marie", uint64(1686061921), uint64(fixedTime.Unix()), uint64(0), int8(0), int8(ItemTypeFolder)}},
 				accessmethods: []sql.Row{
 					{int64(1), int64(10), int8(0)},
 					{int64(2), int64(10), int8(1)},
@@ -1509,6 +1524,46 @@ func TestUpdateShare(t *testing.T) {
 			},
 			err: share.ErrShareNotFound,
 		},
+		{
+			description: "update access methods - by another user",
+			init: []*ocm.Share{
+				{
+					Id:         &ocm.ShareId{OpaqueId: "10"},
+					ResourceId: &providerv1beta1.ResourceId{StorageId: "storage", OpaqueId: "resource-id1"},
+					Name:       "file-name",
+					Token:      "qwerty",
+					Grantee:    &providerv1beta1.Grantee{Type: providerv1beta1.GranteeType_GRANTEE_TYPE_USER, Id: &providerv1beta1.Grantee_UserId{UserId: &userpb.UserId{Idp: "cesnet", OpaqueId: "richard", Type: userpb.UserType_USER_TYPE_FEDERATED}}},
+					Owner:      &userpb.UserId{OpaqueId: "einstein"},
+					Creator:    &userpb.UserId{OpaqueId: "marie"},
+					Ctime:      &typesv1beta1.Timestamp{Seconds: 1686061921},
+					Mtime:      &typesv1beta1.Timestamp{Seconds: 1686061921},
+					ShareType:  ocm.ShareType_SHARE_TYPE_USER,
+					AccessMethods: []*ocm.AccessMethod{
+						share.NewWebDavAccessMethod(conversions.NewViewerRole().CS3ResourcePermissions()),
+						share.NewWebappAccessMethod(appprovider.ViewMode_VIEW_MODE_READ_ONLY),
+					},
+				},
+			},
+			user: &userpb.User{Id: &userpb.UserId{OpaqueId: "bob"}},
+			ref:  &ocm.ShareReference{Spec: &ocm.ShareReference_Id{Id: &ocm.ShareId{OpaqueId: "10"}}},
+			fields: []*ocm.UpdateOCMShareRequest_UpdateField{
+				{
+					Field: &ocm.UpdateOCMShareRequest_UpdateField_AccessMethods{
+						AccessMethods: share.NewWebDavAccessMethod(conversions.NewEditorRole().CS3ResourcePermissions()),
+					},
+				},
+			},
+			err: share.ErrShareNotFound,
+			expected: storeShareExpected{
+				shares: []sql.Row{{int64(10), "qwerty", "storage", "resource-id1", "file-name", "richard@cesnet", "einstein", "marie", uint64(1686061921), uint64(1686061921), uint64(0), int8(0), int8(ItemTypeFolder)}},
+				accessmethods: []sql.Row{
+					{int64(1), int64(10), int8(0)},
+					{int64(2), int64(10), int8(1)},
+				},
+				webdav: []sql.Row{{int64(1), int64(1)}},
+				webapp: []sql.Row{{int64(2), int8(2)}},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1518,15 +1573,9 @@ func TestUpdateShare(t *testing.T) {
 			engine, port, cleanup := startDatabase(ctx, tables)
 			t.Cleanup(cleanup)
 
-			r, err := NewFromConfig(ctx,
-				&config{
-					DBUsername: "root",
-					DBPassword: "",
-					DBAddress:  fmt.Sprintf("%s:%d", address, port),
-					DBName:     dbName,
-					now:        func() time.Time { return fixedTime },
-				},
-			)
+			r, err := NewFromConfig(ctx, testConfig(port, &config{
+				now: func() time.Time { return fixedTime },
+			}))
 
 			if err != nil {
 				t.Fatalf("not expected error while creating share repository driver: %+v", err)
@@ -1537,7 +1586,7 @@ func TestUpdateShare(t *testing.T) {
 				t.Fatalf("not expected error updating share. got=%+v expected=%+v", err, tt.err)
 			}
 
-			if tt.err == nil {
+			if tt.expected.shares != nil {
 				checkShares(ctx, engine, tt.expected, t)
 			}
 		})
@@ -1688,12 +1737,7 @@ func TestGetReceivedShare(t *testing.T) {
 			_, port, cleanup := startDatabase(ctx, tables)
 			t.Cleanup(cleanup)
 
-			r, err := New(context.Background(), map[string]interface{}{
-				"db_username": "root",
-				"db_password": "",
-				"db_address":  fmt.Sprintf("%s:%d", address, port),
-				"db_name":     dbName,
-			})
+			r, err := NewFromConfig(context.Background(), testConfig(port, &config{}))
 
 			if err != nil {
 				t.Fatalf("not expected error while creating share repository driver: %+v", err)
@@ -1800,13 +1844,9 @@ func TestUpdateReceivedShare(t *testing.T) {
 			engine, port, cleanup := startDatabase(ctx, tables)
 			t.Cleanup(cleanup)
 
-			r, err := NewFromConfig(ctx, &config{
-				DBUsername: "root",
-				DBPassword: "",
-				DBAddress:  fmt.Sprintf("%s:%d", address, port),
-				DBName:     dbName,
-				now:        func() time.Time { return fixedTime },
-			})
+			r, err := NewFromConfig(ctx, testConfig(port, &config{
+				now: func() time.Time { return fixedTime },
+			}))
 
 			if err != nil {
 				t.Fatalf("not expected error while creating share repository driver: %+v", err)
@@ -2042,12 +2082,7 @@ func TestListReceviedShares(t *testing.T) {
 			_, port, cleanup := startDatabase(ctx, tables)
 			t.Cleanup(cleanup)
 
-			r, err := New(context.Background(), map[string]interface{}{
-				"db_username": "root",
-				"db_password": "",
-				"db_address":  fmt.Sprintf("%s:%d", address, port),
-				"db_name":     dbName,
-			})
+			r, err := NewFromConfig(context.Background(), testConfig(port, &config{}))
 
 			if err != nil {
 				t.Fatalf("not expected error while creating share repository driver: %+v", err)
@@ -2190,12 +2225,7 @@ func TestStoreReceivedShare(t *testing.T) {
 			engine, port, cleanup := startDatabase(ctx, tables)
 			t.Cleanup(cleanup)
 
-			r, err := New(context.Background(), map[string]interface{}{
-				"db_username": "root",
-				"db_password": "",
-				"db_address":  fmt.Sprintf("%s:%d", address, port),
-				"db_name":     dbName,
-			})
+			r, err := NewFromConfig(context.Background(), testConfig(port, &config{}))
 
 			if err != nil {
 				t.Fatalf("not expected error while creating share repository driver: %+v", err)
@@ -2212,3 +2242,864 @@ func TestStoreReceivedShare(t *testing.T) {
 		})
 	}
 }
+
+func TestStoreReceivedShareAcceptedHook(t *testing.T) {
+	tests := []struct {
+		state ocm.ShareState
+		fired bool
+	}{
+		{state: ocm.ShareState_SHARE_STATE_ACCEPTED, fired: true},
+		{state: ocm.ShareState_SHARE_STATE_PENDING, fired: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.state.String(), func(t *testing.T) {
+			ctx := sql.NewEmptyContext()
+			tables := createReceivedShareTables(ctx, []*ocm.ReceivedShare{})
+			_, port, cleanup := startDatabase(ctx, tables)
+			t.Cleanup(cleanup)
+
+			r, err := NewFromConfig(context.Background(), testConfig(port, &config{}))
+			if err != nil {
+				t.Fatalf("not expected error while creating share repository driver: %+v", err)
+			}
+
+			var fired []*ocm.ReceivedShare
+			r.(share.AcceptedShareNotifier).OnAcceptedReceivedShare(func(_ context.Context, s *ocm.ReceivedShare) {
+				fired = append(fired, s)
+			})
+
+			stored, err := r.StoreReceivedShare(context.TODO(), &ocm.ReceivedShare{
+				RemoteShareId: "1-remote",
+				Name:          "file-name",
+				Grantee:       &providerv1beta1.Grantee{Type: providerv1beta1.GranteeType_GRANTEE_TYPE_USER, Id: &providerv1beta1.Grantee_UserId{UserId: &userpb.UserId{Idp: "cesnet", OpaqueId: "marie"}}},
+				Owner:         &userpb.UserId{Idp: "cernbox", OpaqueId: "einstein"},
+				Creator:       &userpb.UserId{Idp: "cernbox", OpaqueId: "einstein"},
+				Ctime:         &typesv1beta1.Timestamp{Seconds: 1670859468},
+				Mtime:         &typesv1beta1.Timestamp{Seconds: 1670859468},
+				ShareType:     ocm.ShareType_SHARE_TYPE_USER,
+				State:         tt.state,
+				ResourceType:  providerv1beta1.ResourceType_RESOURCE_TYPE_CONTAINER,
+			})
+			if err != nil {
+				t.Fatalf("not expected error storing share: %+v", err)
+			}
+
+			if !tt.fired {
+				if len(fired) != 0 {
+					t.Fatalf("expected the hook not to fire, fired %d times", len(fired))
+				}
+				return
+			}
+			if len(fired) != 1 {
+				t.Fatalf("expected the hook to fire once, fired %d times", len(fired))
+			}
+			if fired[0] != stored || fired[0].GetId().GetOpaqueId() == "" {
+				t.Fatalf("expected the hook to receive the stored share, got %+v", fired[0])
+			}
+		})
+	}
+}
+
+func TestGroupShareRoundTrip(t *testing.T) {
+	ctx := sql.NewEmptyContext()
+	tables := createShareTables(ctx, []*ocm.Share{})
+	engine, port, cleanup := startDatabase(ctx, tables)
+	t.Cleanup(cleanup)
+
+	r, err := NewFromConfig(context.Background(), testConfig(port, &config{}))
+	if err != nil {
+		t.Fatalf("not expected error while creating share repository driver: %+v", err)
+	}
+
+	grantee := &providerv1beta1.Grantee{Type: providerv1beta1.GranteeType_GRANTEE_TYPE_GROUP, Id: &providerv1beta1.Grantee_GroupId{GroupId: &grouppb.GroupId{Idp: "cesnet", OpaqueId: "physicists"}}}
+	stored, err := r.StoreShare(context.TODO(), &ocm.Share{
+		ResourceId: &providerv1beta1.ResourceId{StorageId: "storage", OpaqueId: "resource-id1"},
+		Name:       "file-name",
+		Token:      "qwerty",
+		Grantee:    grantee,
+		Owner:      &userpb.UserId{OpaqueId: "einstein"},
+		Creator:    &userpb.UserId{OpaqueId: "marie"},
+		Ctime:      &typesv1beta1.Timestamp{Seconds: 1670859468},
+		Mtime:      &typesv1beta1.Timestamp{Seconds: 1670859468},
+		ShareType:  ocm.ShareType_SHARE_TYPE_GROUP,
+		AccessMethods: []*ocm.AccessMethod{
+			share.NewWebDavAccessMethod(conversions.NewViewerRole().CS3ResourcePermissions()),
+		},
+	})
+	if err != nil {
+		t.Fatalf("not expected error storing share: %+v", err)
+	}
+
+	checkRows(ctx, engine, []sql.Row{{int64(1), "qwerty", "storage", "resource-id1", "file-name", "physicists@cesnet", "einstein", "marie", uint64(1670859468), uint64(1670859468), nil, int8(ShareTypeGroup), int8(ItemTypeFolder)}}, ocmShareTable, t)
+
+	got, err := r.GetShare(context.TODO(), &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein"}}, &ocm.ShareReference{Spec: &ocm.ShareReference_Id{Id: stored.Id}})
+	if err != nil {
+		t.Fatalf("not expected error getting share: %+v", err)
+	}
+	if got.ShareType != ocm.ShareType_SHARE_TYPE_GROUP {
+		t.Errorf("expected share type %s, got %s", ocm.ShareType_SHARE_TYPE_GROUP, got.ShareType)
+	}
+	if !proto.Equal(got.Grantee, grantee) {
+		t.Errorf("grantee not preserved. got=%+v expected=%+v", render.AsCode(got.Grantee), render.AsCode(grantee))
+	}
+
+	_, err = r.StoreShare(context.TODO(), &ocm.Share{
+		ResourceId: &providerv1beta1.ResourceId{StorageId: "storage", OpaqueId: "resource-id2"},
+		Name:       "file-name",
+		Token:      "asdfgh",
+		Grantee:    &providerv1beta1.Grantee{Type: providerv1beta1.GranteeType_GRANTEE_TYPE_GROUP},
+		Owner:      &userpb.UserId{OpaqueId: "einstein"},
+		Creator:    &userpb.UserId{OpaqueId: "marie"},
+		Ctime:      &typesv1beta1.Timestamp{Seconds: 1670859468},
+		Mtime:      &typesv1beta1.Timestamp{Seconds: 1670859468},
+		ShareType:  ocm.ShareType_SHARE_TYPE_GROUP,
+	})
+	if err == nil {
+		t.Error("expected an error storing a share with a group grantee without id")
+	}
+}
+
+func TestConvertToCS3Grantee(t *testing.T) {
+	g, err := convertToCS3Grantee("physicists@cesnet", ShareTypeGroup)
+	if err != nil {
+		t.Fatalf("not expected error: %+v", err)
+	}
+	if g.GetGroupId().GetOpaqueId() != "physicists" || g.GetGroupId().GetIdp() != "cesnet" {
+		t.Errorf("unexpected grantee %+v", render.AsCode(g))
+	}
+
+	g, err = convertToCS3Grantee("marie@example.org@cesnet", ShareTypeUser)
+	if err != nil {
+		t.Fatalf("not expected error: %+v", err)
+	}
+	if g.GetUserId().GetOpaqueId() != "marie@example.org" || g.GetUserId().GetIdp() != "cesnet" {
+		t.Errorf("unexpected grantee %+v", render.AsCode(g))
+	}
+
+	for _, shareWith := range []string{"physicists", "@cesnet", "physicists@"} {
+		if _, err := convertToCS3Grantee(shareWith, ShareTypeGroup); err == nil {
+			t.Errorf("expected an error converting %q", shareWith)
+		}
+	}
+}
+
+func TestConvertGroupReceivedShare(t *testing.T) {
+	s, err := convertToCS3OCMReceivedShare(&dbReceivedShare{
+		ID:        1,
+		Name:      "file-name",
+		ShareWith: "physicists",
+		Owner:     "einstein@cernbox",
+		Initiator: "marie@cesnet",
+		Type:      ShareTypeGroup,
+		State:     ShareStatePending,
+	}, nil)
+	if err != nil {
+		t.Fatalf("not expected error: %+v", err)
+	}
+
+	grantee := &providerv1beta1.Grantee{Type: providerv1beta1.GranteeType_GRANTEE_TYPE_GROUP, Id: &providerv1beta1.Grantee_GroupId{GroupId: &grouppb.GroupId{OpaqueId: "physicists"}}}
+	if s.ShareType != ocm.ShareType_SHARE_TYPE_GROUP {
+		t.Errorf("expected share type %s, got %s", ocm.ShareType_SHARE_TYPE_GROUP, s.ShareType)
+	}
+	if !proto.Equal(s.Grantee, grantee) {
+		t.Errorf("grantee not preserved. got=%+v expected=%+v", render.AsCode(s.Grantee), render.AsCode(grantee))
+	}
+	if formatLocalGrantee(s.Grantee) != "physicists" {
+		t.Errorf("expected share_with physicists, got %s", formatLocalGrantee(s.Grantee))
+	}
+}
+
+func TestStoreShareMaxExpiration(t *testing.T) {
+	fixedTime := time.Date(2023, time.December, 12, 12, 12, 0, 0, time.UTC)
+	now := uint64(fixedTime.Unix())
+	day := uint64(24 * 60 * 60)
+
+	tests := []struct {
+		description string
+		reject      bool
+		expiration  uint64
+		err         bool
+		expected    uint64
+	}{
+		{
+			description: "within limit",
+			expiration:  now + day,
+			expected:    now + day,
+		},
+		{
+			description: "over limit clamped",
+			expiration:  now + 30*day,
+			expected:    now + 7*day,
+		},
+		{
+			description: "over limit rejected",
+			reject:      true,
+			expiration:  now + 30*day,
+			err:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			ctx := sql.NewEmptyContext()
+			tables := createShareTables(ctx, []*ocm.Share{})
+			engine, port, cleanup := startDatabase(ctx, tables)
+			t.Cleanup(cleanup)
+
+			r, err := NewFromConfig(ctx, testConfig(port, &config{
+				MaxExpiration:             int64(7 * day),
+				RejectExceedingExpiration: tt.reject,
+				now:                       func() time.Time { return fixedTime },
+			}))
+			if err != nil {
+				t.Fatalf("not expected error while creating share repository driver: %+v", err)
+			}
+
+			_, err = r.StoreShare(context.TODO(), &ocm.Share{
+				ResourceId: &providerv1beta1.ResourceId{StorageId: "storage", OpaqueId: "resource-id1"},
+				Name:       "file-name",
+				Token:      "qwerty",
+				Grantee:    &providerv1beta1.Grantee{Type: providerv1beta1.GranteeType_GRANTEE_TYPE_USER, Id: &providerv1beta1.Grantee_UserId{UserId: &userpb.UserId{Idp: "cesnet", OpaqueId: "richard", Type: userpb.UserType_USER_TYPE_FEDERATED}}},
+				Owner:      &userpb.UserId{OpaqueId: "einstein"},
+				Creator:    &userpb.UserId{OpaqueId: "marie"},
+				Ctime:      &typesv1beta1.Timestamp{Seconds: 1670859468},
+				Mtime:      &typesv1beta1.Timestamp{Seconds: 1670859468},
+				Expiration: &typesv1beta1.Timestamp{Seconds: tt.expiration},
+				ShareType:  ocm.ShareType_SHARE_TYPE_USER,
+			})
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected an error storing a share exceeding the maximum expiration")
+				}
+				checkRows(ctx, engine, []sql.Row{}, ocmShareTable, t)
+				return
+			}
+			if err != nil {
+				t.Fatalf("not expected error storing share: %+v", err)
+			}
+
+			checkRows(ctx, engine, []sql.Row{{int64(1), "qwerty", "storage", "resource-id1", "file-name", "richard@cesnet", "einstein", "marie", uint64(1670859468), uint64(1670859468), tt.expected, int8(ShareTypeUser), int8(ItemTypeFolder)}}, ocmShareTable, t)
+		})
+	}
+}
+
+func TestListSharesOfTypes(t *testing.T) {
+	ctx := sql.NewEmptyContext()
+	tables := createShareTables(ctx, []*ocm.Share{})
+	_, port, cleanup := startDatabase(ctx, tables)
+	t.Cleanup(cleanup)
+
+	repo, err := NewFromConfig(context.Background(), testConfig(port, &config{}))
+	if err != nil {
+		t.Fatalf("not expected error while creating share repository driver: %+v", err)
+	}
+	r := repo.(share.ResourceTypeRepository)
+
+	newShare := func(token, resource string) *ocm.Share {
+		return &ocm.Share{
+			ResourceId: &providerv1beta1.ResourceId{StorageId: "storage", OpaqueId: resource},
+			Name:       resource,
+			Token:      token,
+			Grantee:    &providerv1beta1.Grantee{Type: providerv1beta1.GranteeType_GRANTEE_TYPE_USER, Id: &providerv1beta1.Grantee_UserId{UserId: &userpb.UserId{Idp: "cesnet", OpaqueId: "richard", Type: userpb.UserType_USER_TYPE_FEDERATED}}},
+			Owner:      &userpb.UserId{OpaqueId: "einstein"},
+			Creator:    &userpb.UserId{OpaqueId: "einstein"},
+			Ctime:      &typesv1beta1.Timestamp{Seconds: 1670859468},
+			Mtime:      &typesv1beta1.Timestamp{Seconds: 1670859468},
+			ShareType:  ocm.ShareType_SHARE_TYPE_USER,
+		}
+	}
+
+	if _, err := r.StoreShareOfType(context.TODO(), newShare("file-token", "file-id"), providerv1beta1.ResourceType_RESOURCE_TYPE_FILE); err != nil {
+		t.Fatalf("not expected error storing share: %+v", err)
+	}
+	if _, err := r.StoreShareOfType(context.TODO(), newShare("folder-token", "folder-id"), providerv1beta1.ResourceType_RESOURCE_TYPE_CONTAINER); err != nil {
+		t.Fatalf("not expected error storing share: %+v", err)
+	}
+	if _, err := r.StoreShareOfType(context.TODO(), newShare("invalid-token", "invalid-id"), providerv1beta1.ResourceType_RESOURCE_TYPE_INVALID); err == nil {
+		t.Fatal("expected error storing share of an invalid resource type")
+	}
+
+	user := &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein"}}
+	tests := []struct {
+		description string
+		filters     []*ocm.ListOCMSharesRequest_Filter
+		types       []providerv1beta1.ResourceType
+		expected    []string
+	}{
+		{
+			description: "files",
+			types:       []providerv1beta1.ResourceType{providerv1beta1.ResourceType_RESOURCE_TYPE_FILE},
+			expected:    []string{"file-token"},
+		},
+		{
+			description: "folders",
+			types:       []providerv1beta1.ResourceType{providerv1beta1.ResourceType_RESOURCE_TYPE_CONTAINER},
+			expected:    []string{"folder-token"},
+		},
+		{
+			description: "files or folders",
+			types:       []providerv1beta1.ResourceType{providerv1beta1.ResourceType_RESOURCE_TYPE_FILE, providerv1beta1.ResourceType_RESOURCE_TYPE_CONTAINER},
+			expected:    []string{"file-token", "folder-token"},
+		},
+		{
+			description: "files of a folder resource",
+			types:       []providerv1beta1.ResourceType{providerv1beta1.ResourceType_RESOURCE_TYPE_FILE},
+			filters: []*ocm.ListOCMSharesRequest_Filter{
+				share.ResourceIDFilter(&providerv1beta1.ResourceId{StorageId: "storage", OpaqueId: "folder-id"}),
+			},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			got, err := r.ListSharesOfTypes(context.TODO(), user, tt.filters, tt.types)
+			if err != nil {
+				t.Fatalf("not expected error while listing shares: %+v", err)
+			}
+
+			tokens := []string{}
+			for _, s := range got {
+				tokens = append(tokens, s.Token)
+			}
+			if !reflect.DeepEqual(tokens, tt.expected) {
+				t.Fatalf("list of shares do not match. got=%+v expected=%+v", tokens, tt.expected)
+			}
+		})
+	}
+}
+
+func TestListReceivedSharesMountNaming(t *testing.T) {
+	received := func(id string, owner *userpb.UserId) *ocm.ReceivedShare {
+		return &ocm.ReceivedShare{
+			Id:            &ocm.ShareId{OpaqueId: id},
+			RemoteShareId: id + "-remote",
+			Name:          "file-name",
+			Grantee:       &providerv1beta1.Grantee{Type: providerv1beta1.GranteeType_GRANTEE_TYPE_USER, Id: &providerv1beta1.Grantee_UserId{UserId: &userpb.UserId{Idp: "cesnet", OpaqueId: "marie"}}},
+			Owner:         owner,
+			Creator:       owner,
+			Ctime:         &typesv1beta1.Timestamp{Seconds: 1670859468},
+			Mtime:         &typesv1beta1.Timestamp{Seconds: 1670859468},
+			ShareType:     ocm.ShareType_SHARE_TYPE_USER,
+			State:         ocm.ShareState_SHARE_STATE_ACCEPTED,
+			ResourceType:  providerv1beta1.ResourceType_RESOURCE_TYPE_CONTAINER,
+		}
+	}
+	user := &userpb.User{Id: &userpb.UserId{Idp: "cesnet", OpaqueId: "marie"}}
+
+	tests := []struct {
+		strategy string
+		expected []string
+	}{
+		{strategy: "", expected: []string{"file-name", "file-name"}},
+		{strategy: MountNamingIdp, expected: []string{"file-name", "file-name (surfsara)"}},
+		{strategy: MountNamingCounter, expected: []string{"file-name", "file-name (2)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			ctx := sql.NewEmptyContext()
+			tables := createReceivedShareTables(ctx, []*ocm.ReceivedShare{
+				received("1", &userpb.UserId{Idp: "cernbox", OpaqueId: "einstein"}),
+				received("2", &userpb.UserId{Idp: "surfsara", OpaqueId: "richard"}),
+			})
+			engine, port, cleanup := startDatabase(ctx, tables)
+			t.Cleanup(cleanup)
+
+			r, err := NewFromConfig(context.Background(), testConfig(port, &config{MountNaming: tt.strategy}))
+			if err != nil {
+				t.Fatalf("not expected error while creating share repository driver: %+v", err)
+			}
+
+			got, err := r.ListReceivedShares(context.TODO(), user)
+			if err != nil {
+				t.Fatalf("not expected error while listing received shares: %+v", err)
+			}
+			names := map[string]string{}
+			for _, s := range got {
+				names[s.Id.OpaqueId] = s.Name
+			}
+			if names["1"] != tt.expected[0] || names["2"] != tt.expected[1] {
+				t.Fatalf("names do not match. got=%v expected=%v", names, tt.expected)
+			}
+
+			s, err := r.GetReceivedShare(context.TODO(), user, &ocm.ShareReference{Spec: &ocm.ShareReference_Id{Id: &ocm.ShareId{OpaqueId: "2"}}})
+			if err != nil {
+				t.Fatalf("not expected error getting the received share: %+v", err)
+			}
+			if s.Name != tt.expected[1] {
+				t.Errorf("expected name %s, got %s", tt.expected[1], s.Name)
+			}
+
+			// the stored names are not changed
+			checkRows(ctx, engine, []sql.Row{
+				{int64(1), "file-name", "1-remote", int8(ItemTypeFolder), "marie", "einstein@cernbox", "einstein@cernbox", uint64(1670859468), uint64(1670859468), uint64(0), int8(ShareTypeUser), int8(ShareStateAccepted)},
+				{int64(2), "file-name", "2-remote", int8(ItemTypeFolder), "marie", "richard@surfsara", "richard@surfsara", uint64(1670859468), uint64(1670859468), uint64(0), int8(ShareTypeUser), int8(ShareStateAccepted)},
+			}, ocmReceivedShareTable, t)
+
+			s.State = ocm.ShareState_SHARE_STATE_REJECTED
+			s.Name = "file-name"
+			s, err = r.UpdateReceivedShare(context.TODO(), user, s, &field_mask.FieldMask{Paths: []string{"state"}})
+			if err != nil {
+				t.Fatalf("not expected error updating the received share: %+v", err)
+			}
+			if s.Name != tt.expected[1] {
+				t.Errorf("expected the updated share to be named %s, got %s", tt.expected[1], s.Name)
+			}
+		})
+	}
+}
+
+func TestGetReceivedShareMountNamingTaken(t *testing.T) {
+	received := func(id, name string, owner *userpb.UserId) *ocm.ReceivedShare {
+		return &ocm.ReceivedShare{
+			Id:            &ocm.ShareId{OpaqueId: id},
+			RemoteShareId: id + "-remote",
+			Name:          name,
+			Grantee:       &providerv1beta1.Grantee{Type: providerv1beta1.GranteeType_GRANTEE_TYPE_USER, Id: &providerv1beta1.Grantee_UserId{UserId: &userpb.UserId{Idp: "cesnet", OpaqueId: "marie"}}},
+			Owner:         owner,
+			Creator:       owner,
+			Ctime:         &typesv1beta1.Timestamp{Seconds: 1670859468},
+			Mtime:         &typesv1beta1.Timestamp{Seconds: 1670859468},
+			ShareType:     ocm.ShareType_SHARE_TYPE_USER,
+			State:         ocm.ShareState_SHARE_STATE_ACCEPTED,
+			ResourceType:  providerv1beta1.ResourceType_RESOURCE_TYPE_FILE,
+		}
+	}
+	einstein := &userpb.UserId{Idp: "cernbox", OpaqueId: "einstein"}
+	richard := &userpb.UserId{Idp: "surfsara", OpaqueId: "richard"}
+	user := &userpb.User{Id: &userpb.UserId{Idp: "cesnet", OpaqueId: "marie"}}
+
+	ctx := sql.NewEmptyContext()
+	tables := createReceivedShareTables(ctx, []*ocm.ReceivedShare{
+		received("1", "file.txt", einstein),
+		received("2", "file (2).txt", einstein),
+		received("3", "file.txt", richard),
+		received("4", "other.txt", richard),
+	})
+	_, port, cleanup := startDatabase(ctx, tables)
+	t.Cleanup(cleanup)
+
+	r, err := NewFromConfig(context.Background(), testConfig(port, &config{MountNaming: MountNamingCounter}))
+	if err != nil {
+		t.Fatalf("not expected error while creating share repository driver: %+v", err)
+	}
+
+	expected := map[string]string{"1": "file.txt", "2": "file (2).txt", "3": "file (3).txt", "4": "other.txt"}
+	list, err := r.ListReceivedShares(context.TODO(), user)
+	if err != nil {
+		t.Fatalf("not expected error while listing received shares: %+v", err)
+	}
+	for _, s := range list {
+		if s.Name != expected[s.Id.OpaqueId] {
+			t.Errorf("expected share %s to be listed as %s, got %s", s.Id.OpaqueId, expected[s.Id.OpaqueId], s.Name)
+		}
+	}
+	for id, name := range expected {
+		s, err := r.GetReceivedShare(context.TODO(), user, &ocm.ShareReference{Spec: &ocm.ShareReference_Id{Id: &ocm.ShareId{OpaqueId: id}}})
+		if err != nil {
+			t.Fatalf("not expected error getting the received share: %+v", err)
+		}
+		if s.Name != name {
+			t.Errorf("expected share %s to be named %s, got %s", id, name, s.Name)
+		}
+	}
+}
+
+func TestInvalidMountNaming(t *testing.T) {
+	if _, err := New(context.Background(), map[string]interface{}{"mount_naming": "unknown"}); err == nil {
+		t.Error("expected an error for an unknown mount naming strategy")
+	}
+}
+
+func TestSchemaCheck(t *testing.T) {
+	allTables := func(ctx *sql.Context) map[string]*memory.Table {
+		tables := createShareTables(ctx, nil)
+		for name, table := range createReceivedShareTables(ctx, nil) {
+			tables[name] = table
+		}
+		return tables
+	}
+
+	newRepo := func(tables map[string]*memory.Table) error {
+		_, port, cleanup := startDatabase(sql.NewEmptyContext(), tables)
+		t.Cleanup(cleanup)
+		conf := testConfig(port, &config{})
+		conf.SkipSchemaCheck = false
+		_, err := NewFromConfig(context.Background(), conf)
+		return err
+	}
+
+	if err := newRepo(allTables(sql.NewEmptyContext())); err != nil {
+		t.Fatalf("not expected error with the complete schema: %+v", err)
+	}
+
+	tables := allTables(sql.NewEmptyContext())
+	delete(tables, ocmProtTransferTable)
+	err := newRepo(tables)
+	if err == nil {
+		t.Fatal("expected an error with a missing table")
+	}
+	if !strings.Contains(err.Error(), ocmProtTransferTable) {
+		t.Errorf("expected the error to name the missing table, got %v", err)
+	}
+}
+
+func TestSlowQueryLog(t *testing.T) {
+	ctx := sql.NewEmptyContext()
+	_, port, cleanup := startDatabase(ctx, createShareTables(ctx, nil))
+	t.Cleanup(cleanup)
+
+	// every reading of the clock advances it by 10ms
+	var clock time.Time
+	r, err := NewFromConfig(ctx, testConfig(port, &config{
+		SlowQueryMs: 1,
+		now: func() time.Time {
+			clock = clock.Add(10 * time.Millisecond)
+			return clock
+		},
+	}))
+	if err != nil {
+		t.Fatalf("not expected error while creating share repository driver: %+v", err)
+	}
+
+	var buf bytes.Buffer
+	log := zerolog.New(&buf)
+	user := &userpb.User{Id: &userpb.UserId{Idp: "cernbox", OpaqueId: "einstein"}}
+	if _, err := r.ListShares(appctx.WithLogger(context.Background(), &log), user, nil); err != nil {
+		t.Fatalf("not expected error while listing shares: %+v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "sql: slow query") || !strings.Contains(out, `"query":"SELECT`) || !strings.Contains(out, "FROM ocm_shares") {
+		t.Errorf("expected a slow query log entry for the listing of the shares, got %q", out)
+	}
+	if strings.Contains(out, "einstein") {
+		t.Errorf("expected the parameters not to be logged, got %q", out)
+	}
+}
+
+func TestUpdateShareRollback(t *testing.T) {
+	fixedTime := time.Date(2023, time.December, 12, 12, 12, 0, 0, time.UTC)
+	ctx := sql.NewEmptyContext()
+	tables := createShareTables(ctx, []*ocm.Share{
+		{
+			Id:         &ocm.ShareId{OpaqueId: "10"},
+			ResourceId: &providerv1beta1.ResourceId{StorageId: "storage", OpaqueId: "resource-id1"},
+			Name:       "file-name",
+			Token:      "qwerty",
+			Grantee:    &providerv1beta1.Grantee{Type: providerv1beta1.GranteeType_GRANTEE_TYPE_USER, Id: &providerv1beta1.Grantee_UserId{UserId: &userpb.UserId{Idp: "cesnet", OpaqueId: "richard", Type: userpb.UserType_USER_TYPE_FEDERATED}}},
+			Owner:      &userpb.UserId{OpaqueId: "einstein"},
+			Creator:    &userpb.UserId{OpaqueId: "marie"},
+			Ctime:      &typesv1beta1.Timestamp{Seconds: 1686061921},
+			Mtime:      &typesv1beta1.Timestamp{Seconds: 1686061921},
+			ShareType:  ocm.ShareType_SHARE_TYPE_USER,
+			AccessMethods: []*ocm.AccessMethod{
+				share.NewWebDavAccessMethod(conversions.NewViewerRole().CS3ResourcePermissions()),
+				share.NewWebappAccessMethod(appprovider.ViewMode_VIEW_MODE_READ_ONLY),
+			},
+		},
+	})
+	engine, port, cleanup := startDatabase(ctx, tables)
+	t.Cleanup(cleanup)
+
+	r, err := NewFromConfig(ctx, testConfig(port, &config{
+		now: func() time.Time { return fixedTime },
+	}))
+	if err != nil {
+		t.Fatalf("not expected error while creating share repository driver: %+v", err)
+	}
+
+	// the invalid view mode of the last update must not leave the others applied
+	_, err = r.UpdateShare(context.TODO(), &userpb.User{Id: &userpb.UserId{OpaqueId: "marie"}}, &ocm.ShareReference{Spec: &ocm.ShareReference_Id{Id: &ocm.ShareId{OpaqueId: "10"}}},
+		&ocm.UpdateOCMShareRequest_UpdateField{Field: &ocm.UpdateOCMShareRequest_UpdateField_Expiration{Expiration: &typesv1beta1.Timestamp{Seconds: uint64(fixedTime.Unix())}}},
+		&ocm.UpdateOCMShareRequest_UpdateField{Field: &ocm.UpdateOCMShareRequest_UpdateField_AccessMethods{AccessMethods: share.NewWebDavAccessMethod(conversions.NewEditorRole().CS3ResourcePermissions())}},
+		&ocm.UpdateOCMShareRequest_UpdateField{Field: &ocm.UpdateOCMShareRequest_UpdateField_AccessMethods{AccessMethods: share.NewWebappAccessMethod(appprovider.ViewMode(1000))}},
+	)
+	if err == nil {
+		t.Fatal("expected an error updating the share")
+	}
+
+	checkShares(ctx, engine, storeShareExpected{
+		shares: []sql.Row{{int64(10), "qwerty", "storage", "resource-id1", "file-name", "richard@cesnet", "einstein", "marie", uint64(1686061921), uint64(1686061921), uint64(0), int8(0), int8(ItemTypeFolder)}},
+		accessmethods: []sql.Row{
+			{int64(1), int64(10), int8(0)},
+			{int64(2), int64(10), int8(1)},
+		},
+		webdav: []sql.Row{{int64(1), int64(1)}},
+		webapp: []sql.Row{{int64(2), int8(2)}},
+	}, t)
+}
+
+func TestUpdateShareName(t *testing.T) {
+	fixedTime := time.Date(2023, time.December, 12, 12, 12, 0, 0, time.UTC)
+	grantee := &providerv1beta1.Grantee{Type: providerv1beta1.GranteeType_GRANTEE_TYPE_USER, Id: &providerv1beta1.Grantee_UserId{UserId: &userpb.UserId{Idp: "cesnet", OpaqueId: "richard", Type: userpb.UserType_USER_TYPE_FEDERATED}}}
+	owner := &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein"}}
+
+	tests := []struct {
+		description string
+		user        *userpb.User
+		ref         *ocm.ShareReference
+		fields      []*ocm.UpdateOCMShareRequest_UpdateField
+		expiration  uint64
+		err         bool
+	}{
+		{
+			description: "by id",
+			user:        owner,
+			ref:         &ocm.ShareReference{Spec: &ocm.ShareReference_Id{Id: &ocm.ShareId{OpaqueId: "10"}}},
+		},
+		{
+			description: "by key",
+			user:        owner,
+			ref: &ocm.ShareReference{Spec: &ocm.ShareReference_Key{Key: &ocm.ShareKey{
+				Owner:      &userpb.UserId{OpaqueId: "einstein"},
+				ResourceId: &providerv1beta1.ResourceId{StorageId: "storage", OpaqueId: "resource-id1"},
+				Grantee:    grantee,
+			}}},
+		},
+		{
+			description: "with other updates",
+			user:        owner,
+			ref:         &ocm.ShareReference{Spec: &ocm.ShareReference_Id{Id: &ocm.ShareId{OpaqueId: "10"}}},
+			fields: []*ocm.UpdateOCMShareRequest_UpdateField{
+				{Field: &ocm.UpdateOCMShareRequest_UpdateField_Expiration{Expiration: &typesv1beta1.Timestamp{Seconds: 1703164920}}},
+			},
+			expiration: 1703164920,
+		},
+		{
+			description: "with an invalid update",
+			user:        owner,
+			ref:         &ocm.ShareReference{Spec: &ocm.ShareReference_Id{Id: &ocm.ShareId{OpaqueId: "10"}}},
+			fields: []*ocm.UpdateOCMShareRequest_UpdateField{
+				{Field: &ocm.UpdateOCMShareRequest_UpdateField_AccessMethods{AccessMethods: &ocm.AccessMethod{Term: &ocm.AccessMethod_WebdavOptions{WebdavOptions: &ocm.WebDAVAccessMethod{}}}}},
+			},
+			err: true,
+		},
+		{
+			description: "by the creator not owning the share",
+			user:        &userpb.User{Id: &userpb.UserId{OpaqueId: "marie"}},
+			ref:         &ocm.ShareReference{Spec: &ocm.ShareReference_Id{Id: &ocm.ShareId{OpaqueId: "10"}}},
+			err:         true,
+		},
+		{
+			description: "by another user",
+			user:        &userpb.User{Id: &userpb.UserId{OpaqueId: "bob"}},
+			ref:         &ocm.ShareReference{Spec: &ocm.ShareReference_Id{Id: &ocm.ShareId{OpaqueId: "10"}}},
+			err:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			ctx := sql.NewEmptyContext()
+			tables := createShareTables(ctx, []*ocm.Share{
+				{
+					Id:            &ocm.ShareId{OpaqueId: "10"},
+					ResourceId:    &providerv1beta1.ResourceId{StorageId: "storage", OpaqueId: "resource-id1"},
+					Name:          "file-name",
+					Token:         "qwerty",
+					Grantee:       grantee,
+					Owner:         &userpb.UserId{OpaqueId: "einstein"},
+					Creator:       &userpb.UserId{OpaqueId: "marie"},
+					Ctime:         &typesv1beta1.Timestamp{Seconds: 1686061921},
+					Mtime:         &typesv1beta1.Timestamp{Seconds: 1686061921},
+					ShareType:     ocm.ShareType_SHARE_TYPE_USER,
+					AccessMethods: []*ocm.AccessMethod{share.NewWebDavAccessMethod(conversions.NewViewerRole().CS3ResourcePermissions())},
+				},
+			})
+			engine, port, cleanup := startDatabase(ctx, tables)
+			t.Cleanup(cleanup)
+
+			r, err := NewFromConfig(ctx, testConfig(port, &config{
+				now: func() time.Time { return fixedTime },
+			}))
+			if err != nil {
+				t.Fatalf("not expected error while creating share repository driver: %+v", err)
+			}
+
+			got, err := r.(share.NameUpdater).UpdateShareName(context.TODO(), tt.user, tt.ref, "new-name", tt.fields...)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected an error renaming the share")
+				}
+				checkRows(ctx, engine, []sql.Row{{int64(10), "qwerty", "storage", "resource-id1", "file-name", "richard@cesnet", "einstein", "marie", uint64(1686061921), uint64(1686061921), uint64(0), int8(0), int8(ItemTypeFolder)}}, ocmShareTable, t)
+				return
+			}
+			if err != nil {
+				t.Fatalf("not expected error renaming the share: %+v", err)
+			}
+			if got.Name != "new-name" {
+				t.Errorf("expected the renamed share, got name %s", got.Name)
+			}
+			checkRows(ctx, engine, []sql.Row{{int64(10), "qwerty", "storage", "resource-id1", "new-name", "richard@cesnet", "einstein", "marie", uint64(1686061921), uint64(fixedTime.Unix()), tt.expiration, int8(0), int8(ItemTypeFolder)}}, ocmShareTable, t)
+		})
+	}
+}
+
+func TestListSharesByResources(t *testing.T) {
+	ctx := sql.NewEmptyContext()
+	tables := createShareTables(ctx, []*ocm.Share{})
+	_, port, cleanup := startDatabase(ctx, tables)
+	t.Cleanup(cleanup)
+
+	r, err := NewFromConfig(context.Background(), testConfig(port, &config{}))
+	if err != nil {
+		t.Fatalf("not expected error while creating share repository driver: %+v", err)
+	}
+
+	newShare := func(token, resource, grantee, owner string) *ocm.Share {
+		return &ocm.Share{
+			ResourceId:    &providerv1beta1.ResourceId{StorageId: "storage", OpaqueId: resource},
+			Name:          resource,
+			Token:         token,
+			Grantee:       &providerv1beta1.Grantee{Type: providerv1beta1.GranteeType_GRANTEE_TYPE_USER, Id: &providerv1beta1.Grantee_UserId{UserId: &userpb.UserId{Idp: "cesnet", OpaqueId: grantee, Type: userpb.UserType_USER_TYPE_FEDERATED}}},
+			Owner:         &userpb.UserId{OpaqueId: owner},
+			Creator:       &userpb.UserId{OpaqueId: owner},
+			Ctime:         &typesv1beta1.Timestamp{Seconds: 1670859468},
+			Mtime:         &typesv1beta1.Timestamp{Seconds: 1670859468},
+			ShareType:     ocm.ShareType_SHARE_TYPE_USER,
+			AccessMethods: []*ocm.AccessMethod{share.NewWebDavAccessMethod(conversions.NewViewerRole().CS3ResourcePermissions())},
+		}
+	}
+
+	for _, s := range []*ocm.Share{
+		newShare("a-richard", "a", "richard", "einstein"),
+		newShare("a-marie", "a", "marie", "einstein"),
+		newShare("b-richard", "b", "richard", "einstein"),
+		newShare("c-richard", "c", "richard", "einstein"),
+		newShare("d-richard", "d", "richard", "einstein"),
+		newShare("a-other-owner", "a", "albert", "marie"),
+	} {
+		if _, err := r.StoreShare(context.TODO(), s); err != nil {
+			t.Fatalf("not expected error storing share: %+v", err)
+		}
+	}
+
+	// look the resources up in more than one batch
+	defer func(n int) { resourceIDsBatchSize = n }(resourceIDsBatchSize)
+	resourceIDsBatchSize = 2
+
+	user := &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein"}}
+	ids := []*providerv1beta1.ResourceId{
+		{StorageId: "storage", OpaqueId: "a"},
+		{StorageId: "storage", OpaqueId: "b"},
+		{StorageId: "storage", OpaqueId: "d"},
+		{StorageId: "storage", OpaqueId: "not-shared"},
+		{StorageId: "other-storage", OpaqueId: "c"},
+	}
+	got, err := r.(share.ResourcesLister).ListSharesByResources(context.TODO(), user, ids)
+	if err != nil {
+		t.Fatalf("not expected error while listing shares: %+v", err)
+	}
+
+	tokens := make(map[string][]string)
+	for key, shares := range got {
+		for _, s := range shares {
+			if len(s.AccessMethods) != 1 {
+				t.Errorf("expected the access methods of share %s, got %+v", s.Token, s.AccessMethods)
+			}
+			tokens[key] = append(tokens[key], s.Token)
+		}
+	}
+	expected := map[string][]string{
+		resourceid.OwnCloudResourceIDWrap(&providerv1beta1.ResourceId{StorageId: "storage", OpaqueId: "a"}): {"a-richard", "a-marie"},
+		resourceid.OwnCloudResourceIDWrap(&providerv1beta1.ResourceId{StorageId: "storage", OpaqueId: "b"}): {"b-richard"},
+		resourceid.OwnCloudResourceIDWrap(&providerv1beta1.ResourceId{StorageId: "storage", OpaqueId: "d"}): {"d-richard"},
+	}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Fatalf("shares do not match. got=%+v expected=%+v", tokens, expected)
+	}
+}
+
+func TestCaseInsensitiveShareWith(t *testing.T) {
+	fixedTime := time.Date(2023, time.December, 12, 12, 12, 0, 0, time.UTC)
+	// the share is stored for richard@cesnet, the partner sends Richard@CESNET
+	mixedCase := &providerv1beta1.Grantee{Type: providerv1beta1.GranteeType_GRANTEE_TYPE_USER, Id: &providerv1beta1.Grantee_UserId{UserId: &userpb.UserId{Idp: "CESNET", OpaqueId: "Richard", Type: userpb.UserType_USER_TYPE_FEDERATED}}}
+	ref := &ocm.ShareReference{Spec: &ocm.ShareReference_Key{Key: &ocm.ShareKey{
+		Owner:      &userpb.UserId{OpaqueId: "einstein"},
+		ResourceId: &providerv1beta1.ResourceId{StorageId: "storage", OpaqueId: "resource-id1"},
+		Grantee:    mixedCase,
+	}}}
+	user := &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein"}}
+
+	for _, caseInsensitive := range []bool{false, true} {
+		t.Run(fmt.Sprintf("case insensitive %t", caseInsensitive), func(t *testing.T) {
+			ctx := sql.NewEmptyContext()
+			tables := createShareTables(ctx, []*ocm.Share{
+				{
+					Id:            &ocm.ShareId{OpaqueId: "10"},
+					ResourceId:    &providerv1beta1.ResourceId{StorageId: "storage", OpaqueId: "resource-id1"},
+					Name:          "file-name",
+					Token:         "qwerty",
+					Grantee:       &providerv1beta1.Grantee{Type: providerv1beta1.GranteeType_GRANTEE_TYPE_USER, Id: &providerv1beta1.Grantee_UserId{UserId: &userpb.UserId{Idp: "cesnet", OpaqueId: "richard", Type: userpb.UserType_USER_TYPE_FEDERATED}}},
+					Owner:         &userpb.UserId{OpaqueId: "einstein"},
+					Creator:       &userpb.UserId{OpaqueId: "einstein"},
+					Ctime:         &typesv1beta1.Timestamp{Seconds: 1686061921},
+					Mtime:         &typesv1beta1.Timestamp{Seconds: 1686061921},
+					ShareType:     ocm.ShareType_SHARE_TYPE_USER,
+					AccessMethods: []*ocm.AccessMethod{share.NewWebDavAccessMethod(conversions.NewViewerRole().CS3ResourcePermissions())},
+				},
+			})
+			_, port, cleanup := startDatabase(ctx, tables)
+			t.Cleanup(cleanup)
+
+			r, err := NewFromConfig(ctx, testConfig(port, &config{
+				CaseInsensitiveShareWith: caseInsensitive,
+				now:                      func() time.Time { return fixedTime },
+			}))
+			if err != nil {
+				t.Fatalf("not expected error while creating share repository driver: %+v", err)
+			}
+
+			got, err := r.GetShare(context.TODO(), user, ref)
+			if !caseInsensitive {
+				if err != share.ErrShareNotFound {
+					t.Fatalf("expected no share matching a mixed case grantee, got %+v %v", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("not expected error getting the share: %+v", err)
+			}
+			if got.Id.OpaqueId != "10" {
+				t.Fatalf("expected share 10, got %+v", got)
+			}
+
+			exp := &typesv1beta1.Timestamp{Seconds: uint64(fixedTime.Unix())}
+			updated, err := r.UpdateShare(context.TODO(), user, ref, &ocm.UpdateOCMShareRequest_UpdateField{Field: &ocm.UpdateOCMShareRequest_UpdateField_Expiration{Expiration: exp}})
+			if err != nil {
+				t.Fatalf("not expected error updating the share: %+v", err)
+			}
+			if updated.Expiration.GetSeconds() != exp.Seconds {
+				t.Errorf("expected the expiration to be updated, got %+v", updated.Expiration)
+			}
+
+			// a grantee differing only by case from another one deletes a single share
+			if _, err := r.StoreShare(context.TODO(), &ocm.Share{
+				ResourceId: &providerv1beta1.ResourceId{StorageId: "storage", OpaqueId: "resource-id1"},
+				Name:       "file-name",
+				Token:      "asdfgh",
+				Grantee:    &providerv1beta1.Grantee{Type: providerv1beta1.GranteeType_GRANTEE_TYPE_USER, Id: &providerv1beta1.Grantee_UserId{UserId: &userpb.UserId{Idp: "cesnet", OpaqueId: "RICHARD", Type: userpb.UserType_USER_TYPE_FEDERATED}}},
+				Owner:      &userpb.UserId{OpaqueId: "einstein"},
+				Creator:    &userpb.UserId{OpaqueId: "einstein"},
+				Ctime:      &typesv1beta1.Timestamp{Seconds: 1686061921},
+				Mtime:      &typesv1beta1.Timestamp{Seconds: 1686061921},
+				ShareType:  ocm.ShareType_SHARE_TYPE_USER,
+			}); err != nil {
+				t.Fatalf("not expected error storing the share: %+v", err)
+			}
+			if err := r.DeleteShare(context.TODO(), user, ref); err != nil {
+				t.Fatalf("not expected error deleting the share: %+v", err)
+			}
+			left, err := r.ListShares(context.TODO(), user, nil)
+			if err != nil {
+				t.Fatalf("not expected error listing the shares: %+v", err)
+			}
+			if len(left) != 1 {
+				t.Errorf("expected a single share to be deleted, got %d shares left", len(left))
+			}
+		})
+	}
+}
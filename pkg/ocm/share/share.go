@@ -24,6 +24,7 @@ import (
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/cs3org/reva/pkg/errtypes"
 	"google.golang.org/genproto/protobuf/field_mask"
 )
@@ -59,6 +60,74 @@ type Repository interface {
 	UpdateReceivedShare(ctx context.Context, user *userpb.User, share *ocm.ReceivedShare, fieldMask *field_mask.FieldMask) (*ocm.ReceivedShare, error)
 }
 
+// NameUpdater is implemented by the repositories able to rename a share,
+// e.g. after the shared resource has been renamed. The CS3 APIs do not
+// have an update field for the name.
+type NameUpdater interface {
+	// UpdateShareName sets the name of the share and applies the given
+	// updates at once, only the owner can rename it.
+	UpdateShareName(ctx context.Context, user *userpb.User, ref *ocm.ShareReference, name string, f ...*ocm.UpdateOCMShareRequest_UpdateField) (*ocm.Share, error)
+}
+
+// updateNameKey is the opaque key of an UpdateOCMShareRequest holding the
+// new name of the share, as the request does not have a field for it.
+const updateNameKey = "name"
+
+// SetUpdateName makes the request rename the share.
+func SetUpdateName(req *ocm.UpdateOCMShareRequest, name string) {
+	if req.Opaque == nil {
+		req.Opaque = &types.Opaque{}
+	}
+	if req.Opaque.Map == nil {
+		req.Opaque.Map = make(map[string]*types.OpaqueEntry)
+	}
+	req.Opaque.Map[updateNameKey] = &types.OpaqueEntry{
+		Decoder: "plain",
+		Value:   []byte(name),
+	}
+}
+
+// GetUpdateName returns the name the request renames the share to, if any.
+func GetUpdateName(req *ocm.UpdateOCMShareRequest) (string, bool) {
+	e, ok := req.GetOpaque().GetMap()[updateNameKey]
+	if !ok {
+		return "", false
+	}
+	return string(e.Value), true
+}
+
+// ResourcesLister is implemented by the repositories able to list the shares
+// of many resources at once, e.g. to render the sharing status of the
+// children of a folder.
+type ResourcesLister interface {
+	// ListSharesByResources returns the shares created by the user on the given
+	// resources, grouped by the resource ids as wrapped by resourceid.OwnCloudResourceIDWrap.
+	ListSharesByResources(ctx context.Context, user *userpb.User, ids []*provider.ResourceId) (map[string][]*ocm.Share, error)
+}
+
+// ResourceTypeRepository is implemented by the repositories keeping the type
+// of the shared resource. Neither ocm.Share nor the CS3 filters have a field
+// for it, hence the dedicated methods.
+type ResourceTypeRepository interface {
+	// StoreShareOfType stores a share of a resource of the given type.
+	StoreShareOfType(ctx context.Context, share *ocm.Share, t provider.ResourceType) (*ocm.Share, error)
+
+	// ListSharesOfTypes is like ListShares, returning only the shares of
+	// the resources of one of the given types.
+	ListSharesOfTypes(ctx context.Context, user *userpb.User, filters []*ocm.ListOCMSharesRequest_Filter, types []provider.ResourceType) ([]*ocm.Share, error)
+}
+
+// ReceivedShareHook is called with a received share once it has been stored.
+type ReceivedShareHook func(ctx context.Context, s *ocm.ReceivedShare)
+
+// AcceptedShareNotifier is implemented by the repositories able to notify
+// the received shares stored directly in the accepted state, e.g. the ones
+// of a trusted partner, so that the caller can create their mount.
+type AcceptedShareNotifier interface {
+	// OnAcceptedReceivedShare registers the hook, a nil hook disables it.
+	OnAcceptedReceivedShare(h ReceivedShareHook)
+}
+
 // ResourceIDFilter is an abstraction for creating filter by resource id.
 func ResourceIDFilter(id *provider.ResourceId) *ocm.ListOCMSharesRequest_Filter {
 	return &ocm.ListOCMSharesRequest_Filter{
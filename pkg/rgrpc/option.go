@@ -60,3 +60,11 @@ func WithUnaryServerInterceptors(in []grpc.UnaryServerInterceptor) Option {
 		s.UnaryServerInterceptors = in
 	}
 }
+
+// WithVersion sets the version advertised by the server
+// in the VersionHeader of the responses.
+func WithVersion(version string) Option {
+	return func(s *Server) {
+		s.version = version
+	}
+}
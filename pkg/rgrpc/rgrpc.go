@@ -20,16 +20,20 @@ package rgrpc
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"net"
+	"slices"
 
 	"github.com/cs3org/reva/cmd/revad/pkg/config"
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/utils/maps"
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/reflection"
 )
 
@@ -85,26 +89,49 @@ type Server struct {
 	listener net.Listener
 	log      zerolog.Logger
 	services map[string]Service
+	version  string
 }
 
+// VersionHeader is the grpc header carrying the version
+// of the server handling the request.
+const VersionHeader = "x-reva-version"
+
 func InitServices(ctx context.Context, services map[string]config.ServicesConfig) (map[string]Service, error) {
 	s := make(map[string]Service)
-	for name, cfg := range services {
+	var errs []error
+	// initialize the services in a stable order,
+	// reporting the errors of all of them at once
+	names := maps.Keys(services)
+	slices.Sort(names)
+	for _, name := range names {
+		cfg := services[name]
 		new, ok := Services[name]
 		if !ok {
-			return nil, fmt.Errorf("rgrpc: grpc service %s does not exist", name)
+			errs = append(errs, fmt.Errorf("rgrpc: grpc service %s does not exist", name))
+			continue
 		}
 		if cfg.DriversNumber() > 1 {
-			return nil, fmt.Errorf("rgrp: service %s cannot have more than one driver in same server", name)
+			errs = append(errs, fmt.Errorf("rgrp: service %s cannot have more than one driver in same server", name))
+			continue
 		}
 		log := appctx.GetLogger(ctx).With().Str("service", name).Logger()
 		ctx := appctx.WithLogger(ctx, &log)
 		svc, err := new(ctx, cfg[0].Config)
 		if err != nil {
-			return nil, errors.Wrapf(err, "rgrpc: grpc service %s could not be started", name)
+			errs = append(errs, errors.Wrapf(err, "rgrpc: grpc service %s could not be started", name))
+			continue
 		}
 		s[name] = svc
 	}
+	if len(errs) > 0 {
+		// the services already initialized will never be served
+		for name, svc := range s {
+			if err := svc.Close(); err != nil {
+				appctx.GetLogger(ctx).Error().Err(err).Msgf("error closing service %s", name)
+			}
+		}
+		return nil, stderrors.Join(errs...)
+	}
 	return s, nil
 }
 
@@ -189,11 +216,32 @@ func (s *Server) Address() string {
 }
 
 func (s *Server) getInterceptors() []grpc.ServerOption {
-	unaryChain := grpc_middleware.ChainUnaryServer(s.UnaryServerInterceptors...)
-	streamChain := grpc_middleware.ChainStreamServer(s.StreamServerInterceptors...)
+	unary, stream := s.UnaryServerInterceptors, s.StreamServerInterceptors
+	if s.version != "" {
+		unary = append([]grpc.UnaryServerInterceptor{versionUnaryInterceptor(s.version)}, unary...)
+		stream = append([]grpc.StreamServerInterceptor{versionStreamInterceptor(s.version)}, stream...)
+	}
+	unaryChain := grpc_middleware.ChainUnaryServer(unary...)
+	streamChain := grpc_middleware.ChainStreamServer(stream...)
 
 	return []grpc.ServerOption{
 		grpc.UnaryInterceptor(unaryChain),
 		grpc.StreamInterceptor(streamChain),
 	}
 }
+
+// versionUnaryInterceptor sends the server version in the response headers.
+func versionUnaryInterceptor(version string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		_ = grpc.SetHeader(ctx, metadata.Pairs(VersionHeader, version))
+		return handler(ctx, req)
+	}
+}
+
+// versionStreamInterceptor sends the server version in the response headers.
+func versionStreamInterceptor(version string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		_ = ss.SetHeader(metadata.Pairs(VersionHeader, version))
+		return handler(srv, ss)
+	}
+}
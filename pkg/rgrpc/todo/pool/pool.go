@@ -80,16 +80,17 @@ var (
 	dataTxs                = newProvider()
 )
 
-// NewConn creates a new connection to a grpc server
+// NewConn creates a new connection to a grpc server.
+// Additional dial options, like interceptors, can be given with opts.
 // TODO(labkode): make grpc tls configurable.
-func NewConn(options Options) (*grpc.ClientConn, error) {
-	conn, err := grpc.NewClient(
-		options.Endpoint,
+func NewConn(options Options, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	opts = append([]grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithDefaultCallOptions(
 			grpc.MaxCallRecvMsgSize(options.MaxCallRecvMsgSize),
 		),
-	)
+	}, opts...)
+	conn, err := grpc.NewClient(options.Endpoint, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -118,6 +119,17 @@ func GetGatewayServiceClient(opts ...Option) (gateway.GatewayAPIClient, error) {
 	return v, nil
 }
 
+// NewGatewayServiceClient returns a GatewayServiceClient on a new connection,
+// which is not shared with the other clients of the pool.
+// Additional dial options, like interceptors, can be given with dopts.
+func NewGatewayServiceClient(opts []Option, dopts ...grpc.DialOption) (gateway.GatewayAPIClient, error) {
+	conn, err := NewConn(newOptions(opts...), dopts...)
+	if err != nil {
+		return nil, err
+	}
+	return gateway.NewGatewayAPIClient(conn), nil
+}
+
 // GetUserProviderServiceClient returns a UserProviderServiceClient.
 func GetUserProviderServiceClient(opts ...Option) (user.UserAPIClient, error) {
 	userProviders.m.Lock()
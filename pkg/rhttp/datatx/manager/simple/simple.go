@@ -83,6 +83,9 @@ func (m *manager) Handler(fs storage.FS) (http.Handler, error) {
 			if lockholder := r.Header.Get(ocdav.HeaderLockHolder); lockholder != "" {
 				metadata["lockholder"] = lockholder
 			}
+			if totalLength := r.Header.Get(ocdav.HeaderOCTotalLength); totalLength != "" {
+				metadata["totallength"] = totalLength
+			}
 
 			err := fs.Upload(ctx, ref, r.Body, metadata)
 			switch v := err.(type) {
@@ -20,16 +20,19 @@ package rhttp
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"net"
 	"net/http"
 	"path"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/cs3org/reva/cmd/revad/pkg/config"
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/utils/maps"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 )
@@ -63,22 +66,40 @@ func WithLogger(log zerolog.Logger) Config {
 
 func InitServices(ctx context.Context, services map[string]config.ServicesConfig) (map[string]global.Service, error) {
 	s := make(map[string]global.Service)
-	for name, cfg := range services {
+	var errs []error
+	// initialize the services in a stable order,
+	// reporting the errors of all of them at once
+	names := maps.Keys(services)
+	slices.Sort(names)
+	for _, name := range names {
+		cfg := services[name]
 		new, ok := global.Services[name]
 		if !ok {
-			return nil, fmt.Errorf("http service %s does not exist", name)
+			errs = append(errs, fmt.Errorf("http service %s does not exist", name))
+			continue
 		}
 		if cfg.DriversNumber() > 1 {
-			return nil, fmt.Errorf("service %s cannot have more than one driver in the same server", name)
+			errs = append(errs, fmt.Errorf("service %s cannot have more than one driver in the same server", name))
+			continue
 		}
 		log := appctx.GetLogger(ctx).With().Str("service", name).Logger()
 		ctx := appctx.WithLogger(ctx, &log)
 		svc, err := new(ctx, cfg[0].Config)
 		if err != nil {
-			return nil, errors.Wrapf(err, "http service %s could not be started", name)
+			errs = append(errs, errors.Wrapf(err, "http service %s could not be started", name))
+			continue
 		}
 		s[name] = svc
 	}
+	if len(errs) > 0 {
+		// the services already initialized will never be served
+		for name, svc := range s {
+			if err := svc.Close(); err != nil {
+				appctx.GetLogger(ctx).Error().Err(err).Msgf("error closing service %s", name)
+			}
+		}
+		return nil, stderrors.Join(errs...)
+	}
 	return s, nil
 }
 
@@ -18,7 +18,16 @@
 
 package rhttp
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cs3org/reva/cmd/revad/pkg/config"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+)
 
 func TestURLHasPrefix(t *testing.T) {
 	tests := map[string]struct {
@@ -72,3 +81,55 @@ func TestURLHasPrefix(t *testing.T) {
 		})
 	}
 }
+
+type okService struct {
+	closed bool
+}
+
+func (*okService) Handler() http.Handler { return http.NotFoundHandler() }
+func (*okService) Prefix() string        { return "ok" }
+func (s *okService) Close() error        { s.closed = true; return nil }
+func (*okService) Unprotected() []string { return nil }
+
+func TestInitServicesAggregatesErrors(t *testing.T) {
+	global.Register("test_failing_a", func(context.Context, map[string]any) (global.Service, error) {
+		return nil, errors.New("broken a")
+	})
+	global.Register("test_failing_b", func(context.Context, map[string]any) (global.Service, error) {
+		return nil, errors.New("broken b")
+	})
+	ok := &okService{}
+	global.Register("test_ok", func(context.Context, map[string]any) (global.Service, error) {
+		return ok, nil
+	})
+	t.Cleanup(func() {
+		delete(global.Services, "test_failing_a")
+		delete(global.Services, "test_failing_b")
+		delete(global.Services, "test_ok")
+	})
+
+	services := map[string]config.ServicesConfig{
+		"test_failing_a": {{Config: map[string]any{}}},
+		"test_ok":        {{Config: map[string]any{}}},
+		"test_failing_b": {{Config: map[string]any{}}},
+	}
+
+	_, err := InitServices(context.Background(), services)
+	if err == nil {
+		t.Fatal("expected an error initializing the services")
+	}
+	for _, msg := range []string{
+		"http service test_failing_a could not be started: broken a",
+		"http service test_failing_b could not be started: broken b",
+	} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Errorf("expected error to contain %q, got %q", msg, err.Error())
+		}
+	}
+	if strings.Contains(err.Error(), "test_ok") {
+		t.Errorf("unexpected error for the working service: %q", err.Error())
+	}
+	if !ok.closed {
+		t.Error("expected the working service to be closed")
+	}
+}
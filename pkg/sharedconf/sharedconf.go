@@ -66,3 +66,11 @@ func SkipUserGroupsInToken() bool {
 func GetBlockedUsers() []string {
 	return sharedConf.BlockedUsers
 }
+
+// GetTrustedProxies returns the package level configured trusted proxies if not overwritten.
+func GetTrustedProxies(val []string) []string {
+	if len(val) == 0 {
+		return sharedConf.TrustedProxies
+	}
+	return val
+}
@@ -32,8 +32,20 @@ func init() {
 }
 
 type config struct {
-	Root        string `docs:"/var/tmp/reva/;Path of root directory for user storage." mapstructure:"root"`
-	ShareFolder string `docs:"/MyShares;Path for storing share references."            mapstructure:"share_folder"`
+	Root                 string             `docs:"/var/tmp/reva/;Path of root directory for user storage." mapstructure:"root"`
+	ShareFolder          string             `docs:"/MyShares;Path for storing share references."            mapstructure:"share_folder"`
+	DirMode              string             `docs:";Octal permission mode of the created directories."      mapstructure:"dir_mode"`
+	FileMode             string             `docs:";Octal permission mode of the created files."            mapstructure:"file_mode"`
+	PropagateEtags       *bool              `docs:"true;Whether to propagate the etag changes up to the root." mapstructure:"propagate_etags"`
+	SniffMimetype        bool               `docs:"false;Whether to detect the mime type of files with an unknown extension from their content." mapstructure:"sniff_mimetype"`
+	Subfolders           localfs.Subfolders `docs:";Names of the data, .uploads, .shadow, references, recycle_bin and versions folders of the layout." mapstructure:"subfolders"`
+	MaxDepth             int                `docs:"256;Maximum number of segments of the paths, a negative value disables the check." mapstructure:"max_depth"`
+	FollowSymlinks       bool               `docs:"false;Whether symlinks may point outside of the user root." mapstructure:"follow_symlinks"`
+	MaxConcurrentUploads int                `docs:"0;Number of uploads of a user finalized at the same time, unlimited when 0." mapstructure:"max_concurrent_uploads"`
+	UploadWaitTimeout    int                `docs:"60;Seconds an upload beyond the limit waits for a slot, a negative value fails immediately." mapstructure:"upload_wait_timeout"`
+	EnableMetrics        bool               `docs:"false;Whether to record prometheus metrics of the stat, upload, download and delete operations." mapstructure:"enable_metrics"`
+	ChunkTempDir         string             `docs:";Folder where the chunks of the chunked uploads are stored and assembled, defaults to the uploads folder." mapstructure:"chunk_temp_dir"`
+	ChunkTempMinFree     uint64             `docs:"0;Bytes that must be available in the chunk temp dir at startup, not checked when 0." mapstructure:"chunk_temp_min_free"`
 }
 
 func (c *config) ApplyDefaults() {
@@ -54,9 +66,21 @@ func New(ctx context.Context, m map[string]interface{}) (storage.FS, error) {
 	}
 
 	conf := localfs.Config{
-		Root:        c.Root,
-		ShareFolder: c.ShareFolder,
-		DisableHome: true,
+		Root:                 c.Root,
+		ShareFolder:          c.ShareFolder,
+		DirMode:              c.DirMode,
+		FileMode:             c.FileMode,
+		PropagateEtags:       c.PropagateEtags,
+		SniffMimetype:        c.SniffMimetype,
+		Subfolders:           c.Subfolders,
+		MaxDepth:             c.MaxDepth,
+		FollowSymlinks:       c.FollowSymlinks,
+		MaxConcurrentUploads: c.MaxConcurrentUploads,
+		UploadWaitTimeout:    c.UploadWaitTimeout,
+		EnableMetrics:        c.EnableMetrics,
+		ChunkTempDir:         c.ChunkTempDir,
+		ChunkTempMinFree:     c.ChunkTempMinFree,
+		DisableHome:          true,
 	}
 	return localfs.NewLocalFS(&conf)
 }
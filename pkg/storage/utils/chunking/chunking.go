@@ -74,15 +74,32 @@ func GetChunkBLOBInfo(path string) (*ChunkBLOBInfo, error) {
 	}, nil
 }
 
+// TotalSize returns the total size of a chunked upload as declared in the
+// "totallength" entry of the upload metadata, or -1 when it is not declared.
+func TotalSize(metadata map[string]string) (int64, error) {
+	l, ok := metadata["totallength"]
+	if !ok {
+		return -1, nil
+	}
+	size, err := strconv.ParseInt(l, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid total length %q", l)
+	}
+	return size, nil
+}
+
 // ChunkHandler manages chunked uploads, storing the chunks in a temporary directory
 // until it gets the final chunk which is then returned.
 type ChunkHandler struct {
 	ChunkFolder string `mapstructure:"chunk_folder"`
+	// DisableVerification skips the completeness check of the chunks before
+	// they are assembled.
+	DisableVerification bool `mapstructure:"disable_verification"`
 }
 
 // NewChunkHandler creates a handler for chunked uploads.
 func NewChunkHandler(chunkFolder string) *ChunkHandler {
-	return &ChunkHandler{chunkFolder}
+	return &ChunkHandler{ChunkFolder: chunkFolder}
 }
 
 func (c *ChunkHandler) createChunkTempFile() (string, *os.File, error) {
@@ -102,7 +119,31 @@ func (c *ChunkHandler) getChunkFolderName(i *ChunkBLOBInfo) (string, error) {
 	return path, nil
 }
 
-func (c *ChunkHandler) saveChunk(path string, r io.ReadCloser) (bool, string, error) {
+// verifyChunks checks that the chunks folder holds exactly the chunks 0 to
+// total-1 and, when totalSize is not negative, that they add up to totalSize bytes.
+func verifyChunks(chunks []os.FileInfo, total int, totalSize int64) error {
+	present := make([]bool, total)
+	var size int64
+	for _, c := range chunks {
+		n, err := strconv.Atoi(c.Name())
+		if err != nil || n < 0 || n >= total {
+			return fmt.Errorf("unexpected chunk %s in an upload of %d chunks", c.Name(), total)
+		}
+		present[n] = true
+		size += c.Size()
+	}
+	for i, ok := range present {
+		if !ok {
+			return fmt.Errorf("chunk %d of %d is missing", i, total)
+		}
+	}
+	if totalSize >= 0 && size != totalSize {
+		return fmt.Errorf("the chunks add up to %d bytes instead of the declared %d", size, totalSize)
+	}
+	return nil
+}
+
+func (c *ChunkHandler) saveChunk(path string, r io.ReadCloser, totalSize int64) (bool, string, error) {
 	chunkInfo, err := GetChunkBLOBInfo(path)
 	if err != nil {
 		err := fmt.Errorf("error getting chunk info from path: %s", path)
@@ -162,14 +203,31 @@ func (c *ChunkHandler) saveChunk(path string, r io.ReadCloser) (bool, string, er
 		return false, "", nil
 	}
 
+	// from here on the chunks are either assembled or discarded,
+	// so we free space removing the chunks folder in both cases
+	defer os.RemoveAll(chunksFolderName)
+
+	if !c.DisableVerification {
+		if err := verifyChunks(chunks, chunkInfo.TotalChunks, totalSize); err != nil {
+			return false, "", err
+		}
+	}
+
 	assembledFileName, assembledFile, err := c.createChunkTempFile()
 	if err != nil {
 		return false, "", err
 	}
 	defer assembledFile.Close()
 
+	assembled := false
+	defer func() {
+		if !assembled {
+			os.Remove(assembledFileName)
+		}
+	}()
+
 	// walk all chunks and append to assembled file
-	for i := range chunks {
+	for i := 0; i < chunkInfo.TotalChunks; i++ {
 		target := filepath.Join(chunksFolderName, strconv.Itoa(i))
 
 		chunk, err := os.Open(target)
@@ -190,16 +248,23 @@ func (c *ChunkHandler) saveChunk(path string, r io.ReadCloser) (bool, string, er
 	}
 
 	// at this point the assembled file is complete
-	// so we free space removing the chunks folder
-	defer os.RemoveAll(chunksFolderName)
-
+	assembled = true
 	return true, assembledFileName, nil
 }
 
 // WriteChunk saves an intermediate chunk temporarily and assembles all chunks
-// once the final one is received.
+// once the final one is received. Use WriteChunkOfSize when the client
+// declares the total size of the upload, e.g. with the OC-Total-Length header.
 func (c *ChunkHandler) WriteChunk(fn string, r io.ReadCloser) (string, string, error) {
-	finish, chunk, err := c.saveChunk(fn, r)
+	return c.WriteChunkOfSize(fn, r, -1)
+}
+
+// WriteChunkOfSize is like WriteChunk for uploads declaring their total size,
+// the chunks are only assembled when they add up to totalSize bytes.
+// A negative totalSize skips the size check. When the verification fails
+// the chunks are discarded and the upload has to be restarted.
+func (c *ChunkHandler) WriteChunkOfSize(fn string, r io.ReadCloser, totalSize int64) (string, string, error) {
+	finish, chunk, err := c.saveChunk(fn, r, totalSize)
 	if err != nil {
 		return "", "", err
 	}
@@ -0,0 +1,116 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package chunking
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestVerifyChunksMissing(t *testing.T) {
+	dir := t.TempDir()
+	for _, n := range []string{"0", "2"} {
+		if err := os.WriteFile(filepath.Join(dir, n), []byte("abc"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	chunks, err := f.Readdir(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifyChunks(chunks, 3, -1); err == nil || !strings.Contains(err.Error(), "chunk 1 of 3 is missing") {
+		t.Errorf("expected the missing chunk to be reported, got %v", err)
+	}
+	if err := verifyChunks(chunks, 2, -1); err == nil || !strings.Contains(err.Error(), "unexpected chunk 2") {
+		t.Errorf("expected the unexpected chunk to be reported, got %v", err)
+	}
+}
+
+func TestWriteChunkOfSize(t *testing.T) {
+	chunks := []string{"hello ", "chunked ", "world"}
+	write := func(c *ChunkHandler, transfer string, totalSize int64) (string, string, error) {
+		var p, assembled string
+		var err error
+		for i, data := range chunks {
+			fn := "/file.txt-chunking-" + transfer + "-3-" + strconv.Itoa(i)
+			p, assembled, err = c.WriteChunkOfSize(fn, io.NopCloser(strings.NewReader(data)), totalSize)
+			if err != nil {
+				return "", "", err
+			}
+		}
+		return p, assembled, nil
+	}
+
+	dir := t.TempDir()
+	c := NewChunkHandler(dir)
+	p, assembled, err := write(c, "complete", int64(len(strings.Join(chunks, ""))))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p != "/file.txt" {
+		t.Errorf("expected the path /file.txt, got %s", p)
+	}
+	if data, err := os.ReadFile(assembled); err != nil || string(data) != "hello chunked world" {
+		t.Errorf("expected the assembled content, got %q (%v)", data, err)
+	}
+
+	if _, _, err := write(c, "truncated", 100); err == nil || !strings.Contains(err.Error(), "instead of the declared 100") {
+		t.Errorf("expected a size mismatch error, got %v", err)
+	}
+	// the chunks of the failed upload are discarded
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 1 || entries[0].Name() != filepath.Base(assembled) {
+		t.Errorf("expected only the assembled file to be left, got %v (%v)", entries, err)
+	}
+}
+
+func TestTotalSize(t *testing.T) {
+	tests := []struct {
+		metadata map[string]string
+		expected int64
+		err      bool
+	}{
+		{metadata: nil, expected: -1},
+		{metadata: map[string]string{"totallength": "19"}, expected: 19},
+		{metadata: map[string]string{"totallength": "-1"}, err: true},
+		{metadata: map[string]string{"totallength": "abc"}, err: true},
+	}
+
+	for _, tt := range tests {
+		size, err := TotalSize(tt.metadata)
+		if tt.err {
+			if err == nil {
+				t.Errorf("expected an error for %v", tt.metadata)
+			}
+			continue
+		}
+		if err != nil || size != tt.expected {
+			t.Errorf("expected %d for %v, got %d (%v)", tt.expected, tt.metadata, size, err)
+		}
+	}
+}
@@ -42,8 +42,12 @@ func (fs *eosfs) Upload(ctx context.Context, ref *provider.Reference, r io.ReadC
 		return errors.Wrap(err, "eos: error checking path")
 	}
 	if ok {
+		totalSize, err := chunking.TotalSize(metadata)
+		if err != nil {
+			return errtypes.BadRequest("eos: " + err.Error())
+		}
 		var assembledFile string
-		p, assembledFile, err = fs.chunkHandler.WriteChunk(p, r)
+		p, assembledFile, err = fs.chunkHandler.WriteChunkOfSize(p, r, totalSize)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,103 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package localfs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/semaphore"
+)
+
+// uploadLimiter bounds the number of uploads of every key, usually
+// a user, being finalized at the same time.
+type uploadLimiter struct {
+	limit int64
+	// wait is how long an upload waits for a slot,
+	// a negative value fails immediately.
+	wait time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*limiterEntry
+}
+
+// limiterEntry is the semaphore of a key,
+// dropped once none of its uploads hold or wait for it.
+type limiterEntry struct {
+	sem  *semaphore.Weighted
+	refs int
+}
+
+func newUploadLimiter(limit int, wait time.Duration) *uploadLimiter {
+	return &uploadLimiter{
+		limit:   int64(limit),
+		wait:    wait,
+		entries: map[string]*limiterEntry{},
+	}
+}
+
+// acquire takes a slot of the key, waiting for one at most the configured
+// duration. The returned function releases the slot.
+func (l *uploadLimiter) acquire(ctx context.Context, key string) (func(), error) {
+	if l == nil || l.limit <= 0 {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	e, ok := l.entries[key]
+	if !ok {
+		e = &limiterEntry{sem: semaphore.NewWeighted(l.limit)}
+		l.entries[key] = e
+	}
+	e.refs++
+	l.mu.Unlock()
+
+	var err error
+	if l.wait < 0 {
+		if !e.sem.TryAcquire(1) {
+			err = errors.Errorf("localfs: too many concurrent uploads for %s, the limit is %d", key, l.limit)
+		}
+	} else {
+		wctx, cancel := context.WithTimeout(ctx, l.wait)
+		defer cancel()
+		if err = e.sem.Acquire(wctx, 1); err != nil {
+			err = errors.Wrapf(err, "localfs: timeout waiting for one of the %d concurrent uploads of %s", l.limit, key)
+		}
+	}
+	if err != nil {
+		l.unref(key, e)
+		return nil, err
+	}
+
+	return func() {
+		e.sem.Release(1)
+		l.unref(key, e)
+	}, nil
+}
+
+func (l *uploadLimiter) unref(key string, e *limiterEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e.refs--
+	if e.refs == 0 {
+		delete(l.entries, key)
+	}
+}
@@ -24,9 +24,11 @@ import (
 	"fmt"
 	"io"
 	iofs "io/fs"
+	"net/http"
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -48,6 +50,12 @@ import (
 	"github.com/pkg/errors"
 )
 
+// genericMimeType is the mime type of the files whose type is unknown.
+const genericMimeType = "application/octet-stream"
+
+// defaultMaxDepth is the default maximum number of segments of a path.
+const defaultMaxDepth = 256
+
 // Config holds the configuration details for the local fs.
 type Config struct {
 	Root                string `mapstructure:"root"`
@@ -61,6 +69,92 @@ type Config struct {
 	Versions            string `mapstructure:"versions"`
 	Shadow              string `mapstructure:"shadow"`
 	References          string `mapstructure:"references"`
+	DirMode             string `mapstructure:"dir_mode"`
+	FileMode            string `mapstructure:"file_mode"`
+	// PropagateEtags updates the mtime, and so the etag, of the ancestors
+	// of a changed resource up to the user root. Enabled by default.
+	PropagateEtags *bool `mapstructure:"propagate_etags"`
+	// SniffMimetype detects the mime type of the files whose extension is
+	// not known from their first 512 bytes, at the cost of reading them.
+	SniffMimetype bool `mapstructure:"sniff_mimetype"`
+	// Subfolders are the names of the folders of the layout, for datasets
+	// following other conventions.
+	Subfolders Subfolders `mapstructure:"subfolders"`
+	// MaxDepth is the maximum number of segments of the paths
+	// of the storage. Defaults to 256, a negative value disables the check.
+	MaxDepth int `mapstructure:"max_depth"`
+	// FollowSymlinks allows the symlinks of the tree to point outside
+	// of the user root. Disabled by default.
+	FollowSymlinks bool `mapstructure:"follow_symlinks"`
+	// MaxConcurrentUploads is the number of uploads of a user that can be
+	// finalized at the same time. Unlimited by default.
+	MaxConcurrentUploads int `mapstructure:"max_concurrent_uploads"`
+	// UploadWaitTimeout is how long, in seconds, an upload beyond the limit
+	// waits for a slot. Defaults to 60, a negative value fails immediately.
+	UploadWaitTimeout int `mapstructure:"upload_wait_timeout"`
+	// EnableMetrics records prometheus metrics of the duration
+	// and the failures of the stat, upload, download and delete operations.
+	EnableMetrics bool `mapstructure:"enable_metrics"`
+	// ChunkTempDir is where the chunks of the chunked uploads are stored and
+	// assembled, for a filesystem larger than the one of the uploads folder.
+	// Defaults to the uploads folder.
+	ChunkTempDir string `mapstructure:"chunk_temp_dir"`
+	// ChunkTempMinFree is the space, in bytes, that must be available
+	// in the chunk temp dir when the storage starts. Not checked when 0.
+	ChunkTempMinFree uint64 `mapstructure:"chunk_temp_min_free"`
+	// DisableChunkVerification assembles the chunked uploads without checking
+	// that all their chunks are there and add up to the declared total length.
+	DisableChunkVerification bool `mapstructure:"disable_chunk_verification"`
+}
+
+// Subfolders holds the names of the folders of the layout. The data, uploads
+// and shadow folders are created under the root, the others under the shadow folder.
+type Subfolders struct {
+	Data       string `mapstructure:"data"`
+	Uploads    string `mapstructure:"uploads"`
+	Shadow     string `mapstructure:"shadow"`
+	References string `mapstructure:"references"`
+	RecycleBin string `mapstructure:"recycle_bin"`
+	Versions   string `mapstructure:"versions"`
+}
+
+func (s *Subfolders) applyDefaults() {
+	if s.Data == "" {
+		s.Data = "data"
+	}
+	if s.Uploads == "" {
+		s.Uploads = ".uploads"
+	}
+	if s.Shadow == "" {
+		s.Shadow = ".shadow"
+	}
+	if s.References == "" {
+		s.References = "references"
+	}
+	if s.RecycleBin == "" {
+		s.RecycleBin = "recycle_bin"
+	}
+	if s.Versions == "" {
+		s.Versions = "versions"
+	}
+}
+
+// validate checks that the names are single path elements
+// and that the folders sharing a parent have distinct names.
+func (s *Subfolders) validate() error {
+	for _, siblings := range [][]string{{s.Data, s.Uploads, s.Shadow}, {s.References, s.RecycleBin, s.Versions}} {
+		seen := map[string]bool{}
+		for _, n := range siblings {
+			if n == "." || n == ".." || strings.Contains(n, "/") {
+				return errors.Errorf("invalid subfolder name %q", n)
+			}
+			if seen[n] {
+				return errors.Errorf("duplicate subfolder name %q", n)
+			}
+			seen[n] = true
+		}
+	}
+	return nil
 }
 
 func (c *Config) ApplyDefaults() {
@@ -80,22 +174,42 @@ func (c *Config) ApplyDefaults() {
 		c.DataTransfersFolder = "/DataTransfers"
 	}
 
+	if c.PropagateEtags == nil {
+		propagate := true
+		c.PropagateEtags = &propagate
+	}
+
+	if c.MaxDepth == 0 {
+		c.MaxDepth = defaultMaxDepth
+	}
+
+	if c.UploadWaitTimeout == 0 {
+		c.UploadWaitTimeout = 60
+	}
+
 	// ensure share folder always starts with slash
 	c.ShareFolder = path.Join("/", c.ShareFolder)
 
-	c.DataDirectory = path.Join(c.Root, "data")
-	c.Uploads = path.Join(c.Root, ".uploads")
-	c.Shadow = path.Join(c.Root, ".shadow")
+	c.Subfolders.applyDefaults()
+	c.DataDirectory = path.Join(c.Root, c.Subfolders.Data)
+	c.Uploads = path.Join(c.Root, c.Subfolders.Uploads)
+	if c.ChunkTempDir == "" {
+		c.ChunkTempDir = c.Uploads
+	}
+	c.Shadow = path.Join(c.Root, c.Subfolders.Shadow)
 
-	c.References = path.Join(c.Shadow, "references")
-	c.RecycleBin = path.Join(c.Shadow, "recycle_bin")
-	c.Versions = path.Join(c.Shadow, "versions")
+	c.References = path.Join(c.Shadow, c.Subfolders.References)
+	c.RecycleBin = path.Join(c.Shadow, c.Subfolders.RecycleBin)
+	c.Versions = path.Join(c.Shadow, c.Subfolders.Versions)
 }
 
 type localfs struct {
 	conf         *Config
 	db           *sql.DB
 	chunkHandler *chunking.ChunkHandler
+	dirMode      os.FileMode
+	fileMode     os.FileMode
+	uploads      *uploadLimiter
 }
 
 // NewLocalFS returns a storage.FS interface implementation that controls then
@@ -103,29 +217,148 @@ type localfs struct {
 func NewLocalFS(c *Config) (storage.FS, error) {
 	c.ApplyDefaults()
 
+	// a relative root would silently create the data directory
+	// under the current working directory of the process
+	if !path.IsAbs(c.Root) {
+		return nil, errors.Errorf("localfs: root must be an absolute path, got %q", c.Root)
+	}
+
+	if err := c.Subfolders.validate(); err != nil {
+		return nil, errors.Wrap(err, "localfs: invalid subfolders")
+	}
+
+	dirMode, err := parseMode(c.DirMode)
+	if err != nil {
+		return nil, errors.Wrap(err, "localfs: invalid dir_mode")
+	}
+	fileMode, err := parseMode(c.FileMode)
+	if err != nil {
+		return nil, errors.Wrap(err, "localfs: invalid file_mode")
+	}
+
+	chunkHandler := chunking.NewChunkHandler(c.ChunkTempDir)
+	chunkHandler.DisableVerification = c.DisableChunkVerification
+
+	fs := &localfs{
+		conf:         c,
+		chunkHandler: chunkHandler,
+		dirMode:      dirMode,
+		fileMode:     fileMode,
+		uploads:      newUploadLimiter(c.MaxConcurrentUploads, time.Duration(c.UploadWaitTimeout)*time.Second),
+	}
+
 	// create namespaces if they do not exist
-	namespaces := []string{c.DataDirectory, c.Uploads, c.Shadow, c.References, c.RecycleBin, c.Versions}
+	namespaces := []string{c.DataDirectory, c.Uploads, c.Shadow, c.References, c.RecycleBin, c.Versions, c.ChunkTempDir}
 	for _, v := range namespaces {
-		if err := os.MkdirAll(v, 0755); err != nil {
+		if err := fs.mkdirAll(v, 0755); err != nil {
 			return nil, errors.Wrap(err, "could not create home dir "+v)
 		}
 	}
 
+	if err := checkTempDir(c.ChunkTempDir, c.ChunkTempMinFree); err != nil {
+		return nil, errors.Wrap(err, "localfs: invalid chunk_temp_dir")
+	}
+
 	dbName := "localfs.db"
 	if !c.DisableHome {
 		dbName = "localhomefs.db"
 	}
 
-	db, err := initializeDB(c.Root, dbName)
+	fs.db, err = initializeDB(c.Root, dbName)
 	if err != nil {
 		return nil, errors.Wrap(err, "localfs: error initializing db")
 	}
 
-	return &localfs{
-		conf:         c,
-		db:           db,
-		chunkHandler: chunking.NewChunkHandler(c.Uploads),
-	}, nil
+	return fs, nil
+}
+
+// parseMode parses a permission mode given as an octal string.
+// An empty string gives a zero mode, meaning that the defaults apply.
+func parseMode(s string) (os.FileMode, error) {
+	if s == "" {
+		return 0, nil
+	}
+	m, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return 0, err
+	}
+	return os.FileMode(m) & os.ModePerm, nil
+}
+
+// checkTempDir verifies that files can be created in dir and, when minFree
+// is not 0, that at least minFree bytes are available.
+func checkTempDir(dir string, minFree uint64) error {
+	f, err := os.CreateTemp(dir, ".check")
+	if err != nil {
+		return errors.Wrap(err, "directory is not writable")
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+
+	if minFree == 0 {
+		return nil
+	}
+	avail, err := availableSpace(dir)
+	if err != nil {
+		return errors.Wrap(err, "error getting the available space")
+	}
+	if avail < minFree {
+		return errors.Errorf("%d bytes available in %s, at least %d are required", avail, dir, minFree)
+	}
+	return nil
+}
+
+// mkdirAll creates the directory with the configured mode, or with perm if none is configured.
+// The configured mode is set explicitly on the directory and on the parents created along
+// with it, as the one given to MkdirAll is subject to the umask.
+func (fs *localfs) mkdirAll(p string, perm os.FileMode) error {
+	if fs.dirMode == 0 {
+		return os.MkdirAll(p, perm)
+	}
+
+	dirs := []string{p}
+	for d := filepath.Dir(p); d != filepath.Dir(d); d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		}
+		dirs = append(dirs, d)
+	}
+	if err := os.MkdirAll(p, fs.dirMode); err != nil {
+		return err
+	}
+	for _, d := range dirs {
+		if err := os.Chmod(d, fs.dirMode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mkdir is like mkdirAll but fails if the parent does not exist.
+func (fs *localfs) mkdir(p string, perm os.FileMode) error {
+	if fs.dirMode == 0 {
+		return os.Mkdir(p, perm)
+	}
+	if err := os.Mkdir(p, fs.dirMode); err != nil {
+		return err
+	}
+	return os.Chmod(p, fs.dirMode)
+}
+
+// filePerm returns the mode of the files created by the storage.
+func (fs *localfs) filePerm() os.FileMode {
+	if fs.fileMode == 0 {
+		return defaultFilePerm
+	}
+	return fs.fileMode
+}
+
+// chmodFile sets the configured mode on a newly created file.
+func (fs *localfs) chmodFile(p string) error {
+	if fs.fileMode == 0 {
+		return nil
+	}
+	return os.Chmod(p, fs.fileMode)
 }
 
 func (fs *localfs) Shutdown(ctx context.Context) error {
@@ -141,17 +374,88 @@ func (fs *localfs) resolve(ctx context.Context, ref *provider.Reference) (p stri
 		if p, err = fs.GetPathByID(ctx, ref.ResourceId); err != nil {
 			return "", err
 		}
-		return path.Join(p, path.Join("/", ref.Path)), nil
+		rel, err := fs.cleanPath(ref.Path)
+		if err != nil {
+			return "", err
+		}
+		if p, err = fs.cleanPath(path.Join(p, rel)); err != nil {
+			return "", err
+		}
+		return p, fs.checkSymlinks(ctx, p)
 	}
 
 	if ref.Path != "" {
-		return path.Join("/", ref.Path), nil
+		if p, err = fs.cleanPath(ref.Path); err != nil {
+			return "", err
+		}
+		return p, fs.checkSymlinks(ctx, p)
 	}
 
 	// reference is invalid
 	return "", fmt.Errorf("invalid reference %+v. at least resource_id or path must be set", ref)
 }
 
+// cleanPath returns the cleaned absolute form of p. Paths still containing
+// .. segments once cleaned, which would escape their root, and paths deeper
+// than the configured maximum depth are rejected.
+func (fs *localfs) cleanPath(p string) (string, error) {
+	p = path.Clean(p)
+	segments := strings.Split(strings.Trim(p, "/"), "/")
+	for _, s := range segments {
+		if s == ".." {
+			return "", errtypes.BadRequest("localfs: path escapes its root: " + p)
+		}
+	}
+	if fs.conf.MaxDepth > 0 && len(segments) > fs.conf.MaxDepth {
+		return "", errtypes.BadRequest(fmt.Sprintf("localfs: path deeper than %d segments", fs.conf.MaxDepth))
+	}
+	return path.Join("/", p), nil
+}
+
+// checkSymlinks verifies, unless symlinks may be followed, that the real
+// path of p stays within the user root. As p may not exist yet, the real
+// path of its deepest existing ancestor is checked.
+func (fs *localfs) checkSymlinks(ctx context.Context, p string) error {
+	if fs.conf.FollowSymlinks {
+		return nil
+	}
+	if !fs.conf.DisableHome {
+		if _, err := getUser(ctx); err != nil {
+			return err
+		}
+	}
+
+	root := fs.wrap(ctx, "/")
+	existing := fs.wrap(ctx, p)
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		if existing == root {
+			// the root does not exist yet, nothing can escape it
+			return nil
+		}
+		existing = filepath.Dir(existing)
+	}
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return errors.Wrap(err, "localfs: error resolving the root "+root)
+	}
+	real, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// a dangling symlink, whose target could be created anywhere
+			return errtypes.PermissionDenied("localfs: " + p + " contains a dangling symlink")
+		}
+		return errors.Wrap(err, "localfs: error resolving "+existing)
+	}
+	if real != realRoot && !strings.HasPrefix(real, realRoot+string(filepath.Separator)) {
+		return errtypes.PermissionDenied("localfs: " + p + " points outside of the user root")
+	}
+	return nil
+}
+
 func getUser(ctx context.Context) (*userpb.User, error) {
 	u, ok := appctx.ContextGetUser(ctx)
 	if !ok {
@@ -355,7 +659,7 @@ func (fs *localfs) normalize(ctx context.Context, fi os.FileInfo, fn string, mdK
 		Path:          fp,
 		Type:          getResourceType(fi.IsDir()),
 		Etag:          calcEtag(ctx, fi),
-		MimeType:      mime.Detect(fi.IsDir(), fp),
+		MimeType:      fs.detectMimeType(fi, fn, fp),
 		Size:          uint64(fi.Size()),
 		PermissionSet: fs.permissionSet(ctx, owner.Id),
 		Mtime: &types.Timestamp{
@@ -368,6 +672,31 @@ func (fs *localfs) normalize(ctx context.Context, fi os.FileInfo, fn string, mdK
 	return md, nil
 }
 
+// detectMimeType detects the mime type from the extension, falling back to
+// sniffing the content of the file when enabled and the extension is unknown.
+func (fs *localfs) detectMimeType(fi os.FileInfo, fn, fp string) string {
+	mimeType := mime.Detect(fi.IsDir(), fp)
+	if !fs.conf.SniffMimetype || fi.IsDir() || mimeType != genericMimeType {
+		return mimeType
+	}
+
+	f, err := os.Open(fn)
+	if err != nil {
+		return mimeType
+	}
+	defer f.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return mimeType
+	}
+	if sniffed := http.DetectContentType(buf[:n]); sniffed != genericMimeType {
+		return sniffed
+	}
+	return mimeType
+}
+
 func (fs *localfs) convertToFileReference(ctx context.Context, fi os.FileInfo, fn string, mdKeys []string) (*provider.ResourceInfo, error) {
 	info, err := fs.normalize(ctx, fi, fn, mdKeys)
 	if err != nil {
@@ -548,7 +877,7 @@ func (fs *localfs) CreateReference(ctx context.Context, path string, targetURI *
 		return errtypes.PermissionDenied("localfs: cannot create references outside the share folder and data transfers folder")
 	}
 
-	err := os.MkdirAll(fn, 0700)
+	err := fs.mkdirAll(fn, 0700)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return errtypes.NotFound(fn)
@@ -766,7 +1095,7 @@ func (fs *localfs) createHomeInternal(ctx context.Context, fn string) error {
 			return errors.Wrap(err, "local: error stating:"+fn)
 		}
 	}
-	err = os.MkdirAll(fn, 0700)
+	err = fs.mkdirAll(fn, 0700)
 	if err != nil {
 		return errors.Wrap(err, "local: error creating dir:"+fn)
 	}
@@ -787,7 +1116,7 @@ func (fs *localfs) CreateDir(ctx context.Context, ref *provider.Reference) error
 	if _, err := os.Stat(fn); err == nil {
 		return errtypes.AlreadyExists(fn)
 	}
-	err = os.Mkdir(fn, 0700)
+	err = fs.mkdir(fn, 0700)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return errtypes.NotFound(fn)
@@ -803,7 +1132,10 @@ func (fs *localfs) TouchFile(ctx context.Context, ref *provider.Reference) error
 	return fmt.Errorf("unimplemented: TouchFile")
 }
 
-func (fs *localfs) Delete(ctx context.Context, ref *provider.Reference) error {
+func (fs *localfs) Delete(ctx context.Context, ref *provider.Reference) (err error) {
+	if fs.conf.EnableMetrics {
+		defer observe(opDelete, time.Now(), &err)
+	}
 	fn, err := fs.resolve(ctx, ref)
 	if err != nil {
 		return errors.Wrap(err, "localfs: error resolving ref")
@@ -911,7 +1243,10 @@ func (fs *localfs) moveReferences(ctx context.Context, oldName, newName string)
 	return nil
 }
 
-func (fs *localfs) GetMD(ctx context.Context, ref *provider.Reference, mdKeys []string) (*provider.ResourceInfo, error) {
+func (fs *localfs) GetMD(ctx context.Context, ref *provider.Reference, mdKeys []string) (_ *provider.ResourceInfo, err error) {
+	if fs.conf.EnableMetrics {
+		defer observe(opStat, time.Now(), &err)
+	}
 	fn, err := fs.resolve(ctx, ref)
 	if err != nil {
 		return nil, errors.Wrap(err, "localfs: error resolving ref")
@@ -1048,7 +1383,10 @@ func (fs *localfs) listShareFolderRoot(ctx context.Context, home string, mdKeys
 	return finfos, nil
 }
 
-func (fs *localfs) Download(ctx context.Context, ref *provider.Reference) (io.ReadCloser, error) {
+func (fs *localfs) Download(ctx context.Context, ref *provider.Reference) (_ io.ReadCloser, err error) {
+	if fs.conf.EnableMetrics {
+		defer observe(opDownload, time.Now(), &err)
+	}
 	fn, err := fs.resolve(ctx, ref)
 	if err != nil {
 		return nil, errors.Wrap(err, "localfs: error resolving ref")
@@ -1071,7 +1409,7 @@ func (fs *localfs) Download(ctx context.Context, ref *provider.Reference) (io.Re
 
 func (fs *localfs) archiveRevision(ctx context.Context, np string) error {
 	versionsDir := fs.wrapVersions(ctx, fs.unwrap(ctx, np))
-	if err := os.MkdirAll(versionsDir, 0700); err != nil {
+	if err := fs.mkdirAll(versionsDir, 0700); err != nil {
 		return errors.Wrap(err, "localfs: error creating file versions dir "+versionsDir)
 	}
 
@@ -1317,7 +1655,13 @@ func (fs *localfs) UpdateStorageSpace(ctx context.Context, req *provider.UpdateS
 	return nil, errtypes.NotSupported("update storage space")
 }
 
+// propagate sets the mtime of the ancestors of leafPath up to the user root to the
+// current time, so that their etag changes and the clients detect the update.
 func (fs *localfs) propagate(ctx context.Context, leafPath string) error {
+	if !*fs.conf.PropagateEtags {
+		return nil
+	}
+
 	var root string
 	if fs.isShareFolderChild(ctx, leafPath) || strings.HasSuffix(path.Clean(leafPath), fs.conf.ShareFolder) {
 		root = fs.wrapReferences(ctx, "/")
@@ -1329,16 +1673,18 @@ func (fs *localfs) propagate(ctx context.Context, leafPath string) error {
 		return errors.New("internal path: " + leafPath + " outside root: " + root)
 	}
 
-	fi, err := os.Stat(leafPath)
-	if err != nil {
+	if _, err := os.Stat(leafPath); err != nil {
 		return err
 	}
 
+	// the leaf mtime is not used, as it can be older than the
+	// one of the ancestors, e.g. for a moved or restored file
+	now := time.Now()
 	parts := strings.Split(strings.TrimPrefix(leafPath, root), "/")
 	// root never ends in / so the split returns an empty first element, which we can skip
 	// we do not need to chmod the last element because it is the leaf path (< and not <= comparison)
 	for i := 1; i < len(parts); i++ {
-		if err := os.Chtimes(root, fi.ModTime(), fi.ModTime()); err != nil {
+		if err := os.Chtimes(root, now, now); err != nil {
 			return err
 		}
 		root = path.Join(root, parts[i])
@@ -0,0 +1,469 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package localfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	tusd "github.com/tus/tusd/pkg/handler"
+)
+
+func TestNewLocalFSRelativeRoot(t *testing.T) {
+	_, err := NewLocalFS(&Config{Root: "relative/reva"})
+	if err == nil {
+		t.Fatal("expected an error for a relative root")
+	}
+	if !strings.Contains(err.Error(), "absolute path") {
+		t.Fatalf("expected a helpful error message, got %q", err.Error())
+	}
+}
+
+func TestNewLocalFSDirMode(t *testing.T) {
+	tmp := t.TempDir()
+	c := &Config{Root: filepath.Join(tmp, "a", "b"), DirMode: "0770"}
+	fs, err := NewLocalFS(c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = fs.Shutdown(context.Background()) })
+
+	// the parents created along with the root get the mode too
+	for _, d := range []string{filepath.Join(tmp, "a"), c.Root, c.DataDirectory, c.Uploads, c.Versions} {
+		info, err := os.Stat(d)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if info.Mode().Perm() != 0770 {
+			t.Fatalf("directory %s has mode %o, expected %o", d, info.Mode().Perm(), 0770)
+		}
+	}
+}
+
+func TestNewLocalFSInvalidMode(t *testing.T) {
+	if _, err := NewLocalFS(&Config{Root: t.TempDir(), FileMode: "rw-rw----"}); err == nil {
+		t.Fatal("expected an error for a non octal file mode")
+	}
+}
+
+func TestPropagateEtags(t *testing.T) {
+	for _, propagate := range []bool{true, false} {
+		c := &Config{Root: t.TempDir(), DisableHome: true, PropagateEtags: &propagate}
+		fs, err := NewLocalFS(c)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		t.Cleanup(func() { _ = fs.Shutdown(context.Background()) })
+		ctx := appctx.ContextSetUser(context.Background(), &userpb.User{
+			Id:       &userpb.UserId{OpaqueId: "einstein"},
+			Username: "einstein",
+		})
+
+		if err := os.MkdirAll(filepath.Join(c.DataDirectory, "a", "b"), 0755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(c.DataDirectory, "a", "b", "file.txt"), []byte("data"), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// the etag has a precision of one second, so the tree is
+		// moved back in time for the propagation to be visible
+		past := time.Now().Add(-time.Hour)
+		for _, dir := range []string{"", "a", filepath.Join("a", "b")} {
+			if err := os.Chtimes(filepath.Join(c.DataDirectory, dir), past, past); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		root := &provider.Reference{Path: "/"}
+		before, err := fs.GetMD(ctx, root, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := fs.Delete(ctx, &provider.Reference{Path: "/a/b/file.txt"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		after, err := fs.GetMD(ctx, root, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if changed := before.Etag != after.Etag; changed != propagate {
+			t.Errorf("propagate_etags=%v: expected the root etag to change: %v, got etag %s before and %s after", propagate, propagate, before.Etag, after.Etag)
+		}
+	}
+}
+
+func TestSniffMimetype(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+	for _, tt := range []struct {
+		sniff    bool
+		expected string
+	}{
+		{sniff: true, expected: "image/png"},
+		{sniff: false, expected: "application/octet-stream"},
+	} {
+		c := &Config{Root: t.TempDir(), DisableHome: true, SniffMimetype: tt.sniff}
+		fs, err := NewLocalFS(c)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		t.Cleanup(func() { _ = fs.Shutdown(context.Background()) })
+		ctx := appctx.ContextSetUser(context.Background(), &userpb.User{
+			Id:       &userpb.UserId{OpaqueId: "einstein"},
+			Username: "einstein",
+		})
+
+		if err := os.WriteFile(filepath.Join(c.DataDirectory, "image"), png, 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		md, err := fs.GetMD(ctx, &provider.Reference{Path: "/image"}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if md.MimeType != tt.expected {
+			t.Errorf("sniff_mimetype=%v: expected mime type %s, got %s", tt.sniff, tt.expected, md.MimeType)
+		}
+	}
+}
+
+func TestCustomSubfolders(t *testing.T) {
+	c := &Config{
+		Root: t.TempDir(),
+		Subfolders: Subfolders{
+			Data:       "files",
+			Shadow:     "shadow_files",
+			RecycleBin: "files_trashbin",
+			Versions:   "files_versions",
+		},
+	}
+	fs, err := NewLocalFS(c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = fs.Shutdown(context.Background()) })
+	lfs := fs.(*localfs)
+	ctx := appctx.ContextSetUser(context.Background(), &userpb.User{
+		Id:       &userpb.UserId{OpaqueId: "einstein"},
+		Username: "einstein",
+	})
+
+	for _, tt := range []struct {
+		wrap     func(context.Context, string) string
+		expected string
+	}{
+		{lfs.wrap, filepath.Join(c.Root, "files", "einstein", "a", "b.txt")},
+		{lfs.wrapVersions, filepath.Join(c.Root, "shadow_files", "files_versions", "einstein", "a", "b.txt")},
+		{lfs.wrapRecycleBin, filepath.Join(c.Root, "shadow_files", "files_trashbin", "einstein", "a", "b.txt")},
+		{lfs.wrapReferences, filepath.Join(c.Root, "shadow_files", "references", "einstein", "a", "b.txt")},
+	} {
+		internal := tt.wrap(ctx, "/a/b.txt")
+		if internal != tt.expected {
+			t.Errorf("expected internal path %s, got %s", tt.expected, internal)
+		}
+		if external := lfs.unwrap(ctx, internal); external != "/a/b.txt" {
+			t.Errorf("expected %s to unwrap to /a/b.txt, got %s", internal, external)
+		}
+	}
+
+	for _, d := range []string{"files", ".uploads", "shadow_files/files_trashbin", "shadow_files/files_versions"} {
+		if _, err := os.Stat(filepath.Join(c.Root, d)); err != nil {
+			t.Errorf("expected the %s folder to be created: %v", d, err)
+		}
+	}
+}
+
+func TestInvalidSubfolders(t *testing.T) {
+	for _, s := range []Subfolders{
+		{Data: "a/b"},
+		{Shadow: ".."},
+		{Data: "same", Uploads: "same"},
+		{Versions: "recycle_bin"},
+	} {
+		if _, err := NewLocalFS(&Config{Root: t.TempDir(), Subfolders: s}); err == nil {
+			t.Errorf("expected an error for the subfolders %+v", s)
+		}
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	fs, err := NewLocalFS(&Config{Root: t.TempDir(), MaxDepth: 4})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = fs.Shutdown(context.Background()) })
+	lfs := fs.(*localfs)
+	ctx := appctx.ContextSetUser(context.Background(), &userpb.User{
+		Id:       &userpb.UserId{OpaqueId: "einstein"},
+		Username: "einstein",
+	})
+
+	for _, p := range []string{"../etc/passwd", "a/../../etc/passwd", "/a/b/c/d/e"} {
+		if _, err := lfs.resolve(ctx, &provider.Reference{Path: p}); err == nil {
+			t.Errorf("expected %s to be rejected", p)
+		} else if _, ok := err.(errtypes.IsBadRequest); !ok {
+			t.Errorf("expected a bad request for %s, got %v", p, err)
+		}
+	}
+
+	for p, expected := range map[string]string{
+		"/a/b/c/d":      "/a/b/c/d",
+		"a/b/../c":      "/a/c",
+		"/../a/./b.txt": "/a/b.txt",
+	} {
+		resolved, err := lfs.resolve(ctx, &provider.Reference{Path: p})
+		if err != nil {
+			t.Errorf("unexpected error resolving %s: %v", p, err)
+		} else if resolved != expected {
+			t.Errorf("expected %s to resolve to %s, got %s", p, expected, resolved)
+		}
+	}
+}
+
+func TestSymlinkEscape(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := appctx.ContextSetUser(context.Background(), &userpb.User{
+		Id:       &userpb.UserId{OpaqueId: "einstein"},
+		Username: "einstein",
+	})
+
+	for _, follow := range []bool{false, true} {
+		c := &Config{Root: t.TempDir(), FollowSymlinks: follow}
+		fs, err := NewLocalFS(c)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		t.Cleanup(func() { _ = fs.Shutdown(context.Background()) })
+		if err := fs.CreateHome(ctx); err != nil {
+			t.Fatal(err)
+		}
+		home := filepath.Join(c.DataDirectory, "einstein")
+		if err := os.Mkdir(filepath.Join(home, "docs"), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(outside, filepath.Join(home, "escape")); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(filepath.Join(home, "docs"), filepath.Join(home, "inside")); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := fs.GetMD(ctx, &provider.Reference{Path: "/inside"}, nil); err != nil {
+			t.Errorf("follow %t: expected a symlink within the home to be accessible, got %v", follow, err)
+		}
+
+		for _, p := range []string{"/escape", "/escape/secret.txt", "/escape/new.txt"} {
+			_, err := fs.GetMD(ctx, &provider.Reference{Path: p}, nil)
+			_, denied := errors.Cause(err).(errtypes.IsPermissionDenied)
+			if !follow && !denied {
+				t.Errorf("expected the access to %s to be denied, got %v", p, err)
+			}
+			if follow && denied {
+				t.Errorf("expected the access to %s to be allowed when following symlinks", p)
+			}
+		}
+	}
+}
+
+func TestUploadLimiter(t *testing.T) {
+	const limit, uploads = 2, 8
+	l := newUploadLimiter(limit, time.Minute)
+
+	var running, peak int32
+	var wg sync.WaitGroup
+	errs := make(chan error, uploads)
+	for i := 0; i < uploads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := l.acquire(context.Background(), "einstein")
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer release()
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if peak != limit {
+		t.Errorf("expected at most %d concurrent uploads, got %d", limit, peak)
+	}
+	if len(l.entries) != 0 {
+		t.Errorf("expected the semaphores to be dropped, got %d", len(l.entries))
+	}
+
+	// other users are not affected, and beyond the limit
+	// the uploads fail when they should not wait
+	l = newUploadLimiter(1, -1)
+	release, err := l.acquire(context.Background(), "einstein")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.acquire(context.Background(), "einstein"); err == nil {
+		t.Error("expected an error beyond the limit")
+	}
+	releaseMarie, err := l.acquire(context.Background(), "marie")
+	if err != nil {
+		t.Errorf("expected the uploads of another user to be allowed, got %v", err)
+	} else {
+		releaseMarie()
+	}
+	release()
+}
+
+func TestUploadLimiterKey(t *testing.T) {
+	upload := func(id string, u *userpb.User) *fileUpload {
+		return &fileUpload{
+			info: tusd.FileInfo{ID: id},
+			ctx:  appctx.ContextSetUser(context.Background(), u),
+		}
+	}
+	einstein := &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein"}}
+	anonymous := &userpb.User{Id: &userpb.UserId{}}
+
+	if a, b := upload("1", einstein).limiterKey(), upload("2", einstein).limiterKey(); a != b {
+		t.Errorf("expected the uploads of a user to share a key, got %s and %s", a, b)
+	}
+	if a, b := upload("1", anonymous).limiterKey(), upload("2", anonymous).limiterKey(); a == b {
+		t.Errorf("expected the anonymous uploads to have their own key, got %s", a)
+	}
+}
+
+func TestStatMetrics(t *testing.T) {
+	samples := func(op string) uint64 {
+		m := &dto.Metric{}
+		if err := opDuration.WithLabelValues(op).(prometheus.Metric).Write(m); err != nil {
+			t.Fatal(err)
+		}
+		return m.GetHistogram().GetSampleCount()
+	}
+
+	ctx := appctx.ContextSetUser(context.Background(), &userpb.User{
+		Id:       &userpb.UserId{OpaqueId: "einstein"},
+		Username: "einstein",
+	})
+	for _, enabled := range []bool{false, true} {
+		fs, err := NewLocalFS(&Config{Root: t.TempDir(), EnableMetrics: enabled})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		t.Cleanup(func() { _ = fs.Shutdown(context.Background()) })
+		if err := fs.CreateHome(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		before := samples(opStat)
+		if _, err := fs.GetMD(ctx, &provider.Reference{Path: "/"}, nil); err != nil {
+			t.Fatal(err)
+		}
+		expected := before
+		if enabled {
+			expected++
+		}
+		if got := samples(opStat); got != expected {
+			t.Errorf("metrics enabled %t: expected %d stat samples, got %d", enabled, expected, got)
+		}
+	}
+}
+
+func TestChunkTempDir(t *testing.T) {
+	c := &Config{Root: t.TempDir(), ChunkTempDir: filepath.Join(t.TempDir(), "chunks")}
+	fs, err := NewLocalFS(c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = fs.Shutdown(context.Background()) })
+	ctx := appctx.ContextSetUser(context.Background(), &userpb.User{
+		Id:       &userpb.UserId{OpaqueId: "einstein"},
+		Username: "einstein",
+	})
+	if err := fs.CreateHome(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	chunks := []string{"hello ", "world"}
+	for i, data := range chunks {
+		fn := fmt.Sprintf("/file.txt-chunking-transfer-%d-%d", len(chunks), i)
+		ids, err := fs.InitiateUpload(ctx, &provider.Reference{Path: fn}, int64(len(data)), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = fs.Upload(ctx, &provider.Reference{Path: ids["simple"]}, io.NopCloser(strings.NewReader(data)), nil)
+		if i < len(chunks)-1 {
+			if _, ok := err.(errtypes.IsPartialContent); !ok {
+				t.Fatalf("expected a partial content for chunk %d, got %v", i, err)
+			}
+			// the pending chunks are kept in the chunk temp dir
+			if entries, _ := os.ReadDir(c.ChunkTempDir); len(entries) == 0 {
+				t.Errorf("expected the chunks to be stored in %s", c.ChunkTempDir)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error uploading the last chunk: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(c.DataDirectory, "einstein", "file.txt"))
+	if err != nil || string(data) != "hello world" {
+		t.Errorf("expected the assembled file, got %q (%v)", data, err)
+	}
+	if entries, _ := os.ReadDir(c.ChunkTempDir); len(entries) != 0 {
+		t.Errorf("expected the chunk temp dir to be cleaned up, got %d entries", len(entries))
+	}
+
+	if _, err := NewLocalFS(&Config{Root: t.TempDir(), ChunkTempMinFree: math.MaxUint64}); err == nil {
+		t.Error("expected an error when the chunk temp dir lacks space")
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package localfs
+
+import (
+	"context"
+	"time"
+
+	"github.com/cs3org/reva/pkg/prom/registry"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The operations whose metrics are recorded.
+const (
+	opStat     = "stat"
+	opUpload   = "upload"
+	opDownload = "download"
+	opDelete   = "delete"
+)
+
+var opDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "localfs_operation_duration_seconds",
+		Help:    "A histogram of the duration of the localfs operations.",
+		Buckets: prometheus.DefBuckets,
+	},
+	[]string{"operation"},
+)
+
+var opErrors = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "localfs_operation_errors_total",
+		Help: "A counter of the failed localfs operations.",
+	},
+	[]string{"operation"},
+)
+
+func init() {
+	registry.Register("localfs", NewPromCollectors)
+}
+
+// NewPromCollectors returns the prometheus collectors of the localfs operations.
+func NewPromCollectors(_ context.Context, m map[string]interface{}) ([]prometheus.Collector, error) {
+	return []prometheus.Collector{opDuration, opErrors}, nil
+}
+
+// observe records the duration of an operation started at start,
+// and counts it as failed when *err is set. It is meant to be deferred.
+func observe(op string, start time.Time, err *error) {
+	opDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+	if *err != nil {
+		opErrors.WithLabelValues(op).Inc()
+	}
+}
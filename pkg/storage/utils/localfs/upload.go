@@ -24,6 +24,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
@@ -39,7 +40,10 @@ import (
 
 var defaultFilePerm = os.FileMode(0664)
 
-func (fs *localfs) Upload(ctx context.Context, ref *provider.Reference, r io.ReadCloser, metadata map[string]string) error {
+func (fs *localfs) Upload(ctx context.Context, ref *provider.Reference, r io.ReadCloser, metadata map[string]string) (err error) {
+	if fs.conf.EnableMetrics {
+		defer observe(opUpload, time.Now(), &err)
+	}
 	upload, err := fs.GetUpload(ctx, ref.GetPath())
 	if err != nil {
 		return errors.Wrap(err, "localfs: error retrieving upload")
@@ -53,8 +57,12 @@ func (fs *localfs) Upload(ctx context.Context, ref *provider.Reference, r io.Rea
 		return errors.Wrap(err, "localfs: error checking path")
 	}
 	if ok {
+		totalSize, err := chunking.TotalSize(metadata)
+		if err != nil {
+			return errtypes.BadRequest("localfs: " + err.Error())
+		}
 		var assembledFile string
-		p, assembledFile, err = fs.chunkHandler.WriteChunk(p, r)
+		p, assembledFile, err = fs.chunkHandler.WriteChunkOfSize(p, r, totalSize)
 		if err != nil {
 			return err
 		}
@@ -173,11 +181,14 @@ func (fs *localfs) NewUpload(ctx context.Context, info tusd.FileInfo) (upload tu
 		"LogLevel": log.GetLevel().String(),
 	}
 	// Create binary file with no content
-	file, err := os.OpenFile(binPath, os.O_CREATE|os.O_WRONLY, defaultFilePerm)
+	file, err := os.OpenFile(binPath, os.O_CREATE|os.O_WRONLY, fs.filePerm())
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
+	if err := fs.chmodFile(binPath); err != nil {
+		return nil, err
+	}
 
 	u := &fileUpload{
 		info:     info,
@@ -272,7 +283,7 @@ func (upload *fileUpload) GetReader(ctx context.Context) (io.Reader, error) {
 
 // WriteChunk writes the stream from the reader to the given offset of the upload.
 func (upload *fileUpload) WriteChunk(ctx context.Context, offset int64, src io.Reader) (int64, error) {
-	file, err := os.OpenFile(upload.binPath, os.O_WRONLY|os.O_APPEND, defaultFilePerm)
+	file, err := os.OpenFile(upload.binPath, os.O_WRONLY|os.O_APPEND, upload.fs.filePerm())
 	if err != nil {
 		return 0, err
 	}
@@ -302,13 +313,30 @@ func (upload *fileUpload) writeInfo() error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(upload.infoPath, data, defaultFilePerm)
+	return os.WriteFile(upload.infoPath, data, upload.fs.filePerm())
+}
+
+// limiterKey is the key the concurrent uploads are limited by: the user, or
+// the upload itself for the anonymous uploads, which have no user in common.
+func (upload *fileUpload) limiterKey() string {
+	if u, ok := appctx.ContextGetUser(upload.ctx); ok && u.Id.GetOpaqueId() != "" {
+		return "user:" + u.Id.OpaqueId
+	}
+	return "upload:" + upload.info.ID
 }
 
 // FinishUpload finishes an upload and moves the file to the internal destination.
 func (upload *fileUpload) FinishUpload(ctx context.Context) error {
 	np := upload.info.Storage["InternalDestination"]
 
+	// the user of the upload is known from the context of the upload,
+	// which is also the one the slot is waited for with
+	release, err := upload.fs.uploads.acquire(upload.ctx, upload.limiterKey())
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	// TODO check etag with If-Match header
 	// if destination exists
 	// if _, err := os.Stat(np); err == nil {
@@ -325,7 +353,7 @@ func (upload *fileUpload) FinishUpload(ctx context.Context) error {
 		}
 	}
 
-	err := os.Rename(upload.binPath, np)
+	err = os.Rename(upload.binPath, np)
 	if err != nil {
 		return err
 	}
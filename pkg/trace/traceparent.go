@@ -0,0 +1,71 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package trace
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// TraceparentHeader is the W3C trace context header.
+// See https://www.w3.org/TR/trace-context/#traceparent-header
+const TraceparentHeader = "traceparent"
+
+// Traceparent holds the fields of a W3C traceparent header.
+type Traceparent struct {
+	TraceID  string
+	ParentID string
+	Flags    string
+}
+
+// ParseTraceparent parses a version 00 W3C traceparent header.
+func ParseTraceparent(h string) (Traceparent, bool) {
+	parts := strings.Split(strings.TrimSpace(h), "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return Traceparent{}, false
+	}
+	tp := Traceparent{TraceID: parts[1], ParentID: parts[2], Flags: parts[3]}
+	if !isHex(tp.TraceID, 32) || !isHex(tp.ParentID, 16) || !isHex(tp.Flags, 2) ||
+		strings.Trim(tp.TraceID, "0") == "" || strings.Trim(tp.ParentID, "0") == "" {
+		return Traceparent{}, false
+	}
+	return tp, true
+}
+
+// Child returns the traceparent of a new span in the same trace,
+// to be propagated to the downstream services.
+func (t Traceparent) Child() Traceparent {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return Traceparent{TraceID: t.TraceID, ParentID: hex.EncodeToString(b[:]), Flags: t.Flags}
+}
+
+// String returns the traceparent header value.
+func (t Traceparent) String() string {
+	return "00-" + t.TraceID + "-" + t.ParentID + "-" + t.Flags
+}
+
+func isHex(s string, n int) bool {
+	if len(s) != n || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package trace
+
+import "testing"
+
+func TestParseTraceparent(t *testing.T) {
+	tests := map[string]struct {
+		header string
+		ok     bool
+	}{
+		"valid":           {header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", ok: true},
+		"unknown version": {header: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		"short trace id":  {header: "00-4bf92f3577b34da6-00f067aa0ba902b7-01"},
+		"zero trace id":   {header: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		"zero parent id":  {header: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		"uppercase":       {header: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01"},
+		"missing flags":   {header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7"},
+		"not hex":         {header: "00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01"},
+		"empty":           {header: ""},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tp, ok := ParseTraceparent(tt.header)
+			if ok != tt.ok {
+				t.Fatalf("unexpected result parsing %q: got %v", tt.header, ok)
+			}
+			if ok && tp.String() != tt.header {
+				t.Errorf("unexpected header: got %s, expected %s", tp.String(), tt.header)
+			}
+		})
+	}
+}
+
+func TestTraceparentChild(t *testing.T) {
+	tp, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	child := tp.Child()
+	if child.TraceID != tp.TraceID || child.Flags != tp.Flags {
+		t.Errorf("expected the child to be in the same trace, got %s", child)
+	}
+	if child.ParentID == tp.ParentID {
+		t.Error("expected the child to have a new parent id")
+	}
+	if _, ok := ParseTraceparent(child.String()); !ok {
+		t.Errorf("expected a valid traceparent, got %s", child)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package net
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	headerForwardedFor = "X-Forwarded-For"
+	headerRealIP       = "X-Real-Ip"
+)
+
+// TrustedProxies is a list of networks of the proxies
+// whose forwarding headers are trusted.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses a list of CIDRs or single IPs.
+func ParseTrustedProxies(l []string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(l))
+	for _, s := range l {
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", s)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
+		}
+		proxies = append(proxies, n)
+	}
+	return proxies, nil
+}
+
+// Trusted returns true if the ip belongs to one of the trusted proxies.
+func (t TrustedProxies) Trusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range t {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the ip of the client that made the request.
+// The X-Forwarded-For and X-Real-Ip headers are honoured only when the
+// request comes from a trusted proxy, otherwise the remote address is used.
+// In the X-Forwarded-For list the rightmost address not belonging to a
+// trusted proxy is taken, as the ones on its left can be forged by the client.
+func (t TrustedProxies) ClientIP(r *http.Request) string {
+	peer := remoteIP(r.RemoteAddr)
+	if !t.Trusted(peer) {
+		return peer
+	}
+
+	if forwarded := r.Header.Get(headerForwardedFor); forwarded != "" {
+		ips := strings.Split(forwarded, ",")
+		for i := len(ips) - 1; i >= 0; i-- {
+			ip := strings.TrimSpace(ips[i])
+			if i == 0 || !t.Trusted(ip) {
+				return ip
+			}
+		}
+	}
+	if ip := strings.TrimSpace(r.Header.Get(headerRealIP)); ip != "" {
+		return ip
+	}
+	return peer
+}
+
+func remoteIP(addr string) string {
+	if ip, _, err := net.SplitHostPort(addr); err == nil {
+		return ip
+	}
+	return addr
+}
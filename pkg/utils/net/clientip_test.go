@@ -0,0 +1,101 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package net
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	if _, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1", "2001:db8::1"}); err != nil {
+		t.Fatalf("not expected error: %v", err)
+	}
+	for _, invalid := range []string{"10.0.0.0/33", "not-an-ip"} {
+		if _, err := ParseTrustedProxies([]string{invalid}); err == nil {
+			t.Errorf("expected error parsing %q", invalid)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	if err != nil {
+		t.Fatalf("not expected error: %v", err)
+	}
+
+	tests := map[string]struct {
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		"no proxy": {
+			remoteAddr: "198.51.100.7:1234",
+			expected:   "198.51.100.7",
+		},
+		"untrusted peer with forwarded header": {
+			remoteAddr: "198.51.100.7:1234",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.5"},
+			expected:   "198.51.100.7",
+		},
+		"untrusted peer with real ip header": {
+			remoteAddr: "198.51.100.7:1234",
+			headers:    map[string]string{"X-Real-Ip": "203.0.113.5"},
+			expected:   "198.51.100.7",
+		},
+		"trusted peer with forwarded header": {
+			remoteAddr: "10.1.2.3:1234",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.5"},
+			expected:   "203.0.113.5",
+		},
+		"trusted peer with chain of proxies": {
+			remoteAddr: "10.1.2.3:1234",
+			headers:    map[string]string{"X-Forwarded-For": "1.1.1.1, 203.0.113.5, 192.0.2.1"},
+			expected:   "203.0.113.5",
+		},
+		"trusted peer with only trusted proxies": {
+			remoteAddr: "10.1.2.3:1234",
+			headers:    map[string]string{"X-Forwarded-For": "10.0.0.1, 10.0.0.2"},
+			expected:   "10.0.0.1",
+		},
+		"trusted peer with real ip header": {
+			remoteAddr: "192.0.2.1:1234",
+			headers:    map[string]string{"X-Real-Ip": "203.0.113.5"},
+			expected:   "203.0.113.5",
+		},
+		"trusted peer without headers": {
+			remoteAddr: "10.1.2.3:1234",
+			expected:   "10.1.2.3",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if ip := proxies.ClientIP(r); ip != tt.expected {
+				t.Errorf("unexpected client ip: got %s, expected %s", ip, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2018-2024 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package resourceid
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+)
+
+// spaceDelimiter separates the storage id from the space id
+// in the storage id of the resources living in a space.
+const spaceDelimiter = "$"
+
+// validate checks that a resource id can be wrapped and unwrapped without loss.
+func validate(r *provider.ResourceId) error {
+	if r == nil || r.StorageId == "" || r.OpaqueId == "" {
+		return errors.New("resource id must have a storage id and an opaque id")
+	}
+	if !utf8.ValidString(r.StorageId) || !utf8.ValidString(r.OpaqueId) {
+		return errors.New("invalid utf8 string found")
+	}
+	if strings.Contains(r.StorageId, idDelimiter) {
+		return errors.New("storage id must not contain " + idDelimiter)
+	}
+	return nil
+}
+
+// ItemSourceWrap wraps a resource id into the `<storageid>!<opaqueid>` format
+// of the item_source and file_source attributes of the OCS shares.
+func ItemSourceWrap(r *provider.ResourceId) (string, error) {
+	if err := validate(r); err != nil {
+		return "", err
+	}
+	return wrap(r.StorageId, r.OpaqueId), nil
+}
+
+// ItemSourceUnwrap returns the resource id wrapped by ItemSourceWrap.
+func ItemSourceUnwrap(itemSource string) (*provider.ResourceId, error) {
+	r, err := unwrap(itemSource)
+	if err != nil {
+		return nil, err
+	}
+	if err := validate(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// SpaceResourceIDWrap wraps a resource id into the `<storageid>$<spaceid>!<opaqueid>`
+// format of the spaces endpoints. The storage id of the resource must hold the space id.
+func SpaceResourceIDWrap(r *provider.ResourceId) (string, error) {
+	if err := validate(r); err != nil {
+		return "", err
+	}
+	if _, _, err := SplitSpaceStorageID(r.StorageId); err != nil {
+		return "", err
+	}
+	return wrap(r.StorageId, r.OpaqueId), nil
+}
+
+// SpaceResourceIDUnwrap returns the resource id wrapped by SpaceResourceIDWrap.
+func SpaceResourceIDUnwrap(rid string) (*provider.ResourceId, error) {
+	r, err := ItemSourceUnwrap(rid)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := SplitSpaceStorageID(r.StorageId); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// SplitSpaceStorageID splits the storage id of a resource living in a space
+// into the id of the storage and the id of the space.
+func SplitSpaceStorageID(storageID string) (string, string, error) {
+	parts := strings.Split(storageID, spaceDelimiter)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", errors.New("storage id must be of the form <storageid>" + spaceDelimiter + "<spaceid>")
+	}
+	return parts[0], parts[1], nil
+}
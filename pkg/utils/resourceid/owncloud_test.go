@@ -86,3 +86,71 @@ func TestUnwrap(t *testing.T) {
 		}
 	}
 }
+
+func TestItemSourceRoundTrip(t *testing.T) {
+	for _, id := range []*providerv1beta1.ResourceId{
+		{StorageId: "storageid", OpaqueId: "opaqueid"},
+		{StorageId: "provider-1$userspace", OpaqueId: "root"},
+		{StorageId: "storageid", OpaqueId: "opaque!id"},
+	} {
+		itemSource, err := ItemSourceWrap(id)
+		if err != nil {
+			t.Fatalf("unexpected error wrapping %v: %v", id, err)
+		}
+		got, err := ItemSourceUnwrap(itemSource)
+		if err != nil {
+			t.Fatalf("unexpected error unwrapping %s: %v", itemSource, err)
+		}
+		if !utils.ResourceIDEqual(got, id) {
+			t.Errorf("expected %v after the round trip, got %v", id, got)
+		}
+	}
+
+	for _, id := range []*providerv1beta1.ResourceId{
+		nil,
+		{StorageId: "storageid"},
+		{OpaqueId: "opaqueid"},
+		{StorageId: "storage!id", OpaqueId: "opaqueid"},
+	} {
+		if _, err := ItemSourceWrap(id); err == nil {
+			t.Errorf("expected an error wrapping %v", id)
+		}
+	}
+	for _, s := range []string{"", "storageid", "!opaqueid", "storageid!"} {
+		if _, err := ItemSourceUnwrap(s); err == nil {
+			t.Errorf("expected an error unwrapping %q", s)
+		}
+	}
+}
+
+func TestSpaceResourceIDRoundTrip(t *testing.T) {
+	id := &providerv1beta1.ResourceId{StorageId: "provider-1$userspace", OpaqueId: "root"}
+	wrapped, err := SpaceResourceIDWrap(id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if wrapped != "provider-1$userspace!root" {
+		t.Errorf("expected provider-1$userspace!root, got %s", wrapped)
+	}
+	got, err := SpaceResourceIDUnwrap(wrapped)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !utils.ResourceIDEqual(got, id) {
+		t.Errorf("expected %v after the round trip, got %v", id, got)
+	}
+	storageID, spaceID, err := SplitSpaceStorageID(got.StorageId)
+	if err != nil || storageID != "provider-1" || spaceID != "userspace" {
+		t.Errorf("expected provider-1 and userspace, got %s and %s (%v)", storageID, spaceID, err)
+	}
+
+	// the legacy ids carry no space
+	if _, err := SpaceResourceIDWrap(&providerv1beta1.ResourceId{StorageId: "storageid", OpaqueId: "opaqueid"}); err == nil {
+		t.Error("expected an error wrapping an id without space")
+	}
+	for _, s := range []string{"storageid!opaqueid", "$space!root", "provider$!root", "a$b$c!root"} {
+		if _, err := SpaceResourceIDUnwrap(s); err == nil {
+			t.Errorf("expected an error unwrapping %q", s)
+		}
+	}
+}
@@ -402,6 +402,23 @@ func HasPublicShareRole(u *userpb.User) (string, bool) {
 	return "", false
 }
 
+// PublicShareExpiration returns the expiration of the public share the user
+// authenticated with, if the share expires.
+func PublicShareExpiration(u *userpb.User) (time.Time, bool) {
+	if u.GetOpaque() == nil {
+		return time.Time{}, false
+	}
+	e, ok := u.Opaque.Map["public-share-expiration"]
+	if !ok {
+		return time.Time{}, false
+	}
+	secs, err := strconv.ParseInt(string(e.Value), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(secs, 0), true
+}
+
 // HasOCMShareRole return true if the user has a ocm share role.
 // If yes, the string is the type of role, viewer, editor or uploader.
 func HasOCMShareRole(u *userpb.User) (string, bool) {